@@ -51,16 +51,18 @@ func (cmd *CheckCommand) check(path string) error {
 	}
 
 	switch typ {
-	case ethdb.SegmentLDB1:
-		return cmd.checkLDBSegment(path)
+	case ethdb.SegmentLDB1, ethdb.SegmentPBL1:
+		return cmd.checkMutableSegment(path)
 	case ethdb.SegmentETH1:
 		return cmd.checkETHSegment(path)
+	case ethdb.SegmentETH2:
+		return cmd.checkETH2Segment(path)
 	default:
 		return fmt.Errorf("unknown segment type: %q", typ)
 	}
 }
 
-func (cmd *CheckCommand) checkLDBSegment(path string) error {
+func (cmd *CheckCommand) checkMutableSegment(path string) error {
 	return ErrUnsupportedCheckType
 }
 
@@ -106,3 +108,42 @@ func (cmd *CheckCommand) checkETHSegment(path string) error {
 
 	return nil
 }
+
+func (cmd *CheckCommand) checkETH2Segment(path string) error {
+	s := ethdb.NewCompressedFileSegment(filepath.Base(path), path)
+	if err := s.Open(); err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// Print stats.
+	fmt.Printf("[eth2] %s\n", path)
+	fmt.Printf("SIZE: %d bytes\n", s.Size())
+	fmt.Printf("BLOCKS: %d blocks\n", s.BlockCount())
+	fmt.Printf("IDX: %d bytes\n", len(s.Index()))
+	fmt.Printf("LEN: %d items\n", s.Len())
+	fmt.Printf("CAP: %d items\n", s.Cap())
+	fmt.Printf("CHKSUM: %x\n", s.Checksum())
+
+	// Verify checksum integrity.
+	if chksum, err := ethdb.ChecksumFileSegment(path); err != nil {
+		return err
+	} else if !bytes.Equal(chksum, s.Checksum()) {
+		return fmt.Errorf("checksum mismatch: %x != %x", chksum, s.Checksum())
+	}
+
+	// Verify every block decompresses and every index slot points at its key.
+	blocks, err := ethdb.VerifyCompressedFileSegment(s)
+	if err != nil {
+		return err
+	}
+	for _, compression := range []ethdb.Compression{ethdb.CompressionNone, ethdb.CompressionSnappy, ethdb.CompressionZstd} {
+		if n := blocks[compression]; n > 0 {
+			fmt.Printf("CODEC %s: %d blocks\n", compression, n)
+		}
+	}
+
+	fmt.Println("")
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/ethdb"
+)
+
+type ExportCommand struct{}
+
+func NewExportCommand() *ExportCommand {
+	return &ExportCommand{}
+}
+
+func (cmd *ExportCommand) Run(args []string) error {
+	fs := flag.NewFlagSet("gendchain-ethdb-export", flag.ContinueOnError)
+	from := fs.Uint64("from", 0, "first block number")
+	to := fs.Uint64("to", 0, "last block number")
+	compression := fs.String("compression", "", "compression of exported segments (none, snappy or zstd)")
+	partitionSize := fs.Uint64("partition-size", ethdb.DefaultPartitionSize, "number of blocks per segment")
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() < 2 {
+		return errors.New("path and destination path required")
+	} else if *to < *from {
+		return errors.New("last block number must not be lower than first")
+	} else if _, err := os.Stat(fs.Arg(1)); !os.IsNotExist(err) {
+		return fmt.Errorf("destination already exists: %s", fs.Arg(1))
+	}
+
+	src, err := openDB(fs.Arg(0), *partitionSize, "", "")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := openDB(fs.Arg(1), *partitionSize, *compression, "")
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	// Copy canonical blocks and their lookups into the destination.
+	for n := *from; n <= *to; n++ {
+		hash := rawdb.ReadCanonicalHash(src, n)
+		if hash == (common.Hash{}) {
+			return fmt.Errorf("canonical block not found: %d", n)
+		}
+		block := rawdb.ReadBlock(src, hash, n)
+		if block == nil {
+			return fmt.Errorf("block not found: %d", n)
+		}
+		rawdb.WriteBlock(dst, block)
+		rawdb.WriteCanonicalHash(dst, hash, n)
+		rawdb.WriteTxLookupEntries(dst.GlobalTable(), block)
+		if td := rawdb.ReadTd(src.GlobalTable(), hash, n); td != nil {
+			rawdb.WriteTd(dst.GlobalTable(), hash, n, td)
+		}
+		if receipts := rawdb.ReadRawReceipts(src.ReceiptTable(), hash, n); receipts != nil {
+			rawdb.WriteReceipts(dst.ReceiptTable(), hash, n, receipts)
+		}
+	}
+
+	// Compact all block segments into portable segment files.
+	for _, tbl := range dst.Tables() {
+		if tbl.Name == "global" {
+			continue
+		}
+		for _, s := range tbl.SegmentSlice() {
+			if _, ok := s.(ethdb.MutableSegment); !ok {
+				continue
+			} else if err := tbl.CompactSegment(context.Background(), s.Name()); err != nil {
+				return fmt.Errorf("%s/%s: %s", tbl.Name, s.Name(), err)
+			}
+		}
+	}
+
+	fmt.Printf("exported blocks %d to %d\n", *from, *to)
+	return nil
+}
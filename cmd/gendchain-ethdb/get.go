@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/hexutil"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/rlp"
+)
+
+type GetCommand struct{}
+
+func NewGetCommand() *GetCommand {
+	return &GetCommand{}
+}
+
+func (cmd *GetCommand) Run(args []string) error {
+	fs := flag.NewFlagSet("gendchain-ethdb-get", flag.ContinueOnError)
+	tableName := fs.String("table", "", "table name (default inferred from key)")
+	raw := fs.Bool("raw", false, "print the raw value in hex")
+	partitionSize := fs.Uint64("partition-size", ethdb.DefaultPartitionSize, "number of blocks per segment")
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() < 2 {
+		return errors.New("path and key required")
+	}
+
+	key, err := hex.DecodeString(strings.TrimPrefix(fs.Arg(1), "0x"))
+	if err != nil {
+		return fmt.Errorf("invalid hex key: %s", err)
+	}
+
+	db, err := openDB(fs.Arg(0), *partitionSize, "", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Look up the key in its table, falling back to the global table like
+	// rawdb does for keys written there by newer versions.
+	tableNames := []string{*tableName}
+	if *tableName == "" {
+		tableNames = []string{ethdb.KeyTableName(key)}
+		if tableNames[0] != "global" {
+			tableNames = append(tableNames, "global")
+		}
+	}
+	var value []byte
+	for _, name := range tableNames {
+		tbl := db.Table(name)
+		if tbl == nil {
+			return fmt.Errorf("unknown table name: %q", name)
+		}
+		if value, err = tbl.Get(key); err != nil && err != common.ErrNotFound {
+			return err
+		} else if value != nil {
+			break
+		}
+	}
+	if value == nil {
+		return fmt.Errorf("key not found: %x", key)
+	}
+
+	if *raw {
+		fmt.Printf("%x\n", value)
+		return nil
+	}
+	v, err := decodeValue(key, value)
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(buf))
+	return nil
+}
+
+// decodeValue decodes value into the type stored under key by rawdb, or into
+// a generic RLP tree for unknown keys.
+func decodeValue(key, value []byte) (interface{}, error) {
+	switch {
+	case len(key) == 41 && key[0] == 'h':
+		var header types.Header
+		return &header, rlp.DecodeBytes(value, &header)
+	case len(key) == 10 && key[0] == 'h' && key[9] == 'n':
+		return common.BytesToHash(value), nil
+	case len(key) == 42 && key[0] == 'h' && key[41] == 't':
+		var td big.Int
+		return (*hexutil.Big)(&td), rlp.DecodeBytes(value, &td)
+	case len(key) == 41 && key[0] == 'b':
+		var body types.Body
+		return &body, rlp.DecodeBytes(value, &body)
+	case len(key) == 41 && key[0] == 'r':
+		var receipts types.ReceiptsForStorage
+		return receipts, rlp.DecodeBytes(value, &receipts)
+	case len(key) == 33 && key[0] == 'H' && len(value) == 8:
+		return hexutil.Uint64(binary.BigEndian.Uint64(value)), nil
+	default:
+		if v, err := decodeRLP(value); err == nil {
+			return v, nil
+		}
+		return hexutil.Bytes(value), nil
+	}
+}
+
+// decodeRLP decodes b into nested lists of hex strings. Returns an error if b
+// is not a single RLP value.
+func decodeRLP(b []byte) (interface{}, error) {
+	kind, content, rest, err := rlp.Split(b)
+	if err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after rlp value")
+	} else if kind != rlp.List {
+		return hexutil.Bytes(content), nil
+	}
+
+	a := make([]interface{}, 0)
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, err
+		}
+		v, err := decodeRLP(content[:len(content)-len(rest)])
+		if err != nil {
+			return nil, err
+		}
+		a, content = append(a, v), rest
+	}
+	return a, nil
+}
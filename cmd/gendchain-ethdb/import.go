@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+)
+
+type ImportCommand struct{}
+
+func NewImportCommand() *ImportCommand {
+	return &ImportCommand{}
+}
+
+func (cmd *ImportCommand) Run(args []string) error {
+	fs := flag.NewFlagSet("gendchain-ethdb-import", flag.ContinueOnError)
+	partitionSize := fs.Uint64("partition-size", ethdb.DefaultPartitionSize, "number of blocks per segment")
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() < 2 {
+		return errors.New("path and source path required")
+	} else if ok, err := ethdb.IsDBDir(fs.Arg(1)); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("not an ethdb database: %s", fs.Arg(1))
+	}
+
+	dst, err := openDB(fs.Arg(0), *partitionSize, "", "")
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	src, err := openDB(fs.Arg(1), *partitionSize, "", "")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	for _, srcTbl := range src.Tables() {
+		dstTbl := dst.Table(srcTbl.Name)
+		for _, name := range srcTbl.SegmentNames() {
+			if err := importSegment(dstTbl, srcTbl, name); err != nil {
+				return fmt.Errorf("%s/%s: %s", srcTbl.Name, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// importSegment copies the named segment of src into dst. Immutable segments
+// missing from dst are copied as files, others are merged key by key.
+func importSegment(dst, src *ethdb.Table, name string) error {
+	s, err := src.AcquireSegment(name)
+	if err != nil {
+		return err
+	}
+	defer src.ReleaseSegment(s)
+
+	if _, ok := s.(ethdb.MutableSegment); !ok && !hasSegment(dst, name) {
+		if ok, err := isPartition(dst, s); err != nil {
+			return err
+		} else if ok {
+			if err := dst.ImportSegment(context.Background(), name, s.Path()); err != nil {
+				return err
+			}
+			fmt.Printf("imported %s/%s (segment file)\n", dst.Name, name)
+			return nil
+		}
+	}
+
+	n, err := copySegment(dst, s)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %s/%s (%d keys)\n", dst.Name, name, n)
+	return nil
+}
+
+// hasSegment returns true if tbl contains the named segment.
+func hasSegment(tbl *ethdb.Table, name string) bool {
+	for _, other := range tbl.SegmentNames() {
+		if other == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isPartition returns true if tbl partitions every key of s to its name.
+func isPartition(tbl *ethdb.Table, s ethdb.Segment) (bool, error) {
+	ok := true
+	itr := s.Iterator()
+	for ok && itr.Next() {
+		ok = tbl.Partitioner.Partition(itr.Key()) == s.Name()
+	}
+	return ok, itr.Close()
+}
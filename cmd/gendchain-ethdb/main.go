@@ -4,6 +4,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+
+	"github.com/ChainAAS/gendchain/ethdb"
 )
 
 func main() {
@@ -27,8 +29,22 @@ func run(args []string) error {
 		return nil
 	case "check":
 		return NewCheckCommand().Run(args)
+	case "compact":
+		return NewCompactCommand().Run(args)
+	case "export":
+		return NewExportCommand().Run(args)
+	case "get":
+		return NewGetCommand().Run(args)
+	case "import":
+		return NewImportCommand().Run(args)
 	case "keys":
 		return NewKeysCommand().Run(args)
+	case "migrate":
+		return NewMigrateCommand().Run(args)
+	case "stats":
+		return NewStatsCommand().Run(args)
+	case "uncompact":
+		return NewUncompactCommand().Run(args)
 	default:
 		return fmt.Errorf("unknown command: %q", cmd)
 	}
@@ -45,7 +61,71 @@ Usage:
 The commands are:
 
 	check       verify integrity of a segment
+	compact     compact mutable segments into immutable segments
+	export      export a block range as segment files
+	get         print the decoded value of a key
 	help        print this screen
+	import      import segment files exported from another database
 	keys        dump all keys for a table
+	migrate     copy a database to another partition size or engine
+	stats       print table and segment statistics
+	uncompact   convert immutable segments back into mutable segments
 `[1:])
 }
+
+// openDB opens the database at path with the given partition size, compression
+// of compacted segments and engine of mutable segments.
+func openDB(path string, partitionSize uint64, compression, engine string) (*ethdb.DB, error) {
+	c, err := ethdb.ParseCompression(compression)
+	if err != nil {
+		return nil, err
+	}
+	e, err := ethdb.ParseEngine(engine)
+	if err != nil {
+		return nil, err
+	}
+	compactor := ethdb.NewFileSegmentCompactor()
+	compactor.Compression = c
+
+	db := ethdb.NewDB(path)
+	db.PartitionSize = partitionSize
+	db.MinCompactionAge = 0
+	db.SegmentCompactor = compactor
+	db.Engine = e
+	if err := db.Open(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// selectTables returns the named table, or all tables if name is blank.
+func selectTables(db *ethdb.DB, name string) ([]*ethdb.Table, error) {
+	if name == "" {
+		return db.Tables(), nil
+	} else if tbl := db.Table(name); tbl != nil {
+		return []*ethdb.Table{tbl}, nil
+	}
+	return nil, fmt.Errorf("unknown table name: %q", name)
+}
+
+// copySegment writes every key of s to tbl and returns the number of keys.
+func copySegment(tbl *ethdb.Table, s ethdb.Segment) (n int, err error) {
+	itr := s.Iterator()
+	defer itr.Close()
+
+	batch := tbl.NewBatch()
+	for itr.Next() {
+		if err := batch.Put(itr.Key(), itr.Value()); err != nil {
+			return n, err
+		}
+		n++
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return n, err
+			}
+			batch.Reset()
+		}
+	}
+	return n, batch.Write()
+}
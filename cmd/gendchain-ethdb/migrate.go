@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+)
+
+type MigrateCommand struct{}
+
+func NewMigrateCommand() *MigrateCommand {
+	return &MigrateCommand{}
+}
+
+func (cmd *MigrateCommand) Run(args []string) error {
+	fs := flag.NewFlagSet("gendchain-ethdb-migrate", flag.ContinueOnError)
+	fromPartitionSize := fs.Uint64("from-partition-size", ethdb.DefaultPartitionSize, "number of blocks per segment of the source database")
+	partitionSize := fs.Uint64("partition-size", ethdb.DefaultPartitionSize, "number of blocks per segment of the destination database")
+	compression := fs.String("compression", "", "compression of compacted segments (none, snappy or zstd)")
+	engine := fs.String("engine", "", "engine of the mutable segments of the destination database (leveldb or pebble)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() < 2 {
+		return errors.New("path and destination path required")
+	} else if _, err := os.Stat(fs.Arg(1)); !os.IsNotExist(err) {
+		return fmt.Errorf("destination already exists: %s", fs.Arg(1))
+	}
+
+	src, err := openDB(fs.Arg(0), *fromPartitionSize, "", "")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := openDB(fs.Arg(1), *partitionSize, *compression, *engine)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	// Copy segments in order so destination segments are created in order.
+	for _, srcTbl := range src.Tables() {
+		dstTbl := dst.Table(srcTbl.Name)
+		var total int
+		for _, name := range srcTbl.SegmentNames() {
+			s, err := srcTbl.AcquireSegment(name)
+			if err != nil {
+				return err
+			}
+			n, err := copySegment(dstTbl, s)
+			srcTbl.ReleaseSegment(s)
+			if err != nil {
+				return fmt.Errorf("%s/%s: %s", srcTbl.Name, name, err)
+			}
+			total += n
+		}
+		if err := dstTbl.Compact(context.Background()); err != nil {
+			return err
+		}
+		fmt.Printf("migrated %s (%d keys)\n", dstTbl.Name, total)
+	}
+
+	if *engine != "" {
+		fmt.Printf("run with --ethdb.partitionsize=%d --ethdb.engine=%s to open %s\n", *partitionSize, *engine, fs.Arg(1))
+	} else {
+		fmt.Printf("run with --ethdb.partitionsize=%d to open %s\n", *partitionSize, fs.Arg(1))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+)
+
+type StatsCommand struct{}
+
+func NewStatsCommand() *StatsCommand {
+	return &StatsCommand{}
+}
+
+func (cmd *StatsCommand) Run(args []string) error {
+	fs := flag.NewFlagSet("gendchain-ethdb-stats", flag.ContinueOnError)
+	tableName := fs.String("table", "", "table name (default all tables)")
+	partitionSize := fs.Uint64("partition-size", ethdb.DefaultPartitionSize, "number of blocks per segment")
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() == 0 {
+		return errors.New("path required")
+	}
+
+	db, err := openDB(fs.Arg(0), *partitionSize, "", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables, err := selectTables(db, *tableName)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tSEGMENT\tTYPE\tSIZE\tITEMS")
+	for _, tbl := range tables {
+		var totalSize int64
+		var totalItems int
+		names := tbl.SegmentNames()
+		for _, name := range names {
+			typ, size, items, err := segmentStats(tbl, name)
+			if err != nil {
+				return fmt.Errorf("%s/%s: %s", tbl.Name, name, err)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", tbl.Name, name, typ, size, items)
+			totalSize, totalItems = totalSize+size, totalItems+items
+		}
+		fmt.Fprintf(w, "%s\t(%d segments)\t\t%d\t%d\n", tbl.Name, len(names), totalSize, totalItems)
+	}
+	return w.Flush()
+}
+
+// segmentStats returns the type, size in bytes and item count of a segment.
+func segmentStats(tbl *ethdb.Table, name string) (typ string, size int64, items int, err error) {
+	s, err := tbl.AcquireSegment(name)
+	if err != nil {
+		return "", 0, 0, err
+	} else if s == nil {
+		return "", 0, 0, fmt.Errorf("segment not found")
+	}
+	defer tbl.ReleaseSegment(s)
+
+	switch s := s.(type) {
+	case *ethdb.FileSegment:
+		return ethdb.SegmentETH1, int64(s.Size()), s.Len(), nil
+	case *ethdb.CompressedFileSegment:
+		return ethdb.SegmentETH2, int64(s.Size()), s.Len(), nil
+	}
+
+	// Count items and sum file sizes of other segment types.
+	switch s.(type) {
+	case *ethdb.LDBSegment:
+		typ = ethdb.SegmentLDB1
+	case *ethdb.PebbleSegment:
+		typ = ethdb.SegmentPBL1
+	default:
+		typ = fmt.Sprintf("%T", s)
+	}
+	itr := s.Iterator()
+	for itr.Next() {
+		items++
+	}
+	if err := itr.Close(); err != nil {
+		return "", 0, 0, err
+	}
+	if err := filepath.Walk(s.Path(), func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	}); err != nil && !os.IsNotExist(err) {
+		return "", 0, 0, err
+	}
+	return typ, size, items, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+)
+
+type UncompactCommand struct{}
+
+func NewUncompactCommand() *UncompactCommand {
+	return &UncompactCommand{}
+}
+
+func (cmd *UncompactCommand) Run(args []string) error {
+	fs := flag.NewFlagSet("gendchain-ethdb-uncompact", flag.ContinueOnError)
+	tableName := fs.String("table", "", "table name")
+	partitionSize := fs.Uint64("partition-size", ethdb.DefaultPartitionSize, "number of blocks per segment")
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() < 2 {
+		return errors.New("path and segment name required")
+	} else if *tableName == "" {
+		return errors.New("table name required")
+	}
+
+	db, err := openDB(fs.Arg(0), *partitionSize, "", "")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tbl := db.Table(*tableName)
+	if tbl == nil {
+		return fmt.Errorf("unknown table name: %q", *tableName)
+	}
+
+	for _, name := range fs.Args()[1:] {
+		if err := tbl.UncompactSegment(context.Background(), name); err != nil {
+			return fmt.Errorf("%s/%s: %s", tbl.Name, name, err)
+		}
+		fmt.Printf("uncompacted %s/%s\n", tbl.Name, name)
+	}
+	return nil
+}
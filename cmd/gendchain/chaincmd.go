@@ -69,6 +69,7 @@ It expects the genesis file as argument.`,
 			utils.GCModeFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.CacheSnapshotFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -153,6 +153,11 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	// Serve GraphQL queries next to HTTP-RPC if requested.
+	if ctx.GlobalBool(utils.GraphQLEnabledFlag.Name) {
+		utils.RegisterGraphQLService(stack)
+	}
+
 	// Add the GendChain Stats daemon if requested.
 	if cfg.Netstats.URL != "" {
 		utils.RegisterNetStatsService(stack, cfg.Netstats)
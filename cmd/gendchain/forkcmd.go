@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ChainAAS/gendchain/cmd/utils"
+	"github.com/ChainAAS/gendchain/eth"
+	"github.com/ChainAAS/gendchain/node"
+	"github.com/urfave/cli"
+)
+
+var (
+	forksCommandAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: node.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	forksCommand = cli.Command{
+		Action:    utils.MigrateFlags(forks),
+		Name:      "forks",
+		Usage:     "Print the fork schedule and check it against connected peers",
+		ArgsUsage: " ",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The forks command attaches to a running node and prints the status of every
+fork of its chain configuration. Pending forks scheduled by block number are
+shown with a projected activation time, those scheduled by timestamp with a
+projected activation block, both based on the clique block period.
+
+It then lists the fork identifiers announced by the connected peers and flags
+those whose fork schedule mismatches the local one, in which case the command
+exits with an error. Peers on protocol versions before eth/64 don't announce
+a fork identifier and can't be checked.
+`,
+		Flags: []cli.Flag{
+			forksCommandAttachFlag,
+		},
+	}
+)
+
+// forks prints the fork schedule of a running node and the fork identifiers of
+// its peers.
+func forks(ctx *cli.Context) error {
+	client, err := dialRPC(ctx.String(forksCommandAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to gendchain node: %v", err)
+	}
+	defer client.Close()
+
+	var schedule eth.ForkSchedule
+	if err := client.Call(&schedule, "admin_forks"); err != nil {
+		utils.Fatalf("Failed to retrieve fork schedule: %v", err)
+	}
+	if mismatches := printForkSchedule(os.Stdout, &schedule); mismatches > 0 {
+		return fmt.Errorf("%d peers with mismatching fork schedule", mismatches)
+	}
+	return nil
+}
+
+// printForkSchedule writes a fork schedule in a human readable form, returning
+// the number of peers with a mismatching schedule.
+func printForkSchedule(out io.Writer, schedule *eth.ForkSchedule) int {
+	fmt.Fprintf(out, "Head:    #%d at %s\n", schedule.Head, formatTime(uint64(schedule.Time)))
+	fmt.Fprintf(out, "Fork ID: %s (next %d)\n\n", schedule.ForkID, schedule.ForkNext)
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FORK\tSCHEDULED\tSTATUS\tPROJECTED")
+	for _, fork := range schedule.Forks {
+		var scheduled, status, projected string
+		switch {
+		case fork.Block != nil && fork.Time != nil:
+			scheduled = fmt.Sprintf("block %d or %s", fork.Block.ToInt(), formatTime(fork.Time.ToInt().Uint64()))
+		case fork.Block != nil:
+			scheduled = fmt.Sprintf("block %d", fork.Block.ToInt())
+		default:
+			scheduled = formatTime(fork.Time.ToInt().Uint64())
+		}
+		if fork.Active {
+			status = "active"
+		} else {
+			status = "pending"
+		}
+		switch {
+		case fork.ProjectedTime != nil && fork.ProjectedBlock != nil:
+			projected = fmt.Sprintf("%s / block %d", formatTime(fork.ProjectedTime.ToInt().Uint64()), fork.ProjectedBlock.ToInt())
+		case fork.ProjectedTime != nil:
+			projected = formatTime(fork.ProjectedTime.ToInt().Uint64())
+		case fork.ProjectedBlock != nil:
+			projected = fmt.Sprintf("block %d", fork.ProjectedBlock.ToInt())
+		default:
+			projected = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", fork.Name, scheduled, status, projected)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nPeers:   %d\n", len(schedule.Peers))
+	if len(schedule.Peers) == 0 {
+		return 0
+	}
+	mismatches := 0
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "\nPEER\tNAME\tFORK ID\tNEXT\tSTATUS")
+	for _, peer := range schedule.Peers {
+		var id, next, status string
+		switch {
+		case peer.ForkID == "":
+			id, next, status = "-", "-", fmt.Sprintf("unknown (eth/%d)", peer.Version)
+		case peer.Mismatch != "":
+			id, next, status = peer.ForkID, fmt.Sprint(peer.ForkNext), "MISMATCH: "+peer.Mismatch
+			mismatches++
+		default:
+			id, next, status = peer.ForkID, fmt.Sprint(peer.ForkNext), "ok"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", peer.ID, peer.Name, id, next, status)
+	}
+	w.Flush()
+	return mismatches
+}
+
+// formatTime renders a unix timestamp as a UTC date.
+func formatTime(timestamp uint64) string {
+	return time.Unix(int64(timestamp), 0).UTC().Format("2006-01-02 15:04:05 UTC")
+}
@@ -60,12 +60,15 @@ var (
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.StateRetainFlag,
+		utils.StatePruneIntervalFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheGCFlag,
+		utils.CacheSnapshotFlag,
 		utils.TrieCacheGenFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
@@ -85,6 +88,7 @@ var (
 		utils.MinerLegacyExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerEquivocationRemovalFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -113,7 +117,20 @@ var (
 		utils.EthdbBucketFlag,
 		utils.EthdbAccessKeyIDFlag,
 		utils.EthdbSecretAccessKeyFlag,
+		utils.EthdbArchiveFlag,
+		utils.EthdbArchiveDirFlag,
+		utils.EthdbArchiveURLFlag,
+		utils.EthdbArchiveTokenFlag,
+		utils.EthdbFullDownloadFlag,
+		utils.EthdbBlockCacheSizeFlag,
+		utils.EthdbLocalCacheSizeFlag,
+		utils.EthdbPartitionSizeFlag,
+		utils.EthdbHistoryWindowFlag,
 		utils.EthdbMaxOpenSegmentCountFlag,
+		utils.EthdbCompressionFlag,
+		utils.EthdbEngineFlag,
+		utils.EthdbScrubIntervalFlag,
+		utils.EthdbScrubArchiveFlag,
 		configFileFlag,
 	}
 
@@ -122,6 +139,7 @@ var (
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RPCApiFlag,
+		utils.GraphQLEnabledFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
@@ -151,6 +169,8 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		// See snapshot.go:
+		snapshotCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -160,6 +180,8 @@ func init() {
 		consoleCommand,
 		attachCommand,
 		javascriptCommand,
+		// See forkcmd.go:
+		forksCommand,
 		// See misccmd.go:
 		versionCommand,
 		bugCommand,
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ChainAAS/gendchain/cmd/utils"
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/state/pruner"
+	"github.com/ChainAAS/gendchain/core/state/snapshot"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/urfave/cli"
+)
+
+var (
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "A set of commands based on the state of recent blocks",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(pruneState),
+				Name:      "prune-state",
+				Usage:     "Delete the state not reachable from recent blocks",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.StateRetainFlag,
+				},
+				Description: `
+gendchain snapshot prune-state
+
+Deletes all trie nodes and contract code which are not reachable from the state
+of the last --state.retain blocks (128 by default), the latest state persisted
+before them or the genesis state. The node must be stopped.
+
+An interrupted run is resumed the next time the database is opened.`,
+			},
+			{
+				Action:    utils.MigrateFlags(verifyState),
+				Name:      "verify-state",
+				Usage:     "Check the flat state snapshot against the state trie",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+gendchain snapshot verify-state
+
+Rebuilds the state trie from the persisted state snapshot and checks that its
+root matches the one the snapshot was taken at. The whole state is loaded in
+memory. The node must be stopped.`,
+			},
+		},
+	}
+)
+
+func pruneState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	if err := pruner.Recover(chainDb); err != nil {
+		utils.Fatalf("Failed to resume state pruning: %v", err)
+	}
+
+	retain := uint64(pruner.DefaultRetain)
+	if ctx.GlobalIsSet(utils.StateRetainFlag.Name) {
+		retain = ctx.GlobalUint64(utils.StateRetainFlag.Name)
+	}
+	head := rawdb.ReadHeaderNumber(chainDb.GlobalTable(), rawdb.ReadHeadBlockHash(chainDb.GlobalTable()))
+	if head == nil {
+		utils.Fatalf("Head block missing")
+	}
+
+	start := time.Now()
+	p := pruner.New(chainDb, state.NewDatabase(chainDb))
+	from, roots := p.RetainedRoots(*head, retain)
+	log.Info("Pruning state", "head", *head, "from", from, "roots", len(roots))
+	if err := p.Prune(context.Background(), from, roots); err != nil {
+		utils.Fatalf("Failed to prune state: %v", err)
+	}
+	log.Info("State pruning successful", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func verifyState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	root := rawdb.ReadSnapshotRoot(chainDb.GlobalTable())
+	if root == (common.Hash{}) {
+		utils.Fatalf("State snapshot missing")
+	}
+	if rawdb.ReadSnapshotGenerator(chainDb.GlobalTable()) != nil {
+		utils.Fatalf("State snapshot not fully generated, start the node to resume generation")
+	}
+	snaps, err := snapshot.New(chainDb, state.NewDatabase(chainDb).TrieDB(), 0)
+	if err != nil {
+		utils.Fatalf("Failed to open state snapshot: %v", err)
+	}
+	snaps.Load(root)
+	defer snaps.Stop()
+
+	start := time.Now()
+	log.Info("Verifying state snapshot", "root", root)
+	if err := snaps.Verify(root); err != nil {
+		utils.Fatalf("State snapshot verification failed: %v", err)
+	}
+	log.Info("Verified state snapshot", "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
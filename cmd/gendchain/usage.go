@@ -73,6 +73,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TestnetFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.StateRetainFlag,
+			utils.StatePruneIntervalFlag,
 			utils.NetStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
@@ -99,7 +101,20 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.EthdbBucketFlag,
 			utils.EthdbAccessKeyIDFlag,
 			utils.EthdbSecretAccessKeyFlag,
+			utils.EthdbArchiveFlag,
+			utils.EthdbArchiveDirFlag,
+			utils.EthdbArchiveURLFlag,
+			utils.EthdbArchiveTokenFlag,
+			utils.EthdbFullDownloadFlag,
+			utils.EthdbBlockCacheSizeFlag,
+			utils.EthdbLocalCacheSizeFlag,
+			utils.EthdbPartitionSizeFlag,
+			utils.EthdbHistoryWindowFlag,
 			utils.EthdbMaxOpenSegmentCountFlag,
+			utils.EthdbCompressionFlag,
+			utils.EthdbEngineFlag,
+			utils.EthdbScrubIntervalFlag,
+			utils.EthdbScrubArchiveFlag,
 		},
 	},
 	{
@@ -124,6 +139,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.CacheSnapshotFlag,
 			utils.TrieCacheGenFlag,
 		},
 	},
@@ -141,6 +157,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCListenAddrFlag,
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
+			utils.GraphQLEnabledFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
@@ -185,6 +202,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerEquivocationRemovalFlag,
 		},
 	},
 	{
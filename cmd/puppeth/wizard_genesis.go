@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/consensus/clique"
 	"github.com/ChainAAS/gendchain/core"
 	"github.com/ChainAAS/gendchain/log"
 	"github.com/ChainAAS/gendchain/params"
@@ -82,6 +83,35 @@ func (w *wizard) makeGenesis() {
 			copy(genesis.ExtraData[32+i*common.AddressLength:], signer[:])
 		}
 
+		// Optionally hand the signer and voter lists over to a governance contract
+		fmt.Println()
+		fmt.Println("Should signers and voters be governed by an on-chain contract? (y/N)")
+		if w.readDefaultString("n") == "y" {
+			fmt.Println()
+			fmt.Println("Which address should the governance contract live at? (mandatory)")
+			var contract *common.Address
+			for contract == nil {
+				contract = w.readAddress()
+			}
+			fmt.Println()
+			fmt.Println("Which account owns the governance contract? (mandatory)")
+			var owner *common.Address
+			for owner == nil {
+				owner = w.readAddress()
+			}
+			fmt.Println()
+			fmt.Println("Which block should contract governance come into effect? (default = 0)")
+			genesis.Config.Clique.GovernanceBlock = w.readDefaultBigInt(new(big.Int))
+			genesis.Config.Clique.GovernanceContract = *contract
+
+			genesis.Signers, genesis.Voters = signers, signers
+			genesis.Alloc[*contract] = core.GenesisAccount{
+				Balance: new(big.Int),
+				Code:    clique.GovernanceCode,
+				Storage: clique.GovernanceStorage(*owner, signers, signers),
+			}
+		}
+
 	default:
 		log.Crit("Invalid consensus engine choice", "choice", choice)
 	}
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -44,6 +45,7 @@ import (
 	"github.com/ChainAAS/gendchain/eth/downloader"
 	"github.com/ChainAAS/gendchain/eth/gasprice"
 	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/graphql"
 	"github.com/ChainAAS/gendchain/les"
 	"github.com/ChainAAS/gendchain/log"
 	"github.com/ChainAAS/gendchain/metrics"
@@ -184,6 +186,15 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	StateRetainFlag = cli.Uint64Flag{
+		Name:  "state.retain",
+		Usage: "Number of recent blocks whose state is kept by online state pruning (0 = disabled, ignored with --gcmode=archive)",
+	}
+	StatePruneIntervalFlag = cli.Uint64Flag{
+		Name:  "state.pruneinterval",
+		Usage: "Number of blocks between online state pruning runs",
+		Value: eth.DefaultConfig.StatePruneInterval,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -268,6 +279,11 @@ var (
 		Usage: "Percentage of cache memory allowance to use for trie pruning",
 		Value: 25,
 	}
+	CacheSnapshotFlag = cli.IntFlag{
+		Name:  "cache.snapshot",
+		Usage: "Percentage of cache memory allowance to use for state snapshot caching (0 = disable snapshots)",
+		Value: 10,
+	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
@@ -344,6 +360,10 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerEquivocationRemovalFlag = cli.BoolFlag{
+		Name:  "miner.equivocationremoval",
+		Usage: "Propose the removal of signers caught sealing conflicting blocks",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -415,6 +435,10 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable GraphQL queries on the HTTP-RPC server, at /graphql",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -568,10 +592,65 @@ var (
 		Name:  "ethdb.secretaccesskey",
 		Usage: "Ethdb archive secret access key.",
 	}
+	EthdbArchiveFlag = cli.StringFlag{
+		Name:  "ethdb.archive",
+		Usage: "Ethdb archive backend (s3, dir or http). Defaults to s3 if an endpoint and bucket are set.",
+	}
+	EthdbArchiveDirFlag = cli.StringFlag{
+		Name:  "ethdb.archivedir",
+		Usage: "Directory of the dir archive backend, e.g. a mounted network share.",
+	}
+	EthdbArchiveURLFlag = cli.StringFlag{
+		Name:  "ethdb.archiveurl",
+		Usage: "Base URL of the http archive backend.",
+	}
+	EthdbArchiveTokenFlag = cli.StringFlag{
+		Name:  "ethdb.archivetoken",
+		Usage: "Bearer token of the http archive backend.",
+	}
+	EthdbFullDownloadFlag = cli.BoolFlag{
+		Name:  "ethdb.fulldownload",
+		Usage: "Download archived segments as a whole instead of using ranged reads.",
+	}
+	EthdbBlockCacheSizeFlag = cli.IntFlag{
+		Name:  "ethdb.blockcache",
+		Usage: "Megabytes of memory allocated to caching ranged reads of archived segments.",
+		Value: ethdb.DefaultBlockCacheSize,
+	}
+	EthdbLocalCacheSizeFlag = cli.IntFlag{
+		Name:  "ethdb.localcache",
+		Usage: "Disk space in megabytes for local copies of archived segments (0 = purge copies once closed).",
+	}
+	EthdbPartitionSizeFlag = cli.Uint64Flag{
+		Name:  "ethdb.partitionsize",
+		Usage: "Number of blocks per ethdb segment. Must match the size the database was created or migrated with.",
+		Value: ethdb.DefaultPartitionSize,
+	}
+	EthdbHistoryWindowFlag = cli.Uint64Flag{
+		Name:  "ethdb.historywindow",
+		Usage: "Number of recent blocks whose bodies and receipts are kept locally, older ones are only kept in the archive (0 = keep all, ignored with --gcmode=archive).",
+	}
 	EthdbMaxOpenSegmentCountFlag = cli.IntFlag{
 		Name:  "ethdb.maxopensegmentcount",
 		Usage: "Ethdb per-table open segment count.",
 	}
+	EthdbScrubIntervalFlag = cli.DurationFlag{
+		Name:  "ethdb.scrubinterval",
+		Usage: "Time between background verifications of ethdb segments against their manifest (negative disables).",
+		Value: ethdb.DefaultScrubInterval,
+	}
+	EthdbScrubArchiveFlag = cli.BoolFlag{
+		Name:  "ethdb.scrubarchive",
+		Usage: "Verify the checksum of archived segments when scrubbing, reading them whole.",
+	}
+	EthdbCompressionFlag = cli.StringFlag{
+		Name:  "ethdb.compression",
+		Usage: "Ethdb compression of compacted segments (none, snappy or zstd).",
+	}
+	EthdbEngineFlag = cli.StringFlag{
+		Name:  "ethdb.engine",
+		Usage: "Ethdb engine of mutable segments (leveldb or pebble). Existing segments are converted on startup.",
+	}
 
 	EWASMInterpreterFlag = cli.StringFlag{
 		Name:  "vm.ewasm",
@@ -992,9 +1071,52 @@ func setEthdb(ctx *cli.Context, cfg *ethdb.Config) {
 	if ctx.GlobalIsSet(EthdbSecretAccessKeyFlag.Name) {
 		cfg.SecretAccessKey = ctx.GlobalString(EthdbSecretAccessKeyFlag.Name)
 	}
+	if ctx.GlobalIsSet(EthdbArchiveFlag.Name) {
+		cfg.Archive = ctx.GlobalString(EthdbArchiveFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbArchiveDirFlag.Name) {
+		cfg.ArchiveDir = ctx.GlobalString(EthdbArchiveDirFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbArchiveURLFlag.Name) {
+		cfg.ArchiveURL = ctx.GlobalString(EthdbArchiveURLFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbArchiveTokenFlag.Name) {
+		cfg.ArchiveToken = ctx.GlobalString(EthdbArchiveTokenFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbFullDownloadFlag.Name) {
+		cfg.FullDownload = ctx.GlobalBool(EthdbFullDownloadFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbBlockCacheSizeFlag.Name) {
+		cfg.BlockCacheSize = ctx.GlobalInt(EthdbBlockCacheSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbLocalCacheSizeFlag.Name) {
+		cfg.LocalCacheSize = ctx.GlobalInt(EthdbLocalCacheSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbPartitionSizeFlag.Name) {
+		cfg.PartitionSize = ctx.GlobalUint64(EthdbPartitionSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbHistoryWindowFlag.Name) {
+		cfg.HistoryWindow = ctx.GlobalUint64(EthdbHistoryWindowFlag.Name)
+		if ctx.GlobalString(GCModeFlag.Name) == "archive" {
+			log.Warn("Ignoring history window on archive node", "window", cfg.HistoryWindow)
+			cfg.HistoryWindow = 0
+		}
+	}
 	if ctx.GlobalIsSet(EthdbMaxOpenSegmentCountFlag.Name) {
 		cfg.MaxOpenSegmentCount = ctx.GlobalInt(EthdbMaxOpenSegmentCountFlag.Name)
 	}
+	if ctx.GlobalIsSet(EthdbCompressionFlag.Name) {
+		cfg.Compression = ctx.GlobalString(EthdbCompressionFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbEngineFlag.Name) {
+		cfg.Engine = ctx.GlobalString(EthdbEngineFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbScrubIntervalFlag.Name) {
+		cfg.ScrubInterval = ctx.GlobalDuration(EthdbScrubIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(EthdbScrubArchiveFlag.Name) {
+		cfg.ScrubArchive = ctx.GlobalBool(EthdbScrubArchiveFlag.Name)
+	}
 }
 
 // CheckExclusive verifies that only a single instance of the provided flags was
@@ -1088,10 +1210,23 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	if ctx.GlobalIsSet(StateRetainFlag.Name) {
+		cfg.StateRetain = ctx.GlobalUint64(StateRetainFlag.Name)
+		if cfg.NoPruning && cfg.StateRetain > 0 {
+			log.Warn("Ignoring state retention on archive node", "retain", cfg.StateRetain)
+			cfg.StateRetain = 0
+		}
+	}
+	if ctx.GlobalIsSet(StatePruneIntervalFlag.Name) {
+		cfg.StatePruneInterval = ctx.GlobalUint64(StatePruneIntervalFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
+		cfg.SnapshotCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
+	}
 	if ctx.GlobalIsSet(MinerNotifyFlag.Name) {
 		cfg.MinerNotify = strings.Split(ctx.GlobalString(MinerNotifyFlag.Name), ",")
 	}
@@ -1125,6 +1260,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(MinerNoVerfiyFlag.Name) {
 		cfg.MinerNoverify = ctx.Bool(MinerNoVerfiyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerEquivocationRemovalFlag.Name) {
+		cfg.MinerEquivocationRemoval = ctx.GlobalBool(MinerEquivocationRemovalFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1319,6 +1457,25 @@ func RegisterNetStatsService(stack *node.Node, cfg netstats.Config) {
 	}
 }
 
+// RegisterGraphQLService adds the GraphQL service, served on the HTTP-RPC
+// server, to a node.
+func RegisterGraphQLService(stack *node.Node) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Resolve queries against the eth or les service, whichever is running
+		var ethServ *eth.GendChain
+		if err := ctx.Service(&ethServ); err == nil {
+			return graphql.New(ethServ.ApiBackend, ethServ.Engine(), ethServ.BlockChain())
+		}
+		var lesServ *les.LightGendChain
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend, lesServ.Engine(), nil)
+		}
+		return nil, errors.New("GraphQL requires a full or light chain service")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context, stack *node.Node) common.Database {
 	var (
@@ -1373,10 +1530,18 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
 		TrieNodeLimit: eth.DefaultConfig.TrieCache,
 		TrieTimeLimit: eth.DefaultConfig.TrieTimeout,
+		SnapshotLimit: eth.DefaultConfig.SnapshotCache,
+	}
+	if !cache.Disabled {
+		cache.StateRetain = ctx.GlobalUint64(StateRetainFlag.Name)
+		cache.StatePruneInterval = ctx.GlobalUint64(StatePruneIntervalFlag.Name)
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
+		cache.SnapshotLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
+	}
 	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)}
 	chain, err = core.NewBlockChain(chainDb, cache, config, engine, vmcfg)
 	if err != nil {
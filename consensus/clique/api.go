@@ -4,8 +4,10 @@ import (
 	"context"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/hexutil"
 	"github.com/ChainAAS/gendchain/consensus"
 	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/params"
 	"github.com/ChainAAS/gendchain/rpc"
 )
 
@@ -16,19 +18,64 @@ type API struct {
 	clique *Clique
 }
 
-// GetSnapshot retrieves the state snapshot at a given block.
-func (api *API) GetSnapshot(ctx context.Context, number *rpc.BlockNumber) (*Snapshot, error) {
-	// Retrieve the requested block number (or current if none requested)
+// NewAPI returns the API of c over chain.
+func NewAPI(chain consensus.ChainReader, c *Clique) *API {
+	return &API{chain: chain, clique: c}
+}
+
+// header resolves the requested block, the current head if none is requested.
+func (api *API) header(blockNrOrHash *rpc.BlockNumberOrHash) (*types.Header, error) {
 	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
+	if blockNrOrHash == nil {
 		header = api.chain.CurrentHeader()
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		switch number {
+		case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+			// The pending block is sealed by the signers of the head
+			header = api.chain.CurrentHeader()
+		case rpc.FinalizedBlockNumber, rpc.SafeBlockNumber:
+			var err error
+			if header, err = api.finalizedHeader(); err != nil {
+				return nil, err
+			}
+		default:
+			header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+		}
 	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+		hash, _ := blockNrOrHash.Hash()
+		header = api.chain.GetHeaderByHash(hash)
+		if header != nil && blockNrOrHash.RequireCanonical {
+			if canonical := api.chain.GetHeaderByNumber(header.Number.Uint64()); canonical == nil || canonical.Hash() != hash {
+				return nil, errNotCanonical
+			}
+		}
 	}
-	// Ensure we have an actually valid block and return its snapshot
 	if header == nil {
 		return nil, errUnknownBlock
 	}
+	return header, nil
+}
+
+// finalizedHeader returns the latest finalized header, as tracked by the chain
+// if it does, or as computed from the current head otherwise.
+func (api *API) finalizedHeader() (*types.Header, error) {
+	if chain, ok := api.chain.(interface{ CurrentFinalizedHeader() *types.Header }); ok {
+		return chain.CurrentFinalizedHeader(), nil
+	}
+	number, err := api.clique.FinalizedNumber(api.chain, api.chain.CurrentHeader())
+	if err != nil {
+		return nil, err
+	}
+	return api.chain.GetHeaderByNumber(number), nil
+}
+
+// GetSnapshot retrieves the state snapshot at a given block.
+func (api *API) GetSnapshot(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*Snapshot, error) {
+	// Resolve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
 	return api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
 }
 
@@ -42,17 +89,11 @@ func (api *API) GetSnapshotAtHash(ctx context.Context, hash common.Hash) (*Snaps
 }
 
 // GetSigners retrieves the list of authorized signers at the specified block.
-func (api *API) GetSigners(ctx context.Context, number *rpc.BlockNumber) ([]common.Address, error) {
-	// Retrieve the requested block number (or current if none requested)
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = api.chain.CurrentHeader()
-	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	// Ensure we have an actually valid block and return the signers from its snapshot
-	if header == nil {
-		return nil, errUnknownBlock
+func (api *API) GetSigners(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Address, error) {
+	// Resolve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
 	if err != nil {
@@ -62,17 +103,11 @@ func (api *API) GetSigners(ctx context.Context, number *rpc.BlockNumber) ([]comm
 }
 
 // GetVoters retrieves the list of authorized voters at the specified block.
-func (api *API) GetVoters(ctx context.Context, number *rpc.BlockNumber) ([]common.Address, error) {
-	// Retrieve the requested block number (or current if none requested)
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = api.chain.CurrentHeader()
-	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	// Ensure we have an actually valid block and return the signers from its snapshot
-	if header == nil {
-		return nil, errUnknownBlock
+func (api *API) GetVoters(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Address, error) {
+	// Resolve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
 	}
 	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
 	if err != nil {
@@ -132,3 +167,117 @@ func (api *API) Discard(address common.Address) {
 
 	delete(api.clique.proposals, address)
 }
+
+// SignerStatus describes the liveness of a single authorized signer.
+type SignerStatus struct {
+	LastSigned uint64 `json:"lastSigned"` // Most recently signed block, 0 if never signed
+	Missed     uint64 `json:"missed"`     // Consecutive in-turn slots missed
+	InTurn     bool   `json:"inTurn"`     // Whether the signer is in turn for the next block
+	Inactive   bool   `json:"inactive"`   // Whether the signer crossed the eviction threshold
+}
+
+// GetSignerStatus retrieves the liveness of each authorized signer at the specified block.
+func (api *API) GetSignerStatus(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (map[common.Address]*SignerStatus, error) {
+	// Resolve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return api.signerStatus(snap), nil
+}
+
+// GetSignerStatusAtHash retrieves the liveness of each authorized signer at a given block.
+func (api *API) GetSignerStatusAtHash(ctx context.Context, hash common.Hash) (map[common.Address]*SignerStatus, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return api.signerStatus(snap), nil
+}
+
+func (api *API) signerStatus(snap *Snapshot) map[common.Address]*SignerStatus {
+	inturn := snap.inturn()
+	status := make(map[common.Address]*SignerStatus, len(snap.Signers))
+	for signer, last := range snap.Signers {
+		status[signer] = &SignerStatus{
+			LastSigned: last,
+			Missed:     snap.Missed[signer],
+			InTurn:     signer == inturn,
+		}
+	}
+	for _, signer := range snap.inactive(api.clique.config.EvictionThreshold) {
+		status[signer].Inactive = true
+	}
+	return status
+}
+
+// RewardSchedule describes the block reward in effect at a given block.
+type RewardSchedule struct {
+	Schedule     *params.RewardSchedule          `json:"schedule"`     // Active schedule, nil for the built-in rules
+	Reward       *hexutil.Big                    `json:"reward"`       // Total reward for the block
+	SignerReward *hexutil.Big                    `json:"signerReward"` // Part of the reward credited to the signer
+	Shares       map[common.Address]*hexutil.Big `json:"shares"`       // Parts of the reward credited to other beneficiaries
+}
+
+// GetRewardSchedule retrieves the block reward policy in effect at the specified block.
+func (api *API) GetRewardSchedule(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*RewardSchedule, error) {
+	// Resolve the requested block (or current if none requested)
+	header, err := api.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	cfg := api.chain.Config()
+	total, signer, shares := blockRewards(cfg, header.Number)
+	result := &RewardSchedule{
+		Schedule:     cfg.RewardSchedule(header.Number),
+		Reward:       (*hexutil.Big)(total),
+		SignerReward: (*hexutil.Big)(signer),
+		Shares:       make(map[common.Address]*hexutil.Big, len(shares)),
+	}
+	for addr, share := range shares {
+		result.Shares[addr] = (*hexutil.Big)(share)
+	}
+	return result, nil
+}
+
+// GetEquivocations retrieves the recorded evidence of signers sealing conflicting
+// blocks at the same height, oldest first.
+func (api *API) GetEquivocations() []*Equivocation {
+	return api.clique.Equivocations()
+}
+
+// Equivocations creates a subscription that fires whenever a signer is caught
+// sealing conflicting blocks at the same height.
+func (api *API) Equivocations(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		evidence := make(chan *Equivocation, 16)
+		api.clique.SubscribeEquivocations(evidence, "clique.API-Equivocations")
+		defer api.clique.UnsubscribeEquivocations(evidence)
+
+		for {
+			select {
+			case e := <-evidence:
+				notifier.Notify(rpcSub.ID, e)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
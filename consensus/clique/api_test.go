@@ -0,0 +1,72 @@
+package clique
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/params"
+	"github.com/ChainAAS/gendchain/rpc"
+)
+
+// testerHeaderChain implements consensus.ChainReader over a list of headers,
+// tracking the finalized one like core.BlockChain.
+type testerHeaderChain struct {
+	headers   []*types.Header
+	finalized uint64
+}
+
+func (c *testerHeaderChain) Config() *params.ChainConfig           { return params.AllCliqueProtocolChanges }
+func (c *testerHeaderChain) CurrentHeader() *types.Header          { return c.headers[len(c.headers)-1] }
+func (c *testerHeaderChain) CurrentFinalizedHeader() *types.Header { return c.headers[c.finalized] }
+func (c *testerHeaderChain) GetBlock(common.Hash, uint64) *types.Block {
+	panic("not supported")
+}
+func (c *testerHeaderChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return c.GetHeaderByHash(hash)
+}
+func (c *testerHeaderChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+func (c *testerHeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+// Tests that block tags are resolved like by the eth API.
+func TestAPIHeader(t *testing.T) {
+	chain := &testerHeaderChain{finalized: 2}
+	for i := 0; i < 5; i++ {
+		chain.headers = append(chain.headers, &types.Header{Number: big.NewInt(int64(i))})
+	}
+	api := NewAPI(chain, nil)
+
+	for number, want := range map[rpc.BlockNumber]uint64{
+		rpc.LatestBlockNumber:    4,
+		rpc.PendingBlockNumber:   4,
+		rpc.FinalizedBlockNumber: 2,
+		rpc.SafeBlockNumber:      2,
+		rpc.EarliestBlockNumber:  0,
+		3:                        3,
+	} {
+		blockNr := rpc.BlockNumberOrHashWithNumber(number)
+		header, err := api.header(&blockNr)
+		if err != nil {
+			t.Errorf("block %d: %v", number, err)
+		} else if have := header.Number.Uint64(); have != want {
+			t.Errorf("block %d: have %d, want %d", number, have, want)
+		}
+	}
+	blockNr := rpc.BlockNumberOrHashWithNumber(5)
+	if _, err := api.header(&blockNr); err != errUnknownBlock {
+		t.Errorf("unexpected error for unknown block: %v", err)
+	}
+}
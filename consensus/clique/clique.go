@@ -18,6 +18,7 @@ import (
 	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/common/hexutil"
 	"github.com/ChainAAS/gendchain/consensus"
+	"github.com/ChainAAS/gendchain/consensus/misc"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/crypto"
 	"github.com/ChainAAS/gendchain/log"
@@ -59,6 +60,10 @@ var (
 	// that is not part of the local blockchain.
 	errUnknownBlock = errors.New("unknown block")
 
+	// errNotCanonical is returned when a block requested by hash is required to
+	// be canonical, but is not part of the canonical chain.
+	errNotCanonical = errors.New("hash is not currently canonical")
+
 	// errInvalidVote is returned if a nonce value is something else that the two
 	// allowed constants of 0x00..0 or 0xff..f.
 	errInvalidVote = errors.New("vote nonce not 0x00..0 or 0xff..f")
@@ -160,6 +165,9 @@ type Clique struct {
 
 	proposals map[common.Address]propose // Current list of proposals we are pushing
 
+	equivocations       *equivocations // Recent seals and evidence of conflicting ones
+	equivocationRemoval bool           // Whether equivocating signers are proposed for removal
+
 	signer common.Address     // Address of the signing key
 	signFn consensus.SignerFn // Signer function to authorize hashes with
 	lock   sync.RWMutex       // Protects the signer fields
@@ -183,6 +191,8 @@ func New(config *params.CliqueConfig, db common.Database) *Clique {
 		recents:    recents,
 		signatures: signatures,
 		proposals:  make(map[common.Address]propose),
+
+		equivocations: newEquivocations(),
 	}
 }
 
@@ -340,13 +350,21 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainReader, header *type
 	if parent.Time.Uint64()+c.config.Period > header.Time.Uint64() {
 		return ErrInvalidTimestamp
 	}
+	// Verify the base fee of EIP-1559 headers against the parent
+	if err := misc.VerifyEip1559Header(chain.Config(), parent, header); err != nil {
+		return err
+	}
 	// Retrieve the snapshot needed to verify this header and cache it
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
 	if err != nil {
 		return err
 	}
-	// If the block is a checkpoint block, verify the signer list
-	if number%c.config.Epoch == 0 {
+	// If the block is a checkpoint block, verify the signer list. Under contract
+	// governance only the shape of the lists can be checked without state; their
+	// content is checked against the contract by VerifyState.
+	if number%c.config.Epoch == 0 && c.config.IsGovernance(header.Number) {
+		return verifyGovernanceLists(header)
+	} else if number%c.config.Epoch == 0 {
 		for i, signer := range snap.signers() {
 			if signer != header.Signers[i] {
 				return errInvalidCheckpointSigners
@@ -501,13 +519,17 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	header.Difficulty = new(big.Int).SetUint64(diff)
 
 	header.Extra = ExtraEnsureVanity(header.Extra)
-	//if not checkpoint
-	if number%c.config.Epoch != 0 {
+	//if not checkpoint and votes are still counted
+	if number%c.config.Epoch != 0 && !c.config.IsGovernance(header.Number) {
 		c.lock.RLock()
 
+		proposals := c.proposals
+		if c.config.IsEviction(header.Number) {
+			proposals = c.evictionProposals(snap)
+		}
 		// Gather all the proposals that make sense voting on
-		addresses := make([]common.Address, 0, len(c.proposals))
-		for address, propose := range c.proposals {
+		addresses := make([]common.Address, 0, len(proposals))
+		for address, propose := range proposals {
 			if snap.validVote(address, propose.Authorize, propose.VoterElection) {
 				addresses = append(addresses, address)
 			}
@@ -515,7 +537,7 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 		// If there's pending proposals, cast a vote on them
 		if len(addresses) > 0 {
 			candidate := addresses[rand.Intn(len(addresses))]
-			propose := c.proposals[candidate]
+			propose := proposals[candidate]
 			header.Extra = ExtraAppendVote(header.Extra, candidate, propose.VoterElection)
 			if propose.Authorize {
 				copy(header.Nonce[:], nonceAuthVote)
@@ -528,6 +550,7 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	}
 
 	if number%c.config.Epoch == 0 {
+		// Under contract governance these are replaced during finalization.
 		header.Signers = snap.signers()
 		header.Voters = snap.voters()
 	}
@@ -550,6 +573,29 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	return nil
 }
 
+// evictionProposals returns the current proposals extended with removal votes
+// against every signer in snap that has missed too many in-turn slots. Explicit
+// proposals take precedence, and the local signer never votes itself out. The
+// caller must hold at least the read lock.
+func (c *Clique) evictionProposals(snap *Snapshot) map[common.Address]propose {
+	inactive := snap.inactive(c.config.EvictionThreshold)
+	if len(inactive) == 0 || len(snap.Signers) <= 1 {
+		return c.proposals
+	}
+	proposals := make(map[common.Address]propose, len(c.proposals)+len(inactive))
+	for address, propose := range c.proposals {
+		proposals[address] = propose
+	}
+	for _, signer := range inactive {
+		if _, ok := proposals[signer]; ok || signer == c.signer {
+			continue
+		}
+		log.Info("Proposing eviction of inactive signer", "signer", signer, "missed", snap.Missed[signer])
+		proposals[signer] = propose{Authorize: false, VoterElection: false}
+	}
+	return proposals
+}
+
 func (c *Clique) Authorize(signer common.Address, signFn consensus.SignerFn) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -621,6 +667,17 @@ func (c *Clique) SealHash(header *types.Header) common.Hash {
 	return SealHash(header)
 }
 
+// FinalizedNumber implements consensus.FinalityReader, returning the number of
+// the latest block a majority of the signers have sealed or built upon in the
+// chain ending at header.
+func (c *Clique) FinalizedNumber(chain consensus.ChainReader, header *types.Header) (uint64, error) {
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return 0, err
+	}
+	return snap.finalized(), nil
+}
+
 // CalcDifficulty returns the difficulty for signer, given all signers and their most recently signed block numbers,
 // with 0 meaning 'has not signed'. With n signers, it will always return values from n/2+1 to n, inclusive, or 0.
 //
@@ -659,7 +716,7 @@ func (c *Clique) APIs(chain consensus.ChainReader) []rpc.API {
 	return []rpc.API{{
 		Namespace: "clique",
 		Version:   "1.0",
-		Service:   &API{chain: chain, clique: c},
+		Service:   NewAPI(chain, c),
 		Public:    false,
 	}}
 }
@@ -740,7 +797,7 @@ func CliqueRLP(header *types.Header) []byte {
 }
 
 func encodeSigHeader(w io.Writer, header *types.Header) {
-	err := rlp.Encode(w, []interface{}{
+	enc := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
 		header.Coinbase,
@@ -758,7 +815,11 @@ func encodeSigHeader(w io.Writer, header *types.Header) {
 		header.Extra,
 		header.MixDigest,
 		header.Nonce,
-	})
+	}
+	if header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	err := rlp.Encode(w, enc)
 	if err != nil {
 		panic("can't encode: " + err.Error())
 	}
@@ -0,0 +1,259 @@
+package clique
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/hashicorp/golang-lru"
+)
+
+const (
+	inmemorySealed   = 4096 // Number of recent sealed headers to keep for equivocation checks
+	maxEquivocations = 1024 // Number of equivocations to keep in the database
+)
+
+// Database keys of the recorded equivocation evidence. Records are stored one
+// per key in a ring of maxEquivocations slots, indexed by their sequence number
+// modulo maxEquivocations, next to the number of records ever recorded.
+var (
+	equivocationPrefix   = []byte("clique-equivocation-")       // equivocationPrefix + slot (uint64 big endian) -> evidence
+	equivocationCountKey = []byte("clique-equivocations-count") // number of records ever recorded (uint64 big endian)
+)
+
+// equivocationKey returns the database key of the slot of the i-th record.
+func equivocationKey(i uint64) []byte {
+	key := make([]byte, len(equivocationPrefix)+8)
+	copy(key, equivocationPrefix)
+	binary.BigEndian.PutUint64(key[len(equivocationPrefix):], i%maxEquivocations)
+	return key
+}
+
+var (
+	errNotEquivocation = errors.New("headers are not an equivocation")
+	errWrongSigner     = errors.New("header not sealed by the accused signer")
+)
+
+// Equivocation is the evidence of a signer sealing two different blocks at the
+// same height. Both headers carry the signer's seal, so the evidence can be
+// verified by anyone.
+type Equivocation struct {
+	Signer common.Address `json:"signer"` // Signer that sealed both headers
+	Number uint64         `json:"number"` // Height both headers were sealed at
+	First  *types.Header  `json:"first"`  // Header seen first
+	Second *types.Header  `json:"second"` // Conflicting header seen afterwards
+}
+
+// Verify checks that both headers are sealed by the signer at the same height,
+// but are different blocks.
+func (e *Equivocation) Verify() error {
+	if e.First == nil || e.Second == nil || e.First.Hash() == e.Second.Hash() ||
+		e.First.Number.Uint64() != e.Number || e.Second.Number.Uint64() != e.Number {
+		return errNotEquivocation
+	}
+	sigcache, _ := lru.NewARC(2)
+	for _, header := range []*types.Header{e.First, e.Second} {
+		signer, err := ecrecover(header, sigcache)
+		if err != nil {
+			return err
+		}
+		if signer != e.Signer {
+			return errWrongSigner
+		}
+	}
+	return nil
+}
+
+// sealKey identifies the slot a signer sealed a header for.
+type sealKey struct {
+	signer common.Address
+	number uint64
+}
+
+// equivocations tracks recently sealed headers per signer and the evidence of
+// any conflicting seals.
+type equivocations struct {
+	sealed *lru.ARCCache // Recently seen header for each signer and height
+
+	evidence []*Equivocation // Recorded evidence, oldest first (loaded lazily)
+	count    uint64          // Number of records ever recorded
+	loaded   bool            // Whether evidence has been loaded from the database
+	subs     map[chan<- *Equivocation]string
+	lock     sync.Mutex
+}
+
+func newEquivocations() *equivocations {
+	sealed, _ := lru.NewARC(inmemorySealed)
+	return &equivocations{sealed: sealed}
+}
+
+// ObserveHeader implements consensus.EquivocationDetector, recording the seal
+// of a verified header and reporting it if its signer already sealed another
+// block at the same height.
+func (c *Clique) ObserveHeader(header *types.Header) {
+	c.checkEquivocation(header)
+}
+
+// checkEquivocation records the seal of header, returning the evidence if its
+// signer sealed a different header at the same height before.
+func (c *Clique) checkEquivocation(header *types.Header) *Equivocation {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	signer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return nil
+	}
+	key := sealKey{signer: signer, number: number}
+	prev, ok := c.equivocations.sealed.Get(key)
+	if !ok {
+		c.equivocations.sealed.Add(key, header)
+		return nil
+	}
+	first := prev.(*types.Header)
+	if first.Hash() == header.Hash() {
+		return nil
+	}
+	evidence := &Equivocation{Signer: signer, Number: number, First: first, Second: header}
+	if !c.recordEquivocation(evidence) {
+		return nil
+	}
+	log.Warn("Signer sealed conflicting blocks", "signer", signer, "number", number, "first", first.Hash(), "second", header.Hash())
+
+	c.lock.Lock()
+	if c.equivocationRemoval && signer != c.signer {
+		c.proposals[signer] = propose{Authorize: false, VoterElection: false}
+		log.Info("Proposing removal of equivocating signer", "signer", signer)
+	}
+	c.lock.Unlock()
+	return evidence
+}
+
+// SetEquivocationRemoval sets whether signers caught sealing conflicting blocks
+// are proposed for removal. Like any proposal this is local policy of the node,
+// not part of the chain configuration.
+func (c *Clique) SetEquivocationRemoval(enabled bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.equivocationRemoval = enabled
+}
+
+// recordEquivocation persists the evidence and notifies subscribers, unless the
+// signer was already caught at the same height. It reports whether the evidence
+// is new.
+func (c *Clique) recordEquivocation(evidence *Equivocation) bool {
+	e := c.equivocations
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	c.loadEquivocations()
+	for _, known := range e.evidence {
+		if known.Signer == evidence.Signer && known.Number == evidence.Number {
+			return false
+		}
+	}
+	e.evidence = append(e.evidence, evidence)
+	if len(e.evidence) > maxEquivocations {
+		e.evidence = e.evidence[len(e.evidence)-maxEquivocations:]
+	}
+	// Store the record in its own slot, overwriting the oldest one once full
+	if blob, err := json.Marshal(evidence); err != nil {
+		log.Error("Failed to encode equivocation", "err", err)
+	} else {
+		var count [8]byte
+		binary.BigEndian.PutUint64(count[:], e.count+1)
+
+		batch := c.db.GlobalTable().NewBatch()
+		batch.Put(equivocationKey(e.count), blob)
+		batch.Put(equivocationCountKey, count[:])
+		if err := batch.Write(); err != nil {
+			log.Error("Failed to store equivocation", "err", err)
+		}
+	}
+	e.count++
+	for sub, name := range e.subs {
+		select {
+		case sub <- evidence:
+		default:
+			log.Warn("Equivocation feed send dropped: channel full", "name", name, "cap", cap(sub), "signer", evidence.Signer, "number", evidence.Number)
+		}
+	}
+	return true
+}
+
+// loadEquivocations reads the recorded evidence from the database on first use.
+// The caller must hold the equivocations lock.
+func (c *Clique) loadEquivocations() {
+	e := c.equivocations
+	if e.loaded {
+		return
+	}
+	e.loaded = true
+
+	table := c.db.GlobalTable()
+	blob, err := table.Get(equivocationCountKey)
+	if err != nil || len(blob) != 8 {
+		return
+	}
+	e.count = binary.BigEndian.Uint64(blob)
+
+	first := uint64(0)
+	if e.count > maxEquivocations {
+		first = e.count - maxEquivocations
+	}
+	for i := first; i < e.count; i++ {
+		blob, err := table.Get(equivocationKey(i))
+		if err != nil {
+			log.Error("Missing equivocation record", "index", i, "err", err)
+			continue
+		}
+		evidence := new(Equivocation)
+		if err := json.Unmarshal(blob, evidence); err != nil {
+			log.Error("Failed to decode equivocation", "index", i, "err", err)
+			continue
+		}
+		e.evidence = append(e.evidence, evidence)
+	}
+}
+
+// Equivocations returns the recorded evidence of signers sealing conflicting
+// blocks, oldest first.
+func (c *Clique) Equivocations() []*Equivocation {
+	e := c.equivocations
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	c.loadEquivocations()
+	return append([]*Equivocation(nil), e.evidence...)
+}
+
+// SubscribeEquivocations registers a channel receiving newly detected evidence.
+func (c *Clique) SubscribeEquivocations(ch chan<- *Equivocation, name string) {
+	e := c.equivocations
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.subs == nil {
+		e.subs = make(map[chan<- *Equivocation]string)
+	}
+	e.subs[ch] = name
+}
+
+// UnsubscribeEquivocations removes and closes a channel registered with
+// SubscribeEquivocations.
+func (c *Clique) UnsubscribeEquivocations(ch chan<- *Equivocation) {
+	e := c.equivocations
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if _, ok := e.subs[ch]; ok {
+		delete(e.subs, ch)
+		close(ch)
+	}
+}
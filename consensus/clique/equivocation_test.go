@@ -0,0 +1,118 @@
+package clique
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/params"
+)
+
+// Tests that a signer sealing two different blocks at the same height is caught,
+// and that the evidence is persisted, published and acted upon.
+func TestEquivocation(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	newHeader := func(number int64, vanity byte, signer string) *types.Header {
+		header := &types.Header{
+			Number:     big.NewInt(number),
+			Time:       big.NewInt(number * int64(params.DefaultCliquePeriod)),
+			Difficulty: big.NewInt(1),
+			Signer:     make([]byte, signatureLength),
+			Extra:      make([]byte, extraVanity),
+		}
+		header.Extra[0] = vanity
+		accounts.sign(header, signer)
+		return header
+	}
+	db := ethdb.NewMemDatabase()
+	engine := New(&params.CliqueConfig{}, db)
+	engine.SetEquivocationRemoval(true)
+
+	evidence := make(chan *Equivocation, 1)
+	engine.SubscribeEquivocations(evidence, "test")
+	defer engine.UnsubscribeEquivocations(evidence)
+
+	// Seals at different heights, by different signers or of the same block are fine
+	first := newHeader(1, 0, "A")
+	for _, header := range []*types.Header{first, first, newHeader(2, 1, "A"), newHeader(1, 1, "B")} {
+		if e := engine.checkEquivocation(header); e != nil {
+			t.Fatalf("unexpected equivocation: %+v", e)
+		}
+	}
+	// A second block by A at height 1 is not
+	second := newHeader(1, 1, "A")
+	e := engine.checkEquivocation(second)
+	if e == nil {
+		t.Fatalf("equivocation not detected")
+	}
+	if e.Signer != accounts.address("A") || e.Number != 1 || e.First.Hash() != first.Hash() || e.Second.Hash() != second.Hash() {
+		t.Errorf("evidence mismatch: %+v", e)
+	}
+	if err := e.Verify(); err != nil {
+		t.Errorf("evidence failed to verify: %v", err)
+	}
+	select {
+	case published := <-evidence:
+		if published != e {
+			t.Errorf("published evidence mismatch: have %+v, want %+v", published, e)
+		}
+	default:
+		t.Errorf("evidence not published")
+	}
+	if proposal, ok := engine.proposals[accounts.address("A")]; !ok || proposal.Authorize {
+		t.Errorf("removal not proposed: %+v", engine.proposals)
+	}
+	// Further conflicts at the same height add nothing new
+	if e := engine.checkEquivocation(newHeader(1, 2, "A")); e != nil {
+		t.Errorf("duplicate equivocation reported: %+v", e)
+	}
+	// The evidence survives a restart
+	recorded := New(&params.CliqueConfig{}, db).Equivocations()
+	if len(recorded) != 1 || recorded[0].Second.Hash() != second.Hash() {
+		t.Fatalf("recorded evidence mismatch: %+v", recorded)
+	}
+	if err := recorded[0].Verify(); err != nil {
+		t.Errorf("recorded evidence failed to verify: %v", err)
+	}
+	// Evidence naming the wrong signer is rejected
+	forged := *recorded[0]
+	forged.Signer = accounts.address("B")
+	if err := forged.Verify(); err != errWrongSigner {
+		t.Errorf("forged evidence: have %v, want %v", err, errWrongSigner)
+	}
+}
+
+// Tests that evidence is stored one record per key, keeping only the most
+// recent maxEquivocations records.
+func TestEquivocationRecords(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	engine := New(&params.CliqueConfig{}, db)
+	for i := uint64(1); i <= maxEquivocations+2; i++ {
+		if !engine.recordEquivocation(&Equivocation{Number: i}) {
+			t.Fatalf("record %d: evidence not recorded", i)
+		}
+	}
+	if engine.recordEquivocation(&Equivocation{Number: maxEquivocations + 2}) {
+		t.Fatalf("duplicate evidence recorded")
+	}
+	blob, err := db.GlobalTable().Get(equivocationKey(0))
+	if err != nil {
+		t.Fatalf("failed to read first slot: %v", err)
+	}
+	var oldest Equivocation
+	if err := json.Unmarshal(blob, &oldest); err != nil || oldest.Number != maxEquivocations+1 {
+		t.Fatalf("first slot not reused: %+v, %v", oldest, err)
+	}
+	recorded := New(&params.CliqueConfig{}, db).Equivocations()
+	if len(recorded) != maxEquivocations {
+		t.Fatalf("recorded evidence count mismatch: have %d, want %d", len(recorded), maxEquivocations)
+	}
+	for i, e := range recorded {
+		if want := uint64(i) + 3; e.Number != want {
+			t.Fatalf("record %d: number mismatch: have %d, want %d", i, e.Number, want)
+		}
+	}
+}
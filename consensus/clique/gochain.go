@@ -3,24 +3,34 @@ package clique
 import (
 	"math/big"
 
+	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/consensus"
 	"github.com/ChainAAS/gendchain/core/state"
 	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/params"
 )
 
-// BlockReward is the reward in wei distributed each block.
+// BlockReward is the reward in wei distributed each block, unless a reward
+// schedule is active.
 var BlockReward = big.NewInt(7e+18)
 
 // Finalize implements consensus.Engine, ensuring no uncles are set, but this does give rewards.
-func (_ *Clique) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt, block bool) *types.Block {
+func (c *Clique) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, receipts []*types.Receipt, block bool) *types.Block {
 	cfg := chain.Config()
-	signerReward := BlockReward
-	if cfg.IsHafthor(header.Number) {
-		// Split the reward for staking.
-		signerReward = new(big.Int).Rsh(BlockReward, 1)            // half
-		stakeReward := new(big.Int).Sub(BlockReward, signerReward) // difference so that total is exactly BlockReward
-		// Reward the stakers.
-		state.AddBalance(cfg.HafthorStakeAddress, stakeReward)
+	if block && header.Number.Uint64()%c.config.Epoch == 0 && c.config.IsGovernance(header.Number) {
+		// Checkpoints carry the lists held by the governance contract.
+		signers, voters, err := c.governanceLists(chain, header, state)
+		if err != nil {
+			log.Error("Failed to read governance contract", "number", header.Number, "err", err)
+		} else {
+			header.Signers, header.Voters = signers, voters
+		}
+	}
+	_, signerReward, shares := blockRewards(cfg, header.Number)
+	// Reward the beneficiaries, e.g. the stakers.
+	for addr, share := range shares {
+		state.AddBalance(addr, share)
 	}
 	// Reward the signer.
 	state.AddBalance(header.Coinbase, signerReward)
@@ -34,3 +44,30 @@ func (_ *Clique) Finalize(chain consensus.ChainReader, header *types.Header, sta
 	}
 	return nil
 }
+
+// blockRewards returns the total reward for block number, the part of it that
+// goes to the signer, and the parts that go to other beneficiaries.
+func blockRewards(cfg *params.ChainConfig, number *big.Int) (total, signer *big.Int, shares map[common.Address]*big.Int) {
+	shares = make(map[common.Address]*big.Int)
+	if schedule := cfg.RewardSchedule(number); schedule != nil {
+		// Split the scheduled reward among the beneficiaries.
+		var split []*big.Int
+		total = schedule.BlockReward(number)
+		split, signer = schedule.Split(total)
+		for i, b := range schedule.Beneficiaries {
+			if share, ok := shares[b.Address]; ok {
+				share.Add(share, split[i])
+			} else {
+				shares[b.Address] = split[i]
+			}
+		}
+		return total, signer, shares
+	}
+	if cfg.IsHafthor(number) {
+		// Split the reward for staking.
+		signer = new(big.Int).Rsh(BlockReward, 1)                               // half
+		shares[cfg.HafthorStakeAddress] = new(big.Int).Sub(BlockReward, signer) // difference so that total is exactly BlockReward
+		return BlockReward, signer, shares
+	}
+	return BlockReward, BlockReward, shares
+}
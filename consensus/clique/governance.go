@@ -0,0 +1,197 @@
+package clique
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/hexutil"
+	"github.com/ChainAAS/gendchain/consensus"
+	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/crypto"
+	"github.com/ChainAAS/gendchain/log"
+)
+
+// Storage layout of the governance contract. The signer and voter lists are
+// laid out like Solidity dynamic arrays: the length lives in the slot itself,
+// and the elements start at keccak256(slot).
+var (
+	governanceSignersSlot = common.BigToHash(big.NewInt(0))
+	governanceVotersSlot  = common.BigToHash(big.NewInt(1))
+	governanceOwnerSlot   = common.BigToHash(big.NewInt(2))
+)
+
+// maxGovernanceList caps the number of entries read from a governance list, so
+// that a corrupted length slot can't stall the engine.
+const maxGovernanceList = 1024
+
+// GovernanceCode is the runtime bytecode of the reference governance contract.
+// It exposes the following methods, where only the owner may call the setters:
+//
+//	owner() returns (address)
+//	transferOwnership(address)
+//	setSigners(address[])
+//	setVoters(address[])
+//
+// A DAO is expected to own the contract. Lists take effect at the next epoch
+// checkpoint after the governance fork. Setting a shorter list does not clear
+// the trailing storage slots; only the stored length is authoritative.
+var GovernanceCode = hexutil.MustDecode("0x" +
+	"7c010000000000000000000000000000000000000000000000000000000060003504" + // selector = calldata[0:4]
+	"80638da5cb5b14610053578063f2fde38b1461005f578063a377266214610071578063845023f21461007857" + // dispatch on selector
+	"5b600080fd" + // revert
+	"5b60025460005260206000f3" + // owner()
+	"5b33600254141561004e5760043560025500" + // transferOwnership(address)
+	"5b600061007f565b600161007f56" + // setSigners / setVoters pick the list slot
+	"5b33600254141561004e5760043560040180358083558260005260206000206000" + // owner check, store length, hash slot
+	"5b828110156100be5780602002840160200135828201556001016100a0565b00") // copy elements into keccak256(slot)+i
+
+// GovernanceDeployCode returns the init code deploying GovernanceCode, with
+// the deploying account as the owner.
+func GovernanceDeployCode() []byte {
+	size := byte(len(GovernanceCode))
+	code := []byte{
+		0x33, 0x60, 0x02, 0x55, // CALLER PUSH1 2 SSTORE
+		0x60, size, 0x60, 0x10, 0x60, 0x00, 0x39, // CODECOPY(0, 16, size)
+		0x60, size, 0x60, 0x00, 0xf3, // RETURN(0, size)
+	}
+	return append(code, GovernanceCode...)
+}
+
+// GovernanceStorage returns the initial storage of a governance contract owned
+// by owner and holding the given signer and voter lists, for use in a genesis
+// allocation together with GovernanceCode.
+func GovernanceStorage(owner common.Address, signers, voters []common.Address) map[common.Hash]common.Hash {
+	storage := map[common.Hash]common.Hash{
+		governanceOwnerSlot: owner.Hash(),
+	}
+	for slot, list := range map[common.Hash][]common.Address{
+		governanceSignersSlot: signers,
+		governanceVotersSlot:  voters,
+	} {
+		storage[slot] = common.BigToHash(big.NewInt(int64(len(list))))
+		base := crypto.Keccak256Hash(slot[:]).Big()
+		for i, addr := range list {
+			storage[common.BigToHash(new(big.Int).Add(base, big.NewInt(int64(i))))] = addr.Hash()
+		}
+	}
+	return storage
+}
+
+// readGovernanceList reads the list stored at slot of the governance contract,
+// sorted in ascending order and without duplicates. Lists longer than
+// maxGovernanceList are treated as empty.
+func readGovernanceList(statedb *state.StateDB, contract common.Address, slot common.Hash) []common.Address {
+	n := statedb.GetState(contract, slot).Big()
+	if !n.IsUint64() || n.Uint64() > maxGovernanceList {
+		log.Warn("Ignoring oversized governance list", "contract", contract, "slot", slot, "length", n)
+		return nil
+	}
+	base := crypto.Keccak256Hash(slot[:]).Big()
+	seen := make(map[common.Address]struct{})
+	list := make([]common.Address, 0, n.Uint64())
+	for i := uint64(0); i < n.Uint64(); i++ {
+		key := common.BigToHash(new(big.Int).Add(base, new(big.Int).SetUint64(i)))
+		addr := common.BytesToAddress(statedb.GetState(contract, key).Bytes())
+		if _, ok := seen[addr]; ok || addr == (common.Address{}) {
+			continue
+		}
+		seen[addr] = struct{}{}
+		list = append(list, addr)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i][:], list[j][:]) < 0
+	})
+	return list
+}
+
+// governanceLists returns the signer and voter lists that the checkpoint header
+// must carry in governance mode, read from the contract in statedb. Empty lists
+// fall back to the current ones, so that a missing or misconfigured contract
+// can't halt the chain.
+func (c *Clique) governanceLists(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) ([]common.Address, []common.Address, error) {
+	signers := readGovernanceList(statedb, c.config.GovernanceContract, governanceSignersSlot)
+	voters := readGovernanceList(statedb, c.config.GovernanceContract, governanceVotersSlot)
+	if len(signers) == 0 || len(voters) == 0 {
+		number := header.Number.Uint64()
+		snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(signers) == 0 {
+			signers = snap.signers()
+		}
+		if len(voters) == 0 {
+			voters = snap.voters()
+		}
+	}
+	return signers, voters, nil
+}
+
+// errInvalidGovernanceLists is returned if a checkpoint block in governance mode
+// carries signer or voter lists which differ from the governance contract.
+var errInvalidGovernanceLists = errors.New("checkpoint lists differ from governance contract")
+
+// VerifyState implements consensus.StateVerifier, checking that checkpoint
+// blocks in governance mode carry the lists held by the governance contract.
+func (c *Clique) VerifyState(chain consensus.ChainReader, header *types.Header, statedb *state.StateDB) error {
+	number := header.Number.Uint64()
+	if number == 0 || number%c.config.Epoch != 0 || !c.config.IsGovernance(header.Number) {
+		return nil
+	}
+	signers, voters, err := c.governanceLists(chain, header, statedb)
+	if err != nil {
+		return err
+	}
+	if !addressesEqual(signers, header.Signers) || !addressesEqual(voters, header.Voters) {
+		return errInvalidGovernanceLists
+	}
+	return nil
+}
+
+// verifyGovernanceLists checks the checkpoint lists of a governance mode header
+// without the state of its block, which header-only verification lacks. The
+// lists must be non-empty, no longer than maxGovernanceList, free of the zero
+// address and strictly ascending, as produced by governanceLists.
+//
+// Skipping the comparison with the contract is safe for full nodes, which run
+// VerifyState on import before the checkpoint is committed. Nodes that only
+// verify headers trust the checkpoint sealer for the lists, as they already
+// trust it for the state root that the contract lives in.
+func verifyGovernanceLists(header *types.Header) error {
+	if !governanceListValid(header.Signers) {
+		return errInvalidCheckpointSigners
+	} else if !governanceListValid(header.Voters) {
+		return errInvalidCheckpointVoters
+	}
+	return nil
+}
+
+func governanceListValid(list []common.Address) bool {
+	if len(list) == 0 || len(list) > maxGovernanceList {
+		return false
+	}
+	for i, addr := range list {
+		if addr == (common.Address{}) {
+			return false
+		} else if i > 0 && bytes.Compare(list[i-1][:], addr[:]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func addressesEqual(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
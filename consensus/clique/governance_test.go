@@ -0,0 +1,199 @@
+package clique
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core"
+	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/core/vm/runtime"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/params"
+)
+
+// packAddresses ABI encodes a call with a single address[] argument.
+func packAddresses(selector []byte, addrs []common.Address) []byte {
+	input := append([]byte{}, selector...)
+	input = append(input, common.BigToHash(big.NewInt(32)).Bytes()...)
+	input = append(input, common.BigToHash(big.NewInt(int64(len(addrs)))).Bytes()...)
+	for _, addr := range addrs {
+		input = append(input, addr.Hash().Bytes()...)
+	}
+	return input
+}
+
+var (
+	selectorOwner      = common.FromHex("0x8da5cb5b")
+	selectorTransfer   = common.FromHex("0xf2fde38b")
+	selectorSetSigners = common.FromHex("0xa3772662")
+	selectorSetVoters  = common.FromHex("0x845023f2")
+)
+
+// Tests that the reference governance contract only lets its owner update the
+// lists, and that the engine reads back what was written.
+func TestGovernanceContract(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x1000")
+		owner    = common.HexToAddress("0xaa")
+		intruder = common.HexToAddress("0xbb")
+		a        = common.HexToAddress("0x03")
+		b        = common.HexToAddress("0x01")
+		c        = common.HexToAddress("0x02")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	statedb.SetCode(contract, GovernanceCode)
+	for key, value := range GovernanceStorage(owner, []common.Address{a}, []common.Address{a}) {
+		statedb.SetState(contract, key, value)
+	}
+	if signers := readGovernanceList(statedb, contract, governanceSignersSlot); !addressesEqual(signers, []common.Address{a}) {
+		t.Fatalf("genesis signers mismatch: have %x, want [%x]", signers, a)
+	}
+	call := func(from common.Address, input []byte) ([]byte, error) {
+		ret, _, err := runtime.Call(contract, input, &runtime.Config{
+			ChainConfig: params.AllCliqueProtocolChanges,
+			Origin:      from,
+			State:       statedb,
+		})
+		return ret, err
+	}
+	// Strangers must not be able to touch the lists
+	if _, err := call(intruder, packAddresses(selectorSetSigners, []common.Address{intruder})); err == nil {
+		t.Fatalf("intruder updated the signers")
+	}
+	// The owner can, and the engine reads them back sorted and deduplicated
+	if _, err := call(owner, packAddresses(selectorSetSigners, []common.Address{a, b, c, b})); err != nil {
+		t.Fatalf("failed to set signers: %v", err)
+	}
+	if _, err := call(owner, packAddresses(selectorSetVoters, []common.Address{c, a})); err != nil {
+		t.Fatalf("failed to set voters: %v", err)
+	}
+	if signers := readGovernanceList(statedb, contract, governanceSignersSlot); !addressesEqual(signers, []common.Address{b, c, a}) {
+		t.Errorf("signers mismatch: have %x, want %x", signers, []common.Address{b, c, a})
+	}
+	if voters := readGovernanceList(statedb, contract, governanceVotersSlot); !addressesEqual(voters, []common.Address{c, a}) {
+		t.Errorf("voters mismatch: have %x, want %x", voters, []common.Address{c, a})
+	}
+	// Ownership can be handed over
+	if _, err := call(owner, append(append([]byte{}, selectorTransfer...), intruder.Hash().Bytes()...)); err != nil {
+		t.Fatalf("failed to transfer ownership: %v", err)
+	}
+	ret, err := call(b, selectorOwner)
+	if err != nil {
+		t.Fatalf("failed to query owner: %v", err)
+	}
+	if have := common.BytesToAddress(ret); have != intruder {
+		t.Errorf("owner mismatch: have %x, want %x", have, intruder)
+	}
+}
+
+// Tests that deploying the governance contract makes the deployer its owner.
+func TestGovernanceDeploy(t *testing.T) {
+	deployer := common.HexToAddress("0xaa")
+	cfg := &runtime.Config{ChainConfig: params.AllCliqueProtocolChanges, Origin: deployer}
+	code, address, _, err := runtime.Create(GovernanceDeployCode(), cfg)
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	if !bytes.Equal(code, GovernanceCode) {
+		t.Fatalf("deployed code mismatch: have %x, want %x", code, GovernanceCode)
+	}
+	if owner := cfg.State.GetState(address, governanceOwnerSlot); owner != deployer.Hash() {
+		t.Errorf("owner mismatch: have %x, want %x", owner, deployer.Hash())
+	}
+}
+
+// Tests that under contract governance votes are ignored, and the lists only
+// change at checkpoints.
+func TestGovernanceSnapshot(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B")}
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity),
+		Signers:   signers,
+		Voters:    signers,
+		Signer:    make([]byte, signatureLength),
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.Commit(db)
+
+	config := &params.CliqueConfig{Epoch: 3, GovernanceBlock: big.NewInt(1)}
+	headers := make([]*types.Header, 4)
+	for j := range headers {
+		headers[j] = &types.Header{
+			Number: big.NewInt(int64(j) + 1),
+			Time:   big.NewInt(int64(j) * int64(params.DefaultCliquePeriod)),
+			Signer: make([]byte, signatureLength),
+			Extra:  make([]byte, extraVanity),
+		}
+		if j > 0 {
+			headers[j].ParentHash = headers[j-1].Hash()
+		}
+		if j < 2 {
+			// Both voters want C in, which would pass without governance
+			copy(headers[j].Nonce[:], nonceAuthVote)
+			headers[j].Extra = ExtraAppendVote(headers[j].Extra, accounts.address("C"), false)
+		}
+		if headers[j].Number.Uint64()%config.Epoch == 0 {
+			// The governance contract replaced B with D
+			headers[j].Signers = sortedAddresses(accounts.address("A"), accounts.address("D"))
+			headers[j].Voters = []common.Address{accounts.address("A")}
+		}
+		accounts.sign(headers[j], []string{"A", "B", "A", "D"}[j])
+	}
+	engine := New(config, db)
+
+	snap, err := engine.snapshot(&testerChainReader{db: db}, 2, headers[1].Hash(), headers[:2])
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	if have := snap.signers(); !addressesEqual(have, sortedAddresses(signers...)) {
+		t.Errorf("votes were counted: have signers %x, want %x", have, sortedAddresses(signers...))
+	}
+	head := headers[len(headers)-1]
+	snap, err = engine.snapshot(&testerChainReader{db: db}, head.Number.Uint64(), head.Hash(), headers)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	if have, want := snap.signers(), sortedAddresses(accounts.address("A"), accounts.address("D")); !addressesEqual(have, want) {
+		t.Errorf("signers mismatch: have %x, want %x", have, want)
+	}
+	if have, want := snap.voters(), []common.Address{accounts.address("A")}; !addressesEqual(have, want) {
+		t.Errorf("voters mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that header-only verification rejects checkpoint lists that the
+// governance contract could never produce.
+func TestVerifyGovernanceLists(t *testing.T) {
+	var (
+		a = common.HexToAddress("0x01")
+		b = common.HexToAddress("0x02")
+	)
+	for i, tt := range []struct {
+		signers, voters []common.Address
+		err             error
+	}{
+		{[]common.Address{a, b}, []common.Address{a}, nil},
+		{nil, []common.Address{a}, errInvalidCheckpointSigners},
+		{[]common.Address{b, a}, []common.Address{a}, errInvalidCheckpointSigners},
+		{[]common.Address{a, a}, []common.Address{a}, errInvalidCheckpointSigners},
+		{[]common.Address{{}, a}, []common.Address{a}, errInvalidCheckpointSigners},
+		{[]common.Address{a}, nil, errInvalidCheckpointVoters},
+		{[]common.Address{a}, []common.Address{b, a}, errInvalidCheckpointVoters},
+		{[]common.Address{a}, make([]common.Address, maxGovernanceList+1), errInvalidCheckpointVoters},
+	} {
+		header := &types.Header{Signers: tt.signers, Voters: tt.voters}
+		if err := verifyGovernanceLists(header); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func sortedAddresses(addrs ...common.Address) []common.Address {
+	snap := newGenesisSnapshot(nil, nil, 0, common.Hash{}, addrs, nil)
+	return snap.signers()
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/core/types"
@@ -38,6 +39,7 @@ type Snapshot struct {
 	Voters  map[common.Address]struct{} `json:"voters"`  // Set of authorized voters at this moment
 	Votes   []*Vote                     `json:"votes"`   // List of votes cast in chronological order
 	Tally   map[common.Address]Tally    `json:"tally"`   // Current vote tally to avoid recalculating
+	Missed  map[common.Address]uint64   `json:"missed"`  // Consecutive in-turn slots each signer has failed to seal
 }
 
 // newGenesisSnapshot creates a new snapshot with the specified startup parameters. This
@@ -52,6 +54,7 @@ func newGenesisSnapshot(config *params.CliqueConfig, sigcache *lru.ARCCache, num
 		Signers:  make(map[common.Address]uint64),
 		Voters:   make(map[common.Address]struct{}),
 		Tally:    make(map[common.Address]Tally),
+		Missed:   make(map[common.Address]uint64),
 	}
 	for _, signer := range signers {
 		snap.Signers[signer] = 0
@@ -74,6 +77,10 @@ func loadSnapshot(config *params.CliqueConfig, sigcache *lru.ARCCache, db common
 	}
 	snap.config = config
 	snap.sigcache = sigcache
+	if snap.Missed == nil {
+		// Snapshots stored before liveness tracking carry no missed slots.
+		snap.Missed = make(map[common.Address]uint64)
+	}
 
 	return snap, nil
 }
@@ -98,6 +105,7 @@ func (s *Snapshot) copy() *Snapshot {
 		Voters:   make(map[common.Address]struct{}),
 		Votes:    make([]*Vote, len(s.Votes)),
 		Tally:    make(map[common.Address]Tally),
+		Missed:   make(map[common.Address]uint64),
 	}
 	for signer, signed := range s.Signers {
 		cpy.Signers[signer] = signed
@@ -108,6 +116,9 @@ func (s *Snapshot) copy() *Snapshot {
 	for address, tally := range s.Tally {
 		cpy.Tally[address] = tally
 	}
+	for signer, missed := range s.Missed {
+		cpy.Missed[signer] = missed
+	}
 	copy(cpy.Votes, s.Votes)
 
 	return cpy
@@ -202,8 +213,21 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 				return nil, fmt.Errorf("%s not authorized to sign %d: signed recently %d, next eligible signature %d", signer.Hex(), number, lastBlockSigned, next)
 			}
 		}
+		// Charge the in-turn signer with a missed slot if somebody else sealed
+		// in its place, and clear the sealing signer's record.
+		if inturn := snap.inturn(); inturn != signer {
+			snap.Missed[inturn]++
+		}
+		delete(snap.Missed, signer)
 		snap.Signers[signer] = number
 
+		// Under contract governance the checkpoints dictate the lists, votes are ignored
+		if s.config.IsGovernance(header.Number) {
+			if number%s.config.Epoch == 0 {
+				snap.adopt(header.Signers, header.Voters)
+			}
+			continue
+		}
 		// Verify if signer can vote
 		if _, ok := snap.Voters[signer]; ok {
 
@@ -257,6 +281,7 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 					_, voter := snap.Voters[candidate]
 					if !voter {
 						delete(snap.Signers, candidate)
+						delete(snap.Missed, candidate)
 					} else {
 						delete(snap.Voters, candidate)
 						// Discard any previous votes the deauthorized voter cast
@@ -291,6 +316,27 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+// adopt replaces the authorized signers and voters with the given lists, as
+// carried by a checkpoint header under contract governance. Signers that stay
+// authorized keep their most recently signed block and missed slots.
+func (s *Snapshot) adopt(signers, voters []common.Address) {
+	authorized := make(map[common.Address]uint64, len(signers))
+	for _, signer := range signers {
+		authorized[signer] = s.Signers[signer]
+	}
+	for signer := range s.Missed {
+		if _, ok := authorized[signer]; !ok {
+			delete(s.Missed, signer)
+		}
+	}
+	s.Signers = authorized
+
+	s.Voters = make(map[common.Address]struct{}, len(voters))
+	for _, voter := range voters {
+		s.Voters[voter] = struct{}{}
+	}
+}
+
 // signers retrieves the list of authorized signers in ascending order.
 func (s *Snapshot) signers() []common.Address {
 	signers := make([]common.Address, 0, len(s.Signers))
@@ -329,3 +375,53 @@ func (s *Snapshot) nextSignableBlockNumber(lastSignedBlockNumber uint64) uint64
 	n := uint64(len(s.Signers))
 	return lastSignedBlockNumber + n/2 + 1
 }
+
+// inturn returns the signer whose turn it is to seal the next block, which is
+// the one with the highest difficulty according to CalcDifficulty: the signer
+// that signed least recently, or the lowest address among those that have not
+// signed yet.
+func (s *Snapshot) inturn() common.Address {
+	var (
+		inturn common.Address
+		oldest uint64
+		found  bool
+	)
+	for signer, last := range s.Signers {
+		if !found || last < oldest || (last == oldest && bytes.Compare(signer[:], inturn[:]) < 0) {
+			inturn, oldest, found = signer, last, true
+		}
+	}
+	return inturn
+}
+
+// inactive returns the signers that have missed at least threshold consecutive
+// in-turn slots, in ascending order. A zero threshold disables the check.
+func (s *Snapshot) inactive(threshold uint64) []common.Address {
+	if threshold == 0 {
+		return nil
+	}
+	var inactive []common.Address
+	for _, signer := range s.signers() {
+		if s.Missed[signer] >= threshold {
+			inactive = append(inactive, signer)
+		}
+	}
+	return inactive
+}
+
+// finalized returns the number of the latest block which a majority of the
+// signers have sealed or built upon. Since a signer may only seal once every
+// n/2+1 blocks, no competing chain can overtake such a block without a
+// majority of the signers sealing twice at the same height.
+func (s *Snapshot) finalized() uint64 {
+	signed := make([]uint64, 0, len(s.Signers))
+	for _, last := range s.Signers {
+		signed = append(signed, last)
+	}
+	sort.Slice(signed, func(i, j int) bool { return signed[i] > signed[j] })
+
+	if len(signed) == 0 {
+		return 0
+	}
+	return signed[len(signed)/2]
+}
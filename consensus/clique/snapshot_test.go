@@ -516,3 +516,107 @@ func (tt *votingTest) run(t *testing.T) {
 		}
 	}
 }
+
+// Tests that signers failing to seal their in-turn slots are tracked, and that
+// sealing a block clears the record.
+func TestSignerLiveness(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity),
+		Signers:   signers,
+		Voters:    signers,
+		Signer:    make([]byte, signatureLength),
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.Commit(db)
+
+	// C is offline, so A and B alternate sealing every block.
+	headers := make([]*types.Header, 6)
+	for j := range headers {
+		headers[j] = &types.Header{
+			Number: big.NewInt(int64(j) + 1),
+			Time:   big.NewInt(int64(j) * int64(params.DefaultCliquePeriod)),
+			Signer: make([]byte, signatureLength),
+			Extra:  make([]byte, extraVanity),
+		}
+		if j > 0 {
+			headers[j].ParentHash = headers[j-1].Hash()
+		}
+		accounts.sign(headers[j], []string{"A", "B"}[j%2])
+	}
+	head := headers[len(headers)-1]
+
+	snap, err := New(&params.CliqueConfig{}, db).
+		snapshot(&testerChainReader{db: db}, head.Number.Uint64(), head.Hash(), headers)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	for _, name := range []string{"A", "B"} {
+		if missed := snap.Missed[accounts.address(name)]; missed != 0 {
+			t.Errorf("signer %s: have %d missed slots, want 0", name, missed)
+		}
+	}
+	// C may only have been out of turn while everybody was yet to sign.
+	c := accounts.address("C")
+	if missed := snap.Missed[c]; missed < 4 {
+		t.Errorf("signer C: have %d missed slots, want at least 4", missed)
+	}
+	if inturn := snap.inturn(); inturn != c {
+		t.Errorf("in-turn signer mismatch: have %x, want %x", inturn, c)
+	}
+	if inactive := snap.inactive(4); len(inactive) != 1 || inactive[0] != c {
+		t.Errorf("inactive signers mismatch: have %x, want [%x]", inactive, c)
+	}
+	if inactive := snap.inactive(0); len(inactive) != 0 {
+		t.Errorf("zero threshold reported inactive signers: %x", inactive)
+	}
+}
+
+// Tests that a block is only finalized once a majority of the signers have
+// sealed it or built on top of it.
+func TestSnapshotFinalized(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	names := []string{"A", "B", "C", "D", "E"}
+	signers := make([]common.Address, len(names))
+	for i, name := range names {
+		signers[i] = accounts.address(name)
+	}
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity),
+		Signers:   signers,
+		Voters:    signers,
+		Signer:    make([]byte, signatureLength),
+	}
+	db := ethdb.NewMemDatabase()
+	genesis.Commit(db)
+
+	headers := make([]*types.Header, 7)
+	for j := range headers {
+		headers[j] = &types.Header{
+			Number: big.NewInt(int64(j) + 1),
+			Time:   big.NewInt(int64(j) * int64(params.DefaultCliquePeriod)),
+			Signer: make([]byte, signatureLength),
+			Extra:  make([]byte, extraVanity),
+		}
+		if j > 0 {
+			headers[j].ParentHash = headers[j-1].Hash()
+		}
+		accounts.sign(headers[j], names[j%len(names)])
+	}
+	engine := New(&params.CliqueConfig{}, db)
+
+	// Three of the five signers are needed for a majority
+	for i, want := range []uint64{0, 0, 1, 2, 3, 4, 5} {
+		head := headers[i]
+		snap, err := engine.snapshot(&testerChainReader{db: db}, head.Number.Uint64(), head.Hash(), headers[:i+1])
+		if err != nil {
+			t.Fatalf("head %d: failed to create snapshot: %v", head.Number, err)
+		}
+		if have := snap.finalized(); have != want {
+			t.Errorf("head %d: finalized mismatch: have %d, want %d", head.Number, have, want)
+		}
+	}
+}
@@ -77,6 +77,30 @@ type Engine interface {
 	Authorize(common.Address, SignerFn)
 }
 
+// StateVerifier is an optional interface for engines whose header fields depend
+// on the state produced by executing the block.
+type StateVerifier interface {
+	// VerifyState checks the header against the post-transaction state of its
+	// block, after the engine's own finalization has been applied.
+	VerifyState(chain ChainReader, header *types.Header, state *state.StateDB) error
+}
+
+// FinalityReader is an optional interface for engines providing deterministic
+// finality, where blocks past a certain depth can no longer be reorganised.
+type FinalityReader interface {
+	// FinalizedNumber returns the number of the latest final block in the chain
+	// ending at header.
+	FinalizedNumber(chain ChainReader, header *types.Header) (uint64, error)
+}
+
+// EquivocationDetector is an optional interface for engines able to detect a
+// signer sealing conflicting blocks at the same height.
+type EquivocationDetector interface {
+	// ObserveHeader records the seal of a verified header, which may be on any
+	// chain, and reports its signer if it conflicts with an earlier seal.
+	ObserveHeader(header *types.Header)
+}
+
 // SignerFn is a signer callback function to request a hash to be signed by a
 // backing account.
 type SignerFn func(account accounts.Account, mimeType string, data []byte) ([]byte, error)
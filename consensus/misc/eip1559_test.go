@@ -0,0 +1,100 @@
+package misc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/params"
+)
+
+// londonConfig returns a chain config with London activated at block 5.
+func londonConfig() *params.ChainConfig {
+	config := *params.AllCliqueProtocolChanges
+	config.LondonBlock = big.NewInt(5)
+	return &config
+}
+
+// Tests that the base fee is only accepted from the London fork on and that
+// it must match the value derived from the parent.
+func TestBlockBaseFeeVerification(t *testing.T) {
+	config := londonConfig()
+	initial := config.InitialBaseFee()
+
+	tests := []struct {
+		parentNumber  int64
+		parentBaseFee *big.Int
+		baseFee       *big.Int
+		ok            bool
+	}{
+		{3, nil, nil, true},                   // Before the fork
+		{3, nil, big.NewInt(1), false},        // Before the fork, with base fee
+		{4, nil, initial, true},               // Fork block
+		{4, nil, nil, false},                  // Fork block, missing base fee
+		{4, nil, big.NewInt(1), false},        // Fork block, wrong base fee
+		{5, initial, initial, true},           // At target
+		{5, initial, big.NewInt(1000), false}, // Wrong base fee
+		{5, initial, new(big.Int).Add(initial, big.NewInt(1)), false},
+	}
+	for i, test := range tests {
+		parent := &types.Header{
+			Number:   big.NewInt(test.parentNumber),
+			GasLimit: 20000000,
+			GasUsed:  10000000,
+			BaseFee:  test.parentBaseFee,
+		}
+		header := &types.Header{
+			Number:   big.NewInt(test.parentNumber + 1),
+			GasLimit: 20000000,
+			BaseFee:  test.baseFee,
+		}
+		if err := VerifyEip1559Header(config, parent, header); (err == nil) != test.ok {
+			t.Errorf("test %d: verification mismatch: have %v, want ok %v", i, err, test.ok)
+		}
+	}
+}
+
+// Tests that the base fee moves towards the gas target.
+func TestCalcBaseFee(t *testing.T) {
+	config := londonConfig()
+
+	tests := []struct {
+		parentBaseFee   int64
+		parentGasLimit  uint64
+		parentGasUsed   uint64
+		expectedBaseFee int64
+	}{
+		{params.InitialBaseFee, 20000000, 10000000, params.InitialBaseFee}, // usage == target
+		{params.InitialBaseFee, 20000000, 9000000, 987500000},              // usage below target
+		{params.InitialBaseFee, 20000000, 11000000, 1012500000},            // usage above target
+		{params.InitialBaseFee, 20000000, 0, 875000000},                    // empty block
+		{params.InitialBaseFee, 20000000, 20000000, 1125000000},            // full block
+		{7, 20000000, 10000001, 8},                                         // minimum increase
+	}
+	for i, test := range tests {
+		parent := &types.Header{
+			Number:   big.NewInt(32),
+			GasLimit: test.parentGasLimit,
+			GasUsed:  test.parentGasUsed,
+			BaseFee:  big.NewInt(test.parentBaseFee),
+		}
+		if have, want := CalcBaseFee(config, parent), big.NewInt(test.expectedBaseFee); have.Cmp(want) != 0 {
+			t.Errorf("test %d: base fee mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
+
+// Tests that the fork block continues from the Darvaza default gas price.
+func TestInitialBaseFeeFromDarvaza(t *testing.T) {
+	config := londonConfig()
+	parent := &types.Header{Number: big.NewInt(4)}
+
+	if have := CalcBaseFee(config, parent); have.Cmp(big.NewInt(params.InitialBaseFee)) != 0 {
+		t.Errorf("initial base fee mismatch: have %v, want %v", have, params.InitialBaseFee)
+	}
+	config.DarvazaBlock = big.NewInt(0)
+	config.DarvazaDefaultGas = big.NewInt(2000 * params.Shannon)
+	if have := CalcBaseFee(config, parent); have.Cmp(config.DarvazaDefaultGas) != 0 {
+		t.Errorf("initial base fee mismatch: have %v, want %v", have, config.DarvazaDefaultGas)
+	}
+}
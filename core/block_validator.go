@@ -83,6 +83,12 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 	if root := statedb.IntermediateRoot(v.config.IsEIP158(header.Number)); header.Root != root {
 		return fmt.Errorf("invalid merkle root (remote: %x local: %x)", header.Root, root)
 	}
+	// Let the engine check any header fields derived from the state.
+	if sv, ok := v.engine.(consensus.StateVerifier); ok {
+		if err := sv.VerifyState(v.bc, header, statedb); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
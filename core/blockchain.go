@@ -2,6 +2,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -20,6 +21,7 @@ import (
 	"github.com/ChainAAS/gendchain/consensus"
 	"github.com/ChainAAS/gendchain/core/rawdb"
 	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/state/pruner"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/core/vm"
 	"github.com/ChainAAS/gendchain/ethdb"
@@ -35,6 +37,10 @@ var (
 
 	ErrNoGenesis = errors.New("Genesis not found in chain")
 	ErrStopping  = errors.New("stopping")
+
+	// ErrFinalizedReorg is returned if a chain reorganisation would remove a
+	// block the consensus engine considers final.
+	ErrFinalizedReorg = errors.New("reorg past finalized block")
 )
 
 const (
@@ -75,6 +81,11 @@ type CacheConfig struct {
 	Disabled      bool          // Whether to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+
+	StateRetain        uint64 // Number of recent blocks whose state is kept by online pruning (0 = disabled)
+	StatePruneInterval uint64 // Number of blocks between online state pruning runs
+
+	SnapshotLimit int // Memory allowance (MB) to cache flat state snapshot entries (0 = no snapshots)
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -99,6 +110,9 @@ type BlockChain struct {
 	triegc *prque.Prque    // Priority queue mapping block numbers to tries to gc
 	gcproc time.Duration   // Accumulates canonical block processing for trie dumping
 
+	statePruning   int32  // Set while an online state pruning run is in progress. Must be called atomically.
+	lastStatePrune uint64 // Block number at which the last online state pruning run started
+
 	hc *HeaderChain
 
 	rmLogsFeed      RemovedLogsFeed
@@ -117,6 +131,7 @@ type BlockChain struct {
 	checkpoint       int          // checkpoint counts towards the new checkpoint
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
+	currentFinalized atomic.Value // Latest canonical header the consensus engine considers final
 
 	stateCache    state.Database // State database to reuse between imports (contains state cache)
 	bodyCache     *lru.Cache     // Cache for the most recent block bodies
@@ -125,6 +140,9 @@ type BlockChain struct {
 	blockCache    *lru.Cache     // Cache for the most recent entire blocks
 	futureBlocks  *lru.Cache     // future blocks are blocks added for later processing
 
+	historyHead  uint64        // latest head to prune history for. Must be accessed atomically.
+	historyPrune chan struct{} // signals the history pruning loop of a new head
+
 	quit    chan struct{} // blockchain quit channel. Must hold write lock on wgQuitMu to close.
 	running int32         // running must be called atomically
 	// procInterrupt must be atomically called
@@ -163,13 +181,14 @@ func NewBlockChain(db common.Database, cacheConfig *CacheConfig, chainConfig *pa
 		cacheConfig:   cacheConfig,
 		db:            db,
 		triegc:        prque.New(nil),
-		stateCache:    state.NewDatabase(db),
+		stateCache:    state.NewDatabaseWithSnapshots(db, 0, cacheConfig.SnapshotLimit),
 		quit:          make(chan struct{}),
 		bodyCache:     bodyCache,
 		bodyRLPCache:  bodyRLPCache,
 		receiptsCache: receiptsCache,
 		blockCache:    blockCache,
 		futureBlocks:  futureBlocks,
+		historyPrune:  make(chan struct{}, 1),
 		engine:        engine,
 		vmConfig:      vmConfig,
 		parWorkers:    runtime.GOMAXPROCS(0),
@@ -187,9 +206,14 @@ func NewBlockChain(db common.Database, cacheConfig *CacheConfig, chainConfig *pa
 	if bc.genesisBlock == nil {
 		return nil, ErrNoGenesis
 	}
+	// Finish any state pruning interrupted before the state is used
+	if err := pruner.Recover(db); err != nil {
+		return nil, err
+	}
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	bc.lastStatePrune = bc.CurrentBlock().NumberU64()
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range BadHashes {
 		if header := bc.GetHeaderByHash(hash); header != nil {
@@ -207,6 +231,9 @@ func NewBlockChain(db common.Database, cacheConfig *CacheConfig, chainConfig *pa
 	}
 	// Take ownership of this particular state
 	go bc.update()
+	if db, ok := db.(historyPruner); ok && bc.wgAdd() {
+		go bc.pruneHistoryLoop(db)
+	}
 	return bc, nil
 }
 
@@ -242,6 +269,11 @@ func (bc *BlockChain) loadLastState() error {
 	// Everything seems to be fine, set as the head block
 	bc.currentBlock.Store(currentBlock)
 
+	// Make sure the flat state snapshot is of the head state
+	if snaps := bc.stateCache.Snapshots(); snaps != nil && snaps.Snapshot(currentBlock.Root()) == nil {
+		snaps.Load(currentBlock.Root())
+	}
+
 	// Restore the last known head header
 	currentHeader := currentBlock.Header()
 	if head := rawdb.ReadHeadHeaderHash(bc.db.GlobalTable()); head != (common.Hash{}) {
@@ -259,6 +291,17 @@ func (bc *BlockChain) loadLastState() error {
 		}
 	}
 
+	// Restore the last known finalized block, unless the chain was rewound past it
+	finalized := bc.genesisBlock.Header()
+	if hash := rawdb.ReadFinalizedBlockHash(bc.db.GlobalTable()); hash != (common.Hash{}) {
+		if header := bc.GetHeaderByHash(hash); header != nil && header.Number.Uint64() <= currentBlock.NumberU64() &&
+			rawdb.ReadCanonicalHash(bc.db, header.Number.Uint64()) == hash {
+			finalized = header
+		}
+	}
+	bc.currentFinalized.Store(finalized)
+	bc.updateFinalized(currentBlock.Header())
+
 	// Issue a status log for the user
 	currentFastBlock := bc.CurrentFastBlock()
 
@@ -269,6 +312,7 @@ func (bc *BlockChain) loadLastState() error {
 	log.Info("Loaded most recent local header", "number", currentHeader.Number, "hash", currentHeader.Hash(), "td", headerTd)
 	log.Info("Loaded most recent local full block", "number", currentBlock.Number(), "hash", currentBlock.Hash(), "td", blockTd)
 	log.Info("Loaded most recent local fast block", "number", currentFastBlock.Number(), "hash", currentFastBlock.Hash(), "td", fastTd)
+	log.Info("Loaded most recent finalized block", "number", bc.CurrentFinalizedHeader().Number, "hash", bc.CurrentFinalizedHeader().Hash())
 
 	return nil
 }
@@ -343,6 +387,7 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	bc.mu.Lock()
 	bc.currentBlock.Store(block)
 	bc.mu.Unlock()
+	bc.pruneHistory(block.NumberU64())
 
 	log.Info("Committed new head block", "number", block.Number(), "hash", hash)
 	return nil
@@ -365,6 +410,36 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock.Load().(*types.Block)
 }
 
+// CurrentFinalizedHeader retrieves the latest canonical header the consensus
+// engine considers final. Engines without deterministic finality leave it at
+// the genesis block.
+func (bc *BlockChain) CurrentFinalizedHeader() *types.Header {
+	return bc.currentFinalized.Load().(*types.Header)
+}
+
+// updateFinalized advances the finalized block to the one the consensus engine
+// reports for the chain ending at head. The finalized block never moves back.
+func (bc *BlockChain) updateFinalized(head *types.Header) {
+	engine, ok := bc.engine.(consensus.FinalityReader)
+	if !ok {
+		return
+	}
+	number, err := engine.FinalizedNumber(bc, head)
+	if err != nil {
+		log.Warn("Failed to determine finalized block", "number", head.Number, "hash", head.Hash(), "err", err)
+		return
+	}
+	if number <= bc.CurrentFinalizedHeader().Number.Uint64() || number > head.Number.Uint64() {
+		return
+	}
+	header := bc.GetHeaderByNumber(number)
+	if header == nil {
+		return
+	}
+	rawdb.WriteFinalizedBlockHash(bc.db.GlobalTable(), header.Hash())
+	bc.currentFinalized.Store(header)
+}
+
 // SetProcessor sets the processor required for making state modifications.
 func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.procmu.Lock()
@@ -428,6 +503,9 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) error {
 	bc.hc.SetCurrentHeader(bc.genesisBlock.Header())
 	bc.currentFastBlock.Store(bc.genesisBlock)
 
+	rawdb.WriteFinalizedBlockHash(bc.db.GlobalTable(), bc.genesisBlock.Hash())
+	bc.currentFinalized.Store(bc.genesisBlock.Header())
+
 	return nil
 }
 
@@ -504,6 +582,102 @@ func (bc *BlockChain) insert(block *types.Block) {
 		rawdb.WriteHeadFastBlockHash(bc.db.GlobalTable(), block.Hash())
 		bc.currentFastBlock.Store(block)
 	}
+	bc.pruneHistory(block.NumberU64())
+}
+
+// historyPruner is implemented by databases which drop the bodies and
+// receipts of blocks falling out of a history window.
+type historyPruner interface {
+	PruneHistory(ctx context.Context, head uint64) error
+	HistoryTail() uint64
+}
+
+// pruneHistory schedules dropping the bodies and receipts falling out of the
+// history window of head, if the database supports it.
+func (bc *BlockChain) pruneHistory(head uint64) {
+	atomic.StoreUint64(&bc.historyHead, head)
+	select {
+	case bc.historyPrune <- struct{}{}:
+	default:
+	}
+}
+
+// pruneHistoryLoop prunes the history of db up to the latest scheduled head,
+// off the block insertion path, until the chain is stopped.
+func (bc *BlockChain) pruneHistoryLoop(db historyPruner) {
+	defer bc.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-bc.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for {
+		select {
+		case <-bc.historyPrune:
+		case <-ctx.Done():
+			return
+		}
+		head := atomic.LoadUint64(&bc.historyHead)
+		if err := db.PruneHistory(ctx, head); err != nil && ctx.Err() == nil {
+			log.Error("Failed to prune history", "head", head, "err", err)
+		}
+	}
+}
+
+// pruneState starts an online state pruning run in the background once every
+// StatePruneInterval blocks, unless one is already in progress. Runs are
+// cancelled on Stop and resumed on restart.
+func (bc *BlockChain) pruneState(number uint64) {
+	retain, interval := bc.cacheConfig.StateRetain, bc.cacheConfig.StatePruneInterval
+	if retain == 0 || interval == 0 || number < bc.lastStatePrune+interval {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&bc.statePruning, 0, 1) {
+		return
+	}
+	if !bc.wgAdd() {
+		atomic.StoreInt32(&bc.statePruning, 0)
+		return
+	}
+	bc.lastStatePrune = number
+
+	go func() {
+		defer bc.wg.Done()
+		defer atomic.StoreInt32(&bc.statePruning, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-bc.quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		p := pruner.New(bc.db, bc.stateCache)
+		from, roots := p.RetainedRoots(number, retain)
+		log.Info("Pruning state", "number", number, "from", from, "roots", len(roots))
+		if err := p.Prune(ctx, from, roots); err == context.Canceled {
+			log.Info("State pruning interrupted")
+		} else if err != nil {
+			log.Error("Failed to prune state", "err", err)
+		}
+	}()
+}
+
+// HistoryTail returns the number of the oldest block whose body and receipts
+// may be available. Older blocks only have their header.
+func (bc *BlockChain) HistoryTail() uint64 {
+	if db, ok := bc.db.(historyPruner); ok {
+		return db.HistoryTail()
+	}
+	return 0
 }
 
 // Genesis retrieves the chain's genesis block.
@@ -523,7 +697,7 @@ func (bc *BlockChain) GetBody(hash common.Hash) *types.Body {
 	if number == nil {
 		return nil
 	}
-	body := rawdb.ReadBody(bc.db.BodyTable(), hash, *number)
+	body, _ := rawdb.ReadBody(bc.db.BodyTable(), hash, *number)
 	if body == nil {
 		return nil
 	}
@@ -623,7 +797,10 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 		return nil
 	}
 
-	receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.Config())
+	receipts, err := rawdb.ReadReceipts(bc.db, hash, *number, bc.Config())
+	if err != nil {
+		return nil
+	}
 	bc.receiptsCache.Add(hash, receipts)
 	return receipts
 }
@@ -681,6 +858,17 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	// Flatten the flat state snapshot into the head state, which is stored to
+	// disk below, so it can be loaded again on restart.
+	if snaps := bc.stateCache.Snapshots(); snaps != nil {
+		if root := bc.CurrentBlock().Root(); snaps.Snapshot(root) != nil {
+			if err := snaps.Cap(root, 0); err != nil {
+				log.Error("Failed to flatten state snapshot", "err", err)
+			}
+		}
+		snaps.Stop()
+	}
+
 	// Ensure the state of a recent block is also stored to disk before exiting.
 	// We're writing three different states to catch different restart scenarios:
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
@@ -818,7 +1006,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			continue
 		}
 		// Compute all the non-consensus fields of the receipts
-		if err := receipts.DeriveFields(bc.chainConfig, block.Hash(), block.NumberU64(), block.Transactions()); err != nil {
+		if err := receipts.DeriveFields(bc.chainConfig, block.Hash(), block.NumberU64(), block.Time(), block.Transactions()); err != nil {
 			return i, fmt.Errorf("failed to derive receipts data: %v", err)
 		}
 		// Write all the data out into the database
@@ -922,11 +1110,20 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	}
 	triedb := bc.stateCache.TrieDB()
 
+	// The state root stored to disk below, the flat state snapshot is
+	// persisted up to it.
+	var persisted common.Hash
+
 	// If we're running an archive node, always flush
 	if bc.cacheConfig.Disabled {
 		if err := triedb.Commit(root, false); err != nil {
 			return NonStatTy, err
 		}
+		if current := block.NumberU64(); current > triesInMemory {
+			if header := bc.GetHeaderByNumber(current - triesInMemory); header != nil {
+				persisted = header.Root
+			}
+		}
 	} else {
 		// Full but not archive node, do proper garbage collection
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
@@ -955,6 +1152,8 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				// Flush an entire trie and restart the counters
 				if err := triedb.Commit(header.Root, true); err != nil {
 					log.Error("Cannot commit trie db", "err", err)
+				} else {
+					persisted = header.Root
 				}
 				lastWrite = chosen
 				bc.gcproc = 0
@@ -969,6 +1168,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				triedb.Dereference(root.(common.Hash))
 			}
 		}
+		bc.pruneState(block.NumberU64())
 	}
 	rawdb.WriteReceipts(bc.db.ReceiptTable(), block.Hash(), block.NumberU64(), receipts)
 	local := chainHead{localTd, currentBlock.NumberU64(), currentBlock.GasUsed()}
@@ -990,10 +1190,29 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	} else {
 		status = SideStatTy
 	}
+	// Write the flat state snapshot to disk only up to a persisted state, so
+	// that it still matches the chain after an unclean shutdown, and merge the
+	// layers below the ones of the tries in memory. Rebuild it if the new head
+	// state has none.
+	if snaps := bc.stateCache.Snapshots(); snaps != nil {
+		if persisted != (common.Hash{}) && snaps.Snapshot(persisted) != nil {
+			if err := snaps.Persist(persisted); err != nil {
+				log.Warn("Failed to persist state snapshot", "root", persisted, "err", err)
+			}
+		}
+		if snaps.Snapshot(root) != nil {
+			if err := snaps.Cap(root, triesInMemory-1); err != nil {
+				log.Warn("Failed to cap state snapshot", "root", root, "err", err)
+			}
+		} else if status == CanonStatTy {
+			snaps.Rebuild(root)
+		}
+	}
 
 	// Set new head.
 	if status == CanonStatTy {
 		bc.insert(block)
+		bc.updateFinalized(block.Header())
 	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
@@ -1108,7 +1327,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 	go func() {
 
 		for _, block := range chain {
-			signer := types.MakeSigner(bc.Config(), block.Number())
+			signer := types.MakeSigner(bc.Config(), block.Number(), block.Time())
 			var wi int32 = -1
 			txs := block.Transactions()
 			l32 := int32(len(txs))
@@ -1350,7 +1569,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			if number == nil {
 				return
 			}
-			receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig)
+			receipts, _ := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig)
 			for _, receipt := range receipts {
 				for _, log := range receipt.Logs {
 					del := *log
@@ -1402,6 +1621,12 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("Invalid new chain")
 		}
 	}
+	// Never drop blocks the consensus engine considers final
+	if finalized := bc.CurrentFinalizedHeader(); len(oldChain) > 0 && commonBlock.NumberU64() < finalized.Number.Uint64() {
+		log.Warn("Refusing reorg past finalized block", "number", commonBlock.Number(), "hash", commonBlock.Hash(),
+			"finalized", finalized.Number, "drop", len(oldChain), "add", len(newChain))
+		return ErrFinalizedReorg
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debug
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,11 +16,13 @@ import (
 	"github.com/ChainAAS/gendchain/consensus/clique"
 	"github.com/ChainAAS/gendchain/core/rawdb"
 	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/state/snapshot"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/core/vm"
 	"github.com/ChainAAS/gendchain/crypto"
 	"github.com/ChainAAS/gendchain/ethdb"
 	"github.com/ChainAAS/gendchain/params"
+	"github.com/ChainAAS/gendchain/rlp"
 )
 
 // newTestBlockChain creates a blockchain without validation.
@@ -697,7 +700,9 @@ func TestFastVsFullChains(t *testing.T) {
 		} else if types.CalcUncleHash(fblock.Uncles()) != types.CalcUncleHash(ablock.Uncles()) {
 			t.Errorf("block #%d [%x]: uncles mismatch: have %v, want %v", num, hash, fblock.Uncles(), ablock.Uncles())
 		}
-		if freceipts, areceipts := rawdb.ReadReceipts(fastDb, hash, *rawdb.ReadHeaderNumber(fastDb.GlobalTable(), hash), fast.Config()), rawdb.ReadReceipts(archiveDb, hash, *rawdb.ReadHeaderNumber(archiveDb.GlobalTable(), hash), archive.Config()); types.DeriveSha(freceipts) != types.DeriveSha(areceipts) {
+		freceipts, _ := rawdb.ReadReceipts(fastDb, hash, *rawdb.ReadHeaderNumber(fastDb.GlobalTable(), hash), fast.Config())
+		areceipts, _ := rawdb.ReadReceipts(archiveDb, hash, *rawdb.ReadHeaderNumber(archiveDb.GlobalTable(), hash), archive.Config())
+		if types.DeriveSha(freceipts) != types.DeriveSha(areceipts) {
 			t.Errorf("block #%d [%x]: receipts mismatch: have %v, want %v", num, hash, freceipts, areceipts)
 		}
 	}
@@ -1414,3 +1419,264 @@ func TestLargeReorgTrieGC(t *testing.T) {
 		}
 	}
 }
+
+// finalityFaker is a fake engine which finalizes blocks at a fixed depth.
+type finalityFaker struct {
+	consensus.Engine
+	depth uint64
+}
+
+func (e *finalityFaker) FinalizedNumber(chain consensus.ChainReader, header *types.Header) (uint64, error) {
+	if number := header.Number.Uint64(); number > e.depth {
+		return number - e.depth, nil
+	}
+	return 0, nil
+}
+
+// Tests that the finalized block follows the engine, survives a restart, and
+// that reorgs past it are refused while shallower ones still go through.
+func TestReorgPastFinalized(t *testing.T) {
+	engine := &finalityFaker{Engine: clique.NewFaker(), depth: 3}
+	db, blockchain, err := newCanonical(engine, 10, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	if have := blockchain.CurrentFinalizedHeader().Number.Uint64(); have != 7 {
+		t.Fatalf("finalized number mismatch: have %d, want 7", have)
+	}
+	if have, want := rawdb.ReadFinalizedBlockHash(db.GlobalTable()), blockchain.GetHeaderByNumber(7).Hash(); have != want {
+		t.Fatalf("stored finalized hash mismatch: have %x, want %x", have, want)
+	}
+	// A heavier fork below the finalized block must be rejected
+	head := blockchain.CurrentBlock().Hash()
+	fork, _ := GenerateChain(params.TestChainConfig, blockchain.GetBlockByNumber(5), engine, db, 8, func(i int, b *BlockGen) {
+		b.SetDifficulty(10)
+	})
+	if _, err := blockchain.InsertChain(fork); err != ErrFinalizedReorg {
+		t.Fatalf("reorg past finalized block: have %v, want %v", err, ErrFinalizedReorg)
+	}
+	if blockchain.CurrentBlock().Hash() != head {
+		t.Fatalf("head moved to the rejected fork")
+	}
+	// A heavier fork above it is fine, and advances the finalized block
+	fork, _ = GenerateChain(params.TestChainConfig, blockchain.GetBlockByNumber(8), engine, db, 4, func(i int, b *BlockGen) {
+		b.SetDifficulty(10)
+	})
+	if _, err := blockchain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to reorg above finalized block: %v", err)
+	}
+	if have, want := blockchain.CurrentFinalizedHeader().Hash(), fork[0].Hash(); have != want {
+		t.Fatalf("finalized hash mismatch after reorg: have %x, want %x", have, want)
+	}
+	// Reopening the chain restores the finalized block
+	blockchain.Stop()
+	blockchain, err = NewBlockChain(db, nil, params.AllCliqueProtocolChanges, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	if have, want := blockchain.CurrentFinalizedHeader().Hash(), fork[0].Hash(); have != want {
+		t.Fatalf("finalized hash mismatch after restart: have %x, want %x", have, want)
+	}
+}
+
+// Tests that online state pruning deletes old persisted state while blocks are
+// being processed, keeping the recent state usable.
+func TestBlockChain_PruneState(t *testing.T) {
+	var (
+		gendb   = ethdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, clique.NewFaker(), gendb, 300, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{byte(i), byte(i >> 8)}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+
+	// Flush a state for every block past the in-memory window.
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, StateRetain: 16, StatePruneInterval: 100}
+	chain, err := NewBlockChain(db, cacheConfig, gspec.Config, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	for deadline := time.Now().Add(10 * time.Second); atomic.LoadInt32(&chain.statePruning) == 1; {
+		if time.Now().After(deadline) {
+			t.Fatal("state pruning did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Early persisted state is gone, the head state is complete.
+	if ok, _ := db.Has(blocks[9].Root().Bytes()); ok {
+		t.Fatal("expected old state to be pruned")
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatal(it.Error)
+	}
+
+	// The chain reopens at its head.
+	chain.Stop()
+	chain, err = NewBlockChain(db, cacheConfig, gspec.Config, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	if head := chain.CurrentBlock().Hash(); head != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("unexpected head: %x", head)
+	}
+}
+
+// Tests that the state snapshot follows the chain, serves state reads and
+// survives a restart.
+func TestBlockChain_Snapshot(t *testing.T) {
+	var (
+		gendb   = ethdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, clique.NewFaker(), gendb, 200, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{byte(i), byte(i >> 8)}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, SnapshotLimit: 1}
+	chain, err := NewBlockChain(db, cacheConfig, gspec.Config, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	head := blocks[len(blocks)-1]
+	if chain.stateCache.Snapshots().Snapshot(head.Root()) == nil {
+		t.Fatal("head snapshot missing")
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := statedb.GetNonce(address), uint64(len(blocks)); have != want {
+		t.Fatalf("unexpected nonce: have %d, want %d", have, want)
+	}
+
+	// The snapshot is flattened on stop and matches the head state once
+	// reopened and generated.
+	chain.Stop()
+	chain, err = NewBlockChain(db, cacheConfig, gspec.Config, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		err := chain.stateCache.Snapshots().Verify(head.Root())
+		if err == nil {
+			break
+		} else if err != snapshot.ErrNotCoveredYet || time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// State reads are served by the snapshot: an account only written to it
+	// is visible.
+	fake := common.Address{0xfa}
+	enc, err := rlp.EncodeToBytes(&state.Account{Balance: big.NewInt(42), Root: types.EmptyRootHash, CodeHash: crypto.Keccak256Hash(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawdb.WriteAccountSnapshot(db.GlobalTable(), crypto.Keccak256Hash(fake[:]), enc)
+	if statedb, err = chain.State(); err != nil {
+		t.Fatal(err)
+	}
+	if balance := statedb.GetBalance(fake); balance.Int64() != 42 {
+		t.Fatalf("account not read from snapshot: balance %v", balance)
+	}
+}
+
+func TestBlockChain_SnapshotUncleanShutdown(t *testing.T) {
+	var (
+		gendb   = ethdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(gendb)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, clique.NewFaker(), gendb, 200, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{byte(i), byte(i >> 8)}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+
+	// Commit the state of every block leaving the in-memory window.
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: time.Nanosecond, SnapshotLimit: 1}
+	chain, err := NewBlockChain(db, cacheConfig, gspec.Config, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to process block %d: %v", n, err)
+	}
+	persisted := blocks[len(blocks)-triesInMemory-1]
+	if have := rawdb.ReadSnapshotRoot(db.GlobalTable()); have != persisted.Root() {
+		t.Fatalf("unexpected snapshot root: have %x, want %x", have, persisted.Root())
+	}
+
+	// Reopen without stopping: the chain rewinds to the persisted state, which
+	// the snapshot on disk still matches.
+	reopened, err := NewBlockChain(db, cacheConfig, gspec.Config, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Stop()
+	if head := reopened.CurrentBlock(); head.Hash() != persisted.Hash() {
+		t.Fatalf("unexpected head: have %d, want %d", head.NumberU64(), persisted.NumberU64())
+	}
+	if rawdb.ReadSnapshotGenerator(db.GlobalTable()) != nil {
+		t.Fatal("snapshot regenerated")
+	}
+	if err := reopened.stateCache.Snapshots().Verify(persisted.Root()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/consensus"
+	"github.com/ChainAAS/gendchain/consensus/misc"
 	"github.com/ChainAAS/gendchain/core/state"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/core/vm"
@@ -63,7 +64,7 @@ func (b *BlockGen) AddTx(tx *types.Transaction) {
 		b.SetCoinbase(common.Address{})
 	}
 	b.statedb.Prepare(tx.Hash(), common.Hash{}, len(b.txs))
-	signer := types.MakeSigner(b.config, b.header.Number)
+	signer := types.MakeSigner(b.config, b.header.Number, b.header.Time)
 	// Create a new emv context and environment.
 	evmContext := NewEVMContextLite(b.header, nil, &b.header.Coinbase)
 	vmenv := vm.NewEVM(evmContext, b.statedb, b.config, vm.Config{})
@@ -89,6 +90,11 @@ func (b *BlockGen) Number() *big.Int {
 	return new(big.Int).Set(b.header.Number)
 }
 
+// Time returns the timestamp of the block being generated.
+func (b *BlockGen) Time() *big.Int {
+	return new(big.Int).Set(b.header.Time)
+}
+
 // AddUncheckedReceipt forcefully adds a receipts to the block without a
 // backing transaction.
 //
@@ -158,6 +164,9 @@ func GenerateChain(config *params.ChainConfig, first *types.Block, engine consen
 			Voters:     parent.Voters(),
 			Time:       new(big.Int).Add(parent.Time(), new(big.Int).SetUint64(config.Clique.Period)),
 		}
+		if config.IsLondon(b.header.Number, b.header.Time) {
+			b.header.BaseFee = misc.CalcBaseFee(config, parent.Header())
+		}
 
 		// Execute any user modifications to the block and finalize it
 		if gen != nil {
@@ -16,4 +16,12 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// ErrTipAboveFeeCap is returned if a transaction's max priority fee per gas is
+	// higher than its max fee per gas.
+	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
+
+	// ErrFeeCapTooLow is returned if a transaction's max fee per gas is lower than
+	// the base fee of the block it is included in.
+	ErrFeeCapTooLow = errors.New("max fee per gas less than block base fee")
 )
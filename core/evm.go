@@ -39,6 +39,7 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 		Difficulty:  header.Difficulty,
 		GasLimit:    header.GasLimit,
 		GasPrice:    msg.GasPrice(),
+		BaseFee:     header.BaseFee,
 	}
 }
 
@@ -60,6 +61,7 @@ func NewEVMContextLite(header *types.Header, chain ChainContext, author *common.
 		Time:        header.Time,
 		Difficulty:  header.Difficulty,
 		GasLimit:    header.GasLimit,
+		BaseFee:     header.BaseFee,
 	}
 }
 
@@ -0,0 +1,149 @@
+// Package forkid implements a compact identifier of a chain's fork schedule,
+// which peers exchange to detect mismatching configurations.
+package forkid
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"sort"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/params"
+)
+
+// timestampThreshold separates fork block numbers from fork timestamps in the
+// Next field of an ID, no chain is expected to reach this block number.
+const timestampThreshold = 1_000_000_000
+
+var (
+	// ErrRemoteStale is returned by Validate if a remote fork checksum is a subset
+	// of our already applied forks, but the announced next fork block is not on
+	// our already passed chain.
+	ErrRemoteStale = errors.New("remote needs update")
+
+	// ErrLocalIncompatibleOrStale is returned by Validate if a remote fork checksum
+	// does not match any local checksum variation, signalling that the two chains
+	// have diverged in the past at some point (possibly at genesis), or if the
+	// remote announces a fork we have already passed without applying it.
+	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// ID is a fork identifier: a checksum of the genesis hash and the forks a chain
+// has passed, along with the next fork it is scheduled for.
+type ID struct {
+	Hash [4]byte // CRC32 checksum of the genesis block and passed fork blocks and times
+	Next uint64  // Block number or timestamp of the next upcoming fork, or 0 if none is known
+}
+
+// NewID calculates the fork ID of a chain at the given head block and time.
+func NewID(config *params.ChainConfig, genesis common.Hash, head, time uint64) ID {
+	hash := crc32.ChecksumIEEE(genesis[:])
+
+	forksByBlock, forksByTime := gatherForks(config)
+	for _, fork := range forksByBlock {
+		if fork > head {
+			return ID{Hash: checksumToBytes(hash), Next: fork}
+		}
+		hash = checksumUpdate(hash, fork)
+	}
+	for _, fork := range forksByTime {
+		if fork > time {
+			return ID{Hash: checksumToBytes(hash), Next: fork}
+		}
+		hash = checksumUpdate(hash, fork)
+	}
+	return ID{Hash: checksumToBytes(hash), Next: 0}
+}
+
+// Validate checks whether a remote fork ID is compatible with the local chain
+// at the given head block and time. A nil error doesn't imply that both agree
+// on forks neither has reached yet.
+func Validate(config *params.ChainConfig, genesis common.Hash, head, time uint64, remote ID) error {
+	forksByBlock, forksByTime := gatherForks(config)
+	forks := append(append([]uint64{}, forksByBlock...), forksByTime...)
+
+	// Calculate the checksum after each fork, sums[i] having passed i of them
+	sums := make([][4]byte, len(forks)+1)
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range forks {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+	// Find the number of forks passed locally
+	passed := 0
+	for ; passed < len(forks); passed++ {
+		if passed < len(forksByBlock) && forks[passed] > head {
+			break
+		}
+		if passed >= len(forksByBlock) && forks[passed] > time {
+			break
+		}
+	}
+	if sums[passed] == remote.Hash {
+		// Same forks passed, the remote must not announce one we are already past
+		if remote.Next > 0 && (head >= remote.Next || (remote.Next > timestampThreshold && time >= remote.Next)) {
+			return ErrLocalIncompatibleOrStale
+		}
+		return nil
+	}
+	for i := 0; i < passed; i++ {
+		if sums[i] == remote.Hash {
+			// The remote is behind us, its next fork must be the one we applied
+			if forks[i] != remote.Next {
+				return ErrRemoteStale
+			}
+			return nil
+		}
+	}
+	for i := passed + 1; i < len(sums); i++ {
+		if sums[i] == remote.Hash {
+			// The remote is ahead of us on our own schedule, we're syncing
+			return nil
+		}
+	}
+	return ErrLocalIncompatibleOrStale
+}
+
+// checksumUpdate calculates the next IEEE CRC32 checksum based on the previous
+// one and a fork block number or timestamp.
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+// checksumToBytes converts a uint32 checksum into a [4]byte array.
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
+
+// gatherForks gathers the sorted, deduplicated fork block numbers and fork
+// timestamps of a chain configuration. Forks active from genesis are left out.
+func gatherForks(config *params.ChainConfig) ([]uint64, []uint64) {
+	var forksByBlock, forksByTime []uint64
+	for _, fork := range config.Forks() {
+		if fork.Block != nil && fork.Block.Sign() > 0 {
+			forksByBlock = append(forksByBlock, fork.Block.Uint64())
+		}
+		if fork.Time != nil && fork.Time.Sign() > 0 {
+			forksByTime = append(forksByTime, fork.Time.Uint64())
+		}
+	}
+	return dedup(forksByBlock), dedup(forksByTime)
+}
+
+// dedup sorts a list of fork points and removes the duplicates.
+func dedup(forks []uint64) []uint64 {
+	sort.Slice(forks, func(i, j int) bool { return forks[i] < forks[j] })
+	for i := 1; i < len(forks); i++ {
+		if forks[i] == forks[i-1] {
+			forks = append(forks[:i], forks[i+1:]...)
+			i--
+		}
+	}
+	return forks
+}
@@ -0,0 +1,88 @@
+package forkid
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/params"
+)
+
+var (
+	testGenesis = common.HexToHash("0x01")
+	testConfig  = &params.ChainConfig{
+		HomesteadBlock: big.NewInt(0),
+		DarvazaBlock:   big.NewInt(10),
+		HafthorBlock:   big.NewInt(20),
+		IstanbulBlock:  big.NewInt(20),
+		LondonTime:     big.NewInt(1_600_000_000),
+		PragueTime:     big.NewInt(1_700_000_000),
+	}
+)
+
+// Tests that fork IDs advance as the chain passes block and timestamp forks.
+func TestNewID(t *testing.T) {
+	var (
+		base   = NewID(testConfig, testGenesis, 0, 0)
+		darv   = NewID(testConfig, testGenesis, 10, 0)
+		hafth  = NewID(testConfig, testGenesis, 20, 0)
+		london = NewID(testConfig, testGenesis, 30, 1_600_000_000)
+		prague = NewID(testConfig, testGenesis, 40, 1_700_000_000)
+	)
+	for _, test := range []struct {
+		head, time uint64
+		want       ID
+	}{
+		{0, 0, ID{Hash: base.Hash, Next: 10}},
+		{9, 0, ID{Hash: base.Hash, Next: 10}},
+		{10, 0, ID{Hash: darv.Hash, Next: 20}},
+		{25, 1_599_999_999, ID{Hash: hafth.Hash, Next: 1_600_000_000}},
+		{30, 1_600_000_000, ID{Hash: london.Hash, Next: 1_700_000_000}},
+		{40, 1_800_000_000, ID{Hash: prague.Hash, Next: 0}},
+	} {
+		if have := NewID(testConfig, testGenesis, test.head, test.time); have != test.want {
+			t.Errorf("head %d, time %d: fork ID mismatch: have %x, want %x", test.head, test.time, have, test.want)
+		}
+	}
+	ids := map[[4]byte]bool{}
+	for _, id := range []ID{base, darv, hafth, london, prague} {
+		ids[id.Hash] = true
+	}
+	if len(ids) != 5 {
+		t.Errorf("fork checksums not distinct: %d", len(ids))
+	}
+}
+
+// Tests that remote fork IDs are validated against the local schedule.
+func TestValidate(t *testing.T) {
+	var (
+		head, time = uint64(30), uint64(1_600_000_100) // London passed, Prague upcoming
+		local      = NewID(testConfig, testGenesis, head, time)
+	)
+	rescheduled := *testConfig
+	rescheduled.PragueTime = big.NewInt(1_750_000_000)
+
+	for i, test := range []struct {
+		remote ID
+		err    error
+	}{
+		// Identical schedule and progress
+		{local, nil},
+		// Remote still before London, announcing it
+		{NewID(testConfig, testGenesis, 30, 0), nil},
+		// Remote before London, not knowing about it
+		{ID{Hash: NewID(testConfig, testGenesis, 30, 0).Hash, Next: 0}, ErrRemoteStale},
+		// Remote already past Prague
+		{NewID(testConfig, testGenesis, 40, 1_700_000_000), nil},
+		// Remote announcing Prague at a time we're already past
+		{ID{Hash: local.Hash, Next: 1_500_000_000}, ErrLocalIncompatibleOrStale},
+		// Remote with a rescheduled but not yet reached Prague
+		{NewID(&rescheduled, testGenesis, head, time), nil},
+		// Remote on another chain
+		{NewID(testConfig, common.HexToHash("0x02"), head, time), ErrLocalIncompatibleOrStale},
+	} {
+		if err := Validate(testConfig, testGenesis, head, time, test.remote); err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/ChainAAS/gendchain/common"
@@ -47,6 +48,7 @@ type Genesis struct {
 	Number     uint64      `json:"number"`
 	GasUsed    uint64      `json:"gasUsed"`
 	ParentHash common.Hash `json:"parentHash"`
+	BaseFee    *big.Int    `json:"baseFeePerGas"`
 }
 
 // GenesisAlloc specifies the initial state that is part of the genesis block.
@@ -94,6 +96,7 @@ type genesisSpecMarshaling struct {
 	GasUsed    math.HexOrDecimal64
 	Number     math.HexOrDecimal64
 	Difficulty *math.HexOrDecimal256
+	BaseFee    *math.HexOrDecimal256
 	Alloc      map[common.UnprefixedAddress]GenesisAccount
 }
 
@@ -156,6 +159,11 @@ func SetupGenesisBlockWithOverride(db common.Database, genesis *Genesis, constan
 	if genesis != nil && genesis.Config == nil {
 		return params.AllCliqueProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil {
+		if err := genesis.Config.CheckRewardSchedules(); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
 
 	// Just commit the new block if there is no stored genesis block.
 	stored := rawdb.ReadCanonicalHash(db, 0)
@@ -200,18 +208,49 @@ func SetupGenesisBlockWithOverride(db common.Database, genesis *Genesis, constan
 
 	// Check config compatibility and write the config. Compatibility errors
 	// are returned to the caller unless we're already at block zero.
-	height := rawdb.ReadHeaderNumber(db.GlobalTable(), rawdb.ReadHeadHeaderHash(db.GlobalTable()))
+	headHash := rawdb.ReadHeadHeaderHash(db.GlobalTable())
+	height := rawdb.ReadHeaderNumber(db.GlobalTable(), headHash)
 	if height == nil {
 		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
 	}
-	compatErr := storedcfg.CheckCompatible(newcfg, *height)
-	if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
+	head := rawdb.ReadHeader(db.HeaderTable(), headHash, *height)
+	if head == nil {
+		return newcfg, stored, fmt.Errorf("missing head header %x", headHash)
+	}
+	compatErr := storedcfg.CheckCompatible(newcfg, *height, head.Time.Uint64())
+	if compatErr != nil && *height != 0 && (compatErr.RewindTo != 0 || compatErr.RewindToTime != 0) {
 		return newcfg, stored, compatErr
 	}
 	rawdb.WriteChainConfig(db.GlobalTable(), stored, newcfg)
 	return newcfg, stored, nil
 }
 
+// HeaderReader is the part of a full or light chain needed to look up its
+// canonical headers.
+type HeaderReader interface {
+	CurrentHeader() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+}
+
+// RewindHeight returns the block number the chain must be rewound to in order
+// to resolve a configuration compatibility error. Conflicts over forks scheduled
+// by time are resolved by the last canonical block at or before RewindToTime.
+func RewindHeight(chain HeaderReader, compat *params.ConfigCompatError) uint64 {
+	if compat.RewindToTime == 0 {
+		return compat.RewindTo
+	}
+	// Block times only increase along the chain, search for the first one past it
+	head := chain.CurrentHeader().Number.Uint64()
+	n := sort.Search(int(head)+1, func(i int) bool {
+		header := chain.GetHeaderByNumber(uint64(i))
+		return header == nil || header.Time.Uint64() > compat.RewindToTime
+	})
+	if n == 0 {
+		return 0
+	}
+	return uint64(n - 1)
+}
+
 func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	switch {
 	case g != nil:
@@ -263,6 +302,13 @@ func (g *Genesis) ToBlock(db common.Database) *types.Block {
 	if g.Difficulty == nil {
 		head.Difficulty = big.NewInt(1)
 	}
+	if g.Config != nil && g.Config.IsLondon(head.Number, head.Time) {
+		if g.BaseFee != nil {
+			head.BaseFee = g.BaseFee
+		} else {
+			head.BaseFee = g.Config.InitialBaseFee()
+		}
+	}
 	if _, err := statedb.Commit(false); err != nil {
 		log.Error("Cannot commit genesis to state db", "err", err)
 	}
@@ -163,6 +163,43 @@ func TestSetupGenesis(t *testing.T) {
 	}
 }
 
+// Tests that a rescheduled fork time is detected against a stored chain and
+// resolved into the last block before the old fork time.
+func TestSetupGenesisTimestampFork(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		genesis = &Genesis{Config: &params.ChainConfig{
+			ChainId:    big.NewInt(1337),
+			LondonTime: big.NewInt(12),
+			Clique:     params.DefaultCliqueConfig(),
+		}}
+		block = genesis.MustCommit(db)
+	)
+	bc, _ := NewBlockChain(db, nil, genesis.Config, clique.NewFullFaker(), vm.Config{})
+	defer bc.Stop()
+
+	blocks, _ := GenerateChain(genesis.Config, block, clique.NewFaker(), db, 4, nil)
+	if _, err := bc.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	var update Genesis
+	if err := json.Unmarshal([]byte(`{"config": {"chainId": 1337, "londonTime": 30, "clique": {"period": 5, "epoch": 3000}}, "gasLimit": "0x0", "difficulty": "0x1", "alloc": {}}`), &update); err != nil {
+		t.Fatalf("failed to decode genesis: %v", err)
+	}
+	_, _, err := SetupGenesisBlock(db, &update)
+	compat, ok := err.(*params.ConfigCompatError)
+	if !ok {
+		t.Fatalf("expected compatibility error, have %v", err)
+	}
+	if compat.RewindToTime != 11 {
+		t.Errorf("rewind time mismatch: have %d, want 11", compat.RewindToTime)
+	}
+	// Blocks are 5 seconds apart, #2 at 10 is the last before the fork
+	if height := RewindHeight(bc, compat); height != 2 {
+		t.Errorf("rewind height mismatch: have %d, want 2", height)
+	}
+}
+
 func TestDefaultGenesisBlock(t *testing.T) {
 	for _, test := range []struct {
 		name    string
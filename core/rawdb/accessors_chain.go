@@ -153,6 +153,29 @@ func WriteHeadFastBlockHash(db DatabaseWriter, hash common.Hash) {
 	})
 }
 
+// ReadFinalizedBlockHash retrieves the hash of the latest finalized block.
+func ReadFinalizedBlockHash(db DatabaseReader) common.Hash {
+	var data []byte
+	Must("get finalized block hash", func() (err error) {
+		data, err = db.Get(finalizedBlockKey)
+		if err == common.ErrNotFound {
+			err = nil
+		}
+		return
+	})
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteFinalizedBlockHash stores the hash of the latest finalized block.
+func WriteFinalizedBlockHash(db DatabaseWriter, hash common.Hash) {
+	Must("put finalized block hash", func() error {
+		return db.Put(finalizedBlockKey, hash.Bytes())
+	})
+}
+
 // ReadFastTrieProgress retrieves the number of tries nodes fast synced to allow
 // reporting correct numbers across restarts.
 func ReadFastTrieProgress(db DatabaseReader) uint64 {
@@ -178,6 +201,39 @@ func WriteFastTrieProgress(db DatabaseWriter, count uint64) {
 	})
 }
 
+// ReadStatePrune retrieves the number of the oldest block whose state is kept
+// by an unfinished state pruning run, or nil if no run is in progress.
+func ReadStatePrune(db DatabaseReader) *uint64 {
+	var data []byte
+	Must("get state prune", func() (err error) {
+		data, err = db.Get(statePruneKey)
+		if err == common.ErrNotFound {
+			err = nil
+		}
+		return
+	})
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteStatePrune stores the number of the oldest block whose state is kept,
+// so an interrupted state pruning run can be resumed.
+func WriteStatePrune(db DatabaseWriter, number uint64) {
+	Must("put state prune", func() error {
+		return db.Put(statePruneKey, encodeBlockNumber(number))
+	})
+}
+
+// DeleteStatePrune removes the state pruning marker once a run completes.
+func DeleteStatePrune(db DatabaseDeleter) {
+	Must("delete state prune", func() error {
+		return db.Delete(statePruneKey)
+	})
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	var data []byte
@@ -248,22 +304,32 @@ func DeleteHeader(global, headerTable common.Deleter, hash common.Hash, number u
 }
 
 // ReadBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
+// Returns nil if the body was pruned.
 func ReadBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
-	var data []byte
+	data, _ := readBodyRLP(db, hash, number)
+	return data
+}
+
+// readBodyRLP retrieves the block body in RLP encoding, or returns
+// ethdb.ErrHistoryPruned if it was pruned.
+func readBodyRLP(db DatabaseReader, hash common.Hash, number uint64) (data rlp.RawValue, pruned error) {
 	Must("read body", func() (err error) {
 		data, err = db.Get(numHashKey(bodyPrefix, number, hash))
 		if err == common.ErrNotFound {
 			err = nil
+		} else if err == ethdb.ErrHistoryPruned {
+			pruned, err = err, nil
 		}
 		return
 	})
-	return data
+	return data, pruned
 }
 
-// WriteBodyRLP stores an RLP encoded block body into the database.
+// WriteBodyRLP stores an RLP encoded block body into the database. Bodies of
+// pruned blocks are not stored.
 func WriteBodyRLP(db DatabaseWriter, hash common.Hash, number uint64, rlp rlp.RawValue) {
 	Must("write body", func() error {
-		return db.Put(numHashKey(bodyPrefix, number, hash), rlp)
+		return ignorePruned(db.Put(numHashKey(bodyPrefix, number, hash), rlp))
 	})
 }
 
@@ -272,7 +338,7 @@ func HasBody(db DatabaseReader, hash common.Hash, number uint64) bool {
 	var has bool
 	Must("has body", func() (err error) {
 		has, err = db.Has(numHashKey(bodyPrefix, number, hash))
-		if err == common.ErrNotFound {
+		if err == common.ErrNotFound || err == ethdb.ErrHistoryPruned {
 			err = nil
 		}
 		return
@@ -280,18 +346,21 @@ func HasBody(db DatabaseReader, hash common.Hash, number uint64) bool {
 	return has
 }
 
-// ReadBody retrieves the block body corresponding to the hash.
-func ReadBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
-	data := ReadBodyRLP(db, hash, number)
-	if len(data) == 0 {
-		return nil
+// ReadBody retrieves the block body corresponding to the hash. Returns
+// ethdb.ErrHistoryPruned if the body was pruned.
+func ReadBody(db DatabaseReader, hash common.Hash, number uint64) (*types.Body, error) {
+	data, err := readBodyRLP(db, hash, number)
+	if err != nil {
+		return nil, err
+	} else if len(data) == 0 {
+		return nil, nil
 	}
 	body := new(types.Body)
 	if err := rlp.Decode(bytes.NewReader(data), body); err != nil {
 		log.Error("Invalid block body RLP", "hash", hash, "err", err)
-		return nil
+		return nil, nil
 	}
-	return body
+	return body, nil
 }
 
 // WriteBody stores a block body into the database.
@@ -306,7 +375,7 @@ func WriteBody(db DatabaseWriter, hash common.Hash, number uint64, body *types.B
 // DeleteBody removes all block body data associated with a hash.
 func DeleteBody(db DatabaseDeleter, hash common.Hash, number uint64) {
 	Must("delete block body", func() error {
-		return db.Delete(numHashKey(bodyPrefix, number, hash))
+		return ignorePruned(db.Delete(numHashKey(bodyPrefix, number, hash)))
 	})
 }
 
@@ -352,26 +421,40 @@ func DeleteTd(db DatabaseDeleter, hash common.Hash, number uint64) {
 // ReadRawReceipts retrieves all the transaction receipts belonging to a block.
 // The receipt metadata fields are not guaranteed to be populated, so they
 // should not be used. Use ReadReceipts instead if the metadata is needed.
+//
+// Returns nil if the receipts were pruned.
 func ReadRawReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
+	receipts, _ := readRawReceipts(db, hash, number)
+	return receipts
+}
+
+// readRawReceipts retrieves all the transaction receipts belonging to a block,
+// or returns ethdb.ErrHistoryPruned if they were pruned.
+func readRawReceipts(db DatabaseReader, hash common.Hash, number uint64) (types.Receipts, error) {
 	// Retrieve the flattened receipt slice
 	var data []byte
+	var pruned error
 	Must("get receipts", func() (err error) {
 		data, err = db.Get(numHashKey(blockReceiptsPrefix, number, hash))
 		if err == common.ErrNotFound {
 			err = nil
+		} else if err == ethdb.ErrHistoryPruned {
+			pruned, err = err, nil
 		}
 		return
 	})
-	if len(data) == 0 {
-		return nil
+	if pruned != nil {
+		return nil, pruned
+	} else if len(data) == 0 {
+		return nil, nil
 	}
 	// Convert the receipts from their storage form to their internal representation
 	var receipts types.ReceiptsForStorage
 	if err := rlp.DecodeBytes(data, &receipts); err != nil {
 		log.Error("Invalid receipt array RLP", "hash", hash, "err", err)
-		return nil
+		return nil, nil
 	}
-	return types.Receipts(receipts)
+	return types.Receipts(receipts), nil
 }
 
 // ReadReceipts retrieves all the transaction receipts belonging to a block, including
@@ -380,26 +463,35 @@ func ReadRawReceipts(db DatabaseReader, hash common.Hash, number uint64) types.R
 //
 // The current implementation populates these metadata fields by reading the receipts'
 // corresponding block body, so if the block body is not found it will return nil even
-// if the receipt itself is stored.
-func ReadReceipts(db common.Database, hash common.Hash, number uint64, config *params.ChainConfig) types.Receipts {
+// if the receipt itself is stored. Returns ethdb.ErrHistoryPruned if either was
+// pruned.
+func ReadReceipts(db common.Database, hash common.Hash, number uint64, config *params.ChainConfig) (types.Receipts, error) {
 	// We're deriving many fields from the block body, retrieve beside the receipt
-	receipts := ReadRawReceipts(db.ReceiptTable(), hash, number)
-	if receipts == nil {
-		return nil
+	receipts, err := readRawReceipts(db.ReceiptTable(), hash, number)
+	if receipts == nil || err != nil {
+		return nil, err
 	}
-	body := ReadBody(db.BodyTable(), hash, number)
-	if body == nil {
+	body, err := ReadBody(db.BodyTable(), hash, number)
+	if err != nil {
+		return nil, err
+	} else if body == nil {
 		log.Error("Missing body but have receipt", "hash", hash, "number", number)
-		return nil
+		return nil, nil
 	}
-	if err := receipts.DeriveFields(config, hash, number, body.Transactions); err != nil {
+	// The block time only matters for forks scheduled by timestamp
+	var time *big.Int
+	if header := ReadHeader(db.HeaderTable(), hash, number); header != nil {
+		time = header.Time
+	}
+	if err := receipts.DeriveFields(config, hash, number, time, body.Transactions); err != nil {
 		log.Error("Failed to derive block receipts fields", "hash", hash, "number", number, "err", err)
-		return nil
+		return nil, nil
 	}
-	return receipts
+	return receipts, nil
 }
 
 // WriteReceipts stores all the transaction receipts belonging to a block.
+// Receipts of pruned blocks are not stored.
 func WriteReceipts(db DatabaseWriter, hash common.Hash, number uint64, receipts types.Receipts) {
 	// Convert the receipts into their storage form and serialize them
 	bytes, err := rlp.EncodeToBytes((types.ReceiptsForStorage)(receipts))
@@ -408,14 +500,14 @@ func WriteReceipts(db DatabaseWriter, hash common.Hash, number uint64, receipts
 	}
 	// Store the flattened receipt slice
 	Must("put receipts", func() error {
-		return db.Put(numHashKey(blockReceiptsPrefix, number, hash), bytes)
+		return ignorePruned(db.Put(numHashKey(blockReceiptsPrefix, number, hash), bytes))
 	})
 }
 
 // DeleteReceipts removes all receipt data associated with a block hash.
 func DeleteReceipts(db DatabaseDeleter, hash common.Hash, number uint64) {
 	Must("delete receipts", func() error {
-		return db.Delete(numHashKey(blockReceiptsPrefix, number, hash))
+		return ignorePruned(db.Delete(numHashKey(blockReceiptsPrefix, number, hash)))
 	})
 }
 
@@ -430,7 +522,7 @@ func ReadBlock(db common.Database, hash common.Hash, number uint64) *types.Block
 	if header == nil {
 		return nil
 	}
-	body := ReadBody(db.BodyTable(), hash, number)
+	body, _ := ReadBody(db.BodyTable(), hash, number)
 	if body == nil {
 		return nil
 	}
@@ -477,3 +569,12 @@ func FindCommonAncestor(db DatabaseReader, a, b *types.Header) *types.Header {
 	}
 	return a
 }
+
+// ignorePruned returns nil if err is ethdb.ErrHistoryPruned, so that writes of
+// pruned history are dropped instead of retried.
+func ignorePruned(err error) error {
+	if err == ethdb.ErrHistoryPruned {
+		return nil
+	}
+	return err
+}
@@ -60,12 +60,12 @@ func TestBodyStorage(t *testing.T) {
 	rlp.Encode(hasher, body)
 	hash := common.BytesToHash(hasher.Sum(nil))
 
-	if entry := ReadBody(db.BodyTable(), hash, 0); entry != nil {
+	if entry, _ := ReadBody(db.BodyTable(), hash, 0); entry != nil {
 		t.Fatalf("Non existent body returned: %v", entry)
 	}
 	// Write and verify the body in the database
 	WriteBody(db.BodyTable(), hash, 0, body)
-	if entry := ReadBody(db.BodyTable(), hash, 0); entry == nil {
+	if entry, _ := ReadBody(db.BodyTable(), hash, 0); entry == nil {
 		t.Fatalf("Stored body not found")
 	} else if types.DeriveSha(types.Transactions(entry.Transactions)) != types.DeriveSha(types.Transactions(body.Transactions)) || types.CalcUncleHash(entry.Uncles) != types.CalcUncleHash(body.Uncles) {
 		t.Fatalf("Retrieved body mismatch: have %v, want %v", entry, body)
@@ -82,7 +82,7 @@ func TestBodyStorage(t *testing.T) {
 	}
 	// Delete the body and verify the execution
 	DeleteBody(db.BodyTable(), hash, 0)
-	if entry := ReadBody(db.BodyTable(), hash, 0); entry != nil {
+	if entry, _ := ReadBody(db.BodyTable(), hash, 0); entry != nil {
 		t.Fatalf("Deleted body returned: %v", entry)
 	}
 }
@@ -104,7 +104,7 @@ func TestBlockStorage(t *testing.T) {
 	if entry := ReadHeader(db.HeaderTable(), block.Hash(), block.NumberU64()); entry != nil {
 		t.Fatalf("Non existent header returned: %v", entry)
 	}
-	if entry := ReadBody(db.BodyTable(), block.Hash(), block.NumberU64()); entry != nil {
+	if entry, _ := ReadBody(db.BodyTable(), block.Hash(), block.NumberU64()); entry != nil {
 		t.Fatalf("Non existent body returned: %v", entry)
 	}
 	// Write and verify the block in the database
@@ -119,7 +119,7 @@ func TestBlockStorage(t *testing.T) {
 	} else if entry.Hash() != block.Header().Hash() {
 		t.Fatalf("Retrieved header mismatch: have %v, want %v", entry, block.Header())
 	}
-	if entry := ReadBody(db.BodyTable(), block.Hash(), block.NumberU64()); entry == nil {
+	if entry, _ := ReadBody(db.BodyTable(), block.Hash(), block.NumberU64()); entry == nil {
 		t.Fatalf("Stored body not found")
 	} else if types.DeriveSha(types.Transactions(entry.Transactions)) != types.DeriveSha(block.Transactions()) || types.CalcUncleHash(entry.Uncles) != types.CalcUncleHash(block.Uncles()) {
 		t.Fatalf("Retrieved body mismatch: have %v, want %v", entry, block.Body())
@@ -132,7 +132,7 @@ func TestBlockStorage(t *testing.T) {
 	if entry := ReadHeader(db.HeaderTable(), block.Hash(), block.NumberU64()); entry != nil {
 		t.Fatalf("Deleted header returned: %v", entry)
 	}
-	if entry := ReadBody(db.BodyTable(), block.Hash(), block.NumberU64()); entry != nil {
+	if entry, _ := ReadBody(db.BodyTable(), block.Hash(), block.NumberU64()); entry != nil {
 		t.Fatalf("Deleted body returned: %v", entry)
 	}
 }
@@ -223,6 +223,7 @@ func TestHeadStorage(t *testing.T) {
 	blockHead := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block header")})
 	blockFull := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block full")})
 	blockFast := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block fast")})
+	blockFinal := types.NewBlockWithHeader(&types.Header{Extra: []byte("test block finalized")})
 
 	// Check that no head entries are in a pristine database
 	if entry := ReadHeadHeaderHash(db.GlobalTable()); entry != (common.Hash{}) {
@@ -234,10 +235,14 @@ func TestHeadStorage(t *testing.T) {
 	if entry := ReadHeadFastBlockHash(db.GlobalTable()); entry != (common.Hash{}) {
 		t.Fatalf("Non fast head block entry returned: %v", entry)
 	}
+	if entry := ReadFinalizedBlockHash(db.GlobalTable()); entry != (common.Hash{}) {
+		t.Fatalf("Non finalized block entry returned: %v", entry)
+	}
 	// Assign separate entries for the head header and block
 	WriteHeadHeaderHash(db.GlobalTable(), blockHead.Hash())
 	WriteHeadBlockHash(db.GlobalTable(), blockFull.Hash())
 	WriteHeadFastBlockHash(db.GlobalTable(), blockFast.Hash())
+	WriteFinalizedBlockHash(db.GlobalTable(), blockFinal.Hash())
 
 	// Check that both heads are present, and different (i.e. two heads maintained)
 	if entry := ReadHeadHeaderHash(db.GlobalTable()); entry != blockHead.Hash() {
@@ -249,6 +254,9 @@ func TestHeadStorage(t *testing.T) {
 	if entry := ReadHeadFastBlockHash(db.GlobalTable()); entry != blockFast.Hash() {
 		t.Fatalf("Fast head block hash mismatch: have %v, want %v", entry, blockFast.Hash())
 	}
+	if entry := ReadFinalizedBlockHash(db.GlobalTable()); entry != blockFinal.Hash() {
+		t.Fatalf("Finalized block hash mismatch: have %v, want %v", entry, blockFinal.Hash())
+	}
 }
 
 // Tests that receipts associated with a single block can be stored and retrieved.
@@ -291,7 +299,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 
 	// Check that no receipt entries are in a pristine database
 	hash := common.BytesToHash([]byte{0x03, 0x14})
-	if rs := ReadReceipts(db, hash, 0, params.TestChainConfig); len(rs) != 0 {
+	if rs, _ := ReadReceipts(db, hash, 0, params.TestChainConfig); len(rs) != 0 {
 		t.Fatalf("non existent receipts returned: %v", rs)
 	}
 	// Insert the body that corresponds to the receipts
@@ -299,7 +307,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 
 	// Insert the receipt slice into the database and check presence
 	WriteReceipts(db.ReceiptTable(), hash, 0, receipts)
-	if rs := ReadReceipts(db, hash, 0, params.TestChainConfig); len(rs) == 0 {
+	if rs, _ := ReadReceipts(db, hash, 0, params.TestChainConfig); len(rs) == 0 {
 		t.Fatalf("no receipts returned")
 	} else {
 		if err := checkReceiptsRLP(rs, receipts); err != nil {
@@ -308,7 +316,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 	}
 	// Delete the body and ensure that the receipts are no longer returned (metadata can't be recomputed)
 	DeleteBody(db.BodyTable(), hash, 0)
-	if rs := ReadReceipts(db, hash, 0, params.TestChainConfig); rs != nil {
+	if rs, _ := ReadReceipts(db, hash, 0, params.TestChainConfig); rs != nil {
 		t.Fatalf("receipts returned when body was deleted: %v", rs)
 	}
 	// Ensure that receipts without metadata can be returned without the block body too
@@ -319,7 +327,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 	WriteBody(db.BodyTable(), hash, 0, body)
 
 	DeleteReceipts(db.ReceiptTable(), hash, 0)
-	if rs := ReadReceipts(db, hash, 0, params.TestChainConfig); len(rs) != 0 {
+	if rs, _ := ReadReceipts(db, hash, 0, params.TestChainConfig); len(rs) != 0 {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
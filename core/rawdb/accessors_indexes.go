@@ -7,6 +7,7 @@ import (
 
 	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/ethdb"
 	"github.com/ChainAAS/gendchain/log"
 	"github.com/ChainAAS/gendchain/rlp"
 )
@@ -72,8 +73,10 @@ func ReadTransaction(db common.Database, hash common.Hash) (*types.Transaction,
 	if blockHash == (common.Hash{}) {
 		return nil, common.Hash{}, 0, 0
 	}
-	body := ReadBody(db.BodyTable(), blockHash, *blockNumber)
-	if body == nil {
+	body, err := ReadBody(db.BodyTable(), blockHash, *blockNumber)
+	if err == ethdb.ErrHistoryPruned {
+		return nil, common.Hash{}, 0, 0
+	} else if body == nil {
 		log.Error("Transaction referenced missing", "number", blockNumber, "hash", blockHash)
 		return nil, common.Hash{}, 0, 0
 	}
@@ -97,7 +100,10 @@ func ReadReceipt(db common.Database, hash common.Hash, config *params.ChainConfi
 	if blockHash == (common.Hash{}) {
 		return nil, common.Hash{}, 0, 0
 	}
-	receipts := ReadReceipts(db, blockHash, *blockNumber, config)
+	receipts, err := ReadReceipts(db, blockHash, *blockNumber, config)
+	if err == ethdb.ErrHistoryPruned {
+		return nil, common.Hash{}, 0, 0
+	}
 	for receiptIndex, receipt := range receipts {
 		if receipt.TxHash == hash {
 			return receipt, blockHash, *blockNumber, uint64(receiptIndex)
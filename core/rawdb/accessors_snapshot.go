@@ -0,0 +1,143 @@
+package rawdb
+
+import (
+	"github.com/ChainAAS/gendchain/common"
+)
+
+// ReadSnapshotRoot retrieves the state root of the flat snapshot on disk, or
+// the zero hash if there is none.
+func ReadSnapshotRoot(db DatabaseReader) common.Hash {
+	var data []byte
+	Must("get snapshot root", func() (err error) {
+		data, err = db.Get(snapshotRootKey)
+		if err == common.ErrNotFound {
+			err = nil
+		}
+		return
+	})
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteSnapshotRoot stores the state root of the flat snapshot on disk.
+func WriteSnapshotRoot(db DatabaseWriter, root common.Hash) {
+	Must("put snapshot root", func() error {
+		return db.Put(snapshotRootKey, root[:])
+	})
+}
+
+// DeleteSnapshotRoot removes the state root of the flat snapshot, marking the
+// snapshot on disk as unusable until it is written again.
+func DeleteSnapshotRoot(db DatabaseDeleter) {
+	Must("delete snapshot root", func() error {
+		return db.Delete(snapshotRootKey)
+	})
+}
+
+// ReadSnapshotGenerator retrieves the encoded progress of snapshot generation.
+func ReadSnapshotGenerator(db DatabaseReader) []byte {
+	var data []byte
+	Must("get snapshot generator", func() (err error) {
+		data, err = db.Get(snapshotGeneratorKey)
+		if err == common.ErrNotFound {
+			err = nil
+		}
+		return
+	})
+	return data
+}
+
+// WriteSnapshotGenerator stores the encoded progress of snapshot generation.
+func WriteSnapshotGenerator(db DatabaseWriter, generator []byte) {
+	Must("put snapshot generator", func() error {
+		return db.Put(snapshotGeneratorKey, generator)
+	})
+}
+
+// DeleteSnapshotGenerator removes the progress of a completed snapshot generation.
+func DeleteSnapshotGenerator(db DatabaseDeleter) {
+	Must("delete snapshot generator", func() error {
+		return db.Delete(snapshotGeneratorKey)
+	})
+}
+
+// ReadAccountSnapshot retrieves the account trie value of an account from the
+// flat snapshot, or nil if the account does not exist.
+func ReadAccountSnapshot(db DatabaseReader, hash common.Hash) []byte {
+	var data []byte
+	Must("get account snapshot", func() (err error) {
+		data, err = db.Get(hashKey(snapshotAccountPrefix, hash))
+		if err == common.ErrNotFound {
+			err = nil
+		}
+		return
+	})
+	return data
+}
+
+// WriteAccountSnapshot stores the account trie value of an account in the
+// flat snapshot.
+func WriteAccountSnapshot(db DatabaseWriter, hash common.Hash, entry []byte) {
+	Must("put account snapshot", func() error {
+		return db.Put(hashKey(snapshotAccountPrefix, hash), entry)
+	})
+}
+
+// DeleteAccountSnapshot removes an account from the flat snapshot.
+func DeleteAccountSnapshot(db DatabaseDeleter, hash common.Hash) {
+	Must("delete account snapshot", func() error {
+		return db.Delete(hashKey(snapshotAccountPrefix, hash))
+	})
+}
+
+// IterateAccountSnapshots calls fn for every account of the flat snapshot.
+func IterateAccountSnapshots(db DatabaseIterator, fn func(hash common.Hash, entry []byte) error) error {
+	return db.IteratePrefix([]byte{snapshotAccountPrefix}, func(key, value []byte) error {
+		if len(key) != 1+common.HashLength {
+			return nil
+		}
+		return fn(common.BytesToHash(key[1:]), value)
+	})
+}
+
+// ReadStorageSnapshot retrieves the storage trie value of a slot from the flat
+// snapshot, or nil if the slot is empty.
+func ReadStorageSnapshot(db DatabaseReader, accountHash, storageHash common.Hash) []byte {
+	var data []byte
+	Must("get storage snapshot", func() (err error) {
+		data, err = db.Get(storageSnapshotKey(accountHash, storageHash))
+		if err == common.ErrNotFound {
+			err = nil
+		}
+		return
+	})
+	return data
+}
+
+// WriteStorageSnapshot stores the storage trie value of a slot in the flat
+// snapshot.
+func WriteStorageSnapshot(db DatabaseWriter, accountHash, storageHash common.Hash, entry []byte) {
+	Must("put storage snapshot", func() error {
+		return db.Put(storageSnapshotKey(accountHash, storageHash), entry)
+	})
+}
+
+// DeleteStorageSnapshot removes a slot from the flat snapshot.
+func DeleteStorageSnapshot(db DatabaseDeleter, accountHash, storageHash common.Hash) {
+	Must("delete storage snapshot", func() error {
+		return db.Delete(storageSnapshotKey(accountHash, storageHash))
+	})
+}
+
+// IterateStorageSnapshots calls fn for every slot of an account in the flat
+// snapshot.
+func IterateStorageSnapshots(db DatabaseIterator, accountHash common.Hash, fn func(hash common.Hash, entry []byte) error) error {
+	return db.IteratePrefix(hashKey(snapshotStoragePrefix, accountHash), func(key, value []byte) error {
+		if len(key) != 1+2*common.HashLength {
+			return nil
+		}
+		return fn(common.BytesToHash(key[1+common.HashLength:]), value)
+	})
+}
@@ -16,3 +16,9 @@ type DatabaseWriter interface {
 type DatabaseDeleter interface {
 	Delete(key []byte) error
 }
+
+// DatabaseIterator wraps the IteratePrefix method of a backing data store.
+type DatabaseIterator interface {
+	// IteratePrefix calls fn for every key/value pair whose key starts with prefix.
+	IteratePrefix(prefix []byte, fn func(key, value []byte) error) error
+}
@@ -17,6 +17,9 @@ const (
 	blockReceiptsPrefix byte = 'r' // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
 	lookupPrefix        byte = 'l' // lookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix     byte = 'B' // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+
+	snapshotAccountPrefix byte = 'a' // snapshotAccountPrefix + account hash -> account trie value
+	snapshotStoragePrefix byte = 'o' // snapshotStoragePrefix + account hash + storage hash -> storage trie value
 )
 
 // The fields below define the low level database schema prefixing.
@@ -33,9 +36,23 @@ var (
 	// headFastBlockKey tracks the latest known incomplete block's hash duirng fast sync.
 	headFastBlockKey = []byte("LastFast")
 
+	// finalizedBlockKey tracks the hash of the latest block the consensus engine
+	// considers final.
+	finalizedBlockKey = []byte("LastFinalized")
+
 	// fastTrieProgressKey tracks the number of trie entries imported during fast sync.
 	fastTrieProgressKey = []byte("TrieSync")
 
+	// statePruneKey tracks the oldest block whose state is kept by an
+	// unfinished state pruning run.
+	statePruneKey = []byte("StatePrune")
+
+	// snapshotRootKey tracks the state root of the flat snapshot on disk.
+	snapshotRootKey = []byte("SnapshotRoot")
+
+	// snapshotGeneratorKey tracks the progress of snapshot generation.
+	snapshotGeneratorKey = []byte("SnapshotGenerator")
+
 	preimagePrefix = "secure-key-"              // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
@@ -99,3 +116,12 @@ func preimageKey(hash common.Hash) []byte {
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
 }
+
+// storageSnapshotKey = snapshotStoragePrefix + account hash + storage hash
+func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
+	var k [65]byte
+	k[0] = snapshotStoragePrefix
+	copy(k[1:], accountHash[:])
+	copy(k[33:], storageHash[:])
+	return k[:]
+}
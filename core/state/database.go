@@ -5,6 +5,8 @@ import (
 	"sync"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/state/snapshot"
+	"github.com/ChainAAS/gendchain/log"
 	"github.com/ChainAAS/gendchain/trie"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -40,6 +42,9 @@ type Database interface {
 
 	// TrieDB retrieves the low level trie database used for data storage.
 	TrieDB() *trie.Database
+
+	// Snapshots retrieves the flat state snapshots, or nil if not maintained.
+	Snapshots() *snapshot.Tree
 }
 
 // Trie is a Ethereum Merkle Trie.
@@ -72,8 +77,25 @@ func NewDatabaseWithCache(db common.Database, cache int) Database {
 	}
 }
 
+// NewDatabaseWithSnapshots creates a backing store for state like
+// NewDatabaseWithCache, which also maintains flat snapshots of the recent
+// states using snapshots megabytes of memory for caching. No snapshots are
+// maintained if snapshots is zero. The snapshots are empty until loaded.
+func NewDatabaseWithSnapshots(db common.Database, cache, snapshots int) Database {
+	sdb := NewDatabaseWithCache(db, cache).(*cachingDB)
+	if snapshots > 0 {
+		snaps, err := snapshot.New(db, sdb.db, snapshots)
+		if err != nil {
+			log.Warn("State snapshots disabled", "err", err)
+		}
+		sdb.snaps = snaps
+	}
+	return sdb
+}
+
 type cachingDB struct {
 	db            *trie.Database
+	snaps         *snapshot.Tree
 	mu            sync.Mutex
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
@@ -148,6 +170,11 @@ func (db *cachingDB) TrieDB() *trie.Database {
 	return db.db
 }
 
+// Snapshots retrieves the flat state snapshots, or nil if not maintained.
+func (db *cachingDB) Snapshots() *snapshot.Tree {
+	return db.snaps
+}
+
 // cachedTrie inserts its trie into a cachingDB on commit.
 type cachedTrie struct {
 	*trie.SecureTrie
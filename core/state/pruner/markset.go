@@ -0,0 +1,88 @@
+package pruner
+
+import (
+	"os"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// markSet is the set of node and code hashes reachable from the retained roots.
+// It holds the whole retained state, so it is kept in a temporary LevelDB
+// database whose bloom filters spare disk reads for most hashes not in the set.
+// Hashes are added in batches, buffered in memory until written.
+type markSet struct {
+	path    string
+	db      *leveldb.DB
+	batch   *leveldb.Batch
+	pending map[common.Hash]struct{} // added to batch, not yet written
+	n       int
+}
+
+// newMarkSet returns an empty set stored at path, removing any set left there
+// by an interrupted run.
+func newMarkSet(path string) (*markSet, error) {
+	if err := os.RemoveAll(path); err != nil {
+		return nil, err
+	}
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		OpenFilesCacheCapacity: 64,
+		BlockCacheCapacity:     32 * opt.MiB,
+		WriteBuffer:            16 * opt.MiB,
+		Filter:                 filter.NewBloomFilter(10),
+		NoSync:                 true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &markSet{
+		path:    path,
+		db:      db,
+		batch:   new(leveldb.Batch),
+		pending: make(map[common.Hash]struct{}),
+	}, nil
+}
+
+// has returns whether hash is in the set.
+func (s *markSet) has(hash common.Hash) (bool, error) {
+	if _, ok := s.pending[hash]; ok {
+		return true, nil
+	}
+	return s.db.Has(hash[:], nil)
+}
+
+// add adds hash to the set. It must not be in the set yet.
+func (s *markSet) add(hash common.Hash) error {
+	s.batch.Put(hash[:], nil)
+	s.pending[hash] = struct{}{}
+	s.n++
+	if len(s.pending) >= ethdb.IdealBatchSize/common.HashLength {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered hashes to disk.
+func (s *markSet) flush() error {
+	if err := s.db.Write(s.batch, nil); err != nil {
+		return err
+	}
+	s.batch.Reset()
+	s.pending = make(map[common.Hash]struct{})
+	return nil
+}
+
+// len returns the number of hashes in the set.
+func (s *markSet) len() int { return s.n }
+
+// close closes and removes the set.
+func (s *markSet) close() error {
+	err := s.db.Close()
+	if rerr := os.RemoveAll(s.path); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
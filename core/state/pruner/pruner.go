@@ -0,0 +1,361 @@
+// Package pruner implements mark-and-sweep pruning of the state stored in the
+// global table.
+package pruner
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/crypto"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/rlp"
+)
+
+const (
+	// DefaultRetain is the default number of recent blocks whose state is kept.
+	DefaultRetain = 128
+
+	// logInterval is the time between progress reports.
+	logInterval = 8 * time.Second
+)
+
+var emptyCode = crypto.Keccak256Hash(nil)
+
+// errMarkSet is returned when the mark set can't be read or written, which must
+// abort a run rather than skip the root being marked.
+var errMarkSet = errors.New("pruner: mark set unavailable")
+
+// ErrNoState is returned when none of the retained state is available, which
+// would otherwise delete all of the state.
+var ErrNoState = errors.New("pruner: no retained state available")
+
+// Iterator is implemented by tables which can list their contents.
+type Iterator interface {
+	Iterate(fn func(key, value []byte) error) error
+}
+
+// Pruner deletes the state trie nodes and contract code held in the global
+// table which are not reachable from a set of retained state roots. The
+// reachable hashes are marked in a set on disk, next to the tables of a
+// segmented database or in the temporary directory otherwise.
+//
+// Nodes flushed to disk by the trie database during a run are never deleted,
+// so a pruner can run alongside block processing. A run records its progress
+// in the global table before deleting anything and is resumed by Recover if
+// interrupted.
+type Pruner struct {
+	db      common.Database
+	statedb state.Database // resolves tries, through the trie cache if online
+
+	marked    *markSet                 // reachable from a retained root
+	protected map[common.Hash]struct{} // flushed during the run
+	mu        sync.Mutex               // protects protected
+}
+
+// New returns a new pruner of the state in db, read through statedb.
+func New(db common.Database, statedb state.Database) *Pruner {
+	return &Pruner{
+		db:      db,
+		statedb: statedb,
+	}
+}
+
+// RetainedRoots returns the state roots to keep for the canonical chain at
+// head: the available states of the last retain blocks, the latest state
+// persisted before them if none of those is, and the genesis state. Also
+// returns the number of the oldest block kept besides the genesis.
+func (p *Pruner) RetainedRoots(head, retain uint64) (from uint64, roots []common.Hash) {
+	var persisted bool
+	for number := head; number > 0 && (!persisted || head-number <= retain); number-- {
+		header := rawdb.ReadHeader(p.db.HeaderTable(), rawdb.ReadCanonicalHash(p.db, number), number)
+		if header == nil {
+			continue
+		}
+		// A restart rewinds to the latest persisted state, which may be
+		// older than the recent states held by the trie cache.
+		onDisk, _ := p.db.GlobalTable().Has(header.Root[:])
+		if head-number <= retain {
+			if _, err := p.statedb.OpenTrie(header.Root); err != nil {
+				continue
+			}
+		} else if !onDisk {
+			continue
+		}
+		roots, from = append(roots, header.Root), number
+		persisted = persisted || onDisk
+	}
+	if genesis := rawdb.ReadHeader(p.db.HeaderTable(), rawdb.ReadCanonicalHash(p.db, 0), 0); genesis != nil {
+		roots = append(roots, genesis.Root)
+	}
+	return from, roots
+}
+
+// Prune deletes the state not reachable from roots. from is the number of the
+// oldest retained block, recorded to resume an interrupted run.
+func (p *Pruner) Prune(ctx context.Context, from uint64, roots []common.Hash) error {
+	return p.prune(ctx, roots, &from)
+}
+
+func (p *Pruner) prune(ctx context.Context, roots []common.Hash, from *uint64) error {
+	path, err := p.markSetPath()
+	if err != nil {
+		return err
+	}
+	if p.marked, err = newMarkSet(path); err != nil {
+		return err
+	}
+	p.protected = make(map[common.Hash]struct{})
+	defer func() {
+		if err := p.marked.close(); err != nil {
+			log.Warn("Failed to remove state pruning marks", "path", path, "err", err)
+		}
+		p.marked, p.protected = nil, nil
+	}()
+
+	triedb := p.statedb.TrieDB()
+	triedb.SetFlushHook(p.protect)
+	defer triedb.SetFlushHook(nil)
+
+	// Keep the retained tries in the trie cache until they are marked.
+	for _, root := range roots {
+		triedb.Reference(root, common.Hash{})
+	}
+	err = p.mark(ctx, roots)
+	for _, root := range roots {
+		triedb.Dereference(root)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Nothing has been deleted so far. From here on the run must complete
+	// before the state is used again.
+	if from != nil {
+		rawdb.WriteStatePrune(p.db.GlobalTable(), *from)
+	}
+	if err := p.sweep(ctx); err != nil {
+		return err
+	}
+	rawdb.DeleteStatePrune(p.db.GlobalTable())
+	return nil
+}
+
+// markSetPath returns the path of the mark set of a run.
+func (p *Pruner) markSetPath() (string, error) {
+	if db, ok := p.db.(*ethdb.DB); ok {
+		return filepath.Join(db.Path, "prunemarks"), nil
+	}
+	return ioutil.TempDir("", "prunemarks")
+}
+
+// protect keeps a node written to disk during a run.
+func (p *Pruner) protect(hash common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.protected[hash] = struct{}{}
+}
+
+// mark marks all nodes and code reachable from roots. Unavailable roots are
+// skipped, unless none is available.
+func (p *Pruner) mark(ctx context.Context, roots []common.Hash) error {
+	var (
+		start   = time.Now()
+		logged  = time.Now()
+		skipped int
+	)
+	for _, root := range roots {
+		if err := p.markState(ctx, root, start, &logged); err != nil {
+			if err == context.Canceled || err == context.DeadlineExceeded || err == errMarkSet {
+				return err
+			}
+			log.Warn("Skipping unavailable state", "root", root, "err", err)
+			skipped++
+		}
+	}
+	if skipped == len(roots) {
+		return ErrNoState
+	}
+	log.Info("Marked state to keep", "roots", len(roots)-skipped, "nodes", p.marked.len(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// markState marks the state trie at root, its storage tries and code.
+func (p *Pruner) markState(ctx context.Context, root common.Hash, start time.Time, logged *time.Time) error {
+	t, err := p.statedb.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	return p.markTrie(ctx, t, start, logged, func(leaf []byte) error {
+		var account state.Account
+		if err := rlp.DecodeBytes(leaf, &account); err != nil {
+			return err
+		}
+		if account.Root != types.EmptyRootHash {
+			st, err := p.statedb.OpenStorageTrie(common.Hash{}, account.Root)
+			if err != nil {
+				return err
+			} else if err := p.markTrie(ctx, st, start, logged, nil); err != nil {
+				return err
+			}
+		}
+		if account.CodeHash != emptyCode {
+			if _, err := p.markHash(account.CodeHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// markTrie marks the nodes of t, skipping subtries already marked, and calls
+// onLeaf with the value of every leaf reached.
+func (p *Pruner) markTrie(ctx context.Context, t state.Trie, start time.Time, logged *time.Time, onLeaf func([]byte) error) error {
+	it := t.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		descend = true
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if added, err := p.markHash(hash); err != nil {
+				return err
+			} else if !added {
+				descend = false
+				continue
+			}
+
+			if time.Since(*logged) > logInterval {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				log.Info("Marking state to keep", "nodes", p.marked.len(), "elapsed", common.PrettyDuration(time.Since(start)))
+				*logged = time.Now()
+			}
+		}
+		if it.Leaf() && onLeaf != nil {
+			if err := onLeaf(it.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// markHash adds hash to the mark set, reporting whether it wasn't marked yet.
+func (p *Pruner) markHash(hash common.Hash) (bool, error) {
+	ok, err := p.marked.has(hash)
+	if err == nil && !ok {
+		err = p.marked.add(hash)
+	}
+	if err != nil {
+		log.Error("Failed to update state pruning marks", "err", err)
+		return false, errMarkSet
+	}
+	return !ok, nil
+}
+
+// sweep deletes the nodes and code of the global table which are neither
+// marked nor protected. Only keys holding the hash of their value are swept,
+// which leaves any other 32 byte key of the global table alone.
+func (p *Pruner) sweep(ctx context.Context) error {
+	global := p.db.GlobalTable()
+	tbl, ok := global.(Iterator)
+	if !ok {
+		return errors.New("pruner: global table cannot be iterated")
+	}
+
+	var (
+		start   = time.Now()
+		logged  = time.Now()
+		batch   = global.NewBatch()
+		pending []common.Hash
+		sizes   []int
+		size    common.StorageSize
+		nodes   int
+	)
+
+	// Protected nodes are checked while holding the lock until the deletes are
+	// written, so a node flushed concurrently is either skipped or rewritten.
+	flush := func() error {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		for i, hash := range pending {
+			if _, ok := p.protected[hash]; ok {
+				continue
+			}
+			if err := batch.Delete(hash[:]); err != nil {
+				return err
+			}
+			nodes, size = nodes+1, size+common.StorageSize(common.HashLength+sizes[i])
+		}
+		pending, sizes = pending[:0], sizes[:0]
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+
+	err := tbl.Iterate(func(key, value []byte) error {
+		if len(key) != common.HashLength {
+			return nil
+		}
+		hash := common.BytesToHash(key)
+		if ok, err := p.marked.has(hash); err != nil {
+			return err
+		} else if ok || crypto.Keccak256Hash(value) != hash {
+			return nil
+		}
+		pending, sizes = append(pending, hash), append(sizes, len(value))
+
+		if len(pending) >= ethdb.IdealBatchSize/common.HashLength {
+			if err := flush(); err != nil {
+				return err
+			} else if err := ctx.Err(); err != nil {
+				return err
+			}
+			if time.Since(logged) > logInterval {
+				log.Info("Pruning state data", "nodes", nodes, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	} else if err := flush(); err != nil {
+		return err
+	}
+	log.Info("Pruned state data", "nodes", nodes, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// Recover resumes a pruning run interrupted by a crash or shutdown, keeping
+// the persisted state of the canonical blocks since the one it recorded. It
+// must be called before the state is used, and does nothing if no run was in
+// progress.
+func Recover(db common.Database) error {
+	from := rawdb.ReadStatePrune(db.GlobalTable())
+	if from == nil {
+		return nil
+	}
+	head := rawdb.ReadHeaderNumber(db.GlobalTable(), rawdb.ReadHeadBlockHash(db.GlobalTable()))
+	if head == nil || *head < *from {
+		return errors.New("pruner: head block missing, cannot resume state pruning")
+	}
+	log.Info("Resuming interrupted state pruning", "from", *from, "head", *head)
+
+	p := New(db, state.NewDatabase(db))
+	_, roots := p.RetainedRoots(*head, *head-*from)
+	return p.prune(context.Background(), roots, nil)
+}
@@ -0,0 +1,142 @@
+package pruner_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/consensus/clique"
+	"github.com/ChainAAS/gendchain/core"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/state/pruner"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/core/vm"
+	"github.com/ChainAAS/gendchain/crypto"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/params"
+)
+
+func TestPruner_Prune(t *testing.T) {
+	db, blocks := newTestChain(t, 10)
+
+	// Hash sized keys which don't hold the hash of their value aren't state.
+	other := crypto.Keccak256([]byte("other"))
+	if err := db.GlobalTable().Put(other, []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	keys := db.Len()
+
+	p := pruner.New(db, state.NewDatabase(db))
+	from, roots := p.RetainedRoots(10, 2)
+	if from != 8 {
+		t.Fatalf("unexpected from: %d", from)
+	} else if len(roots) != 4 {
+		t.Fatalf("unexpected roots: %d", len(roots))
+	}
+	if err := p.Prune(context.Background(), from, roots); err != nil {
+		t.Fatal(err)
+	} else if n := db.Len(); n >= keys {
+		t.Fatalf("expected keys to be deleted: %d >= %d", n, keys)
+	} else if v := rawdb.ReadStatePrune(db.GlobalTable()); v != nil {
+		t.Fatalf("unexpected marker: %d", *v)
+	}
+
+	// Retained state is complete, older state is gone.
+	for _, block := range blocks {
+		err := checkState(db, block.Root())
+		if n := block.NumberU64(); n == 0 || n >= 8 {
+			if err != nil {
+				t.Fatalf("block %d: %v", n, err)
+			}
+		} else if err == nil {
+			t.Fatalf("block %d: expected state to be pruned", n)
+		}
+	}
+
+	// Chain data is left alone.
+	if rawdb.ReadHeadBlockHash(db.GlobalTable()) != blocks[10].Hash() {
+		t.Fatal("unexpected head block")
+	} else if v, err := db.GlobalTable().Get(other); err != nil || string(v) != "value" {
+		t.Fatalf("unexpected other key: %q / %v", v, err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	db, blocks := newTestChain(t, 10)
+
+	// Nothing to do without a marker.
+	keys := db.Len()
+	if err := pruner.Recover(db); err != nil {
+		t.Fatal(err)
+	} else if db.Len() != keys {
+		t.Fatal("unexpected deletes")
+	}
+
+	// An interrupted run keeps the state since the recorded block.
+	rawdb.WriteStatePrune(db.GlobalTable(), 5)
+	if err := pruner.Recover(db); err != nil {
+		t.Fatal(err)
+	} else if v := rawdb.ReadStatePrune(db.GlobalTable()); v != nil {
+		t.Fatalf("unexpected marker: %d", *v)
+	}
+	for _, block := range blocks {
+		err := checkState(db, block.Root())
+		if n := block.NumberU64(); n == 0 || n >= 5 {
+			if err != nil {
+				t.Fatalf("block %d: %v", n, err)
+			}
+		} else if err == nil {
+			t.Fatalf("block %d: expected state to be pruned", n)
+		}
+	}
+}
+
+// newTestChain returns an archive database holding the state of n blocks,
+// each funding a new account, and the blocks including the genesis.
+func newTestChain(tb testing.TB, n int) (*ethdb.MemDatabase, []*types.Block) {
+	tb.Helper()
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		db      = ethdb.NewMemDatabase()
+		gspec   = &core.Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int), Clique: params.DefaultCliqueConfig()},
+			Alloc:  core.GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		gendb   = ethdb.NewMemDatabase()
+		signer  = types.HomesteadSigner{}
+	)
+	gspec.MustCommit(gendb)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, clique.NewFaker(), gendb, n, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{byte(i + 1)}, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		gen.AddTx(tx)
+	})
+
+	chain, err := core.NewBlockChain(db, &core.CacheConfig{Disabled: true}, gspec.Config, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer chain.Stop()
+	if i, err := chain.InsertChain(blocks); err != nil {
+		tb.Fatalf("failed to insert block %d: %v", i, err)
+	}
+	return db, append([]*types.Block{genesis}, blocks...)
+}
+
+// checkState returns an error if any node of the state at root is missing.
+func checkState(db *ethdb.MemDatabase, root common.Hash) error {
+	st, err := state.New(root, state.NewDatabase(db))
+	if err != nil {
+		return err
+	}
+	it := state.NewNodeIterator(st)
+	for it.Next() {
+	}
+	return it.Error
+}
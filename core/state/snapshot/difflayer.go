@@ -0,0 +1,144 @@
+package snapshot
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ChainAAS/gendchain/common"
+)
+
+// diffLayer holds the state changes of a block on top of its parent layer.
+// Its data is only modified once the layer is marked stale, when the layer
+// above it is flattened into it.
+type diffLayer struct {
+	parent snapshot    // layer below, replaced when flattened into the disk layer
+	root   common.Hash // state root the layer represents
+	stale  uint32      // set once flattened or dropped
+
+	destructSet map[common.Hash]struct{}               // accounts deleted or recreated
+	accountData map[common.Hash][]byte                 // account trie values, nil if deleted
+	storageData map[common.Hash]map[common.Hash][]byte // storage trie values, nil if deleted
+
+	lock sync.RWMutex // protects parent, and the data while it's flattened
+}
+
+// newDiffLayer returns a diff layer for root on top of parent.
+func newDiffLayer(parent snapshot, root common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) *diffLayer {
+	if destructs == nil {
+		destructs = make(map[common.Hash]struct{})
+	}
+	if accounts == nil {
+		accounts = make(map[common.Hash][]byte)
+	}
+	if storage == nil {
+		storage = make(map[common.Hash]map[common.Hash][]byte)
+	}
+	return &diffLayer{
+		parent:      parent,
+		root:        root,
+		destructSet: destructs,
+		accountData: accounts,
+		storageData: storage,
+	}
+}
+
+// Root returns the state root the layer represents.
+func (dl *diffLayer) Root() common.Hash { return dl.root }
+
+// Parent returns the layer below.
+func (dl *diffLayer) Parent() snapshot {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+	return dl.parent
+}
+
+// Stale reports whether the layer has been flattened or dropped.
+func (dl *diffLayer) Stale() bool { return atomic.LoadUint32(&dl.stale) != 0 }
+
+func (dl *diffLayer) markStale() { atomic.StoreUint32(&dl.stale, 1) }
+
+// AccountRLP returns the account trie value of an account, falling through to
+// the layers below if the block did not change it.
+func (dl *diffLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.Stale() {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if data, ok := dl.accountData[hash]; ok {
+		dl.lock.RUnlock()
+		return data, nil
+	}
+	_, destructed := dl.destructSet[hash]
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	if destructed {
+		return nil, nil
+	}
+	return parent.AccountRLP(hash)
+}
+
+// Storage returns the storage trie value of a slot of an account, falling
+// through to the layers below if the block did not change it.
+func (dl *diffLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	if dl.Stale() {
+		dl.lock.RUnlock()
+		return nil, ErrSnapshotStale
+	}
+	if storage, ok := dl.storageData[accountHash]; ok {
+		if data, ok := storage[storageHash]; ok {
+			dl.lock.RUnlock()
+			return data, nil
+		}
+	}
+	_, destructed := dl.destructSet[accountHash]
+	parent := dl.parent
+	dl.lock.RUnlock()
+
+	if destructed {
+		return nil, nil
+	}
+	return parent.Storage(accountHash, storageHash)
+}
+
+// flatten merges dl and the diff layers below it into a single layer on top
+// of the disk layer, marking the merged layers stale. The data of the bottom
+// layer is extended in place, so that repeatedly flattening onto it costs the
+// size of the layers merged rather than of everything accumulated. Returns dl
+// itself if its parent is the disk layer.
+func (dl *diffLayer) flatten() *diffLayer {
+	parent, ok := dl.Parent().(*diffLayer)
+	if !ok {
+		return dl
+	}
+	parent = parent.flatten()
+
+	// Readers of the parent check staleness under its lock, so none of them
+	// sees the data while it's modified.
+	parent.lock.Lock()
+	defer parent.lock.Unlock()
+
+	parent.markStale()
+	for hash := range dl.destructSet {
+		parent.destructSet[hash] = struct{}{}
+		delete(parent.accountData, hash)
+		delete(parent.storageData, hash)
+	}
+	for hash, data := range dl.accountData {
+		parent.accountData[hash] = data
+	}
+	for hash, storage := range dl.storageData {
+		slots, ok := parent.storageData[hash]
+		if !ok {
+			slots = make(map[common.Hash][]byte, len(storage))
+			parent.storageData[hash] = slots
+		}
+		for slot, data := range storage {
+			slots[slot] = data
+		}
+	}
+	dl.markStale()
+	return newDiffLayer(parent.parent, dl.root, parent.destructSet, parent.accountData, parent.storageData)
+}
@@ -0,0 +1,217 @@
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/trie"
+	"github.com/allegro/bigcache"
+)
+
+// diskLayer is the snapshot persisted in the global table, with a cache of the
+// entries read from it.
+type diskLayer struct {
+	diskdb database           // global table holding the snapshot
+	triedb *trie.Database     // trie database to generate the snapshot from
+	cache  *bigcache.BigCache // cache of entries read, nil to disable
+	root   common.Hash        // state root of the snapshot
+	stale  bool               // set once flattened into or dropped
+
+	genMarker  []byte             // last account generated, nil once complete
+	genPending chan struct{}      // closed once generation completes
+	genAbort   chan chan struct{} // requests generation to stop
+
+	lock sync.RWMutex
+}
+
+// newDiskLayer returns the disk layer of root, fully generated.
+func newDiskLayer(diskdb database, triedb *trie.Database, cache *bigcache.BigCache, root common.Hash) *diskLayer {
+	return &diskLayer{
+		diskdb: diskdb,
+		triedb: triedb,
+		cache:  cache,
+		root:   root,
+	}
+}
+
+// newCache returns a cache of size megabytes, or nil if size is zero.
+func newCache(size int) *bigcache.BigCache {
+	if size <= 0 {
+		return nil
+	}
+	cache, _ := bigcache.NewBigCache(bigcache.Config{
+		Shards:             1024,
+		LifeWindow:         time.Hour,
+		MaxEntriesInWindow: size * 1024,
+		MaxEntrySize:       512,
+		HardMaxCacheSize:   size,
+	})
+	return cache
+}
+
+// Root returns the state root of the snapshot.
+func (dl *diskLayer) Root() common.Hash { return dl.root }
+
+// Parent always returns nil, the disk layer being the bottom of the tree.
+func (dl *diskLayer) Parent() snapshot { return nil }
+
+// Stale reports whether the layer has been flattened into or dropped.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+	return dl.stale
+}
+
+// AccountRLP returns the account trie value of an account.
+func (dl *diskLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if err := dl.check(hash); err != nil {
+		return nil, err
+	}
+	return dl.read(hash[:], func() []byte { return rawdb.ReadAccountSnapshot(dl.diskdb, hash) }), nil
+}
+
+// Storage returns the storage trie value of a slot of an account.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if err := dl.check(accountHash); err != nil {
+		return nil, err
+	}
+	key := append(accountHash[:], storageHash[:]...)
+	return dl.read(key, func() []byte { return rawdb.ReadStorageSnapshot(dl.diskdb, accountHash, storageHash) }), nil
+}
+
+// check returns an error if the entries of an account cannot be read. The
+// caller must hold the read lock.
+func (dl *diskLayer) check(accountHash common.Hash) error {
+	if dl.stale {
+		return ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return ErrNotCoveredYet
+	}
+	return nil
+}
+
+// covered reports whether the entries of an account have been generated.
+func (dl *diskLayer) covered(accountHash common.Hash) bool {
+	return dl.genMarker == nil || bytes.Compare(accountHash[:], dl.genMarker) <= 0
+}
+
+// read returns the entry of key from the cache, or from disk through load.
+func (dl *diskLayer) read(key []byte, load func() []byte) []byte {
+	if dl.cache != nil {
+		if blob, err := dl.cache.Get(string(key)); err == nil {
+			if len(blob) == 0 {
+				return nil
+			}
+			return blob
+		}
+	}
+	blob := load()
+	dl.cacheEntry(key, blob)
+	return blob
+}
+
+// cacheEntry caches the entry of key, with a nil blob for a missing entry.
+func (dl *diskLayer) cacheEntry(key, blob []byte) {
+	if dl.cache != nil {
+		dl.cache.Set(string(key), blob)
+	}
+}
+
+// startGeneration generates the rest of the snapshot in the background.
+func (dl *diskLayer) startGeneration() {
+	dl.genPending = make(chan struct{})
+	dl.genAbort = make(chan chan struct{})
+	go dl.generate()
+}
+
+// stopGeneration stops the generation of the snapshot, if running, once its
+// progress is persisted. The caller must hold the tree lock.
+func (dl *diskLayer) stopGeneration() {
+	if dl.genAbort == nil {
+		return
+	}
+	abort := make(chan struct{})
+	select {
+	case dl.genAbort <- abort:
+		<-abort
+	case <-dl.genPending:
+	}
+	dl.genAbort = nil
+}
+
+// diffToDisk writes the data of bottom, whose parent must be the disk layer,
+// to disk and returns the disk layer of its root. Accounts beyond the range
+// already generated are skipped, as generation resumes on the new root.
+func diffToDisk(bottom *diffLayer) *diskLayer {
+	base := bottom.Parent().(*diskLayer)
+	base.stopGeneration()
+
+	base.lock.Lock()
+	defer base.lock.Unlock()
+
+	batch := base.diskdb.NewBatch()
+	for hash := range bottom.destructSet {
+		if !base.covered(hash) {
+			continue
+		}
+		rawdb.DeleteAccountSnapshot(batch, hash)
+		base.cacheEntry(hash[:], nil)
+
+		if err := rawdb.IterateStorageSnapshots(base.diskdb, hash, func(slot common.Hash, _ []byte) error {
+			rawdb.DeleteStorageSnapshot(batch, hash, slot)
+			base.cacheEntry(append(hash[:], slot[:]...), nil)
+			return nil
+		}); err != nil {
+			log.Crit("Failed to iterate storage snapshot", "account", hash, "err", err)
+		}
+	}
+	for hash, data := range bottom.accountData {
+		if !base.covered(hash) {
+			continue
+		}
+		if len(data) > 0 {
+			rawdb.WriteAccountSnapshot(batch, hash, data)
+		} else {
+			rawdb.DeleteAccountSnapshot(batch, hash)
+		}
+		base.cacheEntry(hash[:], data)
+	}
+	for hash, storage := range bottom.storageData {
+		if !base.covered(hash) {
+			continue
+		}
+		for slot, data := range storage {
+			if len(data) > 0 {
+				rawdb.WriteStorageSnapshot(batch, hash, slot, data)
+			} else {
+				rawdb.DeleteStorageSnapshot(batch, hash, slot)
+			}
+			base.cacheEntry(append(hash[:], slot[:]...), data)
+		}
+	}
+	rawdb.WriteSnapshotRoot(batch, bottom.root)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write snapshot layer", "root", bottom.root, "err", err)
+	}
+	log.Debug("Flattened snapshot layer", "root", bottom.root, "accounts", len(bottom.accountData), "destructs", len(bottom.destructSet))
+
+	res := newDiskLayer(base.diskdb, base.triedb, base.cache, bottom.root)
+	res.genMarker = base.genMarker
+	if res.genMarker != nil {
+		res.startGeneration()
+	}
+	base.stale = true
+	bottom.markStale()
+	return res
+}
@@ -0,0 +1,243 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/rlp"
+	"github.com/ChainAAS/gendchain/trie"
+)
+
+// emptyRoot is the root hash of an empty trie.
+var emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// logInterval is the time between progress reports.
+const logInterval = 8 * time.Second
+
+// errAborted is returned internally when generation is stopped.
+var errAborted = errors.New("aborted")
+
+// generatorProgress is the persisted progress of snapshot generation.
+type generatorProgress struct {
+	Wiping bool   // whether the entries of a previous snapshot are being deleted
+	Marker []byte // last account generated
+}
+
+// marker returns the in-memory generation marker of the progress.
+func (p *generatorProgress) marker() []byte {
+	if p.Wiping || p.Marker == nil {
+		return []byte{}
+	}
+	return p.Marker
+}
+
+func decodeProgress(enc []byte) (*generatorProgress, error) {
+	var progress generatorProgress
+	if err := rlp.DecodeBytes(enc, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func writeProgress(db common.Putter, progress *generatorProgress) {
+	enc, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		log.Crit("Failed to encode snapshot generator", "err", err)
+	}
+	rawdb.WriteSnapshotGenerator(db, enc)
+}
+
+// generateSnapshot discards any snapshot on disk and starts generating the one
+// of root in the background.
+func generateSnapshot(diskdb database, triedb *trie.Database, cache int, root common.Hash) *diskLayer {
+	batch := diskdb.NewBatch()
+	rawdb.WriteSnapshotRoot(batch, root)
+	writeProgress(batch, &generatorProgress{Wiping: true})
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write snapshot generator", "err", err)
+	}
+	base := newDiskLayer(diskdb, triedb, newCache(cache), root)
+	base.genMarker = []byte{}
+	base.startGeneration()
+	return base
+}
+
+// generate deletes any previous snapshot and writes the accounts and storage
+// of the state trie from the persisted marker on, until complete or aborted.
+func (dl *diskLayer) generate() {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		batch  = dl.diskdb.NewBatch()
+	)
+	progress, err := decodeProgress(rawdb.ReadSnapshotGenerator(dl.diskdb))
+	if err != nil {
+		dl.fail(err)
+		return
+	}
+	if progress.Wiping {
+		if abort, err := dl.wipe(batch); err == errAborted {
+			close(abort)
+			return
+		} else if err != nil {
+			dl.fail(err)
+			return
+		}
+		progress = &generatorProgress{}
+		writeProgress(batch, progress)
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write snapshot generator", "err", err)
+		}
+		batch.Reset()
+	}
+
+	accTrie, err := trie.New(dl.root, dl.triedb)
+	if err != nil {
+		dl.fail(err)
+		return
+	}
+	var (
+		marker   = progress.Marker
+		accounts int
+		slots    int
+	)
+	it := trie.NewIterator(accTrie.NodeIterator(marker))
+	for it.Next() {
+		if bytes.Equal(it.Key, marker) {
+			continue // generated before
+		}
+		hash := common.BytesToHash(it.Key)
+
+		var account Account
+		if err := rlp.DecodeBytes(it.Value, &account); err != nil {
+			dl.fail(err)
+			return
+		}
+		rawdb.WriteAccountSnapshot(batch, hash, it.Value)
+		if account.Root != emptyRoot && account.Root != (common.Hash{}) {
+			storeTrie, err := trie.New(account.Root, dl.triedb)
+			if err != nil {
+				dl.fail(err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				rawdb.WriteStorageSnapshot(batch, hash, common.BytesToHash(storeIt.Key), storeIt.Value)
+				slots++
+			}
+			if storeIt.Err != nil {
+				dl.fail(storeIt.Err)
+				return
+			}
+		}
+		accounts++
+
+		// The batch only ever holds complete accounts, so the snapshot never
+		// has entries beyond the marker.
+		select {
+		case abort := <-dl.genAbort:
+			dl.commit(batch, hash)
+			close(abort)
+			return
+		default:
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			dl.commit(batch, hash)
+		}
+		if time.Since(logged) > logInterval {
+			log.Info("Generating state snapshot", "root", dl.root, "at", hash, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		dl.fail(it.Err)
+		return
+	}
+
+	rawdb.DeleteSnapshotGenerator(batch)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write snapshot", "err", err)
+	}
+	dl.lock.Lock()
+	dl.genMarker = nil
+	close(dl.genPending)
+	dl.lock.Unlock()
+
+	log.Info("Generated state snapshot", "root", dl.root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// commit writes the batch along with the progress up to marker, and makes the
+// entries generated so far readable.
+func (dl *diskLayer) commit(batch common.Batch, marker common.Hash) {
+	writeProgress(batch, &generatorProgress{Marker: marker[:]})
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write snapshot", "err", err)
+	}
+	batch.Reset()
+
+	dl.lock.Lock()
+	dl.genMarker = marker[:]
+	dl.lock.Unlock()
+}
+
+// fail stops generation after an error, typically the state trie being
+// dropped from memory, discarding the accounts not committed yet. The layer
+// keeps serving the range generated until the tree stops it, which resumes
+// generation on the next disk layer.
+func (dl *diskLayer) fail(err error) {
+	log.Warn("Failed to generate state snapshot", "root", dl.root, "err", err)
+	abort := <-dl.genAbort
+	close(abort)
+}
+
+// wipe deletes the entries of any previous snapshot. Returns errAborted and
+// the abort request if stopped.
+func (dl *diskLayer) wipe(batch common.Batch) (chan struct{}, error) {
+	var (
+		start   = time.Now()
+		abort   chan struct{}
+		pending int
+		deleted int
+	)
+	flush := func() {
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to delete snapshot", "err", err)
+		}
+		batch.Reset()
+		deleted, pending = deleted+pending, 0
+	}
+	err := rawdb.IterateAccountSnapshots(dl.diskdb, func(hash common.Hash, _ []byte) error {
+		if err := rawdb.IterateStorageSnapshots(dl.diskdb, hash, func(slot common.Hash, _ []byte) error {
+			rawdb.DeleteStorageSnapshot(batch, hash, slot)
+			pending++
+			return nil
+		}); err != nil {
+			return err
+		}
+		rawdb.DeleteAccountSnapshot(batch, hash)
+		pending++
+
+		select {
+		case abort = <-dl.genAbort:
+			return errAborted
+		default:
+		}
+		if pending >= ethdb.IdealBatchSize/common.HashLength {
+			flush()
+		}
+		return nil
+	})
+	flush()
+	if err != nil {
+		return abort, err
+	}
+	if deleted > 0 {
+		log.Info("Deleted previous state snapshot", "entries", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil, nil
+}
@@ -0,0 +1,149 @@
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/crypto"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/rlp"
+	"github.com/ChainAAS/gendchain/trie"
+)
+
+// newTestState writes a state trie of n accounts, every third one with a few
+// storage slots, and returns its root.
+func newTestState(t *testing.T, triedb *trie.Database, n int) common.Hash {
+	accTrie, _ := trie.New(common.Hash{}, triedb)
+	for i := 0; i < n; i++ {
+		account := Account{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: emptyRoot, CodeHash: crypto.Keccak256Hash(nil)}
+		if i%3 == 0 {
+			storeTrie, _ := trie.New(common.Hash{}, triedb)
+			for j := 1; j <= 3; j++ {
+				val, _ := rlp.EncodeToBytes(uint64(i*10 + j))
+				storeTrie.Update(crypto.Keccak256([]byte{byte(i), byte(j)}), val)
+			}
+			root, err := storeTrie.Commit(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			account.Root = root
+		}
+		enc, _ := rlp.EncodeToBytes(&account)
+		accTrie.Update(crypto.Keccak256([]byte{byte(i)}), enc)
+	}
+	root, err := accTrie.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestGenerateSnapshot(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	triedb := trie.NewDatabase(db)
+	root := newTestState(t, triedb, 100)
+
+	// Leftovers of a previous snapshot are wiped.
+	stale := common.HexToHash("0x01")
+	rawdb.WriteAccountSnapshot(db, stale, []byte{0x01})
+	rawdb.WriteStorageSnapshot(db, stale, stale, []byte{0x01})
+
+	base := generateSnapshot(db, triedb, 0, root)
+	<-base.genPending
+
+	if base.genMarker != nil {
+		t.Fatalf("unexpected marker: %x", base.genMarker)
+	}
+	if rawdb.ReadSnapshotGenerator(db) != nil {
+		t.Fatal("generator not deleted")
+	}
+	if have := rawdb.ReadSnapshotRoot(db); have != root {
+		t.Fatalf("unexpected root: have %x, want %x", have, root)
+	}
+	if rawdb.ReadAccountSnapshot(db, stale) != nil || rawdb.ReadStorageSnapshot(db, stale, stale) != nil {
+		t.Fatal("previous snapshot not wiped")
+	}
+	var accounts int
+	if err := rawdb.IterateAccountSnapshots(db, func(common.Hash, []byte) error {
+		accounts++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if accounts != 100 {
+		t.Fatalf("unexpected accounts: %d", accounts)
+	}
+	tree := &Tree{diskdb: db, triedb: triedb, layers: map[common.Hash]snapshot{root: base}}
+	if err := tree.Verify(root); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateSnapshot_Resume(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	triedb := trie.NewDatabase(db)
+	root := newTestState(t, triedb, 100)
+
+	full := generateSnapshot(db, triedb, 0, root)
+	<-full.genPending
+
+	// Drop the entries past some account and record it as the progress, as
+	// if generation was interrupted there.
+	var hashes []common.Hash
+	rawdb.IterateAccountSnapshots(db, func(hash common.Hash, _ []byte) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	marker := hashes[40]
+	for _, hash := range hashes[41:] {
+		rawdb.DeleteAccountSnapshot(db, hash)
+	}
+	writeProgress(db, &generatorProgress{Marker: marker[:]})
+
+	tree, err := New(db, triedb, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Load(root)
+	base := tree.layers[root].(*diskLayer)
+	base.lock.RLock()
+	resumed := base.genMarker != nil
+	base.lock.RUnlock()
+	if !resumed {
+		t.Fatal("generation not resumed")
+	}
+	<-base.genPending
+
+	if err := tree.Verify(root); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify_Corrupt(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	triedb := trie.NewDatabase(db)
+	root := newTestState(t, triedb, 10)
+
+	base := generateSnapshot(db, triedb, 0, root)
+	<-base.genPending
+	tree := &Tree{diskdb: db, triedb: triedb, layers: map[common.Hash]snapshot{root: base}}
+
+	var hash common.Hash
+	rawdb.IterateAccountSnapshots(db, func(h common.Hash, _ []byte) error {
+		hash = h
+		return nil
+	})
+	enc, _ := rlp.EncodeToBytes(&Account{Nonce: 1000, Balance: big.NewInt(0), Root: emptyRoot, CodeHash: crypto.Keccak256Hash(nil)})
+	rawdb.WriteAccountSnapshot(db, hash, enc)
+
+	if err := tree.Verify(root); err == nil {
+		t.Fatal("expected verification to fail")
+	}
+}
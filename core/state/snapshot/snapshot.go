@@ -0,0 +1,321 @@
+// Package snapshot implements a flat snapshot of the account and storage
+// state, used to read the state without traversing the tries.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/trie"
+)
+
+var (
+	// ErrSnapshotStale is returned from data accessors if the layer has been
+	// flattened into the one below it or dropped from the tree.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned from data accessors if the disk layer is
+	// being generated and the requested account has not been reached yet.
+	ErrNotCoveredYet = errors.New("not covered yet")
+
+	// errSnapshotCycle is returned if a layer is built on top of its own root.
+	errSnapshotCycle = errors.New("snapshot cycle")
+)
+
+// Account is the state trie representation of an account, as stored in the
+// snapshot. It mirrors state.Account.
+type Account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash // merkle root of the storage trie
+	CodeHash common.Hash
+}
+
+// Snapshot is the flat state of the accounts and storage at a state root.
+// Values are the RLP encoded values of the corresponding trie, and a nil value
+// means the entry does not exist.
+type Snapshot interface {
+	// Root returns the state root the snapshot represents.
+	Root() common.Hash
+
+	// AccountRLP returns the account trie value of an account.
+	AccountRLP(hash common.Hash) ([]byte, error)
+
+	// Storage returns the storage trie value of a slot of an account.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is implemented by the disk and diff layers of a tree.
+type snapshot interface {
+	Snapshot
+
+	// Parent returns the layer below, or nil for the disk layer.
+	Parent() snapshot
+
+	// Stale reports whether the layer can no longer be read.
+	Stale() bool
+}
+
+// database is the global table holding the disk layer.
+type database interface {
+	common.Table
+	rawdb.DatabaseIterator
+}
+
+// Tree is a set of snapshot layers: a single disk layer, persisted in the
+// global table, with the in-memory diff layers of later blocks on top of it.
+//
+// Diff layers are added by Update as blocks are processed, merged in memory by
+// Cap and flattened into the disk layer by Persist once their state is stored
+// in the trie database. The disk layer is generated in the background from the
+// state trie when it's missing or does not match the chain.
+type Tree struct {
+	diskdb database       // global table holding the disk layer
+	triedb *trie.Database // trie database to generate the disk layer from
+	cache  int            // megabytes of memory to cache disk layer entries
+
+	layers map[common.Hash]snapshot // layers by state root
+	lock   sync.RWMutex
+}
+
+// New returns an empty snapshot tree over db, which is populated by Load.
+// cache is the number of megabytes of memory used to cache disk layer entries.
+func New(db common.Database, triedb *trie.Database, cache int) (*Tree, error) {
+	diskdb, ok := db.GlobalTable().(database)
+	if !ok {
+		return nil, errors.New("snapshot: global table cannot be iterated")
+	}
+	return &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		cache:  cache,
+		layers: make(map[common.Hash]snapshot),
+	}, nil
+}
+
+// Load replaces the layers of the tree with the disk layer of root. The
+// persisted snapshot is used if it matches root, otherwise it's regenerated in
+// the background.
+func (t *Tree) Load(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.release()
+	base := loadSnapshot(t.diskdb, t.triedb, t.cache, root)
+	if base == nil {
+		log.Info("Rebuilding state snapshot", "root", root)
+		base = generateSnapshot(t.diskdb, t.triedb, t.cache, root)
+	}
+	t.layers = map[common.Hash]snapshot{root: base}
+}
+
+// Rebuild discards all layers and regenerates the disk layer of root in the
+// background.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	log.Info("Rebuilding state snapshot", "root", root)
+	t.release()
+	t.layers = map[common.Hash]snapshot{root: generateSnapshot(t.diskdb, t.triedb, t.cache, root)}
+}
+
+// Stop stops any generation of the disk layer, recording its progress to be
+// resumed by a later Load.
+func (t *Tree) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, layer := range t.layers {
+		if disk, ok := layer.(*diskLayer); ok {
+			disk.stopGeneration()
+		}
+	}
+}
+
+// release stops generation and marks all layers stale, so any reader falls
+// back to the tries. The caller must hold the tree lock.
+func (t *Tree) release() {
+	for _, layer := range t.layers {
+		switch layer := layer.(type) {
+		case *diskLayer:
+			layer.stopGeneration()
+			layer.lock.Lock()
+			layer.stale = true
+			layer.lock.Unlock()
+		case *diffLayer:
+			layer.markStale()
+		}
+	}
+}
+
+// Snapshot returns the layer of root, or nil if there is none.
+func (t *Tree) Snapshot(root common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if layer, ok := t.layers[root]; ok {
+		return layer
+	}
+	return nil
+}
+
+// Update adds a diff layer for root on top of the layer of parentRoot. The
+// maps are owned by the layer afterwards: destructs holds the accounts deleted
+// or recreated, applied before accounts and storage, in which nil values delete
+// the entry.
+func (t *Tree) Update(root, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	if root == parentRoot {
+		return errSnapshotCycle
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	parent, ok := t.layers[parentRoot]
+	if !ok {
+		return fmt.Errorf("parent [%#x] snapshot missing", parentRoot)
+	}
+	if _, ok := t.layers[root]; ok {
+		return nil // same state reached by another block
+	}
+	t.layers[root] = newDiffLayer(parent, root, destructs, accounts, storage)
+	return nil
+}
+
+// Cap flattens the diff layers below the layers most recent ones on the path
+// to root into a single diff layer on top of the disk layer, and drops the
+// layers which do not build on the result. A layers of zero flattens everything
+// up to root into the disk layer.
+//
+// Apart from that, the disk layer is only written by Persist, so that it stays
+// at a state root persisted in the trie database.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	diff, ok := layer.(*diffLayer)
+	if !ok {
+		return nil // nothing above the disk layer
+	}
+	if layers == 0 {
+		t.drop(diffToDisk(diff.flatten()))
+		return nil
+	}
+	for i := 0; i < layers-1; i++ {
+		parent, ok := diff.Parent().(*diffLayer)
+		if !ok {
+			return nil // fewer diff layers than allowed
+		}
+		diff = parent
+	}
+	parent, ok := diff.Parent().(*diffLayer)
+	if !ok {
+		return nil
+	}
+	if _, ok := parent.Parent().(*diskLayer); ok {
+		return nil // already a single layer below
+	}
+	bottom := parent.flatten()
+
+	diff.lock.Lock()
+	diff.parent = bottom
+	diff.lock.Unlock()
+
+	t.drop(bottom)
+	return nil
+}
+
+// Persist flattens the layer of root and the diff layers below it into the
+// disk layer, moving the layers built on root onto the disk layer and dropping
+// the layers which do not build on it. root must be persisted in the trie
+// database, so that the disk layer still matches the chain when it's reopened
+// after an unclean shutdown.
+func (t *Tree) Persist(root common.Hash) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	diff, ok := layer.(*diffLayer)
+	if !ok {
+		return nil // already the disk layer
+	}
+	var children []*diffLayer
+	for _, layer := range t.layers {
+		if child, ok := layer.(*diffLayer); ok && child.Parent() == diff {
+			children = append(children, child)
+		}
+	}
+	base := diffToDisk(diff.flatten())
+	for _, child := range children {
+		child.lock.Lock()
+		child.parent = base
+		child.lock.Unlock()
+	}
+	t.drop(base)
+	return nil
+}
+
+// drop replaces the flattened layers by base, their result, and drops the
+// layers built on any stale layer. The caller must hold the tree lock.
+func (t *Tree) drop(base snapshot) {
+	// Layers are linked by identity, as the result shares its root with the
+	// layer flattened into it.
+	children := make(map[snapshot][]snapshot)
+	for _, layer := range t.layers {
+		if diff, ok := layer.(*diffLayer); ok {
+			parent := diff.Parent()
+			children[parent] = append(children[parent], diff)
+		}
+	}
+	var remove func(layer snapshot)
+	remove = func(layer snapshot) {
+		if diff, ok := layer.(*diffLayer); ok {
+			diff.markStale()
+		}
+		if t.layers[layer.Root()] == layer {
+			delete(t.layers, layer.Root())
+		}
+		for _, child := range children[layer] {
+			remove(child)
+		}
+		delete(children, layer)
+	}
+	for _, layer := range t.layers {
+		if layer.Stale() {
+			remove(layer)
+		}
+	}
+	t.layers[base.Root()] = base
+}
+
+// loadSnapshot returns the disk layer persisted in diskdb if it matches root,
+// resuming its generation if unfinished, or nil.
+func loadSnapshot(diskdb database, triedb *trie.Database, cache int, root common.Hash) *diskLayer {
+	if rawdb.ReadSnapshotRoot(diskdb) != root {
+		return nil
+	}
+	base := newDiskLayer(diskdb, triedb, newCache(cache), root)
+	if enc := rawdb.ReadSnapshotGenerator(diskdb); enc != nil {
+		progress, err := decodeProgress(enc)
+		if err != nil {
+			log.Warn("Failed to decode snapshot generator", "err", err)
+			return nil
+		}
+		base.genMarker = progress.marker()
+		base.startGeneration()
+		log.Info("Resuming state snapshot generation", "root", root, "at", common.BytesToHash(base.genMarker))
+	}
+	return base
+}
@@ -0,0 +1,191 @@
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/trie"
+)
+
+// newTestTree returns a tree over an empty, fully generated disk layer.
+func newTestTree(t *testing.T) (*Tree, *ethdb.MemDatabase) {
+	db := ethdb.NewMemDatabase()
+	tree, err := New(db, trie.NewDatabase(db), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := common.HexToHash("0x01")
+	rawdb.WriteSnapshotRoot(db, root)
+	tree.Load(root)
+	return tree, db
+}
+
+func TestTree_Update(t *testing.T) {
+	tree, _ := newTestTree(t)
+	var (
+		base = common.HexToHash("0x01")
+		r2   = common.HexToHash("0x02")
+		r3   = common.HexToHash("0x03")
+		acc  = common.HexToHash("0xaa")
+		slot = common.HexToHash("0xbb")
+	)
+	if err := tree.Update(base, base, nil, nil, nil); err != errSnapshotCycle {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tree.Update(r3, r2, nil, nil, nil); err == nil {
+		t.Fatal("expected missing parent error")
+	}
+	if err := tree.Update(r2, base, nil,
+		map[common.Hash][]byte{acc: {0x01}},
+		map[common.Hash]map[common.Hash][]byte{acc: {slot: {0x02}}}); err != nil {
+		t.Fatal(err)
+	}
+	// The account is recreated without storage.
+	if err := tree.Update(r3, r2, map[common.Hash]struct{}{acc: {}},
+		map[common.Hash][]byte{acc: {0x03}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	snap := tree.Snapshot(r2)
+	if data, err := snap.AccountRLP(acc); err != nil || !bytes.Equal(data, []byte{0x01}) {
+		t.Fatalf("unexpected account: %x, %v", data, err)
+	}
+	if data, err := snap.Storage(acc, slot); err != nil || !bytes.Equal(data, []byte{0x02}) {
+		t.Fatalf("unexpected slot: %x, %v", data, err)
+	}
+	snap = tree.Snapshot(r3)
+	if data, err := snap.AccountRLP(acc); err != nil || !bytes.Equal(data, []byte{0x03}) {
+		t.Fatalf("unexpected account: %x, %v", data, err)
+	}
+	if data, err := snap.Storage(acc, slot); err != nil || data != nil {
+		t.Fatalf("unexpected slot: %x, %v", data, err)
+	}
+	if data, err := tree.Snapshot(base).AccountRLP(acc); err != nil || data != nil {
+		t.Fatalf("unexpected account: %x, %v", data, err)
+	}
+}
+
+func TestTree_Cap(t *testing.T) {
+	tree, db := newTestTree(t)
+	var (
+		base  = common.HexToHash("0x01")
+		roots = []common.Hash{base}
+		acc   = common.HexToHash("0xaa")
+		slot  = common.HexToHash("0xbb")
+	)
+	// A chain of 5 layers, each writing the account and slot.
+	for i := 2; i <= 6; i++ {
+		root := common.BytesToHash([]byte{byte(i)})
+		if err := tree.Update(root, roots[len(roots)-1], nil,
+			map[common.Hash][]byte{acc: {byte(i)}},
+			map[common.Hash]map[common.Hash][]byte{acc: {slot: {byte(i)}}}); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	// A fork on top of the second layer.
+	fork := common.HexToHash("0xff")
+	if err := tree.Update(fork, roots[1], nil, map[common.Hash][]byte{acc: {0xff}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	staleSnap := tree.Snapshot(roots[2])
+
+	// Keep two diff layers on top of a single one merging the layers below,
+	// without writing the disk layer.
+	if err := tree.Cap(roots[5], 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.layers) != 4 {
+		t.Fatalf("unexpected layers: %d", len(tree.layers))
+	}
+	bottom, ok := tree.layers[roots[3]].(*diffLayer)
+	if !ok || bottom.Parent() != tree.layers[base] {
+		t.Fatal("expected diff layer of the third block on the disk layer")
+	}
+	if have := rawdb.ReadSnapshotRoot(db); have != base {
+		t.Fatalf("unexpected disk root: %x", have)
+	}
+	if data := rawdb.ReadAccountSnapshot(db, acc); data != nil {
+		t.Fatalf("unexpected flattened account: %x", data)
+	}
+	if data, err := bottom.Storage(acc, slot); err != nil || !bytes.Equal(data, []byte{4}) {
+		t.Fatalf("unexpected merged slot: %x, %v", data, err)
+	}
+	if tree.Snapshot(fork) != nil {
+		t.Fatal("fork on flattened layer not dropped")
+	}
+	if _, err := staleSnap.AccountRLP(acc); err != ErrSnapshotStale {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, err := tree.Snapshot(roots[5]).Storage(acc, slot); err != nil || !bytes.Equal(data, []byte{6}) {
+		t.Fatalf("unexpected slot: %x, %v", data, err)
+	}
+	if data, err := tree.Snapshot(roots[4]).AccountRLP(acc); err != nil || !bytes.Equal(data, []byte{5}) {
+		t.Fatalf("unexpected account: %x, %v", data, err)
+	}
+
+	// Persist the merged layer, keeping the layers above it.
+	if err := tree.Persist(roots[3]); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.layers) != 3 {
+		t.Fatalf("unexpected layers: %d", len(tree.layers))
+	}
+	if _, ok := tree.layers[roots[3]].(*diskLayer); !ok {
+		t.Fatal("expected disk layer at the third block")
+	}
+	if have := rawdb.ReadSnapshotRoot(db); have != roots[3] {
+		t.Fatalf("unexpected disk root: %x", have)
+	}
+	if data := rawdb.ReadAccountSnapshot(db, acc); !bytes.Equal(data, []byte{4}) {
+		t.Fatalf("unexpected flattened account: %x", data)
+	}
+	if data := rawdb.ReadStorageSnapshot(db, acc, slot); !bytes.Equal(data, []byte{4}) {
+		t.Fatalf("unexpected flattened slot: %x", data)
+	}
+	if data, err := tree.Snapshot(roots[4]).Storage(acc, slot); err != nil || !bytes.Equal(data, []byte{5}) {
+		t.Fatalf("unexpected slot: %x, %v", data, err)
+	}
+
+	// Flatten everything.
+	if err := tree.Cap(roots[5], 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.layers) != 1 {
+		t.Fatalf("unexpected layers: %d", len(tree.layers))
+	}
+	if data, err := tree.Snapshot(roots[5]).AccountRLP(acc); err != nil || !bytes.Equal(data, []byte{6}) {
+		t.Fatalf("unexpected account: %x, %v", data, err)
+	}
+	if have := rawdb.ReadSnapshotRoot(db); have != roots[5] {
+		t.Fatalf("unexpected disk root: %x", have)
+	}
+}
+
+func TestTree_Load(t *testing.T) {
+	tree, db := newTestTree(t)
+	base := common.HexToHash("0x01")
+	acc := common.HexToHash("0xaa")
+	rawdb.WriteAccountSnapshot(db, acc, []byte{0x01})
+
+	snap := tree.Snapshot(base)
+	if data, err := snap.AccountRLP(acc); err != nil || !bytes.Equal(data, []byte{0x01}) {
+		t.Fatalf("unexpected account: %x, %v", data, err)
+	}
+
+	// A different root discards the layers and regenerates the snapshot.
+	tree.Load(emptyRoot)
+	<-tree.layers[emptyRoot].(*diskLayer).genPending
+	if _, err := snap.AccountRLP(acc); err != ErrSnapshotStale {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, err := tree.Snapshot(emptyRoot).AccountRLP(acc); err != nil || data != nil {
+		t.Fatalf("unexpected account: %x, %v", data, err)
+	}
+	if rawdb.ReadSnapshotRoot(db) != emptyRoot {
+		t.Fatal("unexpected disk root")
+	}
+}
@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/rlp"
+	"github.com/ChainAAS/gendchain/trie"
+)
+
+// Verify checks the snapshot of root against the state trie it represents, by
+// rebuilding the storage tries and the account trie from its entries and
+// comparing their roots. The whole state is held in memory, so it's meant for
+// offline checks and tests.
+func (t *Tree) Verify(root common.Hash) error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	var diffs []*diffLayer
+	for {
+		diff, ok := layer.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, diff)
+		layer = diff.Parent()
+	}
+	disk := layer.(*diskLayer)
+	disk.lock.RLock()
+	defer disk.lock.RUnlock()
+	if disk.genMarker != nil {
+		return ErrNotCoveredYet
+	}
+
+	// Load the disk layer and apply the diff layers, oldest first.
+	var (
+		accounts = make(map[common.Hash][]byte)
+		storage  = make(map[common.Hash]map[common.Hash][]byte)
+	)
+	err := rawdb.IterateAccountSnapshots(disk.diskdb, func(hash common.Hash, entry []byte) error {
+		accounts[hash] = common.CopyBytes(entry)
+		slots := make(map[common.Hash][]byte)
+		if err := rawdb.IterateStorageSnapshots(disk.diskdb, hash, func(slot common.Hash, entry []byte) error {
+			slots[slot] = common.CopyBytes(entry)
+			return nil
+		}); err != nil {
+			return err
+		}
+		storage[hash] = slots
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(diffs) - 1; i >= 0; i-- {
+		diff := diffs[i]
+		for hash := range diff.destructSet {
+			delete(accounts, hash)
+			delete(storage, hash)
+		}
+		for hash, data := range diff.accountData {
+			if len(data) > 0 {
+				accounts[hash] = data
+			} else {
+				delete(accounts, hash)
+			}
+		}
+		for hash, slots := range diff.storageData {
+			if storage[hash] == nil {
+				storage[hash] = make(map[common.Hash][]byte)
+			}
+			for slot, data := range slots {
+				if len(data) > 0 {
+					storage[hash][slot] = data
+				} else {
+					delete(storage[hash], slot)
+				}
+			}
+		}
+	}
+
+	// Rebuild the tries and compare their roots.
+	accTrie := newVerifyTrie()
+	for hash, entry := range accounts {
+		var account Account
+		if err := rlp.DecodeBytes(entry, &account); err != nil {
+			return fmt.Errorf("invalid account %#x: %v", hash, err)
+		}
+		storeTrie := newVerifyTrie()
+		for slot, data := range storage[hash] {
+			storeTrie.Update(slot[:], data)
+		}
+		if have := storeTrie.Hash(); have != account.Root && !(have == emptyRoot && account.Root == (common.Hash{})) {
+			return fmt.Errorf("storage root mismatch for account %#x: have %#x, want %#x", hash, have, account.Root)
+		}
+		delete(storage, hash)
+		accTrie.Update(hash[:], entry)
+	}
+	for hash, slots := range storage {
+		if len(slots) > 0 {
+			return fmt.Errorf("storage of missing account %#x", hash)
+		}
+	}
+	if have := accTrie.Hash(); have != root {
+		return fmt.Errorf("state root mismatch: have %#x, want %#x", have, root)
+	}
+	return nil
+}
+
+// newVerifyTrie returns an empty trie, held in memory.
+func newVerifyTrie() *trie.Trie {
+	tr, _ := trie.New(common.Hash{}, trie.NewDatabase(ethdb.NewMemDatabase()))
+	return tr
+}
@@ -66,6 +66,7 @@ type stateObject struct {
 
 	originStorage Storage // Storage cache of original entries to dedup rewrites
 	dirtyStorage  Storage // Storage entries that need to be flushed to disk
+	fakeStorage   Storage // Fake committed storage which constructed by caller for debugging purpose.
 
 	// Cache flags.
 	// When an object is marked suicided it will be delete from the trie
@@ -116,7 +117,7 @@ func (a *Account) EncodeRLP(w io.Writer) error {
 
 // MarshalRLP returns an RLP encoded byte slice.
 func (a *Account) MarshalRLP() (_ []byte, err error) {
-	buf := make([]byte, rlp.MaxHeadSize, rlp.MaxHeadSize+rlp.Uint64Size(a.Nonce)+rlp.MaxBigIntSize+rlp.BytesSize([]byte(a.Root[:]))+rlp.BytesSize([]byte(a.CodeHash[:])))
+	buf := make([]byte, rlp.MaxHeadSize, rlp.MaxHeadSize+rlp.Uint64Size(a.Nonce)+rlp.BigIntSize(a.Balance)+rlp.BytesSize([]byte(a.Root[:]))+rlp.BytesSize([]byte(a.CodeHash[:])))
 	buf = rlp.AppendUint64(buf, a.Nonce)
 	if buf, err = rlp.AppendBigInt(buf, a.Balance); err != nil {
 		return nil, err
@@ -213,11 +214,27 @@ func (so *stateObject) GetCommittedState(db Database, key common.Hash) common.Ha
 	if cached {
 		return value
 	}
-	// Otherwise load the value from the database
-	enc, err := so.getTrie(db).TryGet(key[:])
-	if err != nil {
-		so.setError(err)
-		return common.Hash{}
+	// If the fake storage is set, only lookup the state here(in the debugging mode)
+	if so.fakeStorage != nil {
+		return so.fakeStorage[key]
+	}
+	// Otherwise load the value from the snapshot if it has it, the trie
+	// otherwise. Storage destructed in this block is empty.
+	var (
+		enc []byte
+		err error
+	)
+	if so.db.snap != nil {
+		if _, destructed := so.db.snapDestructs[so.addrHash]; destructed {
+			return common.Hash{}
+		}
+		enc, err = so.db.snap.Storage(so.addrHash, crypto.Keccak256Hash(key[:]))
+	}
+	if so.db.snap == nil || err != nil {
+		if enc, err = so.getTrie(db).TryGet(key[:]); err != nil {
+			so.setError(err)
+			return common.Hash{}
+		}
 	}
 	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
@@ -246,6 +263,25 @@ func (so *stateObject) SetState(db Database, key, value common.Hash) {
 	so.setState(key, value)
 }
 
+// SetStorage replaces the entire state storage with the given one.
+//
+// After this function is called, all original state will be ignored and the
+// fake state storage is treated as the committed storage. Further updates are
+// journaled and cached as dirty entries like for any other account.
+//
+// Note this function should only be used for debugging purpose.
+func (so *stateObject) SetStorage(storage map[common.Hash]common.Hash) {
+	so.fakeStorage = make(Storage, len(storage))
+	for key, value := range storage {
+		so.fakeStorage[key] = value
+	}
+	so.originStorage = make(Storage)
+	so.dirtyStorage = make(Storage)
+
+	// Don't bother journal since this function should only be used for
+	// debugging and the `fake` storage won't be committed to database.
+}
+
 func (so *stateObject) setState(key, value common.Hash) {
 	so.dirtyStorage[key] = value
 }
@@ -253,6 +289,25 @@ func (so *stateObject) setState(key, value common.Hash) {
 // updateTrie writes cached storage modifications into the object's storage trie.
 func (so *stateObject) updateTrie(db Database) Trie {
 	tr := so.getTrie(db)
+
+	// The fake storage is not backed by the trie, only promote the dirty
+	// entries so that later transactions see them as committed.
+	if so.fakeStorage != nil {
+		for key, value := range so.dirtyStorage {
+			delete(so.dirtyStorage, key)
+			so.originStorage[key] = value
+		}
+		return tr
+	}
+
+	// Record the changes for the snapshot, if the state has one.
+	var storage map[common.Hash][]byte
+	if so.db.snap != nil && len(so.dirtyStorage) > 0 {
+		if storage = so.db.snapStorage[so.addrHash]; storage == nil {
+			storage = make(map[common.Hash][]byte)
+			so.db.snapStorage[so.addrHash] = storage
+		}
+	}
 	for key, value := range so.dirtyStorage {
 		delete(so.dirtyStorage, key)
 
@@ -264,11 +319,17 @@ func (so *stateObject) updateTrie(db Database) Trie {
 
 		if (value == common.Hash{}) {
 			so.setError(tr.TryDelete(key[:]))
+			if storage != nil {
+				storage[crypto.Keccak256Hash(key[:])] = nil
+			}
 			continue
 		}
 		// Encoding []byte cannot fail, ok to ignore the error.
 		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
 		so.setError(tr.TryUpdate(key[:], v))
+		if storage != nil {
+			storage[crypto.Keccak256Hash(key[:])] = v
+		}
 	}
 	return tr
 }
@@ -340,6 +401,9 @@ func (so *stateObject) deepCopy(db *StateDB) *stateObject {
 	stateObject.code = so.code
 	stateObject.dirtyStorage = so.dirtyStorage.Copy()
 	stateObject.originStorage = so.originStorage.Copy()
+	if so.fakeStorage != nil {
+		stateObject.fakeStorage = so.fakeStorage.Copy()
+	}
 	stateObject.suicided = so.suicided
 	stateObject.dirtyCode = so.dirtyCode
 	stateObject.deleted = so.deleted
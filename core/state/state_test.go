@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/math"
 	"github.com/ChainAAS/gendchain/crypto"
 	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/rlp"
 	checker "gopkg.in/check.v1"
 )
 
@@ -243,3 +245,20 @@ func compareStateObjects(so0, so1 *stateObject, t *testing.T) {
 		}
 	}
 }
+
+func TestAccountMarshalRLP(t *testing.T) {
+	for _, balance := range []*big.Int{new(big.Int), big.NewInt(1), math.MaxBig256} {
+		acc := &Account{Nonce: 1, Balance: balance, Root: common.Hash{0x01}, CodeHash: emptyCodeHash}
+		have, err := acc.MarshalRLP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := rlp.EncodeToBytes([]interface{}{acc.Nonce, acc.Balance, acc.Root, acc.CodeHash})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Errorf("balance %v: have %x, want %x", balance, have, want)
+		}
+	}
+}
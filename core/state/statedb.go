@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/state/snapshot"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/crypto"
 	"github.com/ChainAAS/gendchain/log"
@@ -45,6 +46,14 @@ type StateDB struct {
 	db   Database
 	trie Trie
 
+	// Flat snapshot of the state the trie was opened at, used for reads, and
+	// the changes to add as a layer on top of it once committed.
+	snaps         *snapshot.Tree
+	snap          snapshot.Snapshot
+	snapDestructs map[common.Hash]struct{}
+	snapAccounts  map[common.Hash][]byte
+	snapStorage   map[common.Hash]map[common.Hash][]byte
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[common.Address]*stateObject
 	stateObjectsDirty map[common.Address]struct{}
@@ -59,6 +68,9 @@ type StateDB struct {
 
 	preimages map[common.Hash][]byte
 
+	// Per-transaction access list
+	accessList *accessList
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -74,7 +86,7 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	sdb := &StateDB{
 		db:                db,
 		trie:              tr,
 		stateObjects:      make(map[common.Address]*stateObject),
@@ -82,7 +94,24 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
 		journal:           newJournal(),
-	}, nil
+		accessList:        newAccessList(),
+	}
+	sdb.openSnapshot(root)
+	return sdb, nil
+}
+
+// openSnapshot sets up reading from the snapshot of root, if there is one.
+func (db *StateDB) openSnapshot(root common.Hash) {
+	db.snaps, db.snap = db.db.Snapshots(), nil
+	db.snapDestructs, db.snapAccounts, db.snapStorage = nil, nil, nil
+	if db.snaps == nil {
+		return
+	}
+	if db.snap = db.snaps.Snapshot(root); db.snap != nil {
+		db.snapDestructs = make(map[common.Hash]struct{})
+		db.snapAccounts = make(map[common.Hash][]byte)
+		db.snapStorage = make(map[common.Hash]map[common.Hash][]byte)
+	}
 }
 
 // Reset clears out all ephemeral state objects from the state db, but keeps
@@ -93,6 +122,7 @@ func (db *StateDB) Reset(root common.Hash) error {
 		return err
 	}
 	db.trie = tr
+	db.openSnapshot(root)
 	db.stateObjects = make(map[common.Address]*stateObject)
 	db.stateObjectsDirty = make(map[common.Address]struct{})
 	db.thash = common.Hash{}
@@ -101,6 +131,7 @@ func (db *StateDB) Reset(root common.Hash) error {
 	db.logs = make(map[common.Hash][]*types.Log)
 	db.logSize = 0
 	db.preimages = make(map[common.Hash][]byte)
+	db.accessList = newAccessList()
 	db.clearJournalAndRefund()
 	return nil
 }
@@ -408,6 +439,15 @@ func (db *StateDB) SetState(addr common.Address, key common.Hash, value common.H
 	}
 }
 
+// SetStorage replaces the entire storage for the specified account with given
+// storage. This function should only be used for debugging.
+func (db *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common.Hash) {
+	stateObject := db.GetOrNewStateObject(addr)
+	if stateObject != nil {
+		stateObject.SetStorage(storage)
+	}
+}
+
 // Suicide marks the given account as suicided.
 // This clears the account balance.
 //
@@ -443,12 +483,20 @@ func (db *StateDB) updateStateObject(stateObject *stateObject) error {
 	if err != nil {
 		panic(fmt.Errorf("can't encode object at %x: %v", addr[:], err))
 	}
+	if db.snap != nil {
+		db.snapAccounts[stateObject.addrHash] = buf
+	}
 	return db.trie.TryUpdate(addr[:], buf)
 }
 
 // deleteStateObject removes the given object from the state trie.
 func (db *StateDB) deleteStateObject(stateObject *stateObject) error {
 	stateObject.deleted = true
+	if db.snap != nil {
+		db.snapDestructs[stateObject.addrHash] = struct{}{}
+		delete(db.snapAccounts, stateObject.addrHash)
+		delete(db.snapStorage, stateObject.addrHash)
+	}
 	addr := stateObject.Address()
 	return db.trie.TryDelete(addr[:])
 }
@@ -463,8 +511,14 @@ func (db *StateDB) getStateObject(addr common.Address) (stateObject *stateObject
 		return obj, nil
 	}
 
-	// Load the object from the database.
-	enc, err := db.trie.TryGet(addr[:])
+	// Load the object from the snapshot if it has it, the trie otherwise.
+	var enc []byte
+	if db.snap != nil {
+		enc, err = db.snap.AccountRLP(crypto.Keccak256Hash(addr[:]))
+	}
+	if db.snap == nil || err != nil {
+		enc, err = db.trie.TryGet(addr[:])
+	}
 	if len(enc) == 0 {
 		return nil, err
 	}
@@ -504,10 +558,17 @@ func (db *StateDB) createObject(addr common.Address) (*stateObject, *stateObject
 	}
 	newobj := newObject(db, addr, Account{})
 	newobj.setNonce(0) // sets the object to dirty
+	// The storage of the previous account must neither be read through the
+	// snapshot nor outlive it.
+	var prevdestruct bool
+	if prev != nil && db.snap != nil {
+		_, prevdestruct = db.snapDestructs[prev.addrHash]
+		db.snapDestructs[prev.addrHash] = struct{}{}
+	}
 	if prev == nil {
 		db.journal.append(createObjectChange{account: &addr})
 	} else {
-		db.journal.append(resetObjectChange{prev: prev})
+		db.journal.append(resetObjectChange{prev: prev, prevdestruct: prevdestruct})
 	}
 	db.setStateObject(newobj)
 	return newobj, prev
@@ -595,6 +656,31 @@ func (db *StateDB) Copy() *StateDB {
 	for hash, preimage := range db.preimages {
 		state.preimages[hash] = preimage
 	}
+	if db.snap != nil {
+		state.snaps, state.snap = db.snaps, db.snap
+		state.snapDestructs = make(map[common.Hash]struct{}, len(db.snapDestructs))
+		for hash := range db.snapDestructs {
+			state.snapDestructs[hash] = struct{}{}
+		}
+		state.snapAccounts = make(map[common.Hash][]byte, len(db.snapAccounts))
+		for hash, data := range db.snapAccounts {
+			state.snapAccounts[hash] = data
+		}
+		state.snapStorage = make(map[common.Hash]map[common.Hash][]byte, len(db.snapStorage))
+		for hash, storage := range db.snapStorage {
+			cpy := make(map[common.Hash][]byte, len(storage))
+			for slot, data := range storage {
+				cpy[slot] = data
+			}
+			state.snapStorage[hash] = cpy
+		}
+	}
+	// Do we need to copy the access list? In practice: No. At the start of a
+	// transaction, the access list is empty. In practice, we only ever copy state
+	// _between_ transactions/blocks, never in the middle of a transaction.
+	// However, it doesn't cost us much to copy an empty list, so we do it anyway
+	// to not blow up if we ever decide copy it in the middle of a transaction
+	state.accessList = db.accessList.Copy()
 	return state
 }
 
@@ -670,6 +756,70 @@ func (db *StateDB) Prepare(thash, bhash common.Hash, ti int) {
 	db.txIndex = ti
 }
 
+// PrepareAccessList handles the preparatory steps for executing a state transition
+// with regards to EIP-2929 and EIP-2930:
+//
+// - Add sender to access list
+// - Add destination to access list
+// - Add precompiles to access list
+// - Add the contents of the optional tx access list
+//
+// This method should only be called if the Berlin fork is active.
+func (db *StateDB) PrepareAccessList(sender common.Address, dst *common.Address, precompiles []common.Address, list types.AccessList) {
+	// Clear out any leftover from previous executions
+	db.accessList = newAccessList()
+
+	db.AddAddressToAccessList(sender)
+	if dst != nil {
+		db.AddAddressToAccessList(*dst)
+		// If it's a create-tx, the destination will be added inside evm.create
+	}
+	for _, addr := range precompiles {
+		db.AddAddressToAccessList(addr)
+	}
+	for _, el := range list {
+		db.AddAddressToAccessList(el.Address)
+		for _, key := range el.StorageKeys {
+			db.AddSlotToAccessList(el.Address, key)
+		}
+	}
+}
+
+// AddAddressToAccessList adds the given address to the access list
+func (db *StateDB) AddAddressToAccessList(addr common.Address) {
+	if db.accessList.AddAddress(addr) {
+		db.journal.append(accessListAddAccountChange{&addr})
+	}
+}
+
+// AddSlotToAccessList adds the given (address, slot)-tuple to the access list
+func (db *StateDB) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	addrMod, slotMod := db.accessList.AddSlot(addr, slot)
+	if addrMod {
+		// In practice, this should not happen, since there is no way to enter the
+		// scope of 'address' without having the 'address' become already added
+		// to the access list (via call-variant, create, etc).
+		// Better safe than sorry, though
+		db.journal.append(accessListAddAccountChange{&addr})
+	}
+	if slotMod {
+		db.journal.append(accessListAddSlotChange{
+			address: &addr,
+			slot:    &slot,
+		})
+	}
+}
+
+// AddressInAccessList returns true if the given address is in the access list.
+func (db *StateDB) AddressInAccessList(addr common.Address) bool {
+	return db.accessList.ContainsAddress(addr)
+}
+
+// SlotInAccessList returns true if the given (address, slot)-tuple is in the access list.
+func (db *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addressPresent bool, slotPresent bool) {
+	return db.accessList.Contains(addr, slot)
+}
+
 func (db *StateDB) clearJournalAndRefund() {
 	db.journal = newJournal()
 	db.validRevisions = db.validRevisions[:0]
@@ -722,5 +872,15 @@ func (db *StateDB) Commit(deleteEmptyObjects bool) (root common.Hash, err error)
 		return nil
 	})
 	log.Debug("Trie cache stats after commit", "misses", trie.CacheMisses(), "unloads", trie.CacheUnloads())
+
+	// Add the changes as a snapshot layer, which owns them from now on.
+	if err == nil && db.snap != nil {
+		if parent := db.snap.Root(); parent != root {
+			if err := db.snaps.Update(root, parent, db.snapDestructs, db.snapAccounts, db.snapStorage); err != nil {
+				log.Warn("Failed to update state snapshot", "from", parent, "to", root, "err", err)
+			}
+		}
+		db.snap, db.snapDestructs, db.snapAccounts, db.snapStorage = nil, nil, nil, nil
+	}
 	return root, err
 }
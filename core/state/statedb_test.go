@@ -422,3 +422,42 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+// TestSetStorage tests that an overridden storage hides the original entries
+// and takes further writes.
+func TestSetStorage(t *testing.T) {
+	sdb, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+	addr := common.HexToAddress("aaaa")
+	sdb.SetState(addr, common.Hash{0x01}, common.Hash{0x01})
+	root, _ := sdb.Commit(false)
+
+	sdb, _ = New(root, sdb.Database())
+	sdb.SetStorage(addr, map[common.Hash]common.Hash{{0x02}: {0x02}})
+	if got := sdb.GetState(addr, common.Hash{0x01}); got != (common.Hash{}) {
+		t.Fatalf("original entry not hidden, got %x", got)
+	}
+	sdb.SetState(addr, common.Hash{0x03}, common.Hash{0x03})
+	for key, want := range map[common.Hash]common.Hash{{0x02}: {0x02}, {0x03}: {0x03}} {
+		if got := sdb.GetState(addr, key); got != want {
+			t.Fatalf("entry %x: expected %x, got %x", key, want, got)
+		}
+	}
+
+	// Writes are journaled and the overridden entries stay the committed ones.
+	snap := sdb.Snapshot()
+	sdb.SetState(addr, common.Hash{0x02}, common.Hash{0x04})
+	if got := sdb.GetCommittedState(addr, common.Hash{0x02}); got != (common.Hash{0x02}) {
+		t.Fatalf("committed entry: expected %x, got %x", common.Hash{0x02}, got)
+	}
+	sdb.RevertToSnapshot(snap)
+	if got := sdb.GetState(addr, common.Hash{0x02}); got != (common.Hash{0x02}) {
+		t.Fatalf("reverted entry: expected %x, got %x", common.Hash{0x02}, got)
+	}
+
+	// Finalised writes become the committed entries of later transactions.
+	sdb.SetState(addr, common.Hash{0x02}, common.Hash{0x04})
+	sdb.Finalise(false)
+	if got := sdb.GetCommittedState(addr, common.Hash{0x02}); got != (common.Hash{0x04}) {
+		t.Fatalf("finalised entry: expected %x, got %x", common.Hash{0x04}, got)
+	}
+}
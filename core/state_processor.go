@@ -51,7 +51,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	// Create a new emv context and environment.
 	evmContext := NewEVMContextLite(header, p.bc, nil)
 	vmenv := vm.NewEVM(evmContext, statedb, p.config, cfg)
-	signer := types.MakeSigner(p.config, header.Number)
+	signer := types.MakeSigner(p.config, header.Number, header.Time)
 
 	// Iterate over and process the individual transactions
 	for i, tx := range txs {
@@ -77,7 +77,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
 func ApplyTransaction(vmenv *vm.EVM, config *params.ChainConfig, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, signer types.Signer) (*types.Receipt, uint64, error) {
-	msg, err := tx.AsMessage(signer)
+	msg, err := tx.AsMessage(signer, header.BaseFee)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -103,6 +103,7 @@ func ApplyTransaction(vmenv *vm.EVM, config *params.ChainConfig, gp *GasPool, st
 	// Create a new receipt for the transaction, storing the intermediate root and gas used by the tx
 	// based on the eip phase, we're passing whether the root touch-delete accounts.
 	receipt := types.NewReceipt(root, failed, *usedGas)
+	receipt.Type = tx.Type()
 	receipt.TxHash = tx.Hash()
 	receipt.GasUsed = gas
 	// if the transaction created a contract, store the creation address in the receipt.
@@ -11,6 +11,7 @@ import (
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/core/vm"
 	"github.com/ChainAAS/gendchain/crypto"
+	"github.com/ChainAAS/gendchain/ethdb"
 	"github.com/ChainAAS/gendchain/params"
 )
 
@@ -114,3 +115,66 @@ func TestStateProcessor(t *testing.T) {
 	}
 	t.Logf("process() duration: %s", time.Since(start))
 }
+
+// Tests that after London the base fee is burned, only the tip is paid to the
+// block signer and messages with a fee cap below the base fee are rejected
+// unless the base fee check is disabled.
+func TestApplyMessageLondonFees(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	coinbase := common.Address{0xc0}
+
+	config := *params.AllCliqueProtocolChanges
+	config.LondonBlock = big.NewInt(0)
+	signer := types.LatestSignerForChainID(config.ChainId)
+
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Time:       big.NewInt(0),
+		Difficulty: big.NewInt(1),
+		GasLimit:   params.GenesisGasLimit,
+		BaseFee:    big.NewInt(10),
+	}
+	for _, test := range []struct {
+		tip, feeCap int64
+		noBaseFee   bool
+		paid        int64 // effective gas price
+		err         error
+	}{
+		{tip: 2, feeCap: 20, paid: 12},
+		{tip: 5, feeCap: 12, paid: 12},
+		{tip: 5, feeCap: 9, err: ErrFeeCapTooLow},
+		{tip: 5, feeCap: 9, noBaseFee: true, paid: 9},
+		{tip: 0, feeCap: 0, noBaseFee: true, paid: 0},
+	} {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		statedb.AddBalance(address, big.NewInt(1000000000))
+
+		tx := types.NewDynamicFeeTransaction(config.ChainId, 0, &common.Address{}, big.NewInt(0), params.TxGas, big.NewInt(test.tip), big.NewInt(test.feeCap), nil, nil)
+		tx, _ = types.SignTx(tx, signer, key)
+		msg, err := tx.AsMessage(signer, header.BaseFee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		evm := vm.NewEVM(NewEVMContext(msg, header, nil, &coinbase), statedb, &config, vm.Config{NoBaseFee: test.noBaseFee})
+		_, used, _, err := ApplyMessage(evm, msg, new(GasPool).AddGas(header.GasLimit))
+		if err != test.err {
+			t.Errorf("tip %d cap %d: error mismatch: have %v, want %v", test.tip, test.feeCap, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		spent := new(big.Int).Sub(big.NewInt(1000000000), statedb.GetBalance(address))
+		if want := new(big.Int).Mul(big.NewInt(test.paid), new(big.Int).SetUint64(used)); spent.Cmp(want) != 0 {
+			t.Errorf("tip %d cap %d: sender paid %v, want %v", test.tip, test.feeCap, spent, want)
+		}
+		tip := new(big.Int).Sub(big.NewInt(test.paid), header.BaseFee)
+		if tip.Sign() < 0 {
+			tip.SetInt64(0)
+		}
+		if want := new(big.Int).Mul(tip, new(big.Int).SetUint64(used)); statedb.GetBalance(coinbase).Cmp(want) != 0 {
+			t.Errorf("tip %d cap %d: signer got %v, want %v", test.tip, test.feeCap, statedb.GetBalance(coinbase), want)
+		}
+	}
+}
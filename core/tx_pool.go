@@ -63,6 +63,10 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrTxTypeNotSupported is returned if a transaction is not supported in the
+	// current network configuration.
+	ErrTxTypeNotSupported = types.ErrTxTypeNotSupported
 )
 
 var (
@@ -226,6 +230,9 @@ type TxPool struct {
 	wg sync.WaitGroup // for shutdown sync
 
 	homestead bool
+	istanbul  bool // Fork indicator whether we are in the istanbul stage
+	eip2718   bool // Fork indicator whether we are using EIP-2718 type transactions
+	eip1559   bool // Fork indicator whether we are using EIP-1559 type transactions
 
 	stop chan struct{}
 }
@@ -241,7 +248,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		config:      config,
 		chainconfig: chainconfig,
 		chain:       chain,
-		signer:      types.NewEIP155Signer(chainconfig.ChainId),
+		signer:      types.LatestSignerForChainID(chainconfig.ChainId),
 		pending:     make(map[common.Address]*txList),
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
@@ -518,6 +525,10 @@ func (pool *TxPool) reset(oldBlock, newBlock *types.Block) {
 	if pool.chainconfig.IsHomestead(pool.currentNum) {
 		pool.homestead = true
 	}
+	next := new(big.Int).Add(pool.currentNum, big.NewInt(1))
+	pool.istanbul = pool.chainconfig.IsIstanbul(next, newBlock.Time())
+	pool.eip2718 = pool.chainconfig.IsBerlin(next, newBlock.Time())
+	pool.eip1559 = pool.chainconfig.IsLondon(next, newBlock.Time())
 	if pool.config.PriceLimit < 1 {
 		pool.gasPrice = gasprice.DefaultFn(pool.chainconfig)(pool.currentNum)
 	}
@@ -740,6 +751,18 @@ func (pool *TxPool) preValidateTx(tx *types.Transaction, local bool) error {
 // rules and adheres to some heuristic limits of the local node (price and size).
 // The caller must hold pool.mu.
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	// Accept only legacy transactions until EIP-2718/2930 activates
+	if !pool.eip2718 && tx.Type() != types.LegacyTxType {
+		return ErrTxTypeNotSupported
+	}
+	// Reject dynamic fee transactions until EIP-1559 activates
+	if !pool.eip1559 && tx.Type() == types.DynamicFeeTxType {
+		return ErrTxTypeNotSupported
+	}
+	// Ensure the tip never exceeds the fee cap
+	if tx.GasTipCap().Cmp(tx.GasFeeCap()) > 0 {
+		return ErrTipAboveFeeCap
+	}
 	// Heuristic limit, reject transactions over 32KB to prevent DOS attacks
 	if tx.Size() > 32*1024 {
 		return ErrOversizedData
@@ -772,7 +795,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.homestead, pool.istanbul)
 	if err != nil {
 		return err
 	}
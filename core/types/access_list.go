@@ -0,0 +1,24 @@
+package types
+
+import (
+	"github.com/ChainAAS/gendchain/common"
+)
+
+// AccessList is an EIP-2930 access list, the accounts and storage slots a
+// transaction declares it will touch.
+type AccessList []AccessTuple
+
+// AccessTuple is the element type of an access list.
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// StorageKeys returns the total number of storage keys in the access list.
+func (al AccessList) StorageKeys() int {
+	sum := 0
+	for _, tuple := range al {
+		sum += len(tuple.StorageKeys)
+	}
+	return sum
+}
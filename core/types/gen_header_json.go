@@ -34,6 +34,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 		Extra       hexutil.Bytes    `json:"extraData"        gencodec:"required"`
 		MixDigest   common.Hash      `json:"mixHash"          gencodec:"required"`
 		Nonce       BlockNonce       `json:"nonce"            gencodec:"required"`
+		BaseFee     *hexutil.Big     `json:"baseFeePerGas" rlp:"optional"`
 		Hash        common.Hash      `json:"hash"`
 	}
 	var enc Header
@@ -55,6 +56,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.Extra = h.Extra
 	enc.MixDigest = h.MixDigest
 	enc.Nonce = h.Nonce
+	enc.BaseFee = (*hexutil.Big)(h.BaseFee)
 	enc.Hash = h.Hash()
 	return json.Marshal(&enc)
 }
@@ -80,6 +82,7 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		Extra       *hexutil.Bytes   `json:"extraData"        gencodec:"required"`
 		MixDigest   *common.Hash     `json:"mixHash"          gencodec:"required"`
 		Nonce       *BlockNonce      `json:"nonce"            gencodec:"required"`
+		BaseFee     *hexutil.Big     `json:"baseFeePerGas" rlp:"optional"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -155,5 +158,8 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'nonce' for Header")
 	}
 	h.Nonce = *dec.Nonce
+	if dec.BaseFee != nil {
+		h.BaseFee = (*big.Int)(dec.BaseFee)
+	}
 	return nil
 }
@@ -163,11 +163,11 @@ func TestDeriveFields(t *testing.T) {
 	hash := common.BytesToHash([]byte{0x03, 0x14})
 
 	clearComputedFieldsOnReceipts(t, receipts)
-	if err := receipts.DeriveFields(params.TestChainConfig, hash, number.Uint64(), txs); err != nil {
+	if err := receipts.DeriveFields(params.TestChainConfig, hash, number.Uint64(), new(big.Int), txs); err != nil {
 		t.Fatalf("DeriveFields(...) = %v, want <nil>", err)
 	}
 	// Iterate over all the computed fields and check that they're correct
-	signer := MakeSigner(params.TestChainConfig, number)
+	signer := MakeSigner(params.TestChainConfig, number, new(big.Int))
 
 	logIndex := uint(0)
 	for i := range receipts {
@@ -323,3 +323,51 @@ func TestReceiptsForStorage_EncodeRLP(t *testing.T) {
 	}
 
 }
+
+// Tests that receipts of typed transactions round trip through both the RLP
+// and the binary envelope encoding.
+func TestTypedReceiptEncoding(t *testing.T) {
+	receipt := &Receipt{
+		Type:              AccessListTxType,
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs: []*Log{
+			{
+				Address: common.BytesToAddress([]byte{0x11}),
+				Topics:  []common.Hash{common.HexToHash("dead"), common.HexToHash("beef")},
+				Data:    []byte{0x01, 0x00, 0xff},
+			},
+		},
+	}
+	receipt.Bloom = CreateBloom(Receipts{receipt})
+
+	bin, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatalf("binary encode error: %v", err)
+	}
+	if bin[0] != AccessListTxType {
+		t.Fatalf("envelope type mismatch: have %d, want %d", bin[0], AccessListTxType)
+	}
+	enc, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatalf("rlp encode error: %v", err)
+	}
+	if want, _ := rlp.EncodeToBytes(bin); !bytes.Equal(enc, want) {
+		t.Fatalf("rlp encoding mismatch: have %x, want %x", enc, want)
+	}
+	for _, decode := range []func(*Receipt) error{
+		func(r *Receipt) error { return r.UnmarshalBinary(bin) },
+		func(r *Receipt) error { return rlp.DecodeBytes(enc, r) },
+	} {
+		dec := new(Receipt)
+		if err := decode(dec); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if dec.Type != receipt.Type || dec.Status != receipt.Status || dec.CumulativeGasUsed != receipt.CumulativeGasUsed {
+			t.Errorf("consensus fields mismatch: have %+v, want %+v", dec, receipt)
+		}
+		if dec.Bloom != receipt.Bloom || !reflect.DeepEqual(dec.Logs, receipt.Logs) {
+			t.Errorf("logs mismatch: have %v, want %v", dec.Logs, receipt.Logs)
+		}
+	}
+}
@@ -16,8 +16,18 @@ import (
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
 
 var (
-	ErrInvalidSig = errors.New("invalid transaction v, r, s values")
-	errNoSigner   = errors.New("missing signing methods")
+	ErrInvalidSig         = errors.New("invalid transaction v, r, s values")
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	ErrGasFeeCapTooLow    = errors.New("fee cap less than base fee")
+	errNoSigner           = errors.New("missing signing methods")
+	errEmptyTypedTx       = errors.New("empty typed transaction bytes")
+)
+
+// Transaction types.
+const (
+	LegacyTxType     = iota // Untyped RLP transactions
+	AccessListTxType        // EIP-2930 transactions with an access list
+	DynamicFeeTxType        // EIP-1559 transactions with a fee cap and a priority tip
 )
 
 type Transaction struct {
@@ -41,6 +51,15 @@ type txdata struct {
 	R *big.Int `json:"r" gencodec:"required"`
 	S *big.Int `json:"s" gencodec:"required"`
 
+	// Typed transaction fields, absent from the legacy RLP encoding.
+	Type       uint8       `json:"type"                 rlp:"-"`
+	ChainID    *big.Int    `json:"chainId,omitempty"    rlp:"-"`
+	AccessList *AccessList `json:"accessList,omitempty" rlp:"-"`
+
+	// GasTipCap is the priority tip of dynamic fee transactions, whose Price
+	// holds the fee cap.
+	GasTipCap *big.Int `json:"maxPriorityFeePerGas,omitempty" rlp:"-"`
+
 	// This is only used when marshaling to JSON.
 	Hash *common.Hash `json:"hash" rlp:"-"`
 }
@@ -54,6 +73,38 @@ type txdataMarshaling struct {
 	V            *hexutil.Big
 	R            *hexutil.Big
 	S            *hexutil.Big
+	Type         hexutil.Uint64
+	ChainID      *hexutil.Big
+	GasTipCap    *hexutil.Big
+}
+
+// accessListTxRLP is the consensus encoding of an EIP-2930 transaction payload,
+// which follows the type byte in the envelope.
+type accessListTxRLP struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *big.Int
+}
+
+// dynamicFeeTxRLP is the consensus encoding of an EIP-1559 transaction payload,
+// which follows the type byte in the envelope.
+type dynamicFeeTxRLP struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+	V, R, S    *big.Int
 }
 
 func NewTransaction(nonce uint64, to common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte) *Transaction {
@@ -89,13 +140,61 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 	return &Transaction{data: d}
 }
 
+// NewAccessListTransaction creates an unsigned EIP-2930 transaction for the given
+// chain. A nil recipient means contract creation.
+func NewAccessListTransaction(chainID *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, accessList AccessList) *Transaction {
+	tx := newTransaction(nonce, to, amount, gasLimit, gasPrice, data)
+	if accessList == nil {
+		accessList = AccessList{}
+	}
+	tx.data.Type = AccessListTxType
+	tx.data.ChainID = new(big.Int)
+	if chainID != nil {
+		tx.data.ChainID.Set(chainID)
+	}
+	tx.data.AccessList = &accessList
+	return tx
+}
+
+// NewDynamicFeeTransaction creates an unsigned EIP-1559 transaction for the
+// given chain, paying at most gasFeeCap per gas of which gasTipCap on top of the
+// base fee goes to the block signer. A nil recipient means contract creation.
+func NewDynamicFeeTransaction(chainID *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasTipCap, gasFeeCap *big.Int, data []byte, accessList AccessList) *Transaction {
+	tx := NewAccessListTransaction(chainID, nonce, to, amount, gasLimit, gasFeeCap, data, accessList)
+	tx.data.Type = DynamicFeeTxType
+	tx.data.GasTipCap = new(big.Int)
+	if gasTipCap != nil {
+		tx.data.GasTipCap.Set(gasTipCap)
+	}
+	return tx
+}
+
+// Type returns the EIP-2718 type of the transaction.
+func (tx *Transaction) Type() uint8 { return tx.data.Type }
+
+// AccessList returns the EIP-2930 access list of the transaction, nil for
+// legacy transactions.
+func (tx *Transaction) AccessList() AccessList {
+	if tx.data.AccessList == nil {
+		return nil
+	}
+	return *tx.data.AccessList
+}
+
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.data.Type != LegacyTxType {
+		return new(big.Int).Set(tx.data.ChainID)
+	}
 	return deriveChainId(tx.data.V)
 }
 
 // Protected returns whether the transaction is protected from replay protection.
+// Typed transactions always commit to a chain id.
 func (tx *Transaction) Protected() bool {
+	if tx.data.Type != LegacyTxType {
+		return true
+	}
 	return isProtectedV(tx.data.V)
 }
 
@@ -108,20 +207,156 @@ func isProtectedV(V *big.Int) bool {
 	return true
 }
 
-// EncodeRLP implements rlp.Encoder
+// EncodeRLP implements rlp.Encoder. Legacy transactions are encoded as an RLP
+// list, typed ones as an RLP string holding their EIP-2718 envelope.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.data.Type == LegacyTxType {
+		return rlp.Encode(w, &tx.data)
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
 }
 
 // DecodeRLP implements rlp.Decoder
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
-	if err == nil {
+	kind, size, err := s.Kind()
+	switch {
+	case err != nil:
+		return err
+	case kind == rlp.List:
+		var dec txdata
+		if err := s.Decode(&dec); err != nil {
+			return err
+		}
+		tx.data = dec
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		return nil
+	default:
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		return tx.decodeTyped(b)
+	}
+}
+
+// MarshalBinary returns the canonical encoding of the transaction: the RLP list
+// for legacy transactions, the type byte followed by the RLP payload otherwise.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.data.Type == LegacyTxType {
+		return rlp.EncodeToBytes(&tx.data)
+	}
+	payload, err := rlp.EncodeToBytes(tx.typedRLP())
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tx.data.Type}, payload...), nil
+}
+
+// UnmarshalBinary decodes the canonical encoding of a transaction.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		// It's a legacy transaction
+		var dec txdata
+		if err := rlp.DecodeBytes(b, &dec); err != nil {
+			return err
+		}
+		tx.data = dec
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
 	}
+	return tx.decodeTyped(b)
+}
 
-	return err
+// decodeTyped decodes an EIP-2718 transaction envelope.
+func (tx *Transaction) decodeTyped(b []byte) error {
+	if len(b) <= 1 {
+		return errEmptyTypedTx
+	}
+	switch b[0] {
+	case AccessListTxType:
+		var dec accessListTxRLP
+		if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+			return err
+		}
+		tx.data = txdata{
+			AccountNonce: dec.Nonce,
+			Price:        dec.GasPrice,
+			GasLimit:     dec.Gas,
+			Recipient:    dec.To,
+			Amount:       dec.Value,
+			Payload:      dec.Data,
+			V:            dec.V,
+			R:            dec.R,
+			S:            dec.S,
+			Type:         AccessListTxType,
+			ChainID:      dec.ChainID,
+			AccessList:   &dec.AccessList,
+		}
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
+	case DynamicFeeTxType:
+		var dec dynamicFeeTxRLP
+		if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
+			return err
+		}
+		tx.data = txdata{
+			AccountNonce: dec.Nonce,
+			Price:        dec.GasFeeCap,
+			GasLimit:     dec.Gas,
+			Recipient:    dec.To,
+			Amount:       dec.Value,
+			Payload:      dec.Data,
+			V:            dec.V,
+			R:            dec.R,
+			S:            dec.S,
+			Type:         DynamicFeeTxType,
+			ChainID:      dec.ChainID,
+			AccessList:   &dec.AccessList,
+			GasTipCap:    dec.GasTipCap,
+		}
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
+	default:
+		return ErrTxTypeNotSupported
+	}
+}
+
+// typedRLP returns the consensus payload of a typed transaction, which follows
+// the type byte in its envelope.
+func (tx *Transaction) typedRLP() interface{} {
+	if tx.data.Type == DynamicFeeTxType {
+		return &dynamicFeeTxRLP{
+			ChainID:    tx.data.ChainID,
+			Nonce:      tx.data.AccountNonce,
+			GasTipCap:  tx.data.GasTipCap,
+			GasFeeCap:  tx.data.Price,
+			Gas:        tx.data.GasLimit,
+			To:         tx.data.Recipient,
+			Value:      tx.data.Amount,
+			Data:       tx.data.Payload,
+			AccessList: tx.AccessList(),
+			V:          tx.data.V,
+			R:          tx.data.R,
+			S:          tx.data.S,
+		}
+	}
+	return &accessListTxRLP{
+		ChainID:    tx.data.ChainID,
+		Nonce:      tx.data.AccountNonce,
+		GasPrice:   tx.data.Price,
+		Gas:        tx.data.GasLimit,
+		To:         tx.data.Recipient,
+		Value:      tx.data.Amount,
+		Data:       tx.data.Payload,
+		AccessList: tx.AccessList(),
+		V:          tx.data.V,
+		R:          tx.data.R,
+		S:          tx.data.S,
+	}
 }
 
 // MarshalJSON encodes the web3 RPC transaction format.
@@ -139,10 +374,30 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		return err
 	}
 
+	switch dec.Type {
+	case LegacyTxType:
+		dec.ChainID, dec.AccessList, dec.GasTipCap = nil, nil, nil
+	case AccessListTxType, DynamicFeeTxType:
+		if dec.ChainID == nil {
+			return errors.New("missing required field 'chainId' in transaction")
+		}
+		if dec.AccessList == nil {
+			dec.AccessList = &AccessList{}
+		}
+		if dec.Type == AccessListTxType {
+			dec.GasTipCap = nil
+		} else if dec.GasTipCap == nil {
+			return errors.New("missing required field 'maxPriorityFeePerGas' in transaction")
+		}
+	default:
+		return ErrTxTypeNotSupported
+	}
 	withSignature := dec.V.Sign() != 0 || dec.R.Sign() != 0 || dec.S.Sign() != 0
 	if withSignature {
 		var V byte
-		if isProtectedV(dec.V) {
+		if dec.Type != LegacyTxType {
+			V = byte(dec.V.Uint64())
+		} else if isProtectedV(dec.V) {
 			chainID := deriveChainId(dec.V).Uint64()
 			V = byte(dec.V.Uint64() - 35 - 2*chainID)
 		} else {
@@ -166,6 +421,35 @@ func (tx *Transaction) Value() *big.Int                    { return new(big.Int)
 func (tx *Transaction) Nonce() uint64                      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool                   { return true }
 
+// GasFeeCap returns the most the transaction pays per gas, which is the gas
+// price of non dynamic fee transactions.
+func (tx *Transaction) GasFeeCap() *big.Int { return new(big.Int).Set(tx.data.Price) }
+
+// GasTipCap returns the most the transaction pays per gas on top of the base
+// fee, which is the gas price of non dynamic fee transactions.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.data.GasTipCap == nil {
+		return new(big.Int).Set(tx.data.Price)
+	}
+	return new(big.Int).Set(tx.data.GasTipCap)
+}
+
+// EffectiveGasTip returns the tip per gas the block signer receives given the
+// base fee, and ErrGasFeeCapTooLow if the fee cap doesn't cover the base fee.
+func (tx *Transaction) EffectiveGasTip(baseFee *big.Int) (*big.Int, error) {
+	if baseFee == nil {
+		return tx.GasTipCap(), nil
+	}
+	if tx.data.Price.Cmp(baseFee) < 0 {
+		return nil, ErrGasFeeCapTooLow
+	}
+	tip := new(big.Int).Sub(tx.data.Price, baseFee)
+	if tipCap := tx.GasTipCap(); tipCap.Cmp(tip) < 0 {
+		tip = tipCap
+	}
+	return tip, nil
+}
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
@@ -176,13 +460,18 @@ func (tx *Transaction) To() *common.Address {
 	return &to
 }
 
-// Hash hashes the RLP encoding of tx.
+// Hash hashes the canonical encoding of tx.
 // It uniquely identifies the transaction.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.data.Type == LegacyTxType {
+		v = rlpHash(tx)
+	} else {
+		v = prefixedRlpHash(tx.data.Type, tx.typedRLP())
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -194,17 +483,24 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	if tx.data.Type == LegacyTxType {
+		rlp.Encode(&c, &tx.data)
+	} else {
+		c = 1 // type byte
+		rlp.Encode(&c, tx.typedRLP())
+	}
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
 
 // AsMessage returns the transaction as a core.Message.
 //
-// AsMessage requires a signer to derive the sender.
+// AsMessage requires a signer to derive the sender. With a base fee the gas
+// price of the message is the effective one, the base fee plus the tip capped
+// by the fee cap.
 //
 // XXX Rename message to something less arbitrary?
-func (tx *Transaction) AsMessage(s Signer) (*Message, error) {
+func (tx *Transaction) AsMessage(s Signer, baseFee *big.Int) (*Message, error) {
 	from, err := Sender(s, tx)
 	if err != nil {
 		return nil, err
@@ -213,12 +509,21 @@ func (tx *Transaction) AsMessage(s Signer) (*Message, error) {
 		nonce:      tx.data.AccountNonce,
 		gasLimit:   tx.data.GasLimit,
 		gasPrice:   tx.data.Price,
+		gasFeeCap:  tx.GasFeeCap(),
+		gasTipCap:  tx.GasTipCap(),
 		to:         tx.data.Recipient,
 		amount:     tx.data.Amount,
 		data:       tx.data.Payload,
+		accessList: tx.AccessList(),
 		checkNonce: true,
 		from:       from,
 	}
+	if baseFee != nil {
+		msg.gasPrice = new(big.Int).Add(msg.gasTipCap, baseFee)
+		if msg.gasPrice.Cmp(msg.gasFeeCap) > 0 {
+			msg.gasPrice = msg.gasFeeCap
+		}
+	}
 	return msg, nil
 }
 
@@ -254,9 +559,10 @@ func (s Transactions) Len() int { return len(s) }
 // Swap swaps the i'th and the j'th element in s.
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-// GetRlp implements Rlpable and returns the i'th element of s in rlp.
+// GetRlp implements Rlpable and returns the canonical encoding of the i'th
+// element of s, which is the EIP-2718 envelope for typed transactions.
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
@@ -363,11 +669,14 @@ type Message struct {
 	amount     *big.Int
 	gasLimit   uint64
 	gasPrice   *big.Int
+	gasFeeCap  *big.Int
+	gasTipCap  *big.Int
 	data       []byte
+	accessList AccessList
 	checkNonce bool
 }
 
-func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, checkNonce bool) *Message {
+func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice, gasFeeCap, gasTipCap *big.Int, data []byte, accessList AccessList, checkNonce bool) *Message {
 	return &Message{
 		from:       from,
 		to:         to,
@@ -375,16 +684,22 @@ func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *b
 		amount:     amount,
 		gasLimit:   gasLimit,
 		gasPrice:   gasPrice,
+		gasFeeCap:  gasFeeCap,
+		gasTipCap:  gasTipCap,
 		data:       data,
+		accessList: accessList,
 		checkNonce: checkNonce,
 	}
 }
 
-func (m *Message) From() common.Address { return m.from }
-func (m *Message) To() *common.Address  { return m.to }
-func (m *Message) GasPrice() *big.Int   { return m.gasPrice }
-func (m *Message) Value() *big.Int      { return m.amount }
-func (m *Message) Gas() uint64          { return m.gasLimit }
-func (m *Message) Nonce() uint64        { return m.nonce }
-func (m *Message) Data() []byte         { return m.data }
-func (m *Message) CheckNonce() bool     { return m.checkNonce }
+func (m *Message) From() common.Address   { return m.from }
+func (m *Message) To() *common.Address    { return m.to }
+func (m *Message) GasPrice() *big.Int     { return m.gasPrice }
+func (m *Message) GasFeeCap() *big.Int    { return m.gasFeeCap }
+func (m *Message) GasTipCap() *big.Int    { return m.gasTipCap }
+func (m *Message) Value() *big.Int        { return m.amount }
+func (m *Message) Gas() uint64            { return m.gasLimit }
+func (m *Message) Nonce() uint64          { return m.nonce }
+func (m *Message) Data() []byte           { return m.data }
+func (m *Message) AccessList() AccessList { return m.accessList }
+func (m *Message) CheckNonce() bool       { return m.checkNonce }
@@ -33,6 +33,24 @@ var (
 		HomesteadSigner{},
 		common.Hex2Bytes("98ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4a8887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a301"),
 	)
+
+	testAddr = common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+
+	emptyEip2718Tx = NewAccessListTransaction(
+		big.NewInt(1),
+		3,
+		&testAddr,
+		big.NewInt(10),
+		25000,
+		big.NewInt(1),
+		common.FromHex("5544"),
+		nil,
+	)
+
+	signedEip2718Tx, _ = emptyEip2718Tx.WithSignature(
+		NewEIP2930Signer(big.NewInt(1)),
+		common.Hex2Bytes("c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b266032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d3752101"),
+	)
 )
 
 func TestTransactionSigHash(t *testing.T) {
@@ -56,6 +74,146 @@ func TestTransactionEncode(t *testing.T) {
 	}
 }
 
+func TestEIP2718TransactionSigHash(t *testing.T) {
+	s := NewEIP2930Signer(big.NewInt(1))
+	if s.Hash(emptyEip2718Tx) != common.HexToHash("49b486f0ec0a60dfbbca2d30cb07c9e8ffb2a2ff41f29a1ab6737475f6ff69f3") {
+		t.Errorf("empty EIP-2718 transaction hash mismatch, got %x", s.Hash(emptyEip2718Tx))
+	}
+	if s.Hash(signedEip2718Tx) != common.HexToHash("49b486f0ec0a60dfbbca2d30cb07c9e8ffb2a2ff41f29a1ab6737475f6ff69f3") {
+		t.Errorf("signed EIP-2718 transaction hash mismatch, got %x", s.Hash(signedEip2718Tx))
+	}
+}
+
+func TestEIP2718TransactionEncode(t *testing.T) {
+	// RLP representation
+	{
+		have, err := rlp.EncodeToBytes(signedEip2718Tx)
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		want := common.FromHex("b86601f8630103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521")
+		if !bytes.Equal(have, want) {
+			t.Errorf("encoded RLP mismatch, got %x", have)
+		}
+		tx, err := decodeTx(have)
+		if err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if tx.Hash() != signedEip2718Tx.Hash() {
+			t.Errorf("decoded transaction hash mismatch, got %x", tx.Hash())
+		}
+	}
+	// Binary representation
+	{
+		have, err := signedEip2718Tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		want := common.FromHex("01f8630103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521")
+		if !bytes.Equal(have, want) {
+			t.Errorf("encoded binary mismatch, got %x", have)
+		}
+		var tx Transaction
+		if err := tx.UnmarshalBinary(have); err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if tx.Hash() != signedEip2718Tx.Hash() {
+			t.Errorf("decoded transaction hash mismatch, got %x", tx.Hash())
+		}
+	}
+}
+
+// Tests that access list transactions are signed for, and only accepted by,
+// signers of their chain.
+func TestEIP2930Signer(t *testing.T) {
+	key, addr := defaultTestKey()
+	signer := NewEIP2930Signer(big.NewInt(1))
+
+	tx, err := SignTx(emptyEip2718Tx, signer, key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	from, err := Sender(signer, tx)
+	if err != nil {
+		t.Fatalf("could not recover sender: %v", err)
+	}
+	if from != addr {
+		t.Errorf("sender mismatch: have %x, want %x", from, addr)
+	}
+	if _, err := Sender(NewEIP2930Signer(big.NewInt(2)), tx); err != ErrInvalidChainId {
+		t.Errorf("foreign chain sender error mismatch: have %v, want %v", err, ErrInvalidChainId)
+	}
+	if _, err := Sender(NewEIP155Signer(big.NewInt(1)), tx); err != ErrTxTypeNotSupported {
+		t.Errorf("pre-berlin sender error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	if _, err := SignTx(emptyEip2718Tx, NewEIP2930Signer(big.NewInt(2)), key); err != ErrInvalidChainId {
+		t.Errorf("foreign chain signing error mismatch: have %v, want %v", err, ErrInvalidChainId)
+	}
+}
+
+// Tests that dynamic fee transactions round-trip through the London signer and
+// the binary encoding, and that their effective tip follows the base fee.
+func TestLondonSigner(t *testing.T) {
+	key, addr := defaultTestKey()
+	signer := NewLondonSigner(big.NewInt(1))
+
+	accesses := AccessList{{Address: testAddr, StorageKeys: []common.Hash{{1}}}}
+	tx := NewDynamicFeeTransaction(big.NewInt(1), 3, &testAddr, big.NewInt(10), 25000, big.NewInt(2), big.NewInt(10), common.FromHex("5544"), accesses)
+	tx, err := SignTx(tx, signer, key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	if tx.Type() != DynamicFeeTxType {
+		t.Fatalf("type mismatch: have %d, want %d", tx.Type(), DynamicFeeTxType)
+	}
+	from, err := Sender(signer, tx)
+	if err != nil {
+		t.Fatalf("could not recover sender: %v", err)
+	}
+	if from != addr {
+		t.Errorf("sender mismatch: have %x, want %x", from, addr)
+	}
+	if _, err := Sender(NewEIP2930Signer(big.NewInt(1)), tx); err != ErrTxTypeNotSupported {
+		t.Errorf("pre-london sender error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	blob, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if blob[0] != DynamicFeeTxType {
+		t.Errorf("type prefix mismatch: have %d, want %d", blob[0], DynamicFeeTxType)
+	}
+	var dec Transaction
+	if err := dec.UnmarshalBinary(blob); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Errorf("decoded transaction hash mismatch, got %x", dec.Hash())
+	}
+	if dec.GasTipCap().Cmp(big.NewInt(2)) != 0 || dec.GasFeeCap().Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("fee caps mismatch: have tip %v cap %v", dec.GasTipCap(), dec.GasFeeCap())
+	}
+	for _, test := range []struct {
+		baseFee int64
+		tip     int64
+		err     error
+	}{
+		{baseFee: 1, tip: 2},
+		{baseFee: 9, tip: 1},
+		{baseFee: 10, tip: 0},
+		{baseFee: 11, err: ErrGasFeeCapTooLow},
+	} {
+		tip, err := tx.EffectiveGasTip(big.NewInt(test.baseFee))
+		if err != test.err {
+			t.Errorf("base fee %d: error mismatch: have %v, want %v", test.baseFee, err, test.err)
+			continue
+		}
+		if err == nil && tip.Int64() != test.tip {
+			t.Errorf("base fee %d: tip mismatch: have %v, want %d", test.baseFee, tip, test.tip)
+		}
+	}
+}
+
 func decodeTx(data []byte) (*Transaction, error) {
 	var tx Transaction
 	t, err := &tx, rlp.Decode(bytes.NewReader(data), &tx)
@@ -167,16 +325,20 @@ func TestTransactionJSON(t *testing.T) {
 	if err != nil {
 		t.Fatalf("could not generate key: %v", err)
 	}
-	signer := NewEIP155Signer(common.Big1)
+	signer := NewEIP2930Signer(common.Big1)
 
 	transactions := make([]*Transaction, 0, 50)
 	for i := uint64(0); i < 25; i++ {
 		var tx *Transaction
-		switch i % 2 {
+		switch i % 3 {
 		case 0:
 			tx = NewTransaction(i, common.Address{1}, common.Big0, 1, common.Big2, []byte("abcdef"))
 		case 1:
 			tx = NewContractCreation(i, common.Big0, 1, common.Big2, []byte("abcdef"))
+		case 2:
+			addr := common.Address{1}
+			accesses := AccessList{{Address: addr, StorageKeys: []common.Hash{{0}}}}
+			tx = NewAccessListTransaction(common.Big1, i, &addr, common.Big0, 1, common.Big2, []byte("abcdef"), accesses)
 		}
 		transactions = append(transactions, tx)
 
@@ -1,8 +1,11 @@
 package vm
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"path/filepath"
 	"testing"
 
 	"github.com/ChainAAS/gendchain/common"
@@ -320,12 +323,73 @@ var bn256PairingTests = []precompiledTest{
 	},
 }
 
-func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+// blake2FInput* are the state, message and counter of the EIP-152 test vectors,
+// which compress the single block "abc" from the BLAKE2b-512 initial state.
+const (
+	blake2FInputState   = "48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b"
+	blake2FInputMessage = "6162630000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"
+	blake2FInputCounter = "03000000000000000000000000000000"
+)
+
+// blake2FTests are the test and benchmark data for the blake2F precompiled
+// contract (EIP-152).
+var blake2FTests = []precompiledTest{
+	{
+		input:    "00000000" + blake2FInputState + blake2FInputMessage + blake2FInputCounter + "01",
+		expected: "08c9bcf367e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d282e6ad7f520e511f6c3e2b8c68059b9442be0454267ce079217e1319cde05b",
+		name:     "vector 4",
+	},
+	{
+		input:    "0000000c" + blake2FInputState + blake2FInputMessage + blake2FInputCounter + "01",
+		expected: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		name:     "vector 5",
+	},
+	{
+		input:    "0000000c" + blake2FInputState + blake2FInputMessage + blake2FInputCounter + "00",
+		expected: "75ab69d3190a562c51aef8d88f1c2775876944407270c42c9844252c26d2875298743e7f6d5ea2f2d3e8d226039cd31b4e426ac4f2d3d666a610c2116fde4735",
+		name:     "vector 6",
+	},
+	{
+		input:    "00000001" + blake2FInputState + blake2FInputMessage + blake2FInputCounter + "01",
+		expected: "b63a380cb2897d521994a85234ee2c181b5f844d2c624c002677e9703449d2fba551b3a8333bcdf5f2f7e08993d53923de3d64fcc68c034e717b9293fed7a421",
+		name:     "vector 7",
+	},
+}
+
+// blake2FMalformedInputTests are blake2F inputs that must be rejected.
+var blake2FMalformedInputTests = []struct {
+	input string
+	err   error
+	name  string
+}{
+	{
+		input: "",
+		err:   errBlake2FInvalidInputLength,
+		name:  "vector 0: empty input",
+	}, {
+		input: "00000c" + blake2FInputState + blake2FInputMessage + blake2FInputCounter + "01",
+		err:   errBlake2FInvalidInputLength,
+		name:  "vector 1: less rounds input",
+	}, {
+		input: "000000000c" + blake2FInputState + blake2FInputMessage + blake2FInputCounter + "01",
+		err:   errBlake2FInvalidInputLength,
+		name:  "vector 2: more rounds input",
+	}, {
+		input: "0000000c" + blake2FInputState + blake2FInputMessage + blake2FInputCounter + "02",
+		err:   errBlake2FInvalidFinalFlag,
+		name:  "vector 3: malformed final block indicator flag",
+	},
+}
+
+func testPrecompiled(contracts map[common.Address]PrecompiledContract, addr string, test precompiledTest, t *testing.T) {
+	p := contracts[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
 		nil, new(big.Int), p.RequiredGas(in))
 	t.Run(fmt.Sprintf("%s-Gas=%d", test.name, contract.Gas), func(t *testing.T) {
+		if test.gas != 0 && contract.Gas != test.gas {
+			t.Errorf("Expected gas %d, got %d", test.gas, contract.Gas)
+		}
 		if res, err := RunPrecompiledContract(p, in, contract); err != nil {
 			t.Error(err)
 		} else if common.Bytes2Hex(res) != test.expected {
@@ -334,11 +398,11 @@ func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
 	})
 }
 
-func benchmarkPrecompiled(addr string, test precompiledTest, bench *testing.B) {
+func benchmarkPrecompiled(contracts map[common.Address]PrecompiledContract, addr string, test precompiledTest, bench *testing.B) {
 	if test.noBenchmark {
 		return
 	}
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := contracts[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	reqGas := p.RequiredGas(in)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
@@ -377,7 +441,7 @@ func BenchmarkPrecompiledEcrecover(bench *testing.B) {
 		expected: "000000000000000000000000ceaccac640adf55b2028469bd36ba501f28b699d",
 		name:     "",
 	}
-	benchmarkPrecompiled("01", t, bench)
+	benchmarkPrecompiled(PrecompiledContractsIstanbul, "01", t, bench)
 }
 
 // Benchmarks the sample inputs from the SHA256 precompile.
@@ -387,7 +451,7 @@ func BenchmarkPrecompiledSha256(bench *testing.B) {
 		expected: "811c7003375852fabd0d362e40e68607a12bdabae61a7d068fe5fdd1dbbf2a5d",
 		name:     "128",
 	}
-	benchmarkPrecompiled("02", t, bench)
+	benchmarkPrecompiled(PrecompiledContractsIstanbul, "02", t, bench)
 }
 
 // Benchmarks the sample inputs from the RIPEMD precompile.
@@ -397,7 +461,7 @@ func BenchmarkPrecompiledRipeMD(bench *testing.B) {
 		expected: "0000000000000000000000009215b8d9882ff46f0dfde6684d78e831467f65e6",
 		name:     "128",
 	}
-	benchmarkPrecompiled("03", t, bench)
+	benchmarkPrecompiled(PrecompiledContractsIstanbul, "03", t, bench)
 }
 
 // Benchmarks the sample inputs from the identiy precompile.
@@ -407,61 +471,170 @@ func BenchmarkPrecompiledIdentity(bench *testing.B) {
 		expected: "38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e000000000000000000000000000000000000000000000000000000000000001b38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e789d1dd423d25f0772d2748d60f7e4b81bb14d086eba8e8e8efb6dcff8a4ae02",
 		name:     "128",
 	}
-	benchmarkPrecompiled("04", t, bench)
+	benchmarkPrecompiled(PrecompiledContractsIstanbul, "04", t, bench)
 }
 
 // Tests the sample inputs from the ModExp EIP 198.
 func TestPrecompiledModExp(t *testing.T) {
 	for _, test := range modexpTests {
-		testPrecompiled("05", test, t)
+		testPrecompiled(PrecompiledContractsIstanbul, "05", test, t)
 	}
 }
 
 // Benchmarks the sample inputs from the ModExp EIP 198.
 func BenchmarkPrecompiledModExp(bench *testing.B) {
 	for _, test := range modexpTests {
-		benchmarkPrecompiled("05", test, bench)
+		benchmarkPrecompiled(PrecompiledContractsIstanbul, "05", test, bench)
 	}
 }
 
 // Tests the sample inputs from the elliptic curve addition EIP 213.
 func TestPrecompiledBn256Add(t *testing.T) {
 	for _, test := range bn256AddTests {
-		testPrecompiled("06", test, t)
+		testPrecompiled(PrecompiledContractsIstanbul, "06", test, t)
 	}
 }
 
 // Benchmarks the sample inputs from the elliptic curve addition EIP 213.
 func BenchmarkPrecompiledBn256Add(bench *testing.B) {
 	for _, test := range bn256AddTests {
-		benchmarkPrecompiled("06", test, bench)
+		benchmarkPrecompiled(PrecompiledContractsIstanbul, "06", test, bench)
 	}
 }
 
 // Tests the sample inputs from the elliptic curve scalar multiplication EIP 213.
 func TestPrecompiledBn256ScalarMul(t *testing.T) {
 	for _, test := range bn256ScalarMulTests {
-		testPrecompiled("07", test, t)
+		testPrecompiled(PrecompiledContractsIstanbul, "07", test, t)
 	}
 }
 
 // Benchmarks the sample inputs from the elliptic curve scalar multiplication EIP 213.
 func BenchmarkPrecompiledBn256ScalarMul(bench *testing.B) {
 	for _, test := range bn256ScalarMulTests {
-		benchmarkPrecompiled("07", test, bench)
+		benchmarkPrecompiled(PrecompiledContractsIstanbul, "07", test, bench)
 	}
 }
 
 // Tests the sample inputs from the elliptic curve pairing check EIP 197.
 func TestPrecompiledBn256Pairing(t *testing.T) {
 	for _, test := range bn256PairingTests {
-		testPrecompiled("08", test, t)
+		testPrecompiled(PrecompiledContractsIstanbul, "08", test, t)
 	}
 }
 
 // Behcnmarks the sample inputs from the elliptic curve pairing check EIP 197.
 func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 	for _, test := range bn256PairingTests {
-		benchmarkPrecompiled("08", test, bench)
+		benchmarkPrecompiled(PrecompiledContractsIstanbul, "08", test, bench)
+	}
+}
+
+func TestPrecompiledBlake2F(t *testing.T) {
+	for _, test := range blake2FTests {
+		testPrecompiled(PrecompiledContractsIstanbul, "09", test, t)
+	}
+}
+
+func BenchmarkPrecompiledBlake2F(bench *testing.B) {
+	for _, test := range blake2FTests {
+		benchmarkPrecompiled(PrecompiledContractsIstanbul, "09", test, bench)
+	}
+}
+
+func TestPrecompiledBlake2FMalformedInput(t *testing.T) {
+	p := PrecompiledContractsIstanbul[common.HexToAddress("09")]
+	for _, test := range blake2FMalformedInputTests {
+		in := common.Hex2Bytes(test.input)
+		contract := NewContract(AccountRef(common.HexToAddress("1337")),
+			nil, new(big.Int), p.RequiredGas(in))
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := RunPrecompiledContract(p, in, contract); err != test.err {
+				t.Errorf("error mismatch: have %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+// blsPrecompiles are the addresses of the BLS12-381 precompiles of EIP-2537 by
+// the name of their test vectors.
+var blsPrecompiles = map[string]string{
+	"blsG1Add":      "0a",
+	"blsG1Mul":      "0b",
+	"blsG1MultiExp": "0c",
+	"blsG2Add":      "0d",
+	"blsG2Mul":      "0e",
+	"blsG2MultiExp": "0f",
+	"blsPairing":    "10",
+	"blsMapG1":      "11",
+	"blsMapG2":      "12",
+}
+
+// loadPrecompiledTests reads the test vectors of a precompile from the
+// testdata/precompiles directory.
+func loadPrecompiledTests(name string, tb testing.TB) []precompiledTest {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "precompiles", name+".json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var vectors []struct {
+		Input, Expected string
+		Gas             uint64
+		Name            string
+		NoBenchmark     bool
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		tb.Fatal(err)
+	}
+	tests := make([]precompiledTest, len(vectors))
+	for i, v := range vectors {
+		tests[i] = precompiledTest{input: v.Input, expected: v.Expected, gas: v.Gas, name: v.Name, noBenchmark: v.NoBenchmark}
+	}
+	return tests
+}
+
+// Tests the BLS12-381 precompiles against the EIP-2537 test vectors.
+func TestPrecompiledBLS12381(t *testing.T) {
+	for name, addr := range blsPrecompiles {
+		for _, test := range loadPrecompiledTests(name, t) {
+			testPrecompiled(PrecompiledContractsPrague, addr, test, t)
+		}
+	}
+}
+
+// Benchmarks the BLS12-381 precompiles on the EIP-2537 test vectors.
+func BenchmarkPrecompiledBLS12381(bench *testing.B) {
+	for name, addr := range blsPrecompiles {
+		for _, test := range loadPrecompiledTests(name, bench) {
+			benchmarkPrecompiled(PrecompiledContractsPrague, addr, test, bench)
+		}
+	}
+}
+
+// Tests that the BLS12-381 precompiles reject the malformed inputs of the
+// EIP-2537 test vectors.
+func TestPrecompiledBLS12381MalformedInput(t *testing.T) {
+	for name, addr := range blsPrecompiles {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "precompiles", "fail-"+name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tests []struct {
+			Input, ExpectedError, Name string
+		}
+		if err := json.Unmarshal(data, &tests); err != nil {
+			t.Fatal(err)
+		}
+		p := PrecompiledContractsPrague[common.HexToAddress(addr)]
+		for _, test := range tests {
+			in := common.Hex2Bytes(test.Input)
+			contract := NewContract(AccountRef(common.HexToAddress("1337")),
+				nil, new(big.Int), p.RequiredGas(in))
+			t.Run(test.Name, func(t *testing.T) {
+				if _, err := RunPrecompiledContract(p, in, contract); err == nil || err.Error() != test.ExpectedError {
+					t.Errorf("error mismatch: have %v, want %v", err, test.ExpectedError)
+				}
+			})
+		}
 	}
 }
@@ -40,8 +40,49 @@ var (
 	homesteadInstructionSet      = newHomesteadInstructionSet()
 	byzantiumInstructionSet      = newByzantiumInstructionSet()
 	constantinopleInstructionSet = newConstantinopleInstructionSet()
+	istanbulInstructionSet       = newIstanbulInstructionSet()
+	berlinInstructionSet         = newBerlinInstructionSet()
 )
 
+// NewBerlinInstructionSet returns the frontier, homestead, byzantium,
+// constantinople, istanbul and berlin instructions.
+func newBerlinInstructionSet() [256]operation {
+	instructionSet := newIstanbulInstructionSet()
+	// EIP-2929: gas cost increases for state access opcodes
+	instructionSet[SLOAD].gasCost = gasSLoadEIP2929
+	instructionSet[SSTORE].gasCost = gasSStoreEIP2929
+	instructionSet[BALANCE].gasCost = makeGasAccountCheckEIP2929(gasBalance)
+	instructionSet[EXTCODESIZE].gasCost = makeGasAccountCheckEIP2929(gasExtCodeSize)
+	instructionSet[EXTCODECOPY].gasCost = makeGasAccountCheckEIP2929(gasExtCodeCopy)
+	instructionSet[EXTCODEHASH].gasCost = makeGasAccountCheckEIP2929(gasExtCodeHash)
+	instructionSet[CALL].gasCost = makeCallVariantGasCallEIP2929(gasCall)
+	instructionSet[CALLCODE].gasCost = makeCallVariantGasCallEIP2929(gasCallCode)
+	instructionSet[DELEGATECALL].gasCost = makeCallVariantGasCallEIP2929(gasDelegateCall)
+	instructionSet[STATICCALL].gasCost = makeCallVariantGasCallEIP2929(gasStaticCall)
+	instructionSet[SELFDESTRUCT].gasCost = gasSuicideEIP2929
+	return instructionSet
+}
+
+// NewIstanbulInstructionSet returns the frontier, homestead, byzantium,
+// constantinople and istanbul instructions.
+func newIstanbulInstructionSet() [256]operation {
+	instructionSet := newConstantinopleInstructionSet()
+	instructionSet[SSTORE].gasCost = gasSStoreEIP2200
+	instructionSet[CHAINID] = operation{
+		execute:       opChainID,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	instructionSet[SELFBALANCE] = operation{
+		execute:       opSelfBalance,
+		gasCost:       constGasFunc(GasFastStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	return instructionSet
+}
+
 // NewConstantinopleInstructionSet returns the frontier, homestead
 // byzantium and contantinople instructions.
 func newConstantinopleInstructionSet() [256]operation {
@@ -163,6 +163,20 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// Forks returns the fork schedule of the local chain, with the activation of
+// pending forks projected from the clique period, and the fork identifiers
+// announced by the connected peers.
+func (api *PrivateAdminAPI) Forks() *ForkSchedule {
+	var (
+		chain    = api.eth.BlockChain()
+		schedule = NewForkSchedule(chain.Config(), chain.Genesis().Hash(), chain.CurrentHeader())
+	)
+	for _, p := range api.eth.protocolManager.peers.All() {
+		schedule.addPeer(p)
+	}
+	return schedule
+}
+
 func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	for _, b := range bs {
 		if !chain.HasBlock(b.Hash(), b.NumberU64()) {
@@ -236,24 +250,22 @@ func NewPublicDebugAPI(eth *GendChain) *PublicDebugAPI {
 }
 
 // DumpBlock retrieves the entire state of the database at a given block.
-func (api *PublicDebugAPI) DumpBlock(ctx context.Context, blockNr rpc.BlockNumber) (state.Dump, error) {
-	if blockNr == rpc.PendingBlockNumber {
+func (api *PublicDebugAPI) DumpBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (state.Dump, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok && blockNr == rpc.PendingBlockNumber {
 		// If we're dumping the pending state, we need to request
 		// both the pending block as well as the pending state from
 		// the miner and operate on those
 		_, stateDb := api.eth.miner.Pending()
 		return stateDb.RawDump(), nil
 	}
-	var block *types.Block
-	if blockNr == rpc.LatestBlockNumber {
-		block = api.eth.blockchain.CurrentBlock()
-	} else {
-		block = api.eth.blockchain.GetBlockByNumber(uint64(blockNr))
+	header, err := api.eth.ApiBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return state.Dump{}, err
 	}
-	if block == nil {
-		return state.Dump{}, fmt.Errorf("block #%d not found", blockNr)
+	if header == nil {
+		return state.Dump{}, fmt.Errorf("block %s not found", blockNrOrHash)
 	}
-	stateDb, err := api.eth.BlockChain().StateAt(block.Root())
+	stateDb, err := api.eth.BlockChain().StateAt(header.Root)
 	if err != nil {
 		return state.Dump{}, err
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/ChainAAS/gendchain/core/vm"
 	"github.com/ChainAAS/gendchain/eth/downloader"
 	"github.com/ChainAAS/gendchain/eth/gasprice"
+	"github.com/ChainAAS/gendchain/internal/ethapi"
 	"github.com/ChainAAS/gendchain/log"
 	"github.com/ChainAAS/gendchain/params"
 	"github.com/ChainAAS/gendchain/rpc"
@@ -65,6 +66,10 @@ func (b *EthApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock().Header(), nil
 	}
+	// Clique has no weaker notion of safety than finality, so both tags agree
+	if blockNr == rpc.FinalizedBlockNumber || blockNr == rpc.SafeBlockNumber {
+		return b.eth.blockchain.CurrentFinalizedHeader(), nil
+	}
 	return b.eth.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -82,7 +87,43 @@ func (b *EthApiBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock(), nil
 	}
-	return b.eth.blockchain.GetBlockByNumber(uint64(blockNr)), nil
+	if blockNr == rpc.FinalizedBlockNumber || blockNr == rpc.SafeBlockNumber {
+		header := b.eth.blockchain.CurrentFinalizedHeader()
+		return b.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
+	}
+	block := b.eth.blockchain.GetBlockByNumber(uint64(blockNr))
+	if block == nil {
+		return nil, b.prunedHistory(uint64(blockNr))
+	}
+	return block, nil
+}
+
+func (b *EthApiBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok {
+		return b.HeaderByNumber(ctx, blockNr)
+	}
+	hash, _ := blockNrOrHash.Hash()
+	header := b.eth.blockchain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, nil
+	}
+	if blockNrOrHash.RequireCanonical {
+		if canonical := b.eth.blockchain.GetHeaderByNumber(header.Number.Uint64()); canonical == nil || canonical.Hash() != hash {
+			return nil, ethapi.ErrBlockNotCanonical
+		}
+	}
+	return header, nil
+}
+
+func (b *EthApiBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok {
+		return b.BlockByNumber(ctx, blockNr)
+	}
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	return b.GetBlock(ctx, header.Hash())
 }
 
 func (b *EthApiBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
@@ -104,18 +145,38 @@ func (b *EthApiBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	return stateDb, header, err
 }
 
+func (b *EthApiBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	if blockNr, ok := blockNrOrHash.Number(); ok {
+		return b.StateAndHeaderByNumber(ctx, blockNr)
+	}
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, nil, err
+	}
+	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	return stateDb, header, err
+}
+
 func (b *EthApiBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return b.eth.blockchain.GetBlockByHash(hash), nil
+	block := b.eth.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		return nil, b.prunedHistoryByHash(hash)
+	}
+	return block, nil
 }
 
 func (b *EthApiBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	return b.eth.blockchain.GetReceiptsByHash(hash), nil
+	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
+	if receipts == nil {
+		return nil, b.prunedHistoryByHash(hash)
+	}
+	return receipts, nil
 }
 
 func (b *EthApiBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
 	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
 	if receipts == nil {
-		return nil, nil
+		return nil, b.prunedHistoryByHash(hash)
 	}
 	logs := make([][]*types.Log, len(receipts))
 	for i, receipt := range receipts {
@@ -124,6 +185,24 @@ func (b *EthApiBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*typ
 	return logs, nil
 }
 
+// prunedHistory returns a PrunedHistoryError if the body and receipts of
+// block number have fallen out of the history window, or nil otherwise.
+func (b *EthApiBackend) prunedHistory(number uint64) error {
+	if tail := b.eth.blockchain.HistoryTail(); number < tail {
+		return &ethapi.PrunedHistoryError{Tail: tail}
+	}
+	return nil
+}
+
+// prunedHistoryByHash is like prunedHistory for a known block hash.
+func (b *EthApiBackend) prunedHistoryByHash(hash common.Hash) error {
+	header := b.eth.blockchain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil
+	}
+	return b.prunedHistory(header.Number.Uint64())
+}
+
 func (b *EthApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(blockHash)
 }
@@ -227,6 +306,14 @@ func (b *EthApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *EthApiBackend) SuggestTipCap(ctx context.Context) (*big.Int, error) {
+	return b.gpo.SuggestTipCap(ctx)
+}
+
+func (b *EthApiBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blocks, lastBlock, rewardPercentiles)
+}
+
 func (b *EthApiBackend) ChainDb() common.Database {
 	return b.eth.ChainDb()
 }
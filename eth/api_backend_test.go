@@ -0,0 +1,189 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/hexutil"
+	"github.com/ChainAAS/gendchain/consensus/clique"
+	"github.com/ChainAAS/gendchain/core"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/core/vm"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/internal/ethapi"
+	"github.com/ChainAAS/gendchain/params"
+	"github.com/ChainAAS/gendchain/rpc"
+)
+
+func TestApiBackendNumberOrHash(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		engine  = clique.NewFaker()
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{testBank: {Balance: big.NewInt(1000000)}}}
+		genesis = gspec.MustCommit(db)
+		recv    = common.Address{0x01}
+	)
+	transfer := func(value int64) func(int, *core.BlockGen) {
+		return func(i int, gen *core.BlockGen) {
+			tx := types.NewTransaction(gen.TxNonce(testBank), recv, big.NewInt(value), params.TxGas, big.NewInt(1), nil)
+			tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testBankKey)
+			gen.AddTx(tx)
+		}
+	}
+	canon, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 2, transfer(1000))
+	fork, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 1, transfer(2000))
+
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.InsertChain(canon); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatal(err)
+	}
+	b := &EthApiBackend{eth: &GendChain{blockchain: chain}}
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		blockNrOrHash rpc.BlockNumberOrHash
+		balance       int64
+		err           error
+	}{
+		{rpc.BlockNumberOrHashWithNumber(1), 1000, nil},
+		{rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 2000, nil},
+		{rpc.BlockNumberOrHashWithHash(canon[0].Hash(), true), 1000, nil},
+		{rpc.BlockNumberOrHashWithHash(fork[0].Hash(), false), 2000, nil},
+		{rpc.BlockNumberOrHashWithHash(fork[0].Hash(), true), 0, ethapi.ErrBlockNotCanonical},
+	} {
+		statedb, header, err := b.StateAndHeaderByNumberOrHash(ctx, tt.blockNrOrHash)
+		if err != tt.err {
+			t.Errorf("%s: unexpected error: have %v, want %v", tt.blockNrOrHash, err, tt.err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+		if balance := statedb.GetBalance(recv); balance.Int64() != tt.balance {
+			t.Errorf("%s: unexpected balance: have %v, want %v", tt.blockNrOrHash, balance, tt.balance)
+		}
+		if block, err := b.BlockByNumberOrHash(ctx, tt.blockNrOrHash); err != nil || block.Hash() != header.Hash() {
+			t.Errorf("%s: unexpected block: %v", tt.blockNrOrHash, err)
+		}
+	}
+	if header, err := b.HeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(common.Hash{0x01}, true)); header != nil || err != nil {
+		t.Errorf("unexpected result for unknown hash: %v, %v", header, err)
+	}
+}
+
+func TestApiBackendSimulate(t *testing.T) {
+	var (
+		db       = ethdb.NewMemDatabase()
+		engine   = clique.NewFaker()
+		gspec    = &core.Genesis{Config: params.TestChainConfig}
+		genesis  = gspec.MustCommit(db)
+		contract = common.Address{0x02}
+		slot     = common.Hash{}
+	)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &EthApiBackend{eth: &GendChain{blockchain: chain, chainConfig: gspec.Config}}
+	ctx := context.Background()
+	latest := rpc.BlockNumberOrHashWithHash(genesis.Hash(), true)
+
+	// The contract increments slot 0, logs and returns the new value.
+	code := hexutil.Bytes(common.Hex2Bytes("6000546001018060005560005260006000a060206000f3"))
+	value := func(n int64) hexutil.Bytes { return common.BigToHash(big.NewInt(n)).Bytes() }
+	call := func(gas uint64) ethapi.CallArgs {
+		return ethapi.CallArgs{From: &testBank, To: &contract, Gas: (*hexutil.Uint64)(&gas)}
+	}
+	sim := func(calls ...ethapi.CallArgs) []ethapi.SimulateArgs {
+		args := make([]ethapi.SimulateArgs, len(calls))
+		for i, call := range calls {
+			args[i].CallArgs = call
+		}
+		return args
+	}
+	stateDiff := map[common.Hash]common.Hash{slot: common.BigToHash(big.NewInt(5))}
+	overrides := &ethapi.StateOverride{contract: {Code: &code, StateDiff: &stateDiff}}
+
+	gendchain := ethapi.NewPublicGendChainAPI(b)
+	results, err := gendchain.Simulate(ctx, sim(call(100000), call(params.TxGas+100), call(100000)), latest, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []struct {
+		ret    hexutil.Bytes
+		failed bool
+	}{{value(6), false}, {nil, true}, {value(7), false}} {
+		res := results[i]
+		if res.Failed != want.failed || !bytes.Equal(res.ReturnData, want.ret) {
+			t.Errorf("call %d: unexpected result: %+v", i, res)
+		}
+		if !res.Failed && (len(res.Logs) != 1 || res.Logs[0].Address != contract || res.Logs[0].TxIndex != uint(i)) {
+			t.Errorf("call %d: unexpected logs: %v", i, res.Logs)
+		}
+	}
+
+	// Overrides of a single call replace the storage and are not persisted.
+	storage := map[common.Hash]common.Hash{slot: common.BigToHash(big.NewInt(41))}
+	api := ethapi.NewPublicBlockChainAPI(b)
+	if ret, err := api.Call(ctx, call(100000), latest, &ethapi.StateOverride{contract: {Code: &code, State: &storage}}); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(ret, value(42)) {
+		t.Errorf("unexpected return value: %x", ret)
+	}
+	if ret, err := api.Call(ctx, call(100000), latest, nil); err != nil || len(ret) != 0 {
+		t.Errorf("unexpected result without overrides: %x, %v", ret, err)
+	}
+
+	// Signed transactions run from their sender, in order with the calls.
+	signer := types.MakeSigner(gspec.Config, genesis.Number(), genesis.Time())
+	signed := func(nonce uint64) ethapi.SimulateArgs {
+		tx := types.NewTransaction(nonce, contract, new(big.Int), 100000, gspec.Config.InitialBaseFee(), nil)
+		tx, _ = types.SignTx(tx, signer, testBankKey)
+		return ethapi.SimulateArgs{Tx: tx}
+	}
+	results, err = gendchain.Simulate(ctx, append([]ethapi.SimulateArgs{signed(0), signed(1)}, sim(call(100000))...), latest, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []hexutil.Bytes{value(6), value(7), value(8)} {
+		if res := results[i]; res.Failed || !bytes.Equal(res.ReturnData, want) {
+			t.Errorf("call %d: unexpected result: %+v", i, res)
+		}
+	}
+	if _, err := gendchain.Simulate(ctx, []ethapi.SimulateArgs{signed(1)}, latest, overrides); err == nil {
+		t.Error("expected error for transaction with future nonce")
+	}
+
+	// Writes to an overridden storage of a failed call are reverted.
+	overrides = &ethapi.StateOverride{contract: {Code: &code, State: &storage}}
+	results, err = gendchain.Simulate(ctx, sim(call(100000)), latest, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Run out of gas at LOG0, after the SSTORE.
+	outOfGas := uint64(results[0].GasUsed) - 7
+	results, err = gendchain.Simulate(ctx, sim(call(outOfGas), call(100000)), latest, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Failed {
+		t.Errorf("expected call 0 to fail: %+v", results[0])
+	}
+	if !bytes.Equal(results[1].ReturnData, value(42)) {
+		t.Errorf("unexpected return value after failed call: %x", results[1].ReturnData)
+	}
+
+	invalid := &ethapi.StateOverride{contract: {State: &storage, StateDiff: &stateDiff}}
+	if _, err := api.Call(ctx, call(100000), latest, invalid); err == nil {
+		t.Error("expected error for both state and stateDiff")
+	}
+}
@@ -116,7 +116,7 @@ func New(sctx *node.ServiceContext, config *Config) (*GendChain, error) {
 		chainConfig:    chainConfig,
 		eventMux:       sctx.EventMux,
 		accountManager: sctx.AccountManager,
-		engine:         clique.New(chainConfig.Clique, chainDb),
+		engine:         newCliqueEngine(chainConfig.Clique, config, chainDb),
 		shutdownChan:   make(chan bool),
 		stopDbUpgrade:  stopDbUpgrade,
 		networkId:      config.NetworkId,
@@ -144,7 +144,14 @@ func New(sctx *node.ServiceContext, config *Config) (*GendChain, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{
+			Disabled:           config.NoPruning,
+			TrieNodeLimit:      config.TrieCache,
+			TrieTimeLimit:      config.TrieTimeout,
+			SnapshotLimit:      config.SnapshotCache,
+			StateRetain:        config.StateRetain,
+			StatePruneInterval: config.StatePruneInterval,
+		}
 	)
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, vmConfig)
 	if err != nil {
@@ -153,8 +160,9 @@ func New(sctx *node.ServiceContext, config *Config) (*GendChain, error) {
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
-		if err := eth.blockchain.SetHead(compat.RewindTo); err != nil {
-			log.Error("Cannot set head during chain rewind", "rewind_to", compat.RewindTo, "err", err)
+		rewindTo := core.RewindHeight(eth.blockchain, compat)
+		if err := eth.blockchain.SetHead(rewindTo); err != nil {
+			log.Error("Cannot set head during chain rewind", "rewind_to", rewindTo, "err", err)
 		}
 		rawdb.WriteChainConfig(chainDb.GlobalTable(), genesisHash, chainConfig)
 	}
@@ -207,6 +215,14 @@ func makeExtraData(extra []byte) []byte {
 	return extra
 }
 
+// newCliqueEngine creates the clique engine of the chain, applying the local
+// policy of the node from config.
+func newCliqueEngine(chainConfig *params.CliqueConfig, config *Config, db common.Database) *clique.Clique {
+	engine := clique.New(chainConfig, db)
+	engine.SetEquivocationRemoval(config.MinerEquivocationRemoval)
+	return engine
+}
+
 // CreateDB creates the chain database.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (common.Database, error) {
 	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
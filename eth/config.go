@@ -20,6 +20,10 @@ var DefaultConfig = Config{
 	DatabaseCache: 768,
 	TrieCache:     256,
 	TrieTimeout:   60 * time.Minute,
+	SnapshotCache: 102,
+
+	StatePruneInterval: 10000,
+
 	MinerGasFloor: params.TargetGasLimit,
 	MinerGasCeil:  params.TargetGasLimit,
 	MinerGasPrice: nil,
@@ -55,6 +59,9 @@ type Config struct {
 	DatabaseCache      int
 	TrieCache          int
 	TrieTimeout        time.Duration
+	SnapshotCache      int    // Megabytes of memory to cache state snapshot entries (0 = no snapshots)
+	StateRetain        uint64 // Number of recent blocks whose state is kept by online pruning (0 = disabled)
+	StatePruneInterval uint64 // Number of blocks between online state pruning runs
 
 	// Mining-related options
 	Etherbase      common.Address `toml:",omitempty"`
@@ -66,6 +73,9 @@ type Config struct {
 	MinerRecommit  time.Duration
 	MinerNoverify  bool
 
+	// Propose the removal of signers caught sealing conflicting blocks
+	MinerEquivocationRemoval bool `toml:",omitempty"`
+
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
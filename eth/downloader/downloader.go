@@ -442,7 +442,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		d.committed = 0
 	}
 	// Initiate the sync using a concurrent header and content retrieval algorithm
-	d.queue.Prepare(origin+1, d.mode)
+	d.queue.Prepare(origin+1, d.mode, d.historyTail(pivot))
 	if d.syncInitHook != nil {
 		d.syncInitHook(origin, height)
 	}
@@ -1503,6 +1503,16 @@ func splitAroundPivot(pivot uint64, results []*fetchResult) (p *fetchResult, bef
 	return p, before, after
 }
 
+// historyTail returns the oldest block whose body and receipts are kept by the
+// local database once synced to pivot, or zero if it keeps all history.
+func (d *Downloader) historyTail(pivot uint64) uint64 {
+	db, ok := d.stateDB.(interface{ HistoryTailAt(head uint64) uint64 })
+	if !ok || pivot == 0 {
+		return 0
+	}
+	return db.HistoryTailAt(pivot)
+}
+
 func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *stateSync) error {
 	// Check for any early termination requests
 	if len(results) == 0 {
@@ -1517,6 +1527,13 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 		}
 	default:
 	}
+	// Skip blocks whose history is pruned, only their headers are kept
+	for len(results) > 0 && d.queue.isPruned(results[0].Header) {
+		results = results[1:]
+	}
+	if len(results) == 0 {
+		return nil
+	}
 	// Retrieve the a batch of results to import
 	first, last := results[0].Header, results[len(results)-1].Header
 	log.Info("Inserting fast-sync blocks", "items", len(results),
@@ -99,7 +99,7 @@ func (dl *downloadTester) makeChain(n int, seed byte, parent *types.Block, paren
 		}
 		// If the block number is multiple of 3, send a bonus transaction to the miner
 		if parent == dl.genesis && i%3 == 0 {
-			signer := types.MakeSigner(params.TestChainConfig, block.Number())
+			signer := types.MakeSigner(params.TestChainConfig, block.Number(), block.Time())
 			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(testAddress), common.Address{seed}, big.NewInt(1000), params.TxGas, nil, nil), signer, testKey)
 			if err != nil {
 				panic(err)
@@ -338,7 +338,10 @@ func (dl *downloadTester) InsertReceiptChain(blocks types.Blocks, receipts []typ
 			return i, errors.New("unknown owner")
 		}
 		if _, ok := dl.ownBlocks[blocks[i].ParentHash()]; !ok {
-			return i, errors.New("unknown parent")
+			// Parents with pruned history only have their header
+			if parent, ok := dl.ownHeaders[blocks[i].ParentHash()]; !ok || !dl.downloader.queue.isPruned(parent) {
+				return i, errors.New("unknown parent")
+			}
 		}
 		dl.ownBlocks[blocks[i].Hash()] = blocks[i]
 		dl.ownReceipts[blocks[i].Hash()] = receipts[i]
@@ -635,6 +638,51 @@ func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, targetBlocks+1)
 }
 
+// historyDB is a test database keeping bodies and receipts from tail on.
+type historyDB struct {
+	common.Database
+	tail uint64
+}
+
+func (db *historyDB) HistoryTailAt(head uint64) uint64 { return db.tail }
+
+// Tests that fast sync does not request the bodies and receipts of blocks
+// falling out of the local history window.
+func TestPrunedHistorySync(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+	tail := uint64(50)
+	tester.downloader.stateDB = &historyDB{Database: tester.stateDb, tail: tail}
+
+	targetBlocks := blockCacheItems - 15
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+
+	// The peer pruned the same history, requesting it would stall the sync.
+	for hash, header := range headers {
+		if n := header.Number.Uint64(); n > 0 && n < tail {
+			delete(blocks, hash)
+			delete(receipts, hash)
+		}
+	}
+	tester.newPeer("peer", 64, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if n := len(tester.ownHeaders); n != targetBlocks+1 {
+		t.Fatalf("synchronised headers mismatch: have %v, want %v", n, targetBlocks+1)
+	}
+	for hash, header := range tester.ownHeaders {
+		_, hasBlock := tester.ownBlocks[hash]
+		if n := header.Number.Uint64(); n > 0 && n < tail && hasBlock {
+			t.Errorf("block #%d: pruned block imported", n)
+		} else if n >= tail && !hasBlock {
+			t.Errorf("block #%d: block missing", n)
+		}
+	}
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling62(t *testing.T)     { testThrottling(t, 62, FullSync) }
@@ -7,6 +7,7 @@ import (
 	"github.com/ChainAAS/gendchain/core"
 	"github.com/ChainAAS/gendchain/core/rawdb"
 	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/ethdb"
 )
 
 // FakePeer is a mock downloader peer that operates on a local database instance
@@ -108,7 +109,9 @@ func (p *FakePeer) RequestBodies(hashes []common.Hash) error {
 	var txs [][]*types.Transaction
 	for _, hash := range hashes {
 		block := rawdb.ReadBlock(p.db, hash, *p.hc.GetBlockNumber(hash))
-
+		if block == nil {
+			continue // pruned history
+		}
 		txs = append(txs, block.Transactions())
 	}
 	p.dl.DeliverBodies(p.id, txs)
@@ -120,7 +123,11 @@ func (p *FakePeer) RequestBodies(hashes []common.Hash) error {
 func (p *FakePeer) RequestReceipts(hashes []common.Hash) error {
 	var receipts [][]*types.Receipt
 	for _, hash := range hashes {
-		receipts = append(receipts, rawdb.ReadRawReceipts(p.db.ReceiptTable(), hash, *p.hc.GetBlockNumber(hash)))
+		r, err := rawdb.ReadReceipts(p.db, hash, *p.hc.GetBlockNumber(hash), p.hc.Config())
+		if err == ethdb.ErrHistoryPruned {
+			continue
+		}
+		receipts = append(receipts, r)
 	}
 	p.dl.DeliverReceipts(p.id, receipts)
 	return nil
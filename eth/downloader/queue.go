@@ -76,6 +76,7 @@ type queue struct {
 	resultCache  []*fetchResult     // Downloaded but not yet delivered fetch results
 	resultOffset uint64             // Offset of the first cached fetch result in the block chain
 	resultSize   common.StorageSize // Approximate size of a block (exponential moving average)
+	historyTail  uint64             // Oldest block whose body and receipts are fetched, older ones are pruned
 
 	lock   *sync.Mutex
 	active *sync.Cond
@@ -125,6 +126,7 @@ func (q *queue) Reset() {
 
 	q.resultCache = make([]*fetchResult, blockCacheItems)
 	q.resultOffset = 0
+	q.historyTail = 0
 }
 
 // Close marks the end of the sync, unblocking WaitResults.
@@ -440,7 +442,7 @@ func (q *queue) ReserveHeaders(p *peerConnection, count int) *fetchRequest {
 // returns a flag whether empty blocks were queued requiring processing.
 func (q *queue) ReserveBodies(p *peerConnection, count int) (*fetchRequest, bool, error) {
 	isNoop := func(header *types.Header) bool {
-		return header.TxHash == types.EmptyRootHash && header.UncleHash == types.EmptyUncleHash
+		return header.TxHash == types.EmptyRootHash && header.UncleHash == types.EmptyUncleHash || q.isPruned(header)
 	}
 	q.lock.Lock()
 	defer q.lock.Unlock()
@@ -453,7 +455,7 @@ func (q *queue) ReserveBodies(p *peerConnection, count int) (*fetchRequest, bool
 // also returns a flag whether empty receipts were queued requiring importing.
 func (q *queue) ReserveReceipts(p *peerConnection, count int) (*fetchRequest, bool, error) {
 	isNoop := func(header *types.Header) bool {
-		return header.ReceiptHash == types.EmptyRootHash
+		return header.ReceiptHash == types.EmptyRootHash || q.isPruned(header)
 	}
 	q.lock.Lock()
 	defer q.lock.Unlock()
@@ -461,6 +463,12 @@ func (q *queue) ReserveReceipts(p *peerConnection, count int) (*fetchRequest, bo
 	return q.reserveHeaders(p, count, q.receiptTaskPool, q.receiptTaskQueue, q.receiptPendPool, q.receiptDonePool, isNoop)
 }
 
+// isPruned returns true if the body and receipts of header fall out of the
+// local history window and are not fetched.
+func (q *queue) isPruned(header *types.Header) bool {
+	return header.Number.Uint64() < q.historyTail
+}
+
 // reserveHeaders reserves a set of data download operations for a given peer,
 // skipping any previously failed ones. This method is a generic version used
 // by the individual special reservation functions.
@@ -856,8 +864,9 @@ func (q *queue) deliver(id string, taskPool map[common.Hash]*types.Header, taskQ
 }
 
 // Prepare configures the result cache to allow accepting and caching inbound
-// fetch results.
-func (q *queue) Prepare(offset uint64, mode SyncMode) {
+// fetch results. Bodies and receipts of blocks before historyTail are not
+// fetched during fast sync, as they would be pruned on import.
+func (q *queue) Prepare(offset uint64, mode SyncMode, historyTail uint64) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 
@@ -866,4 +875,8 @@ func (q *queue) Prepare(offset uint64, mode SyncMode) {
 		q.resultOffset = offset
 	}
 	q.mode = mode
+	q.historyTail = 0
+	if mode == FastSync {
+		q.historyTail = historyTail
+	}
 }
@@ -3,6 +3,7 @@ package filters
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ChainAAS/gendchain/common"
@@ -129,6 +130,27 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
+	// Resolve the finalized and safe tags through the backend
+	if f.begin == rpc.FinalizedBlockNumber.Int64() || f.begin == rpc.SafeBlockNumber.Int64() {
+		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
+		if header == nil || err != nil {
+			return nil, err
+		}
+		f.begin = header.Number.Int64()
+	}
+	if f.end == rpc.FinalizedBlockNumber.Int64() || f.end == rpc.SafeBlockNumber.Int64() {
+		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.end))
+		if header == nil || err != nil {
+			return nil, err
+		}
+		end = header.Number.Uint64()
+	}
+	// Refuse ranges over the limit of the calling client
+	if client, ok := rpc.AuthClientFromContext(ctx); ok && client.MaxLogsBlockRange > 0 && f.begin >= 0 && end >= uint64(f.begin) {
+		if blocks := end - uint64(f.begin) + 1; blocks > client.MaxLogsBlockRange {
+			return nil, &rpc.LimitExceededError{Message: fmt.Sprintf("block range of %d exceeds the limit of %d blocks", blocks, client.MaxLogsBlockRange)}
+		}
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
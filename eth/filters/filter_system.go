@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -177,6 +178,24 @@ func (es *EventSystem) SubscribeLogs(crit gendchain.FilterQuery, logs chan []*ty
 	} else {
 		to = rpc.BlockNumber(crit.ToBlock.Int64())
 	}
+	// the finalized and safe blocks are always in the past, resolve them to
+	// fixed block numbers
+	if from == rpc.FinalizedBlockNumber || from == rpc.SafeBlockNumber || to == rpc.FinalizedBlockNumber || to == rpc.SafeBlockNumber {
+		header, err := es.backend.HeaderByNumber(context.Background(), rpc.FinalizedBlockNumber)
+		if err != nil {
+			return nil, err
+		} else if header == nil {
+			return nil, errors.New("finalized block not found")
+		}
+		if from == rpc.FinalizedBlockNumber || from == rpc.SafeBlockNumber {
+			from = rpc.BlockNumber(header.Number.Int64())
+			crit.FromBlock = new(big.Int).Set(header.Number)
+		}
+		if to == rpc.FinalizedBlockNumber || to == rpc.SafeBlockNumber {
+			to = rpc.BlockNumber(header.Number.Int64())
+			crit.ToBlock = new(big.Int).Set(header.Number)
+		}
+	}
 
 	// only interested in pending logs
 	if from == rpc.PendingBlockNumber && to == rpc.PendingBlockNumber {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"math/rand"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
@@ -29,6 +30,7 @@ type testBackend struct {
 	pendingLogsFeed core.PendingLogsFeed
 	logsFeed        core.LogsFeed
 	chainFeed       core.ChainFeed
+	finalized       *types.Header
 }
 
 func (b *testBackend) ChainDb() common.Database {
@@ -38,6 +40,9 @@ func (b *testBackend) ChainDb() common.Database {
 func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	var hash common.Hash
 	var num uint64
+	if blockNr == rpc.FinalizedBlockNumber || blockNr == rpc.SafeBlockNumber {
+		return b.finalized, nil
+	}
 	if blockNr == rpc.LatestBlockNumber {
 		hash = rawdb.ReadHeadBlockHash(b.db.GlobalTable())
 		number := rawdb.ReadHeaderNumber(b.db.GlobalTable(), hash)
@@ -62,7 +67,7 @@ func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*type
 
 func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	if number := rawdb.ReadHeaderNumber(b.db.GlobalTable(), hash); number != nil {
-		return rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig), nil
+		return rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig)
 	}
 	return nil, nil
 }
@@ -72,7 +77,7 @@ func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types
 	if number == nil {
 		return nil, nil
 	}
-	receipts := rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig)
+	receipts, _ := rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig)
 
 	logs := make([][]*types.Log, len(receipts))
 	for i, receipt := range receipts {
@@ -269,7 +274,7 @@ func TestPendingTxFilter(t *testing.T) {
 func TestLogFilterCreation(t *testing.T) {
 	var (
 		db      = ethdb.NewMemDatabase()
-		backend = &testBackend{db: db}
+		backend = &testBackend{db: db, finalized: &types.Header{Number: big.NewInt(3)}}
 		api     = NewPublicFilterAPI(backend, false)
 
 		testCases = []struct {
@@ -292,6 +297,12 @@ func TestLogFilterCreation(t *testing.T) {
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(100)}, false},
 			// from block "higher" than to block
 			{FilterCriteria{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64()), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}, false},
+			// finalized block to new mined blocks
+			{FilterCriteria{FromBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64()), ToBlock: big.NewInt(rpc.LatestBlockNumber.Int64())}, true},
+			// block range to the safe block
+			{FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(rpc.SafeBlockNumber.Int64())}, true},
+			// from block "higher" than the finalized block
+			{FilterCriteria{FromBlock: big.NewInt(4), ToBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64())}, false},
 		}
 	)
 
@@ -304,6 +315,17 @@ func TestLogFilterCreation(t *testing.T) {
 			t.Errorf("expected testcase %d to fail with an error", i)
 		}
 	}
+
+	// The finalized block is resolved to its number when subscribing.
+	crit := gendchain.FilterQuery{FromBlock: big.NewInt(rpc.FinalizedBlockNumber.Int64()), ToBlock: big.NewInt(rpc.SafeBlockNumber.Int64())}
+	sub, err := api.events.SubscribeLogs(crit, make(chan []*types.Log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	if from, to := sub.f.logsCrit.FromBlock, sub.f.logsCrit.ToBlock; from.Int64() != 3 || to.Int64() != 3 {
+		t.Errorf("unexpected block range: %v - %v", from, to)
+	}
 }
 
 // TestInvalidLogFilterCreation tests whether invalid filter log criteria results in an error
@@ -577,3 +599,49 @@ func TestPendingLogsSubscription(t *testing.T) {
 		backend.pendingLogsFeed.Send(l)
 	}
 }
+
+func TestGetLogs_MaxBlockRange(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		backend = &testBackend{db: db}
+		server  = rpc.NewServer()
+	)
+	genesis := core.GenesisBlockForTesting(db, common.Address{}, big.NewInt(1000000))
+	chain, _ := core.GenerateChain(params.TestChainConfig, genesis, clique.NewFaker(), db, 20, func(int, *core.BlockGen) {})
+	for _, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db.GlobalTable(), block.Hash())
+	}
+	if err := server.RegisterName("eth", NewPublicFilterAPI(backend, false)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetAuth(&rpc.AuthConfig{Clients: []rpc.AuthClient{
+		{Name: "partner", APIKeys: []string{"key"}, MaxLogsBlockRange: 10},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := rpc.DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetHeader("X-API-Key", "key")
+
+	var logs []*types.Log
+	if err := client.Call(&logs, "eth_getLogs", map[string]interface{}{"fromBlock": "0xb"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, crit := range []map[string]interface{}{
+		{"fromBlock": "0x0", "toBlock": "0xa"},
+		{"fromBlock": "0xa"},
+	} {
+		err := client.Call(&logs, "eth_getLogs", crit)
+		if err, ok := err.(rpc.Error); !ok || err.ErrorCode() != -32005 {
+			t.Fatalf("%v: unexpected error: %v", crit, err)
+		}
+	}
+}
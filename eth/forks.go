@@ -0,0 +1,115 @@
+package eth
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/hexutil"
+	"github.com/ChainAAS/gendchain/core/forkid"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/params"
+)
+
+// ForkStatus is the activation status of a single fork at the current head.
+// Forks yet to come carry their activation projected from the clique period.
+type ForkStatus struct {
+	Name           string       `json:"name"`
+	Block          *hexutil.Big `json:"block,omitempty"`          // Scheduled activation block
+	Time           *hexutil.Big `json:"time,omitempty"`           // Scheduled activation time
+	Active         bool         `json:"active"`                   // Whether the fork rules apply at the head
+	ProjectedBlock *hexutil.Big `json:"projectedBlock,omitempty"` // Expected block of a pending time scheduled fork
+	ProjectedTime  *hexutil.Big `json:"projectedTime,omitempty"`  // Expected time of a pending block scheduled fork
+}
+
+// PeerForkStatus is the fork identifier announced by a connected peer, along
+// with the reason it mismatches the local schedule, if it does.
+type PeerForkStatus struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Version  int    `json:"version"`
+	ForkID   string `json:"forkId,omitempty"`
+	ForkNext uint64 `json:"forkNext,omitempty"`
+	Mismatch string `json:"mismatch,omitempty"`
+}
+
+// ForkSchedule is the fork schedule of the local chain and the fork identifiers
+// of the connected peers, as returned by admin_forks.
+type ForkSchedule struct {
+	Head     hexutil.Uint64   `json:"head"`
+	Time     hexutil.Uint64   `json:"time"`
+	Period   uint64           `json:"period"` // Clique block period used for projections, 0 if unknown
+	ForkID   string           `json:"forkId"`
+	ForkNext uint64           `json:"forkNext"`
+	Forks    []ForkStatus     `json:"forks"`
+	Peers    []PeerForkStatus `json:"peers"`
+}
+
+// NewForkSchedule assembles the fork schedule of a chain at the given head.
+func NewForkSchedule(config *params.ChainConfig, genesis common.Hash, head *types.Header) *ForkSchedule {
+	var (
+		number = head.Number.Uint64()
+		time   = head.Time.Uint64()
+		id     = forkid.NewID(config, genesis, number, time)
+	)
+	schedule := &ForkSchedule{
+		Head:     hexutil.Uint64(number),
+		Time:     hexutil.Uint64(time),
+		ForkID:   fmt.Sprintf("%#x", id.Hash),
+		ForkNext: id.Next,
+		Forks:    []ForkStatus{},
+		Peers:    []PeerForkStatus{},
+	}
+	if config.Clique != nil {
+		schedule.Period = config.Clique.Period
+	}
+	period := new(big.Int).SetUint64(schedule.Period)
+	for _, fork := range config.Forks() {
+		status := ForkStatus{
+			Name:   fork.Name,
+			Block:  (*hexutil.Big)(fork.Block),
+			Time:   (*hexutil.Big)(fork.Time),
+			Active: isActive(fork.Block, number) || isActive(fork.Time, time),
+		}
+		if !status.Active && period.Sign() > 0 {
+			if fork.Block != nil {
+				blocks := new(big.Int).Sub(fork.Block, head.Number)
+				status.ProjectedTime = (*hexutil.Big)(blocks.Mul(blocks, period).Add(blocks, head.Time))
+			}
+			if fork.Time != nil {
+				// Round up to the first block at or after the fork time
+				secs := new(big.Int).Sub(fork.Time, head.Time)
+				secs.Add(secs, new(big.Int).Sub(period, common.Big1))
+				status.ProjectedBlock = (*hexutil.Big)(secs.Div(secs, period).Add(secs, head.Number))
+			}
+		}
+		schedule.Forks = append(schedule.Forks, status)
+	}
+	return schedule
+}
+
+// addPeer adds the fork identifier of a connected peer to the schedule. Besides
+// incompatibilities reported by the handshake, peers agreeing on the passed
+// forks but announcing another next one are flagged too.
+func (s *ForkSchedule) addPeer(p *peer) {
+	info := p.Info()
+	status := PeerForkStatus{
+		ID:       p.id,
+		Name:     p.Name(),
+		Version:  info.Version,
+		ForkID:   info.ForkID,
+		ForkNext: info.ForkNext,
+	}
+	switch {
+	case info.ForkError != "":
+		status.Mismatch = info.ForkError
+	case info.ForkID == s.ForkID && info.ForkNext != s.ForkNext:
+		status.Mismatch = fmt.Sprintf("next fork at %d, local at %d", info.ForkNext, s.ForkNext)
+	}
+	s.Peers = append(s.Peers, status)
+}
+
+// isActive returns whether a fork scheduled at s is active at head.
+func isActive(s *big.Int, head uint64) bool {
+	return s != nil && s.Cmp(new(big.Int).SetUint64(head)) <= 0
+}
@@ -2,6 +2,7 @@ package gasprice
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"sort"
 	"sync"
@@ -9,6 +10,7 @@ import (
 	"github.com/ChainAAS/gendchain/log"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/consensus/misc"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/params"
 	"github.com/ChainAAS/gendchain/rpc"
@@ -18,6 +20,13 @@ var (
 	// Deprecated: use DefaultFn
 	Default         = new(big.Int).SetUint64(2 * params.Shannon)
 	DefaultMaxPrice = big.NewInt(500000 * params.Shannon)
+
+	// maxFeeHistory is the maximum number of blocks a fee history request may span.
+	maxFeeHistory = 1024
+
+	errInvalidPercentile = errors.New("invalid reward percentile")
+	errRequestBeyondHead = errors.New("request beyond head block")
+	errMissingReceipts   = errors.New("missing block receipts")
 )
 
 // DefaultFn returns a function to return the default gas price at a given block.
@@ -43,6 +52,7 @@ type Config struct {
 type OracleBackend interface {
 	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
 	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
 	ChainConfig() *params.ChainConfig
 }
 
@@ -187,11 +197,12 @@ func (gpo *Oracle) fetchMinBlockPrice(ctx context.Context, blockNum uint64, ch c
 		ch <- result{}
 		return
 	}
-	signer := types.MakeSigner(gpo.backend.ChainConfig(), new(big.Int).SetUint64(blockNum))
+	signer := types.MakeSigner(gpo.backend.ChainConfig(), block.Number(), block.Time())
 	ch <- result{price: minBlockPrice(ctx, signer, block)}
 }
 
 // minBlockPrice returns the lowest-priced, non-local transaction, or nil if none can be found.
+// After EIP-1559 the effective gas price, base fee plus tip, is compared.
 func minBlockPrice(ctx context.Context, signer types.Signer, block *types.Block) *big.Int {
 	var min *big.Int
 	for _, tx := range block.Transactions() {
@@ -199,13 +210,161 @@ func minBlockPrice(ctx context.Context, signer types.Signer, block *types.Block)
 		if err != nil || sender == block.Coinbase() {
 			continue
 		}
-		if min == nil || tx.CmpGasPrice(min) < 0 {
-			min = tx.GasPrice()
+		price := tx.GasPrice()
+		if baseFee := block.BaseFee(); baseFee != nil {
+			tip, err := tx.EffectiveGasTip(baseFee)
+			if err != nil {
+				continue
+			}
+			price = new(big.Int).Add(tip, baseFee)
+		}
+		if min == nil || price.Cmp(min) < 0 {
+			min = price
 		}
 	}
 	return min
 }
 
+// SuggestTipCap returns a tip per gas so that newly created dynamic fee
+// transactions have a very high chance to be included in the following blocks.
+// It is the suggested gas price less the base fee of the next block.
+func (gpo *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
+	price, err := gpo.SuggestPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, _ := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	config := gpo.backend.ChainConfig()
+	if head == nil || !config.IsLondon(new(big.Int).Add(head.Number, common.Big1), head.Time) {
+		return price, nil
+	}
+	tip := new(big.Int).Sub(price, misc.CalcBaseFee(config, head))
+	if tip.Sign() < 0 {
+		tip.SetUint64(0)
+	}
+	return tip, nil
+}
+
+// FeeHistory returns the base fee per gas and the gas used ratio of a range of
+// blocks ending at lastBlock, plus the base fee of the block following it. If
+// rewardPercentiles is set, the effective tips paid at those percentiles of
+// each block's gas are returned too, weighted by the gas limit of the
+// transactions. Blocks before London report a zero base fee.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	if blocks < 1 {
+		return common.Big0, nil, nil, nil, nil
+	}
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 || (i > 0 && p < rewardPercentiles[i-1]) {
+			return common.Big0, nil, nil, nil, errInvalidPercentile
+		}
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil || err != nil {
+		return common.Big0, nil, nil, nil, err
+	}
+	last := head.Number.Uint64()
+	if lastBlock >= 0 {
+		if uint64(lastBlock) > last {
+			return common.Big0, nil, nil, nil, errRequestBeyondHead
+		}
+		last = uint64(lastBlock)
+	}
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	var (
+		config   = gpo.backend.ChainConfig()
+		oldest   = last + 1 - uint64(blocks)
+		reward   = make([][]*big.Int, 0, blocks)
+		baseFee  = make([]*big.Int, 0, blocks+1)
+		gasRatio = make([]float64, 0, blocks)
+		header   *types.Header
+	)
+	for number := oldest; number <= last; number++ {
+		block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if block == nil || err != nil {
+			return common.Big0, nil, nil, nil, err
+		}
+		header = block.Header()
+		if fee := block.BaseFee(); fee != nil {
+			baseFee = append(baseFee, fee)
+		} else {
+			baseFee = append(baseFee, new(big.Int))
+		}
+		if block.GasLimit() > 0 {
+			gasRatio = append(gasRatio, float64(block.GasUsed())/float64(block.GasLimit()))
+		} else {
+			gasRatio = append(gasRatio, 0)
+		}
+		if len(rewardPercentiles) > 0 {
+			receipts, err := gpo.backend.GetReceipts(ctx, block.Hash())
+			if err != nil {
+				return common.Big0, nil, nil, nil, err
+			}
+			if len(receipts) != len(block.Transactions()) {
+				return common.Big0, nil, nil, nil, errMissingReceipts
+			}
+			reward = append(reward, blockRewards(block, receipts, rewardPercentiles))
+		}
+	}
+	// The base fee of the next block is already known
+	if config.IsLondon(new(big.Int).SetUint64(last+1), header.Time) {
+		baseFee = append(baseFee, misc.CalcBaseFee(config, header))
+	} else {
+		baseFee = append(baseFee, new(big.Int))
+	}
+	if len(rewardPercentiles) == 0 {
+		reward = nil
+	}
+	return new(big.Int).SetUint64(oldest), reward, baseFee, gasRatio, nil
+}
+
+// blockRewards returns the effective tips paid in the block at the given
+// percentiles of the gas used by its transactions, sorted by ascending tip.
+func blockRewards(block *types.Block, receipts types.Receipts, percentiles []float64) []*big.Int {
+	rewards := make([]*big.Int, len(percentiles))
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		for i := range rewards {
+			rewards[i] = new(big.Int)
+		}
+		return rewards
+	}
+	type txTip struct {
+		gas uint64
+		tip *big.Int
+	}
+	var (
+		tips  = make([]txTip, 0, len(txs))
+		total uint64
+	)
+	for i, tx := range txs {
+		tip, err := tx.EffectiveGasTip(block.BaseFee())
+		if err != nil {
+			tip = new(big.Int)
+		}
+		tips = append(tips, txTip{gas: receipts[i].GasUsed, tip: tip})
+		total += receipts[i].GasUsed
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].tip.Cmp(tips[j].tip) < 0 })
+
+	var idx int
+	sum := tips[0].gas
+	for i, p := range percentiles {
+		threshold := uint64(float64(total) * p / 100)
+		for sum < threshold && idx < len(tips)-1 {
+			idx++
+			sum += tips[idx].gas
+		}
+		rewards[i] = tips[idx].tip
+	}
+	return rewards
+}
+
 type bigIntArray []*big.Int
 
 func (s bigIntArray) Len() int           { return len(s) }
@@ -249,6 +249,7 @@ type testBackend struct {
 	config     *params.ChainConfig
 	lastHeader *types.Header
 	blocks     []*types.Block
+	receipts   map[common.Hash]types.Receipts
 }
 
 type block struct {
@@ -257,12 +258,13 @@ type block struct {
 }
 
 type tx struct {
-	price uint64
-	local bool
+	price   uint64
+	local   bool
+	gasUsed uint64 // defaults to params.TxGas
 }
 
 func newTestBackend(config *params.ChainConfig, blockSpec ...block) OracleBackend {
-	tb := &testBackend{config: config}
+	tb := &testBackend{config: config, receipts: make(map[common.Hash]types.Receipts)}
 	if tb.config == nil {
 		tb.config = params.MainnetChainConfig
 	}
@@ -271,7 +273,18 @@ func newTestBackend(config *params.ChainConfig, blockSpec ...block) OracleBacken
 	localAddr := crypto.PubkeyToAddress(localKey.PublicKey)
 	otherKey, _ := crypto.GenerateKey()
 	for i, b := range blockSpec {
-		gasUsed := uint64(len(b.txs)) * params.TxGas
+		var (
+			gasUsed  uint64
+			receipts types.Receipts
+		)
+		for _, tx := range b.txs {
+			used := tx.gasUsed
+			if used == 0 {
+				used = params.TxGas
+			}
+			gasUsed += used
+			receipts = append(receipts, &types.Receipt{GasUsed: used, CumulativeGasUsed: gasUsed})
+		}
 		gasLimit := gasUsed
 		if !b.full {
 			gasLimit += params.TxGas * 5
@@ -290,7 +303,9 @@ func newTestBackend(config *params.ChainConfig, blockSpec ...block) OracleBacken
 			}
 			txs = append(txs, transaction(0, tx.price, key))
 		}
-		tb.blocks = append(tb.blocks, types.NewBlock(header, txs, nil, nil))
+		block := types.NewBlock(header, txs, nil, receipts)
+		tb.blocks = append(tb.blocks, block)
+		tb.receipts[block.Hash()] = receipts
 	}
 	if l := len(tb.blocks); l > 0 {
 		tb.lastHeader = tb.blocks[len(tb.blocks)-1].Header()
@@ -318,6 +333,10 @@ func (b *testBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber
 	return nil, nil
 }
 
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
 func bigInt(i uint64) *big.Int {
 	return new(big.Int).SetUint64(i)
 }
@@ -326,3 +345,43 @@ func transaction(nonce uint64, gasPrice uint64, key *ecdsa.PrivateKey) *types.Tr
 	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), 100, bigInt(gasPrice), nil), types.HomesteadSigner{}, key)
 	return tx
 }
+
+func TestOracle_FeeHistory(t *testing.T) {
+	backend := newTestBackend(nil, block{
+		full: true,
+		txs:  []tx{{price: 10, gasUsed: 3 * params.TxGas}, {price: 30}},
+	}, block{
+		txs: []tx{{price: 20}},
+	}, block{})
+	o := NewOracle(backend, Config{Blocks: 1, Percentile: 60})
+
+	head, _ := backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	oldest, reward, baseFee, gasUsed, err := o.FeeHistory(context.Background(), 3, rpc.LatestBlockNumber, []float64{0, 60, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := head.Number.Uint64() - 2; oldest.Uint64() != want {
+		t.Errorf("oldest block mismatch: have %v, want %d", oldest, want)
+	}
+	if len(baseFee) != 4 || len(gasUsed) != 3 || len(reward) != 3 {
+		t.Fatalf("result length mismatch: %d base fees, %d ratios, %d rewards", len(baseFee), len(gasUsed), len(reward))
+	}
+	for i, fee := range baseFee {
+		if fee.Sign() != 0 {
+			t.Errorf("block %d: pre-london base fee %v, want 0", i, fee)
+		}
+	}
+	if gasUsed[0] != 1 || gasUsed[2] != 0 {
+		t.Errorf("gas used ratio mismatch: have %v", gasUsed)
+	}
+	// Percentiles are weighted by gas used, not by the transactions' gas limits
+	if reward[0][0].Uint64() != 10 || reward[0][1].Uint64() != 10 || reward[0][2].Uint64() != 30 {
+		t.Errorf("reward mismatch: have %v, want [10 10 30]", reward[0])
+	}
+	if reward[2][0].Sign() != 0 || reward[2][1].Sign() != 0 || reward[2][2].Sign() != 0 {
+		t.Errorf("empty block reward mismatch: have %v, want [0 0 0]", reward[2])
+	}
+	if _, _, _, _, err := o.FeeHistory(context.Background(), 1, rpc.LatestBlockNumber, []float64{50, 10}); err != errInvalidPercentile {
+		t.Errorf("percentile error mismatch: have %v, want %v", err, errInvalidPercentile)
+	}
+}
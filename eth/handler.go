@@ -12,6 +12,7 @@ import (
 	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/consensus"
 	"github.com/ChainAAS/gendchain/core"
+	"github.com/ChainAAS/gendchain/core/forkid"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/eth/downloader"
 	"github.com/ChainAAS/gendchain/eth/fetcher"
@@ -30,6 +31,10 @@ const (
 	// The number is referenced from the size of tx pool.
 	txChanSize = 16384
 
+	// chainChanSize is the size of channels listening to ChainEvent and
+	// ChainSideEvent for equivocation checks.
+	chainChanSize = 64
+
 	// The smallest subset of peers to broadcast to.
 	minBroadcastPeers = 4
 )
@@ -63,6 +68,10 @@ type ProtocolManager struct {
 	txsCh        chan core.NewTxsEvent
 	minedBlockCh chan interface{}
 
+	detector consensus.EquivocationDetector // Engine hook for conflicting seals, nil if unsupported
+	chainCh  chan core.ChainEvent
+	sideCh   chan core.ChainSideEvent
+
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
 	txsyncCh    chan *txsync
@@ -140,8 +149,16 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
 
+	manager.detector, _ = engine.(consensus.EquivocationDetector)
 	verifyHeader := func(header *types.Header) error {
-		return engine.VerifyHeader(blockchain, header)
+		if err := engine.VerifyHeader(blockchain, header); err != nil {
+			return err
+		}
+		// Competing blocks may never get imported, so check them on arrival
+		if manager.detector != nil {
+			manager.detector.ObserveHeader(header)
+		}
+		return nil
 	}
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().NumberU64()
@@ -193,6 +210,15 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.eventMux.Subscribe(pm.minedBlockCh, "eth.ProtocolManger")
 	go pm.minedBroadcastLoop()
 
+	// check imported blocks for conflicting seals
+	if pm.detector != nil {
+		pm.chainCh = make(chan core.ChainEvent, chainChanSize)
+		pm.sideCh = make(chan core.ChainSideEvent, chainChanSize)
+		pm.blockchain.SubscribeChainEvent(pm.chainCh, "eth.ProtocolManager")
+		pm.blockchain.SubscribeChainSideEvent(pm.sideCh, "eth.ProtocolManager")
+		go pm.equivocationLoop()
+	}
+
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
@@ -204,6 +230,10 @@ func (pm *ProtocolManager) Stop() {
 
 	pm.txpool.UnsubscribeNewTxsEvent(pm.txsCh) // quits txBroadcastLoop
 	pm.eventMux.Unsubscribe(pm.minedBlockCh)   // quits blockBroadcastLoop
+	if pm.detector != nil {
+		pm.blockchain.UnsubscribeChainEvent(pm.chainCh) // quits equivocationLoop
+		pm.blockchain.UnsubscribeChainSideEvent(pm.sideCh)
+	}
 
 	// Quit the sync loop.
 	// After this send has completed, no new peers will be accepted.
@@ -245,7 +275,15 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		number  = head.Number.Uint64()
 		td      = pm.blockchain.GetTd(hash, number)
 	)
-	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash()); err != nil {
+	var (
+		config = pm.blockchain.Config()
+		forkID = forkid.NewID(config, genesis.Hash(), number, head.Time.Uint64())
+	)
+	forkFilter := func(id forkid.ID) error {
+		current := pm.blockchain.CurrentHeader()
+		return forkid.Validate(config, genesis.Hash(), current.Number.Uint64(), current.Time.Uint64(), id)
+	}
+	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash(), forkID, forkFilter); err != nil {
 		p.Log().Debug("GendChain handshake failed", "err", err)
 		return err
 	}
@@ -422,7 +460,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			} else if err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
-			// Retrieve the requested block body, stopping if enough was found
+			// Retrieve the requested block body, skipping pruned history
+			if header := pm.blockchain.GetHeaderByHash(hash); header == nil || header.Number.Uint64() < pm.blockchain.HistoryTail() {
+				continue
+			}
 			if data := pm.blockchain.GetBodyRLP(hash); len(data) != 0 {
 				bodies = append(bodies, data)
 				bytes += len(data)
@@ -512,7 +553,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			} else if err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
-			// Retrieve the requested block's receipts, skipping if unknown to us
+			// Retrieve the requested block's receipts, skipping if unknown to us or pruned
+			if header := pm.blockchain.GetHeaderByHash(hash); header == nil || header.Number.Uint64() < pm.blockchain.HistoryTail() {
+				continue
+			}
 			results := pm.blockchain.GetReceiptsByHash(hash)
 			if results == nil {
 				if header := pm.blockchain.GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
@@ -675,6 +719,28 @@ func (pm *ProtocolManager) minedBroadcastLoop() {
 	}
 }
 
+// equivocationLoop hands every imported block, canonical or not, to the engine
+// to check for signers sealing conflicting blocks.
+func (pm *ProtocolManager) equivocationLoop() {
+	chainCh, sideCh := pm.chainCh, pm.sideCh
+	for chainCh != nil || sideCh != nil {
+		select {
+		case ev, ok := <-chainCh:
+			if !ok {
+				chainCh = nil
+				continue
+			}
+			pm.detector.ObserveHeader(ev.Block.Header())
+		case ev, ok := <-sideCh:
+			if !ok {
+				sideCh = nil
+				continue
+			}
+			pm.detector.ObserveHeader(ev.Block.Header())
+		}
+	}
+}
+
 func (pm *ProtocolManager) txBroadcastLoop() {
 	for event := range pm.txsCh {
 		pm.BroadcastTxs(event.Txs)
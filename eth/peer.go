@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/forkid"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/log"
 	"github.com/ChainAAS/gendchain/p2p"
@@ -44,9 +45,12 @@ const (
 // PeerInfo represents a short summary of the GendChain sub-protocol metadata known
 // about a connected peer.
 type PeerInfo struct {
-	Version    int      `json:"version"`    // GendChain protocol version negotiated
-	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the peer's blockchain
-	Head       string   `json:"head"`       // SHA3 hash of the peer's best owned block
+	Version    int      `json:"version"`             // GendChain protocol version negotiated
+	Difficulty *big.Int `json:"difficulty"`          // Total difficulty of the peer's blockchain
+	Head       string   `json:"head"`                // SHA3 hash of the peer's best owned block
+	ForkID     string   `json:"forkId,omitempty"`    // Fork identifier announced by the peer (eth/64 and later)
+	ForkNext   uint64   `json:"forkNext,omitempty"`  // Next fork block or time announced by the peer
+	ForkError  string   `json:"forkError,omitempty"` // Reason the peer's fork schedule mismatches ours, if it does
 }
 
 // knownHashes is a capped set of common.Hash safe for concurrent access.
@@ -135,6 +139,9 @@ type peer struct {
 	td   *big.Int
 	lock sync.RWMutex
 
+	forkID  *forkid.ID // Fork identifier announced by the peer, nil before eth/64
+	forkErr error      // Result of validating the announced fork identifier
+
 	knownTxs    knownHashes // Set of transaction hashes known to be known by this peer
 	knownBlocks knownHashes // Set of block hashes known to be known by this peer
 
@@ -213,11 +220,19 @@ func (p *peer) Close() {
 func (p *peer) Info() *PeerInfo {
 	hash, td := p.Head()
 
-	return &PeerInfo{
+	info := &PeerInfo{
 		Version:    p.version,
 		Difficulty: td,
 		Head:       hash.Hex(),
 	}
+	if p.forkID != nil {
+		info.ForkID = fmt.Sprintf("%#x", p.forkID.Hash)
+		info.ForkNext = p.forkID.Next
+	}
+	if p.forkErr != nil {
+		info.ForkError = p.forkErr.Error()
+	}
+	return info
 }
 
 // Head retrieves a copy of the current head hash and total difficulty of the
@@ -386,13 +401,26 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. From eth/64 on the fork
+// identifiers are exchanged too; a mismatching one is recorded and logged, but
+// doesn't fail the handshake.
+func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID, forkFilter func(forkid.ID) error) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
-	var status statusData // safe to read after two values have been received from errc
+	var status statusData64 // safe to read after two values have been received from errc
 
 	go func() {
+		if p.version >= eth64 {
+			errc <- p2p.Send(p.rw, StatusMsg, &statusData64{
+				ProtocolVersion: uint32(p.version),
+				NetworkId:       network,
+				TD:              td,
+				CurrentBlock:    head,
+				GenesisBlock:    genesis,
+				ForkID:          forkID,
+			})
+			return
+		}
 		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
 			ProtocolVersion: uint32(p.version),
 			NetworkId:       network,
@@ -417,10 +445,18 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		}
 	}
 	p.td, p.head = status.TD, status.CurrentBlock
+	if p.version >= eth64 {
+		p.forkID = &status.ForkID
+		if p.forkErr = forkFilter(status.ForkID); p.forkErr != nil {
+			p.Log().Warn("Peer fork schedule mismatch", "forkid", fmt.Sprintf("%#x", status.ForkID.Hash), "next", status.ForkID.Next, "err", p.forkErr)
+		}
+	}
 	return nil
 }
 
-func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
+// readStatus reads the status message of the remote peer. Before eth/64 the
+// message has no fork identifier, which is left empty in status.
+func (p *peer) readStatus(network uint64, status *statusData64, genesis common.Hash) (err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
 		return err
@@ -432,7 +468,21 @@ func (p *peer) readStatus(network uint64, status *statusData, genesis common.Has
 		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
 	}
 	// Decode the handshake and make sure everything matches
-	if err := msg.Decode(&status); err != nil {
+	if p.version >= eth64 {
+		err = msg.Decode(status)
+	} else {
+		var legacy statusData
+		if err = msg.Decode(&legacy); err == nil {
+			*status = statusData64{
+				ProtocolVersion: legacy.ProtocolVersion,
+				NetworkId:       legacy.NetworkId,
+				TD:              legacy.TD,
+				CurrentBlock:    legacy.CurrentBlock,
+				GenesisBlock:    legacy.GenesisBlock,
+			}
+		}
+	}
+	if err != nil {
 		return errResp(ErrDecode, "msg %v: %v", msg, err)
 	}
 	if status.GenesisBlock != genesis {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/forkid"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/crypto"
 	"github.com/ChainAAS/gendchain/eth/downloader"
@@ -24,6 +25,56 @@ var testAccount, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6
 func TestStatusMsgErrors62(t *testing.T) { testStatusMsgErrors(t, 62) }
 func TestStatusMsgErrors63(t *testing.T) { testStatusMsgErrors(t, 63) }
 
+// Tests that from eth/64 on a peer announcing a mismatching fork identifier is
+// accepted, but flagged in its peer info.
+func TestForkIDMismatch64(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	var (
+		genesis = pm.blockchain.Genesis()
+		head    = pm.blockchain.CurrentHeader()
+		td      = pm.blockchain.GetTd(head.Hash(), head.Number.Uint64())
+		local   = forkid.NewID(pm.blockchain.Config(), genesis.Hash(), head.Number.Uint64(), head.Time.Uint64())
+	)
+	defer pm.Stop()
+
+	for i, test := range []struct {
+		id      forkid.ID
+		wantErr error
+	}{
+		{local, nil},
+		{forkid.ID{Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}}, forkid.ErrLocalIncompatibleOrStale},
+	} {
+		p, _ := newTestPeer(fmt.Sprintf("peer %d", i), eth64, pm, false)
+		status := statusData64{uint32(eth64), DefaultConfig.NetworkId, td, head.Hash(), genesis.Hash(), local}
+		if err := p2p.ExpectMsg(p.app, StatusMsg, &status); err != nil {
+			t.Fatalf("test %d: status recv: %v", i, err)
+		}
+		status.ForkID = test.id
+		if err := p2p.Send(p.app, StatusMsg, &status); err != nil {
+			t.Fatalf("test %d: status send: %v", i, err)
+		}
+
+		// Wait for the peer to be registered, then check its fork status
+		var info *PeerInfo
+		for start := time.Now(); time.Since(start) < 2*time.Second; time.Sleep(10 * time.Millisecond) {
+			if peer := pm.peers.Peer(p.peer.id); peer != nil {
+				info = peer.Info()
+				break
+			}
+		}
+		if info == nil {
+			t.Fatalf("test %d: peer not registered", i)
+		}
+		if want := fmt.Sprintf("%#x", test.id.Hash); info.ForkID != want {
+			t.Errorf("test %d: fork ID mismatch: have %s, want %s", i, info.ForkID, want)
+		}
+		if (test.wantErr == nil && info.ForkError != "") || (test.wantErr != nil && info.ForkError != test.wantErr.Error()) {
+			t.Errorf("test %d: fork error mismatch: have %q, want %v", i, info.ForkError, test.wantErr)
+		}
+		p.close()
+	}
+}
+
 func testStatusMsgErrors(t *testing.T, protocol int) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
 	var (
@@ -0,0 +1,861 @@
+package ethdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/edsrzf/mmap-go"
+	"github.com/golang/snappy"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	ErrUnknownCompression = errors.New("ethdb: unknown compression")
+)
+
+const (
+	// CompressedFileSegmentMagic is the magic number at the beginning of the compressed file segment.
+	CompressedFileSegmentMagic = "ETH2"
+
+	// CompressedFileSegmentBlockIndexOffsetSize is the size of the block index offset, in bytes.
+	CompressedFileSegmentBlockIndexOffsetSize = 8
+
+	// CompressedFileSegmentBlockCountSize is the size of the data block count, in bytes.
+	CompressedFileSegmentBlockCountSize = 8
+
+	// CompressedFileSegmentHeaderSize is the total size of the fixed length CompressedFileSegment header.
+	CompressedFileSegmentHeaderSize = 0 +
+		len(CompressedFileSegmentMagic) +
+		FileSegmentChecksumSize +
+		CompressedFileSegmentBlockIndexOffsetSize +
+		CompressedFileSegmentBlockCountSize +
+		FileSegmentIndexOffsetSize +
+		FileSegmentIndexCountSize +
+		FileSegmentIndexCapacitySize
+
+	// CompressedFileSegmentIndexElemSize is the size of a key index slot: the
+	// key hash followed by the position of the key/value pair.
+	CompressedFileSegmentIndexElemSize = 16
+
+	// DefaultCompressedFileSegmentBlockSize is the uncompressed size after which
+	// a data block is closed and compressed.
+	DefaultCompressedFileSegmentBlockSize = 64 * 1024
+
+	// MaxCompressedFileSegmentBlockSize is the largest uncompressed size of a
+	// compressed data block. Larger blocks, holding oversized values, are stored
+	// uncompressed, so a corrupt size is rejected before allocating the block.
+	MaxCompressedFileSegmentBlockSize = 1024 * DefaultCompressedFileSegmentBlockSize
+
+	// CompressedFileSegmentBlockCacheSize is the number of decompressed data
+	// blocks kept per open segment, so that lookups of nearby keys don't
+	// decompress the same block again.
+	CompressedFileSegmentBlockCacheSize = 4
+)
+
+// Compression is the codec used to compress the data blocks of a file segment.
+type Compression uint8
+
+const (
+	// CompressionNone leaves data uncompressed. Compactors write ETH1 segments.
+	CompressionNone Compression = iota
+	CompressionSnappy
+	CompressionZstd
+)
+
+// ParseCompression returns the compression with the given name.
+func ParseCompression(s string) (Compression, error) {
+	switch s {
+	case "", "none":
+		return CompressionNone, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	case "zstd":
+		return CompressionZstd, nil
+	default:
+		return CompressionNone, fmt.Errorf("ethdb: unknown compression %q", s)
+	}
+}
+
+// String returns the name of the compression.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionSnappy:
+		return "snappy"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+}
+
+// zstdDecoder is shared by all segments, it is safe for concurrent DecodeAll calls.
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxCompressedFileSegmentBlockSize))
+
+// Ensure implementation implements interface.
+var _ Segment = (*CompressedFileSegment)(nil)
+
+// CompressedFileSegment represents an immutable key/value file segment for a
+// table whose key/value pairs are stored in compressed data blocks.
+//
+// The data blocks follow the header, each one framed by its compression, its
+// uncompressed and its compressed length. They are followed by the block
+// index, holding the offset of every block, and the key index. The key index
+// is a hash map of key hashes to the block and the offset within the block of
+// the key/value pair, so lookups only decompress the block holding the key.
+type CompressedFileSegment struct {
+	name   string     // segment name
+	path   string     // on-disk path
+	data   []byte     // memory-mapped data
+	file   *os.File   // file backing data
+	blocks *lru.Cache // decompressed blocks by index
+}
+
+// NewCompressedFileSegment returns a new instance of CompressedFileSegment.
+func NewCompressedFileSegment(name, path string) *CompressedFileSegment {
+	return &CompressedFileSegment{
+		name: name,
+		path: path,
+	}
+}
+
+// Open opens and initializes the compressed file segment.
+func (s *CompressedFileSegment) Open() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		log.Error("Cannot open compressed file segment", "path", s.path, "err", err)
+		return err
+	}
+	s.file = file
+
+	// Memory-map data.
+	data, err := mmap.Map(file, mmap.RDONLY, 0)
+	if err != nil {
+		log.Error("Cannot mmap compressed file segment", "path", s.path, "err", err)
+		file.Close()
+		return err
+	}
+	s.data = []byte(data)
+	s.blocks, _ = lru.New(CompressedFileSegmentBlockCacheSize)
+
+	// Ensure header information is valid.
+	if len(data) < CompressedFileSegmentHeaderSize {
+		s.Close()
+		return errors.New("ethdb: file header too short")
+	} else if string(data[:len(CompressedFileSegmentMagic)]) != CompressedFileSegmentMagic {
+		s.Close()
+		return errors.New("ethdb: invalid ethdb file")
+	} else if s.BlockIndexOffset() > s.IndexOffset() || s.IndexOffset() > int64(len(data)) {
+		s.Close()
+		return errors.New("ethdb: index offset out of bound")
+	} else if len(s.BlockIndex()) != s.BlockCount()*8 || len(s.Index()) != s.Cap()*CompressedFileSegmentIndexElemSize {
+		s.Close()
+		return errors.New("ethdb: unexpected index size")
+	}
+	return nil
+}
+
+// Close closes the file and its mmap.
+func (s *CompressedFileSegment) Close() (err error) {
+	if s.blocks != nil {
+		s.blocks.Purge()
+		s.blocks = nil
+	}
+	if s.data != nil {
+		err = (*mmap.MMap)(&s.data).Unmap()
+		s.data = nil
+	}
+	if s.file != nil {
+		if ferr := s.file.Close(); ferr != nil && err == nil {
+			err = ferr
+		}
+		s.file = nil
+	}
+	return
+}
+
+// Name returns the name of the segment.
+func (s *CompressedFileSegment) Name() string { return s.name }
+
+// Path returns the path of the segment.
+func (s *CompressedFileSegment) Path() string { return s.path }
+
+// Size returns the size of the underlying data file.
+func (s *CompressedFileSegment) Size() int {
+	return len(s.data)
+}
+
+// Data returns the underlying mmap data.
+func (s *CompressedFileSegment) Data() []byte {
+	return s.data
+}
+
+// Checksum returns the checksum written to the segment file.
+func (s *CompressedFileSegment) Checksum() []byte {
+	if len(s.data) < len(CompressedFileSegmentMagic)+FileSegmentChecksumSize {
+		return nil
+	}
+	return s.data[4:12]
+}
+
+// header returns the header field at the given position after the checksum.
+func (s *CompressedFileSegment) header(i int) uint64 {
+	return binary.BigEndian.Uint64(s.data[len(CompressedFileSegmentMagic)+FileSegmentChecksumSize+i*8:])
+}
+
+// BlockIndexOffset returns the file offset where the block index starts.
+func (s *CompressedFileSegment) BlockIndexOffset() int64 {
+	if s.data == nil {
+		return -1
+	}
+	return int64(s.header(0))
+}
+
+// BlockCount returns the number of data blocks in the file.
+func (s *CompressedFileSegment) BlockCount() int {
+	if s.data == nil {
+		return 0
+	}
+	return int(s.header(1))
+}
+
+// IndexOffset returns the file offset where the key index starts.
+func (s *CompressedFileSegment) IndexOffset() int64 {
+	if s.data == nil {
+		return -1
+	}
+	return int64(s.header(2))
+}
+
+// Len returns the number of keys in the file.
+func (s *CompressedFileSegment) Len() int {
+	if s.data == nil {
+		return 0
+	}
+	return int(s.header(3))
+}
+
+// Cap returns the capacity of the key index.
+func (s *CompressedFileSegment) Cap() int {
+	if s.data == nil {
+		return 0
+	}
+	return int(s.header(4))
+}
+
+// BlockIndex returns the byte slice containing the block index.
+func (s *CompressedFileSegment) BlockIndex() []byte {
+	if s.data == nil {
+		return nil
+	}
+	return s.data[s.BlockIndexOffset():s.IndexOffset()]
+}
+
+// Index returns the byte slice containing the key index.
+func (s *CompressedFileSegment) Index() []byte {
+	if s.data == nil {
+		return nil
+	}
+	return s.data[s.IndexOffset():]
+}
+
+// BlockOffset returns the file offset of the i-th data block.
+func (s *CompressedFileSegment) BlockOffset(i int) int64 {
+	return int64(binary.BigEndian.Uint64(s.BlockIndex()[i*8:]))
+}
+
+// Block returns the uncompressed contents of the i-th data block, along with
+// the compression it was stored with.
+func (s *CompressedFileSegment) Block(i int) ([]byte, Compression, error) {
+	if i < 0 || i >= s.BlockCount() {
+		return nil, CompressionNone, fmt.Errorf("ethdb: block %d out of range", i)
+	}
+	offset := s.BlockOffset(i)
+	if offset < int64(CompressedFileSegmentHeaderSize) || offset >= s.BlockIndexOffset() {
+		return nil, CompressionNone, fmt.Errorf("ethdb: block %d offset out of bound: %d", i, offset)
+	}
+	return decodeBlock(s.data[offset:s.BlockIndexOffset()])
+}
+
+// cachedBlock returns the uncompressed contents of the i-th data block, from
+// the block cache if possible. Uncompressed blocks reference the mmap data
+// and are never cached.
+func (s *CompressedFileSegment) cachedBlock(i int) ([]byte, error) {
+	if block, ok := s.blocks.Get(i); ok {
+		return block.([]byte), nil
+	}
+	block, compression, err := s.Block(i)
+	if err != nil {
+		return nil, err
+	} else if compression != CompressionNone {
+		s.blocks.Add(i, block)
+	}
+	return block, nil
+}
+
+// Has returns true if the key exists.
+func (s *CompressedFileSegment) Has(key []byte) (bool, error) {
+	value, err := s.get(key)
+	return value != nil, err
+}
+
+// Get returns the value of the given key.
+func (s *CompressedFileSegment) Get(key []byte) ([]byte, error) {
+	value, err := s.get(key)
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return nil, common.ErrNotFound
+	}
+	return common.CopyBytes(value), nil
+}
+
+// get returns the value of the given key, or nil if the key doesn't exist.
+// The value may reference the underlying mmap data.
+func (s *CompressedFileSegment) get(key []byte) ([]byte, error) {
+	capacity := uint64(s.Cap())
+	if capacity == 0 {
+		return nil, nil
+	}
+	mask := capacity - 1
+
+	idx := s.Index()
+	hash := hashKey(key)
+	pos := hash & mask
+
+	for d := uint64(0); ; d++ {
+		// Exit if empty slot found.
+		elem := idx[pos*CompressedFileSegmentIndexElemSize:]
+		currHash, loc := binary.BigEndian.Uint64(elem), binary.BigEndian.Uint64(elem[8:])
+		if loc == 0 {
+			return nil, nil
+		}
+
+		// Exit if distance exceeds current slot.
+		if d > dist(currHash, pos, capacity, mask) {
+			return nil, nil
+		}
+
+		// Only decompress the block if the hashes match.
+		if currHash == hash {
+			block, err := s.cachedBlock(int(loc>>32) - 1)
+			if err != nil {
+				log.Error("Cannot read block in compressed file segment", "path", s.path, "key", fmt.Sprintf("%x", key), "err", err)
+				return nil, err
+			}
+			curr, value, _, err := readKeyValue(block, int(loc&0xffffffff))
+			if err != nil {
+				log.Error("Cannot read key in compressed file segment", "path", s.path, "key", fmt.Sprintf("%x", key), "err", err)
+				return nil, err
+			}
+			if bytes.Equal(curr, key) {
+				return value, nil
+			}
+		}
+		pos = (pos + 1) & mask
+	}
+}
+
+// Iterator returns an iterator for iterating over all key/value pairs.
+func (s *CompressedFileSegment) Iterator() SegmentIterator {
+	return &CompressedFileSegmentIterator{segment: s}
+}
+
+// Ensure implementation implements interface.
+var _ SegmentIterator = (*CompressedFileSegmentIterator)(nil)
+
+// CompressedFileSegmentIterator sequentially iterates over a CompressedFileSegment's
+// key/value pairs, decompressing one data block at a time. Keys and values are
+// only valid until the next call to Next().
+type CompressedFileSegmentIterator struct {
+	segment *CompressedFileSegment
+	block   int    // index of the next block to decompress
+	data    []byte // current uncompressed block
+	offset  int    // offset within the current block
+	err     error
+
+	key   []byte
+	value []byte
+}
+
+// Close releases the iterator and returns any error encountered while
+// reading the data blocks.
+func (itr *CompressedFileSegmentIterator) Close() error {
+	itr.segment, itr.data, itr.offset = nil, nil, 0
+	itr.key, itr.value = nil, nil
+	return itr.err
+}
+
+// Key returns the current key. Must be called after Next().
+func (itr *CompressedFileSegmentIterator) Key() []byte { return itr.key }
+
+// Value returns the current key. Must be called after Next().
+func (itr *CompressedFileSegmentIterator) Value() []byte { return itr.value }
+
+// Next reads the next key/value pair into the buffer.
+func (itr *CompressedFileSegmentIterator) Next() bool {
+	if itr.segment == nil || itr.err != nil {
+		return false
+	}
+
+	// Move to the next non-empty block once the current one is exhausted.
+	for itr.offset >= len(itr.data) {
+		if itr.block >= itr.segment.BlockCount() {
+			return false
+		}
+		if itr.data, _, itr.err = itr.segment.Block(itr.block); itr.err != nil {
+			return false
+		}
+		itr.block, itr.offset = itr.block+1, 0
+	}
+
+	var n int
+	if itr.key, itr.value, n, itr.err = readKeyValue(itr.data, itr.offset); itr.err != nil {
+		return false
+	}
+	itr.offset += n
+	return true
+}
+
+// CompressedFileSegmentEncoder represents an encoder for building an ethdb.CompressedFileSegment.
+type CompressedFileSegmentEncoder struct {
+	f       *os.File
+	flushed bool
+	zstd    *zstd.Encoder
+
+	offset       int64
+	block        []byte  // uncompressed data of the current block
+	blockOffsets []int64 // file offsets of the written blocks
+	elems        []compressedFileSegmentIndexElem
+
+	// Filename of file segment to encode.
+	Path string
+
+	// Compression used for the data blocks.
+	Compression Compression
+
+	// Uncompressed size after which a data block is written out.
+	BlockSize int
+}
+
+// NewCompressedFileSegmentEncoder returns a new instance of CompressedFileSegmentEncoder.
+func NewCompressedFileSegmentEncoder(path string, compression Compression) *CompressedFileSegmentEncoder {
+	return &CompressedFileSegmentEncoder{
+		Path:        path,
+		Compression: compression,
+		BlockSize:   DefaultCompressedFileSegmentBlockSize,
+	}
+}
+
+// Open opens and initializes the output file segment.
+func (enc *CompressedFileSegmentEncoder) Open() (err error) {
+	if enc.f != nil {
+		return errors.New("ethdb: file already open")
+	}
+	switch enc.Compression {
+	case CompressionNone, CompressionSnappy:
+	case CompressionZstd:
+		if enc.zstd, err = zstd.NewWriter(nil); err != nil {
+			return err
+		}
+	default:
+		return ErrUnknownCompression
+	}
+	if enc.f, err = os.OpenFile(enc.Path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666); err != nil {
+		return err
+	}
+
+	// Write magic & leave space for checksum & offsets.
+	if _, err := enc.f.Write([]byte(CompressedFileSegmentMagic)); err != nil {
+		enc.Close()
+		return err
+	} else if _, err := enc.f.Write(make([]byte, CompressedFileSegmentHeaderSize-len(CompressedFileSegmentMagic))); err != nil {
+		enc.Close()
+		return err
+	}
+	enc.offset = int64(CompressedFileSegmentHeaderSize)
+
+	return nil
+}
+
+// Close closes the file handle. File must be flushed before calling close.
+func (enc *CompressedFileSegmentEncoder) Close() error {
+	if enc.zstd != nil {
+		enc.zstd.Close()
+		enc.zstd = nil
+	}
+	if enc.f != nil {
+		if err := enc.f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeKeyValue appends framed key & value byte slices to the current block
+// and records their position. The block is written out once it is full.
+func (enc *CompressedFileSegmentEncoder) EncodeKeyValue(key, value []byte) error {
+	if len(enc.block) > 0 && len(enc.block) >= enc.BlockSize {
+		if err := enc.writeBlock(); err != nil {
+			return err
+		}
+	}
+	if uint64(len(enc.block)) > 0xffffffff {
+		return errors.New("ethdb: compressed file segment block too large")
+	}
+	enc.elems = append(enc.elems, compressedFileSegmentIndexElem{
+		hash: hashKey(key),
+		loc:  uint64(len(enc.blockOffsets)+1)<<32 | uint64(len(enc.block)),
+	})
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(len(key)))
+	enc.block = append(append(enc.block, buf[:n]...), key...)
+	n = binary.PutUvarint(buf, uint64(len(value)))
+	enc.block = append(append(enc.block, buf[:n]...), value...)
+	return nil
+}
+
+// Flush writes out the last block, then the block index, the key index and
+// the checksum.
+func (enc *CompressedFileSegmentEncoder) Flush() error {
+	if enc.flushed {
+		return errors.New("ethdb: file index already flushed")
+	}
+	enc.flushed = true
+
+	if len(enc.block) > 0 {
+		if err := enc.writeBlock(); err != nil {
+			return fmt.Errorf("ethdb: cannot write block: %s", err)
+		}
+	}
+	if err := enc.writeIndex(); err != nil {
+		return fmt.Errorf("ethdb: cannot write index: %s", err)
+	} else if err := enc.writeChecksum(); err != nil {
+		return fmt.Errorf("ethdb: cannot write checksum: %s", err)
+	} else if err := enc.f.Sync(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeBlock compresses and writes out the current block. Blocks that don't
+// shrink are stored uncompressed.
+func (enc *CompressedFileSegmentEncoder) writeBlock() error {
+	compression, payload := enc.Compression, enc.block
+	if len(enc.block) > MaxCompressedFileSegmentBlockSize {
+		compression = CompressionNone
+	}
+	switch compression {
+	case CompressionSnappy:
+		payload = snappy.Encode(nil, enc.block)
+	case CompressionZstd:
+		payload = enc.zstd.EncodeAll(enc.block, nil)
+	}
+	if len(payload) >= len(enc.block) {
+		compression, payload = CompressionNone, enc.block
+	}
+
+	buf := make([]byte, 1+2*binary.MaxVarintLen64)
+	buf[0] = byte(compression)
+	n := 1 + binary.PutUvarint(buf[1:], uint64(len(enc.block)))
+	n += binary.PutUvarint(buf[n:], uint64(len(payload)))
+
+	enc.blockOffsets = append(enc.blockOffsets, enc.offset)
+	if err := enc.write(buf[:n]); err != nil {
+		return err
+	} else if err := enc.write(payload); err != nil {
+		return err
+	}
+	enc.block = enc.block[:0]
+	return nil
+}
+
+func (enc *CompressedFileSegmentEncoder) write(b []byte) error {
+	n, err := enc.f.Write(b)
+	enc.offset += int64(n)
+	return err
+}
+
+func (enc *CompressedFileSegmentEncoder) writeIndex() error {
+	// Write block index.
+	blockIndexOffset := enc.offset
+	buf := make([]byte, 8)
+	for _, offset := range enc.blockOffsets {
+		binary.BigEndian.PutUint64(buf, uint64(offset))
+		if err := enc.write(buf); err != nil {
+			return err
+		}
+	}
+
+	// Build key index in-memory from the recorded hashes.
+	indexOffset := enc.offset
+	idx := newCompressedFileSegmentEncoderIndex(enc, len(enc.elems))
+	for _, elem := range enc.elems {
+		if err := idx.insert(elem); err != nil {
+			return err
+		}
+	}
+	if _, err := idx.WriteTo(enc.f); err != nil {
+		return err
+	}
+
+	// Write offsets, lengths & capacity to the header.
+	hdr := make([]byte, CompressedFileSegmentHeaderSize-len(CompressedFileSegmentMagic)-FileSegmentChecksumSize)
+	binary.BigEndian.PutUint64(hdr[0:8], uint64(blockIndexOffset))
+	binary.BigEndian.PutUint64(hdr[8:16], uint64(len(enc.blockOffsets)))
+	binary.BigEndian.PutUint64(hdr[16:24], uint64(indexOffset))
+	binary.BigEndian.PutUint64(hdr[24:32], uint64(len(enc.elems)))
+	binary.BigEndian.PutUint64(hdr[32:40], uint64(idx.capacity()))
+	if _, err := enc.f.Seek(int64(len(CompressedFileSegmentMagic)+FileSegmentChecksumSize), io.SeekStart); err != nil {
+		return err
+	} else if _, err := enc.f.Write(hdr); err != nil {
+		return err
+	} else if err := enc.f.Sync(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (enc *CompressedFileSegmentEncoder) writeChecksum() error {
+	buf, err := ChecksumFileSegment(enc.Path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := enc.f.Seek(int64(len(CompressedFileSegmentMagic)), io.SeekStart); err != nil {
+		return err
+	} else if _, err := enc.f.Write(buf); err != nil {
+		return err
+	} else if err := enc.f.Sync(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readKey reads back the key at the given location from the written blocks.
+func (enc *CompressedFileSegmentEncoder) readKey(loc uint64) ([]byte, error) {
+	i := int(loc>>32) - 1
+	start := enc.blockOffsets[i]
+	end := enc.offset
+	if i+1 < len(enc.blockOffsets) {
+		end = enc.blockOffsets[i+1]
+	}
+	buf := make([]byte, end-start)
+	if _, err := enc.f.ReadAt(buf, start); err != nil {
+		return nil, err
+	}
+	block, _, err := decodeBlock(buf)
+	if err != nil {
+		return nil, err
+	}
+	key, _, _, err := readKeyValue(block, int(loc&0xffffffff))
+	return key, err
+}
+
+// compressedFileSegmentIndexElem is a key index slot of a compressed file segment.
+type compressedFileSegmentIndexElem struct {
+	hash uint64 // hash of the key
+	loc  uint64 // block number plus one in the upper, offset in block in the lower 32 bits
+}
+
+// compressedFileSegmentEncoderIndex represents a fixed-length RHH-based hash map
+// of key hashes. Keys are only read back from disk on hash collisions.
+type compressedFileSegmentEncoderIndex struct {
+	enc   *CompressedFileSegmentEncoder
+	mask  uint64
+	elems []compressedFileSegmentIndexElem
+}
+
+// newCompressedFileSegmentEncoderIndex returns a new instance of compressedFileSegmentEncoderIndex.
+func newCompressedFileSegmentEncoderIndex(enc *CompressedFileSegmentEncoder, n int) *compressedFileSegmentEncoderIndex {
+	// Determine maximum capacity by padding length and finding next power of 2.
+	const loadFactor = 90
+	capacity := pow2(uint64((n * 100) / loadFactor))
+
+	return &compressedFileSegmentEncoderIndex{
+		enc:   enc,
+		mask:  capacity - 1,
+		elems: make([]compressedFileSegmentIndexElem, capacity),
+	}
+}
+
+// WriteTo writes the index to w. Implements io.WriterTo.
+func (idx *compressedFileSegmentEncoderIndex) WriteTo(w io.Writer) (n int64, err error) {
+	buf := make([]byte, CompressedFileSegmentIndexElemSize)
+	for _, elem := range idx.elems {
+		binary.BigEndian.PutUint64(buf[0:8], elem.hash)
+		binary.BigEndian.PutUint64(buf[8:16], elem.loc)
+
+		nn, err := w.Write(buf)
+		if n += int64(nn); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// capacity returns the computed capacity based on the initial count.
+func (idx *compressedFileSegmentEncoderIndex) capacity() int {
+	return len(idx.elems)
+}
+
+// insert adds the element to the index.
+func (idx *compressedFileSegmentEncoderIndex) insert(elem compressedFileSegmentIndexElem) error {
+	pos := elem.hash & idx.mask
+	capacity := uint64(len(idx.elems))
+
+	var d uint64
+	for {
+		// Exit empty slot exists.
+		if idx.elems[pos].loc == 0 {
+			idx.elems[pos] = elem
+			return nil
+		}
+
+		// Return an error if a duplicate key exists.
+		if curr := idx.elems[pos]; curr.hash == elem.hash {
+			a, err := idx.enc.readKey(curr.loc)
+			if err != nil {
+				return err
+			}
+			b, err := idx.enc.readKey(elem.loc)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(a, b) {
+				return errors.New("ethdb: duplicate key written to file segment")
+			}
+		}
+
+		// Swap if current element has a lower probe distance.
+		tmp := dist(idx.elems[pos].hash, pos, capacity, idx.mask)
+		if tmp < d {
+			elem, idx.elems[pos], d = idx.elems[pos], elem, tmp
+		}
+
+		// Move position forward.
+		pos = (pos + 1) & idx.mask
+		d++
+	}
+}
+
+// decodeBlock decompresses the data block at the start of data.
+func decodeBlock(data []byte) ([]byte, Compression, error) {
+	if len(data) < 1 {
+		return nil, CompressionNone, io.ErrUnexpectedEOF
+	}
+	compression := Compression(data[0])
+	size, n := binary.Uvarint(data[1:])
+	if n <= 0 {
+		return nil, compression, io.ErrUnexpectedEOF
+	}
+	length, m := binary.Uvarint(data[1+n:])
+	if m <= 0 || uint64(len(data)-1-n-m) < length {
+		return nil, compression, io.ErrUnexpectedEOF
+	}
+	payload := data[1+n+m : 1+n+m+int(length)]
+	if compression != CompressionNone && size > MaxCompressedFileSegmentBlockSize {
+		return nil, compression, fmt.Errorf("ethdb: block size too large: %d", size)
+	}
+
+	var block []byte
+	var err error
+	switch compression {
+	case CompressionNone:
+		block = payload
+	case CompressionSnappy:
+		if dlen, err := snappy.DecodedLen(payload); err != nil {
+			return nil, compression, err
+		} else if uint64(dlen) != size {
+			return nil, compression, fmt.Errorf("ethdb: block size mismatch: %d != %d", dlen, size)
+		}
+		block, err = snappy.Decode(nil, payload)
+	case CompressionZstd:
+		block, err = zstdDecoder.DecodeAll(payload, make([]byte, 0, size))
+	default:
+		return nil, compression, ErrUnknownCompression
+	}
+	if err != nil {
+		return nil, compression, err
+	} else if uint64(len(block)) != size {
+		return nil, compression, fmt.Errorf("ethdb: block size mismatch: %d != %d", len(block), size)
+	}
+	return block, compression, nil
+}
+
+// readKeyValue reads the framed key/value pair at offset within block,
+// returning the number of bytes read.
+func readKeyValue(block []byte, offset int) (key, value []byte, n int, err error) {
+	if offset > len(block) {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+	data := block[offset:]
+
+	klen, sz := binary.Uvarint(data)
+	if sz <= 0 || uint64(len(data)-sz) < klen {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+	key, data, n = data[sz:sz+int(klen)], data[sz+int(klen):], sz+int(klen)
+
+	vlen, sz := binary.Uvarint(data)
+	if sz <= 0 || uint64(len(data)-sz) < vlen {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+	value, n = data[sz:sz+int(vlen)], n+sz+int(vlen)
+	return key, value, n, nil
+}
+
+// VerifyCompressedFileSegment checks that every data block of s decompresses
+// and that every key index slot points at a key with the recorded hash. It
+// returns the number of blocks per compression.
+func VerifyCompressedFileSegment(s *CompressedFileSegment) (map[Compression]int, error) {
+	// Collect the used index slots, ordered by position.
+	var elems []compressedFileSegmentIndexElem
+	idx := s.Index()
+	for i := 0; i < s.Cap(); i++ {
+		elem := idx[i*CompressedFileSegmentIndexElemSize:]
+		if loc := binary.BigEndian.Uint64(elem[8:]); loc != 0 {
+			elems = append(elems, compressedFileSegmentIndexElem{hash: binary.BigEndian.Uint64(elem), loc: loc})
+		}
+	}
+	if len(elems) != s.Len() {
+		return nil, fmt.Errorf("index holds %d keys, want %d", len(elems), s.Len())
+	}
+	sort.Slice(elems, func(i, j int) bool { return elems[i].loc < elems[j].loc })
+
+	// Decompress every block once and check the slots pointing into it.
+	blocks := make(map[Compression]int)
+	for i := 0; i < s.BlockCount(); i++ {
+		if i > 0 && s.BlockOffset(i) <= s.BlockOffset(i-1) {
+			return nil, fmt.Errorf("block %d: offset out of order", i)
+		}
+		block, compression, err := s.Block(i)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %s", i, err)
+		}
+		blocks[compression]++
+
+		for ; len(elems) > 0 && int(elems[0].loc>>32)-1 == i; elems = elems[1:] {
+			key, _, _, err := readKeyValue(block, int(elems[0].loc&0xffffffff))
+			if err != nil {
+				return nil, fmt.Errorf("block %d: key at %d: %s", i, elems[0].loc&0xffffffff, err)
+			} else if hashKey(key) != elems[0].hash {
+				return nil, fmt.Errorf("block %d: key at %d: hash mismatch", i, elems[0].loc&0xffffffff)
+			}
+		}
+	}
+	if len(elems) > 0 {
+		return nil, fmt.Errorf("index slot points at missing block %d", int(elems[0].loc>>32)-1)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,319 @@
+package ethdb_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/ChainAAS/gendchain/common"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+)
+
+func TestCompressedFileSegment_Get(t *testing.T) {
+	for _, compression := range []ethdb.Compression{ethdb.CompressionNone, ethdb.CompressionSnappy, ethdb.CompressionZstd} {
+		t.Run(compression.String(), func(t *testing.T) {
+			path := MustTempFile()
+			defer os.Remove(path)
+
+			// Encode compressible key/values across several blocks.
+			enc := ethdb.NewCompressedFileSegmentEncoder(path, compression)
+			enc.BlockSize = 256
+			if err := enc.Open(); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 100; i++ {
+				if err := enc.EncodeKeyValue([]byte{'k', byte(i)}, bytes.Repeat([]byte{byte(i)}, 100)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.Flush(); err != nil {
+				t.Fatal(err)
+			} else if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Ensure the file is detected as a compressed segment.
+			if typ, err := ethdb.SegmentFileType(path); err != nil {
+				t.Fatal(err)
+			} else if typ != ethdb.SegmentETH2 {
+				t.Fatalf("unexpected segment type: %s", typ)
+			}
+
+			// Open as compressed file segment.
+			s := ethdb.NewCompressedFileSegment("test", path)
+			if err := s.Open(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if s.BlockCount() < 2 {
+				t.Fatalf("expected multiple blocks, got %d", s.BlockCount())
+			} else if s.Len() != 100 {
+				t.Fatalf("unexpected length: %d", s.Len())
+			}
+
+			// Fetch existing keys.
+			for i := 0; i < 100; i++ {
+				if v, err := s.Get([]byte{'k', byte(i)}); err != nil {
+					t.Fatal(err)
+				} else if !bytes.Equal(v, bytes.Repeat([]byte{byte(i)}, 100)) {
+					t.Fatalf("unexpected value for key %d: %x", i, v)
+				}
+			}
+
+			// Fetch them again from cached blocks, which callers can't modify.
+			for i := 99; i >= 0; i-- {
+				if v, err := s.Get([]byte{'k', byte(i)}); err != nil {
+					t.Fatal(err)
+				} else if !bytes.Equal(v, bytes.Repeat([]byte{byte(i)}, 100)) {
+					t.Fatalf("unexpected cached value for key %d: %x", i, v)
+				} else {
+					v[0] ^= 0xff
+				}
+				if v, err := s.Get([]byte{'k', byte(i)}); err != nil || v[0] != byte(i) {
+					t.Fatalf("cached value modified for key %d: %x / %v", i, v, err)
+				}
+			}
+
+			// Fetch unknown key.
+			if v, err := s.Get([]byte("no_such_key")); err != common.ErrNotFound {
+				t.Fatalf("unexpected error: %s", err)
+			} else if v != nil {
+				t.Fatalf("expected nil value, got %q", v)
+			} else if ok, err := s.Has([]byte("no_such_key")); err != nil || ok {
+				t.Fatalf("unexpected has: %v, %v", ok, err)
+			}
+
+			// Verify blocks are stored with the requested compression.
+			if blocks, err := ethdb.VerifyCompressedFileSegment(s); err != nil {
+				t.Fatal(err)
+			} else if blocks[compression] != s.BlockCount() {
+				t.Fatalf("unexpected block compressions: %v", blocks)
+			} else if err := ethdb.VerifyFileSegment(path); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCompressedFileSegment_DuplicateKey(t *testing.T) {
+	path := MustTempFile()
+	defer os.Remove(path)
+
+	enc := ethdb.NewCompressedFileSegmentEncoder(path, ethdb.CompressionSnappy)
+	if err := enc.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+
+	if err := enc.EncodeKeyValue([]byte("foo"), []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := enc.EncodeKeyValue([]byte("foo"), []byte("baz")); err != nil {
+		t.Fatal(err)
+	} else if err := enc.Flush(); err == nil {
+		t.Fatal("expected duplicate key error")
+	}
+}
+
+// Ensure ethdb.CompressedFileSegment can fetch keys using randomized test data.
+func TestCompressedFileSegment_Quick(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+
+	const maxCount = 10000
+	const maxKeyLen = 2000
+	const maxValueLen = 10000
+
+	quick.Check(func(keys, values [][]byte, compression ethdb.Compression) bool {
+		path := MustTempFile()
+		defer os.Remove(path)
+
+		// Write data to file.
+		if err := EncodeToCompressedFileSegment(path, keys, values, compression); err != nil {
+			t.Fatal(err)
+		}
+
+		// Open as file.
+		s := ethdb.NewCompressedFileSegment("test", path)
+		if err := s.Open(); err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+
+		// Verify all key/value pairs exist.
+		for i := range keys {
+			if v, err := s.Get(keys[i]); err != nil {
+				t.Fatal(err)
+			} else if !bytes.Equal(values[i], v) {
+				t.Fatalf("value mismatch: (key=%q) expected %q, got %q", keys[i], values[i], v)
+			}
+		}
+
+		// Verify we can iterate them in order.
+		itr, i := s.Iterator(), 0
+		for ; itr.Next(); i++ {
+			if !bytes.Equal(itr.Key(), keys[i]) {
+				t.Fatalf("iterator key mismatch:\nexpected %x\ngot %x", keys[i], itr.Key())
+			} else if !bytes.Equal(itr.Value(), values[i]) {
+				t.Fatal("iterator value mismatch")
+			}
+		}
+		if err := itr.Close(); err != nil {
+			t.Fatal(err)
+		} else if i != len(keys) {
+			t.Fatal("short iterator")
+		}
+
+		return true
+	}, &quick.Config{
+		MaxCount: 10,
+		Values: func(args []reflect.Value, rand *rand.Rand) {
+			n := rand.Intn(maxCount-1) + 1
+			args[0] = reflect.ValueOf(generateKeys(n, 1, maxKeyLen-1, rand))
+			args[1] = reflect.ValueOf(generateValues(n, 0, maxValueLen, rand))
+			args[2] = reflect.ValueOf(ethdb.Compression(rand.Intn(3)))
+		},
+	})
+}
+
+func TestFileSegmentCompactor_Compression(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	tbl := ethdb.NewTable("test", dir, ethdb.NewBlockNumberPartitioner(1000))
+	tbl.MinCompactionAge = 0 // compact immediately
+	tbl.MinMutableSegmentCount = 1
+	tbl.SegmentCompactor = &ethdb.FileSegmentCompactor{Compression: ethdb.CompressionZstd}
+	if err := tbl.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	if err := tbl.Put(numHashKey('b', 200, common.Hash{}), []byte("foo")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Put(numHashKey('b', 1500, common.Hash{}), []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the first segment was compacted into a compressed file segment.
+	segments := tbl.SegmentSlice()
+	if len(segments) != 2 {
+		t.Fatalf("unexpected segment count: %d", len(segments))
+	} else if _, ok := segments[0].(*ethdb.CompressedFileSegment); !ok {
+		t.Fatalf("expected compressed file segment(0), got %T", segments[0])
+	}
+	if v, err := tbl.Get(numHashKey('b', 200, common.Hash{})); err != nil {
+		t.Fatal(err)
+	} else if string(v) != `foo` {
+		t.Fatalf("unexpected value: %q", v)
+	}
+
+	// Uncompacting the segment restores an LDB segment.
+	ldb, err := tbl.SegmentCompactor.UncompactSegment(context.Background(), "test", segments[0], ethdb.EngineLevelDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ldb.Close()
+	if v, err := ldb.Get(numHashKey('b', 200, common.Hash{})); err != nil {
+		t.Fatal(err)
+	} else if string(v) != `foo` {
+		t.Fatalf("unexpected value: %q", v)
+	}
+}
+
+// Ensure a corrupt block size is reported as an error, without allocating it.
+func TestCompressedFileSegment_CorruptBlockSize(t *testing.T) {
+	for _, compression := range []ethdb.Compression{ethdb.CompressionSnappy, ethdb.CompressionZstd} {
+		for _, size := range []uint64{1 << 62, ethdb.MaxCompressedFileSegmentBlockSize + 1, ethdb.MaxCompressedFileSegmentBlockSize} {
+			path := MustTempFile()
+			defer os.Remove(path)
+
+			var keys, values [][]byte
+			for i := 0; i < 10; i++ {
+				keys, values = append(keys, []byte{'k', byte(i)}), append(values, bytes.Repeat([]byte{byte(i)}, 100))
+			}
+			if err := EncodeToCompressedFileSegment(path, keys, values, compression); err != nil {
+				t.Fatal(err)
+			}
+			MustSetFirstBlockSize(t, path, size)
+
+			s := ethdb.NewCompressedFileSegment("test", path)
+			if err := s.Open(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Get(keys[0]); err == nil {
+				t.Fatalf("%s/%d: expected get error", compression, size)
+			} else if _, err := s.Has(keys[0]); err == nil {
+				t.Fatalf("%s/%d: expected has error", compression, size)
+			}
+			s.Close()
+		}
+	}
+}
+
+// MustSetFirstBlockSize rewrites the uncompressed size of the first data block
+// of the single block compressed file segment at path.
+func MustSetFirstBlockSize(tb testing.TB, path string, size uint64) {
+	tb.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	field := func(i int) []byte {
+		return data[len(ethdb.CompressedFileSegmentMagic)+ethdb.FileSegmentChecksumSize+i*8:]
+	}
+	if n := binary.BigEndian.Uint64(field(1)); n != 1 {
+		tb.Fatalf("unexpected block count: %d", n)
+	}
+
+	// Replace the size following the compression of the block, then shift the
+	// block and key index offsets by the change in length.
+	offset := ethdb.CompressedFileSegmentHeaderSize + 1
+	_, n := binary.Uvarint(data[offset:])
+	buf := make([]byte, binary.MaxVarintLen64)
+	buf = buf[:binary.PutUvarint(buf, size)]
+	data = append(data[:offset:offset], append(buf, data[offset+n:]...)...)
+	for _, i := range []int{0, 2} {
+		binary.BigEndian.PutUint64(field(i), binary.BigEndian.Uint64(field(i))+uint64(len(buf)-n))
+	}
+	if err := ioutil.WriteFile(path, data, 0666); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// EncodeToCompressedFileSegment encodes a set of key/value pairs to an
+// ethdb.CompressedFileSegment at path, using small blocks.
+func EncodeToCompressedFileSegment(path string, keys, values [][]byte, compression ethdb.Compression) error {
+	// Build file segment.
+	enc := ethdb.NewCompressedFileSegmentEncoder(path, compression)
+	enc.BlockSize = 4096
+	if err := enc.Open(); err != nil {
+		return err
+	}
+	defer enc.Close()
+
+	// Write all keys.
+	for i := range keys {
+		if err := enc.EncodeKeyValue(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+
+	// Flush all data.
+	if err := enc.Flush(); err != nil {
+		return err
+	} else if err := enc.Close(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,24 +1,74 @@
 package ethdb
 
+import "time"
+
 // Configuration defaults.
 const (
 	DefaultMaxOpenSegmentCount = 10
+
+	// DefaultBlockCacheSize is the default size of the cache of ranged reads
+	// from the archive, in megabytes.
+	DefaultBlockCacheSize = 256
 )
 
 type Config struct {
+	// Archive backend: "s3", "dir" or "http". Defaults to "s3" if an
+	// endpoint and bucket are set, and to no archive otherwise.
+	Archive string `toml:",omitempty"`
+
 	// S3 archive options
 	Endpoint        string `toml:",omitempty"`
 	Bucket          string `toml:",omitempty"`
 	AccessKeyID     string `toml:",omitempty"`
 	SecretAccessKey string `toml:",omitempty"`
 
+	// Cold directory archive options
+	ArchiveDir string `toml:",omitempty"`
+
+	// HTTP object store archive options
+	ArchiveURL   string `toml:",omitempty"`
+	ArchiveToken string `toml:",omitempty"`
+
+	// Download archived segments as a whole instead of reading them with
+	// ranged reads, and size of the ranged read cache in megabytes.
+	FullDownload   bool `toml:",omitempty"`
+	BlockCacheSize int  `toml:",omitempty"`
+
+	// Disk space in megabytes for local copies of archived segments, purged
+	// least recently used first. Zero purges copies once their segment closes.
+	LocalCacheSize int `toml:",omitempty"`
+
+	// Number of blocks per segment. Must match the partition size the
+	// database was created with.
+	PartitionSize uint64 `toml:",omitempty"`
+
+	// Number of recent blocks whose bodies and receipts are kept. Older
+	// segments are dropped locally and kept only in the archive. Zero keeps
+	// all history.
+	HistoryWindow uint64 `toml:",omitempty"`
+
 	// Per-table LRU cache settings.
 	MaxOpenSegmentCount int `toml:",omitempty"`
+
+	// Compression of compacted segments: "none", "snappy" or "zstd".
+	Compression string `toml:",omitempty"`
+
+	// Engine of mutable segments: "leveldb" or "pebble". Existing segments
+	// of another engine are converted when the database is opened. Blank
+	// keeps existing segments and creates LevelDB ones.
+	Engine string `toml:",omitempty"`
+
+	// Time between background scrubs of immutable segments, negative disables
+	// scrubbing, and whether scrubs compute the checksum of archived copies.
+	ScrubInterval time.Duration `toml:",omitempty"`
+	ScrubArchive  bool          `toml:",omitempty"`
 }
 
 // NewConfig returns a new instance of Config with defaults set.
 func NewConfig() Config {
 	return Config{
 		MaxOpenSegmentCount: DefaultMaxOpenSegmentCount,
+		BlockCacheSize:      DefaultBlockCacheSize,
+		ScrubInterval:       DefaultScrubInterval,
 	}
 }
@@ -2,11 +2,15 @@ package ethdb
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -41,6 +45,10 @@ const (
 	// DefaultMinCompactionAge is the minimum age after creation before an LDB
 	// segment can be compacted into a file segment.
 	DefaultMinCompactionAge = 1 * time.Minute
+
+	// MinHistoryWindow is the minimum number of recent blocks whose bodies and
+	// receipts are kept when pruning history.
+	MinHistoryWindow = 128
 )
 
 // DB is the top-level database and contains a mixture of LevelDB & File storage layers.
@@ -65,6 +73,29 @@ type DB struct {
 
 	SegmentOpener    SegmentOpener
 	SegmentCompactor SegmentCompactor
+
+	// Engine of the mutable segments. Existing segments of another engine
+	// are converted when the database is opened. Blank keeps the engine of
+	// existing segments and creates LevelDB segments.
+	Engine Engine
+
+	// Key the table manifests are signed with. Loaded from, or generated to,
+	// the manifest key file of the database if nil.
+	ManifestKey []byte
+
+	// Time between background scrubs of the immutable segments. Zero disables
+	// scrubbing.
+	ScrubInterval time.Duration
+
+	// Compute the checksum of archived copies when scrubbing.
+	ScrubArchive bool
+
+	// Number of recent blocks whose bodies and receipts are kept by
+	// PruneHistory. Zero keeps all history.
+	HistoryWindow uint64
+
+	cancel context.CancelFunc // stops the scrubber
+	done   chan struct{}      // closed once the scrubber stops
 }
 
 // NewDB returns a new instance of DB.
@@ -91,6 +122,16 @@ func (db *DB) Open() error {
 		return err
 	}
 
+	// Load the key to sign table manifests with.
+	if db.ManifestKey == nil {
+		key, err := LoadManifestKey(filepath.Join(db.Path, ManifestKeyFile))
+		if err != nil {
+			log.Error("Cannot load manifest key", "err", err)
+			return err
+		}
+		db.ManifestKey = key
+	}
+
 	db.global = NewTable("global", db.TablePath("global"), &StaticPartitioner{Name: "data"})
 	db.body = NewTable("body", db.TablePath("body"), NewBlockNumberPartitioner(db.PartitionSize))
 	db.header = NewTable("header", db.TablePath("header"), NewBlockNumberPartitioner(db.PartitionSize))
@@ -106,6 +147,8 @@ func (db *DB) Open() error {
 		tbl.MinCompactionAge = db.MinCompactionAge
 		tbl.SegmentOpener = db.SegmentOpener
 		tbl.SegmentCompactor = db.SegmentCompactor
+		tbl.Engine = db.Engine
+		tbl.ManifestKey = db.ManifestKey
 		if err := tbl.Open(); err != nil {
 			log.Error("Cannot open table", "name", tbl.Name, "err", err)
 			db.Close()
@@ -113,11 +156,33 @@ func (db *DB) Open() error {
 		}
 	}
 
+	// Refuse to read segments partitioned with another partition size.
+	if err := db.checkPartitionSize(); err != nil {
+		log.Error("Cannot open database", "path", db.Path, "err", err)
+		db.Close()
+		return err
+	}
+
+	// Start scrubbing in the background.
+	if db.ScrubInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		db.cancel = cancel
+
+		scrubber := NewScrubber(db)
+		scrubber.VerifyArchive = db.ScrubArchive
+		db.done = make(chan struct{})
+		go func() { defer close(db.done); scrubber.Run(ctx, db.ScrubInterval) }()
+	}
+
 	return nil
 }
 
 // Close closes all underlying tables.
 func (db *DB) Close() error {
+	if db.cancel != nil {
+		db.cancel()
+		<-db.done
+	}
 	for _, tbl := range db.Tables() {
 		if tbl != nil {
 			tbl.Close()
@@ -164,7 +229,100 @@ func (db *DB) Table(name string) *Table {
 	}
 }
 
-// migrate converts a source LevelDB database to the new ethdb formatted database.
+// PruneHistory drops the body and receipt segments holding only blocks older
+// than the history window before head. Headers are kept.
+func (db *DB) PruneHistory(ctx context.Context, head uint64) error {
+	tail := db.historyTailPartition(head)
+	if tail == "" {
+		return nil
+	}
+	for _, tbl := range []*Table{db.body, db.receipt} {
+		startTime := time.Now()
+		names, err := tbl.Prune(ctx, tail)
+		if err != nil {
+			return err
+		} else if len(names) > 0 {
+			log.Info("Pruned history", "table", tbl.Name, "segments", len(names), "tail", tail, "elapsed", time.Since(startTime))
+		}
+	}
+	return nil
+}
+
+// HistoryTailAt returns the number of the oldest block whose body and receipts
+// are kept once the chain reaches head. Returns zero if history is not pruned.
+func (db *DB) HistoryTailAt(head uint64) uint64 {
+	n, _ := strconv.ParseUint(db.historyTailPartition(head), 16, 64)
+	return n
+}
+
+// historyTailPartition returns the name of the oldest body and receipt
+// partition kept at head, or blank if none is pruned.
+func (db *DB) historyTailPartition(head uint64) string {
+	window := db.HistoryWindow
+	if window == 0 {
+		return ""
+	} else if window < MinHistoryWindow {
+		window = MinHistoryWindow
+	}
+	if head < window {
+		return ""
+	}
+
+	// Keep the partition holding the oldest block of the window.
+	return NewBlockNumberPartitioner(db.PartitionSize).Partition(blockNumberKey(head - window + 1))
+}
+
+// HistoryTail returns the number of the oldest block whose body and receipts
+// may be kept. Returns zero if history was never pruned.
+func (db *DB) HistoryTail() uint64 {
+	tail := db.body.Tail()
+	if tail == "" {
+		return 0
+	}
+	n, _ := strconv.ParseUint(tail, 16, 64)
+	return n
+}
+
+// blockNumberKey returns a body key for the given block number.
+func blockNumberKey(number uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = 'b'
+	binary.BigEndian.PutUint64(key[1:], number)
+	return key
+}
+
+// checkPartitionSize returns an error if the name of a block segment is not
+// a multiple of the partition size.
+func (db *DB) checkPartitionSize() error {
+	if db.PartitionSize == 0 {
+		return nil
+	}
+	for _, tbl := range []*Table{db.body, db.header, db.receipt} {
+		for _, name := range tbl.SegmentNames() {
+			if n, err := strconv.ParseUint(name, 16, 64); err != nil || n%db.PartitionSize != 0 {
+				return fmt.Errorf("ethdb: segment %s/%s does not match partition size %d", tbl.Name, name, db.PartitionSize)
+			}
+		}
+	}
+	return nil
+}
+
+// KeyTableName returns the name of the table holding key.
+func KeyTableName(key []byte) string {
+	switch {
+	case isBodyKey(key):
+		return "body"
+	case isHeaderKey(key):
+		return "header"
+	case isReceiptKey(key):
+		return "receipt"
+	default:
+		return "global"
+	}
+}
+
+// migrate converts a source LevelDB database to the new ethdb formatted database,
+// or the mutable segments of an ethdb database to the configured engine.
 func (db *DB) migrate() error {
 	const suffix = ".migrating"
 
@@ -177,11 +335,11 @@ func (db *DB) migrate() error {
 		return fmt.Errorf("ethdb.DB.migrate: %s", err)
 	}
 
-	// Ignore if path has already been migrated.
+	// Convert mutable segments if path has already been migrated.
 	if ok, err := IsDBDir(db.Path); err != nil {
 		return fmt.Errorf("ethdb.DB.migrate: cannot check ethdb directory: %s", err)
 	} else if ok {
-		return nil // already migrated
+		return db.convertSegments()
 	}
 
 	// Skip if not an LevelDB directory. Probably empty.
@@ -206,10 +364,16 @@ func (db *DB) migrate() error {
 	}
 	defer src.Close()
 
-	// Clone to temporary destination database.
-	dst := *db
-	dst.Path = tmpPath
-	dst.MinCompactionAge = 0
+	// Clone to temporary destination database, without background scrubbing.
+	dst := &DB{
+		Path:                tmpPath,
+		PartitionSize:       db.PartitionSize,
+		MaxOpenSegmentCount: db.MaxOpenSegmentCount,
+		SegmentOpener:       db.SegmentOpener,
+		SegmentCompactor:    db.SegmentCompactor,
+		Engine:              db.Engine,
+		ManifestKey:         db.ManifestKey,
+	}
 	if err := dst.Open(); err != nil {
 		return fmt.Errorf("cannot open dst database: %s", err)
 	}
@@ -221,19 +385,7 @@ func (db *DB) migrate() error {
 
 	// Write all key/values to new database.
 	for itr.Next() {
-		var tbl *Table
-
-		switch {
-		case isBodyKey(itr.Key()):
-			tbl = dst.BodyTable().(*Table)
-		case isHeaderKey(itr.Key()):
-			tbl = dst.HeaderTable().(*Table)
-		case isReceiptKey(itr.Key()):
-			tbl = dst.ReceiptTable().(*Table)
-		default:
-			tbl = dst.GlobalTable().(*Table)
-		}
-
+		tbl := dst.Table(KeyTableName(itr.Key()))
 		if err := tbl.Put(itr.Key(), itr.Value()); err != nil {
 			return fmt.Errorf("cannot insert item: tbl=%s key=%x err=%q", tbl.Name, itr.Key(), err)
 		}
@@ -261,6 +413,96 @@ func (db *DB) migrate() error {
 	return nil
 }
 
+// convertSegments rewrites the mutable segments of another engine than the
+// configured one. Each segment is written next to the original, which is then
+// set aside, replaced and only then removed, so an interrupted conversion is
+// completed or restarted without losing the segment.
+func (db *DB) convertSegments() error {
+	const suffix, oldSuffix = ".converting", ".replaced"
+
+	if db.Engine == "" {
+		return nil
+	}
+
+	for _, name := range []string{"global", "body", "header", "receipt"} {
+		path := db.TablePath(name)
+		fis, err := ioutil.ReadDir(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("ethdb.DB.migrate: cannot list table: %s", err)
+		}
+
+		// Complete conversions interrupted once the original was set aside,
+		// and remove partial ones.
+		for _, fi := range fis {
+			if !strings.HasSuffix(fi.Name(), oldSuffix) {
+				continue
+			}
+			oldPath := filepath.Join(path, fi.Name())
+			segmentPath := strings.TrimSuffix(oldPath, oldSuffix)
+			if _, err := os.Stat(segmentPath); os.IsNotExist(err) {
+				if err := os.Rename(segmentPath+suffix, segmentPath); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(oldPath); err != nil {
+				return err
+			}
+		}
+		for _, fi := range fis {
+			if !strings.HasSuffix(fi.Name(), suffix) {
+				continue
+			} else if err := os.RemoveAll(filepath.Join(path, fi.Name())); err != nil {
+				return err
+			}
+		}
+		if fis, err = ioutil.ReadDir(path); err != nil {
+			return fmt.Errorf("ethdb.DB.migrate: cannot list table: %s", err)
+		}
+
+		for _, fi := range fis {
+			if !fi.IsDir() || filepath.Ext(fi.Name()) != "" {
+				continue
+			}
+			segmentPath := filepath.Join(path, fi.Name())
+			typ, err := SegmentFileType(segmentPath)
+			if err != nil {
+				return err
+			}
+			engine, ok := SegmentEngine(typ)
+			if !ok || engine == db.Engine {
+				continue
+			}
+
+			startTime := time.Now()
+			log.Info("Converting segment", "table", name, "name", fi.Name(), "from", engine, "to", db.Engine)
+			src, err := engine.OpenSegment(fi.Name(), segmentPath)
+			if err != nil {
+				return err
+			}
+			err = UncompactSegmentTo(src, db.Engine, segmentPath+suffix)
+			if cerr := src.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return fmt.Errorf("ethdb.DB.migrate: cannot convert segment %s/%s: %s", name, fi.Name(), err)
+			}
+			if err := os.Rename(segmentPath, segmentPath+oldSuffix); err != nil {
+				return err
+			} else if err := os.Rename(segmentPath+suffix, segmentPath); err != nil {
+				return err
+			} else if err := os.RemoveAll(segmentPath + oldSuffix); err != nil {
+				return err
+			}
+			log.Info("Converted segment", "table", name, "name", fi.Name(), "elapsed", time.Since(startTime))
+		}
+	}
+	return nil
+}
+
 func isBodyKey(key []byte) bool {
 	return bytes.HasPrefix(key, []byte("b")) && len(key) == 41
 }
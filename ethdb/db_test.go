@@ -1,6 +1,10 @@
 package ethdb_test
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ChainAAS/gendchain/common"
@@ -22,3 +26,134 @@ func TestBlockNumberPartitioner_Partition(t *testing.T) {
 		t.Fatalf("unexpected partition: %v", v)
 	}
 }
+
+func TestDB_Open_PartitionSizeMismatch(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	db := ethdb.NewDB(dir)
+	db.PartitionSize = 100
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	} else if err := db.BodyTable().Put(numHashKey('b', 300, common.Hash{}), []byte("foo")); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Segments written with another partition size are refused.
+	db = ethdb.NewDB(dir)
+	db.PartitionSize = 1000
+	if err := db.Open(); err == nil {
+		db.Close()
+		t.Fatal("expected error")
+	}
+}
+
+func TestDB_PruneHistory(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	db := ethdb.NewDB(dir)
+	db.PartitionSize = 100
+	db.HistoryWindow = ethdb.MinHistoryWindow
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, n := range []uint64{50, 150, 250} {
+		if err := db.BodyTable().Put(numHashKey('b', n, common.Hash{}), []byte("body")); err != nil {
+			t.Fatal(err)
+		} else if err := db.ReceiptTable().Put(numHashKey('r', n, common.Hash{}), []byte("receipt")); err != nil {
+			t.Fatal(err)
+		} else if err := db.HeaderTable().Put(numHashKey('h', n, common.Hash{}), []byte("header")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing is pruned while the window reaches back to genesis.
+	if err := db.PruneHistory(context.Background(), 100); err != nil {
+		t.Fatal(err)
+	} else if v := db.HistoryTail(); v != 0 {
+		t.Fatalf("unexpected tail: %d", v)
+	}
+
+	// Bodies and receipts before the partition of the window are dropped.
+	if err := db.PruneHistory(context.Background(), 250); err != nil {
+		t.Fatal(err)
+	} else if v := db.HistoryTail(); v != 100 {
+		t.Fatalf("unexpected tail: %d", v)
+	} else if _, err := db.BodyTable().Get(numHashKey('b', 50, common.Hash{})); err != ethdb.ErrHistoryPruned {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := db.ReceiptTable().Get(numHashKey('r', 50, common.Hash{})); err != ethdb.ErrHistoryPruned {
+		t.Fatalf("unexpected error: %v", err)
+	} else if v, err := db.BodyTable().Get(numHashKey('b', 150, common.Hash{})); err != nil || string(v) != "body" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	}
+
+	// Headers are kept.
+	if v, err := db.HeaderTable().Get(numHashKey('h', 50, common.Hash{})); err != nil || string(v) != "header" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	}
+}
+
+func TestDB_Open_ConvertSegments(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	db := ethdb.NewDB(dir)
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	} else if err := db.GlobalTable().Put([]byte("foo"), []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := db.BodyTable().Put(numHashKey('b', 300, common.Hash{}), []byte("body")); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A partial conversion left by a crash is discarded.
+	path := filepath.Join(db.TablePath("global"), "data")
+	if err := os.MkdirAll(path+".converting", 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	// A conversion interrupted after the original was set aside is completed.
+	bodyPath := db.TablePath("body")
+	fis, err := ioutil.ReadDir(bodyPath)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(fis) != 1 {
+		t.Fatalf("unexpected body segments: %d", len(fis))
+	}
+	segmentPath := filepath.Join(bodyPath, fis[0].Name())
+	if err := os.Rename(segmentPath, segmentPath+".converting"); err != nil {
+		t.Fatal(err)
+	} else if err := os.MkdirAll(segmentPath+".replaced", 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mutable segments are converted to the configured engine.
+	db = ethdb.NewDB(dir)
+	db.Engine = ethdb.EnginePebble
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, tbl := range db.Tables() {
+		for _, s := range tbl.SegmentSlice() {
+			if _, ok := s.(*ethdb.PebbleSegment); !ok {
+				t.Fatalf("%s/%s: expected pebble segment, got %T", tbl.Name, s.Name(), s)
+			}
+		}
+	}
+	if _, err := os.Stat(path + ".converting"); !os.IsNotExist(err) {
+		t.Fatalf("expected partial conversion to be removed: %v", err)
+	} else if _, err := os.Stat(segmentPath + ".replaced"); !os.IsNotExist(err) {
+		t.Fatalf("expected replaced segment to be removed: %v", err)
+	} else if v, err := db.GlobalTable().Get([]byte("foo")); err != nil || string(v) != "bar" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	} else if v, err := db.BodyTable().Get(numHashKey('b', 300, common.Hash{})); err != nil || string(v) != "body" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	}
+}
@@ -7,12 +7,16 @@ import (
 	"io"
 	"os"
 	"sort"
+
+	"github.com/ChainAAS/gendchain/common"
 )
 
 // Segment file types.
 const (
 	SegmentETH1 = "eth1"
+	SegmentETH2 = "eth2"
 	SegmentLDB1 = "ldb1"
+	SegmentPBL1 = "pbl1"
 )
 
 // Segment represents a subset of Table data.
@@ -38,6 +42,19 @@ type MutableSegment interface {
 	Segment
 	Put(key, value []byte) error
 	Delete(key []byte) error
+
+	// NewBatch returns a batch of writes applied atomically to the segment.
+	NewBatch() common.Batch
+
+	// PrefixIterator returns an iterator over the key/value pairs whose key
+	// starts with prefix.
+	PrefixIterator(prefix []byte) SegmentIterator
+
+	// CompactTo writes the segment to path as a file segment.
+	CompactTo(path string) error
+
+	// CompactToCompressed writes the segment to path as a compressed file segment.
+	CompactToCompressed(path string, compression Compression) error
 }
 
 // SegmentIterator represents a sequentially iterator over all the key/value
@@ -55,20 +72,42 @@ type SegmentOpener interface {
 	ListSegmentNames(path, table string) ([]string, error)
 }
 
-// SegmentCompactor represents an object that can compact from an LDB segment
-// to an immutable segment and back.
+// SegmentCompactor represents an object that can compact from a mutable segment
+// to an immutable segment and back. Segments are uncompacted with the given engine.
 type SegmentCompactor interface {
-	CompactSegment(ctx context.Context, table string, s *LDBSegment) (Segment, error)
-	UncompactSegment(ctx context.Context, table string, s Segment) (*LDBSegment, error)
+	CompactSegment(ctx context.Context, table string, s MutableSegment) (Segment, error)
+	UncompactSegment(ctx context.Context, table string, s Segment, engine Engine) (MutableSegment, error)
+}
+
+// ArchivedSegment represents an immutable segment kept in an archive, with an
+// optional local copy at its path.
+type ArchivedSegment interface {
+	Segment
+
+	// ArchiveEntry returns the manifest entry of the archived copy, using the
+	// checksum written in its header.
+	ArchiveEntry(ctx context.Context) (ManifestEntry, error)
+
+	// VerifyArchive compares the computed and stored checksum of the archived copy.
+	VerifyArchive(ctx context.Context) error
+
+	// RestoreArchive replaces the archived copy with the local copy.
+	RestoreArchive(ctx context.Context) error
 }
 
 // SegmentFileType returns the file type at path.
 func SegmentFileType(path string) (string, error) {
-	// Check if this is a directory. If so then treat as LevelDB database.
+	// Check if this is a directory. If so then treat as a mutable segment,
+	// Pebble if it holds an options file and LevelDB otherwise.
 	fi, err := os.Stat(path)
 	if err != nil {
 		return "", err
 	} else if fi.IsDir() {
+		if ok, err := IsPebbleDir(path); err != nil {
+			return "", err
+		} else if ok {
+			return SegmentPBL1, nil
+		}
 		return SegmentLDB1, nil
 	}
 
@@ -90,11 +129,75 @@ func SegmentFileType(path string) (string, error) {
 	switch string(magic) {
 	case FileSegmentMagic:
 		return SegmentETH1, nil
+	case CompressedFileSegmentMagic:
+		return SegmentETH2, nil
 	default:
 		return "", ErrInvalidSegmentType
 	}
 }
 
+// Engine is the embedded key/value store holding mutable segments.
+type Engine string
+
+// Mutable segment engines.
+const (
+	EngineLevelDB Engine = "leveldb"
+	EnginePebble  Engine = "pebble"
+)
+
+// ParseEngine returns the engine of the given name. A blank name returns a
+// blank engine, which keeps the engine of existing segments and creates
+// LevelDB segments.
+func ParseEngine(s string) (Engine, error) {
+	switch e := Engine(s); e {
+	case "", EngineLevelDB, EnginePebble:
+		return e, nil
+	default:
+		return "", fmt.Errorf("ethdb: unknown engine %q", s)
+	}
+}
+
+// SegmentType returns the type of the mutable segments of the engine.
+func (e Engine) SegmentType() string {
+	if e == EnginePebble {
+		return SegmentPBL1
+	}
+	return SegmentLDB1
+}
+
+// OpenSegment opens, or creates, the mutable segment at path with the engine.
+func (e Engine) OpenSegment(name, path string) (MutableSegment, error) {
+	switch e {
+	case "", EngineLevelDB:
+		s := NewLDBSegment(name, path)
+		if err := s.Open(); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case EnginePebble:
+		s := NewPebbleSegment(name, path)
+		if err := s.Open(); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("ethdb: unknown engine %q", string(e))
+	}
+}
+
+// SegmentEngine returns the engine of a mutable segment type. Returns false
+// for immutable segment types.
+func SegmentEngine(typ string) (Engine, bool) {
+	switch typ {
+	case SegmentLDB1:
+		return EngineLevelDB, true
+	case SegmentPBL1:
+		return EnginePebble, true
+	default:
+		return "", false
+	}
+}
+
 // KeyBlockNumber returns the block number for a given key and returns ok true.
 // If the key does not encode the block number then ok is false.
 func KeyBlockNumber(key []byte) (num uint64, ok bool) {
@@ -147,3 +250,35 @@ func (p *BlockNumberPartitioner) Partition(key []byte) string {
 	}
 	return fmt.Sprintf("%016x", blockNumber)
 }
+
+// CopyFile copies the file at src to dst through a synced temporary file, so a
+// partial copy is never visible at dst. Returns the number of bytes copied.
+func CopyFile(src, dst string) (n int64, err error) {
+	r, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	tmpPath := dst + ".tmp"
+	w, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if n, err = io.Copy(w, r); err != nil {
+		w.Close()
+		return n, err
+	} else if err = w.Sync(); err != nil {
+		w.Close()
+		return n, err
+	} else if err = w.Close(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmpPath, dst)
+}
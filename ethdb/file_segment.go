@@ -313,6 +313,12 @@ func (o *FileSegmentOpener) ListSegmentNames(path, table string) ([]string, erro
 
 // OpenSegment returns an initialized and opened segment.
 func (o *FileSegmentOpener) OpenSegment(table, name, path string) (Segment, error) {
+	return OpenFileSegment(name, path)
+}
+
+// OpenFileSegment opens the file segment at path, either a plain or a
+// compressed one depending on its magic.
+func OpenFileSegment(name, path string) (Segment, error) {
 	// Determine the segment file type.
 	typ, err := SegmentFileType(path)
 	if err != nil {
@@ -326,21 +332,31 @@ func (o *FileSegmentOpener) OpenSegment(table, name, path string) (Segment, erro
 			return nil, err
 		}
 		return segment, nil
+	case SegmentETH2:
+		segment := NewCompressedFileSegment(name, path)
+		if err := segment.Open(); err != nil {
+			return nil, err
+		}
+		return segment, nil
 	default:
 		return nil, ErrSegmentTypeUnknown
 	}
 }
 
-// FileSegmentCompactor locally compacts LDB segments into file segments.
-type FileSegmentCompactor struct{}
+// FileSegmentCompactor locally compacts mutable segments into file segments.
+type FileSegmentCompactor struct {
+	// Compression of the data blocks. Segments are compacted into compressed
+	// file segments unless it is CompressionNone.
+	Compression Compression
+}
 
 // NewFileSegmentCompactor returns a new instance of FileSegmentCompactor.
 func NewFileSegmentCompactor() *FileSegmentCompactor {
 	return &FileSegmentCompactor{}
 }
 
-// CompactSegment compacts an LDB segment into a file segment.
-func (c *FileSegmentCompactor) CompactSegment(ctx context.Context, table string, s *LDBSegment) (Segment, error) {
+// CompactSegment compacts a mutable segment into a file segment.
+func (c *FileSegmentCompactor) CompactSegment(ctx context.Context, table string, s MutableSegment) (Segment, error) {
 	tmpPath := s.Path() + ".tmp"
 	if err := c.CompactSegmentTo(ctx, s, tmpPath); err != nil {
 		return nil, err
@@ -351,26 +367,28 @@ func (c *FileSegmentCompactor) CompactSegment(ctx context.Context, table string,
 	}
 
 	// Reopen as file segment.
-	newSegment := NewFileSegment(s.Name(), s.Path())
-	if err := newSegment.Open(); err != nil {
-		return nil, err
-	}
-	return newSegment, nil
+	return OpenFileSegment(s.Name(), s.Path())
 }
 
-// CompactSegmentTo compacts an LDB segment to a specified path.
-func (c *FileSegmentCompactor) CompactSegmentTo(ctx context.Context, s *LDBSegment, path string) error {
-	if err := s.CompactTo(path); err != nil {
+// CompactSegmentTo compacts a mutable segment to a specified path.
+func (c *FileSegmentCompactor) CompactSegmentTo(ctx context.Context, s MutableSegment, path string) error {
+	var err error
+	if c.Compression == CompressionNone {
+		err = s.CompactTo(path)
+	} else {
+		err = s.CompactToCompressed(path, c.Compression)
+	}
+	if err != nil {
 		os.Remove(path)
 		return err
 	}
 	return nil
 }
 
-// UncompactSegment converts an LDB segment back into a file segment.
-func (c *FileSegmentCompactor) UncompactSegment(ctx context.Context, table string, s Segment) (*LDBSegment, error) {
+// UncompactSegment converts a file segment back into a mutable segment of engine.
+func (c *FileSegmentCompactor) UncompactSegment(ctx context.Context, table string, s Segment, engine Engine) (MutableSegment, error) {
 	tmpPath := s.Path() + ".tmp"
-	if err := c.UncompactSegmentTo(ctx, s, tmpPath); err != nil {
+	if err := c.UncompactSegmentTo(ctx, s, engine, tmpPath); err != nil {
 		return nil, err
 	} else if err := s.Close(); err != nil {
 		return nil, err
@@ -378,17 +396,13 @@ func (c *FileSegmentCompactor) UncompactSegment(ctx context.Context, table strin
 		return nil, err
 	}
 
-	// Reopen as LDB segment.
-	newLDBSegment := NewLDBSegment(s.Name(), s.Path())
-	if err := newLDBSegment.Open(); err != nil {
-		return nil, err
-	}
-	return newLDBSegment, nil
+	// Reopen as mutable segment.
+	return engine.OpenSegment(s.Name(), s.Path())
 }
 
-// UncompactSegmentTo converts a segment back to an LDB segment at path.
-func (c *FileSegmentCompactor) UncompactSegmentTo(ctx context.Context, s Segment, path string) error {
-	if err := UncompactSegmentTo(s, path); err != nil {
+// UncompactSegmentTo converts a segment back to a mutable segment of engine at path.
+func (c *FileSegmentCompactor) UncompactSegmentTo(ctx context.Context, s Segment, engine Engine, path string) error {
+	if err := UncompactSegmentTo(s, engine, path); err != nil {
 		os.Remove(path)
 		return err
 	}
@@ -568,10 +582,17 @@ func ChecksumFileSegment(path string) ([]byte, error) {
 	defer f.Close()
 
 	// Compute checksum for all data after checksum.
-	h := xxhash.New()
 	if _, err := f.Seek(int64(len(FileSegmentMagic)+FileSegmentChecksumSize), io.SeekStart); err != nil {
 		return nil, err
-	} else if _, err := io.Copy(h, f); err != nil {
+	}
+	return ChecksumFileSegmentData(f)
+}
+
+// ChecksumFileSegmentData calculates the checksum of segment data read from r,
+// which must start right after the magic & checksum of the segment header.
+func ChecksumFileSegmentData(r io.Reader) ([]byte, error) {
+	h := xxhash.New()
+	if _, err := io.Copy(h, r); err != nil {
 		return nil, err
 	}
 
@@ -588,13 +609,20 @@ func VerifyFileSegment(path string) error {
 		return err
 	}
 
-	s := NewFileSegment(filepath.Base(path), path)
-	if err := s.Open(); err != nil {
+	s, err := OpenFileSegment(filepath.Base(path), path)
+	if err != nil {
 		return err
 	}
 	defer s.Close()
 
-	if !bytes.Equal(s.Checksum(), computed) {
+	var checksum []byte
+	switch s := s.(type) {
+	case *FileSegment:
+		checksum = s.Checksum()
+	case *CompressedFileSegment:
+		checksum = s.Checksum()
+	}
+	if !bytes.Equal(checksum, computed) {
 		return ErrFileSegmentChecksumMismatch
 	}
 	return nil
@@ -6,6 +6,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // Ensure implementation implements interface.
@@ -77,7 +78,8 @@ func (s *LDBSegment) Delete(key []byte) error {
 	return s.db.Delete(key, nil)
 }
 
-func (s *LDBSegment) newBatch() *ldbSegmentBatch {
+// NewBatch returns a batch of writes to the segment.
+func (s *LDBSegment) NewBatch() common.Batch {
 	return &ldbSegmentBatch{segment: s, batch: new(leveldb.Batch)}
 }
 
@@ -86,9 +88,31 @@ func (s *LDBSegment) Iterator() SegmentIterator {
 	return &ldbSegmentIterator{s.db.NewIterator(nil, nil)}
 }
 
+// PrefixIterator returns a sequential iterator over the keys starting with prefix.
+func (s *LDBSegment) PrefixIterator(prefix []byte) SegmentIterator {
+	return &ldbSegmentIterator{s.db.NewIterator(util.BytesPrefix(prefix), nil)}
+}
+
 // CompactTo writes the segment to disk as a file segment.
 func (s *LDBSegment) CompactTo(path string) error {
-	enc := NewFileSegmentEncoder(path)
+	return encodeSegment(s, NewFileSegmentEncoder(path))
+}
+
+// CompactToCompressed writes the segment to disk as a compressed file segment.
+func (s *LDBSegment) CompactToCompressed(path string, compression Compression) error {
+	return encodeSegment(s, NewCompressedFileSegmentEncoder(path, compression))
+}
+
+// segmentEncoder is implemented by the file segment encoders.
+type segmentEncoder interface {
+	Open() error
+	EncodeKeyValue(key, value []byte) error
+	Flush() error
+	Close() error
+}
+
+// encodeSegment writes all key/value pairs of the segment to enc.
+func encodeSegment(s Segment, enc segmentEncoder) error {
 	if err := enc.Open(); err != nil {
 		return err
 	}
@@ -96,7 +120,7 @@ func (s *LDBSegment) CompactTo(path string) error {
 	itr := s.Iterator()
 	defer itr.Close()
 
-	// Copy all LDB key/value pairs to the file segment.
+	// Copy all key/value pairs to the file segment.
 	for itr.Next() {
 		if err := enc.EncodeKeyValue(itr.Key(), itr.Value()); err != nil {
 			return err
@@ -115,24 +139,36 @@ func (s *LDBSegment) CompactTo(path string) error {
 	return nil
 }
 
-// UncompactSegmentTo writes an LDB segment from a file segment.
-func UncompactSegmentTo(s Segment, path string) error {
-	ldbSegment := NewLDBSegment(s.Name(), path)
-	if err := ldbSegment.Open(); err != nil {
+// UncompactSegmentTo writes a mutable segment of the given engine at path from
+// a file segment.
+func UncompactSegmentTo(s Segment, engine Engine, path string) error {
+	dst, err := engine.OpenSegment(s.Name(), path)
+	if err != nil {
 		return err
 	}
-	defer ldbSegment.Close()
+	defer dst.Close()
 
 	itr := s.Iterator()
 	defer itr.Close()
 
+	batch := dst.NewBatch()
 	for itr.Next() {
-		if err := ldbSegment.Put(itr.Key(), itr.Value()); err != nil {
+		if err := batch.Put(itr.Key(), itr.Value()); err != nil {
 			return err
 		}
+		if batch.ValueSize() >= IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
 	}
-
-	return ldbSegment.Close()
+	if err := itr.Close(); err != nil {
+		return err
+	} else if err := batch.Write(); err != nil {
+		return err
+	}
+	return dst.Close()
 }
 
 // ldbSegmentIterator represents an adapter between goleveldb and the ethdb iterator.
@@ -0,0 +1,252 @@
+package ethdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Manifest errors.
+var (
+	ErrManifestSignatureMismatch = errors.New("ethdb: manifest signature mismatch")
+	ErrSegmentSizeMismatch       = errors.New("ethdb: segment size mismatch")
+)
+
+const (
+	// ManifestFile is the name of the manifest file within a table directory.
+	// The extension keeps it from being listed as a segment.
+	ManifestFile = "manifest.json"
+
+	// ManifestKeyFile is the name of the file within the database directory
+	// holding the key manifests are signed with.
+	ManifestKeyFile = "manifest.key"
+
+	// ManifestKeySize is the size of the manifest signing key, in bytes.
+	ManifestKeySize = 32
+)
+
+// ManifestEntry describes an immutable segment: its name, size and the
+// checksum written in its header.
+type ManifestEntry struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// Manifest lists the immutable segments of a table. It is signed with an
+// HMAC-SHA256 of its entries so that edits by anyone without the database's
+// manifest key are detected.
+type Manifest struct {
+	mu      sync.RWMutex
+	table   string
+	path    string
+	key     []byte
+	entries map[string]ManifestEntry
+}
+
+// manifestFile is the on-disk encoding of a Manifest.
+type manifestFile struct {
+	Table     string          `json:"table"`
+	Segments  []ManifestEntry `json:"segments"`
+	Signature string          `json:"signature"`
+}
+
+// NewManifest returns a new, empty instance of Manifest of a table, stored at
+// path and signed with key.
+func NewManifest(table, path string, key []byte) *Manifest {
+	return &Manifest{
+		table:   table,
+		path:    path,
+		key:     key,
+		entries: make(map[string]ManifestEntry),
+	}
+}
+
+// Path returns the path of the manifest file.
+func (m *Manifest) Path() string { return m.path }
+
+// Load reads the manifest file and verifies its signature. A missing file
+// leaves the manifest empty.
+func (m *Manifest) Load() error {
+	buf, err := ioutil.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var f manifestFile
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return fmt.Errorf("ethdb: cannot decode manifest: %s", err)
+	}
+	sig, err := hex.DecodeString(f.Signature)
+	if err != nil || f.Table != m.table || !hmac.Equal(sig, m.sign(f.Segments)) {
+		return ErrManifestSignatureMismatch
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[string]ManifestEntry, len(f.Segments))
+	for _, e := range f.Segments {
+		m.entries[e.Name] = e
+	}
+	return nil
+}
+
+// Entry returns the entry of the named segment, if any.
+func (m *Manifest) Entry(name string) (ManifestEntry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[name]
+	return e, ok
+}
+
+// Entries returns all entries sorted by name.
+func (m *Manifest) Entries() []ManifestEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	a := make([]ManifestEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		a = append(a, e)
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].Name < a[j].Name })
+	return a
+}
+
+// Put adds or replaces an entry and saves the manifest.
+func (m *Manifest) Put(e ManifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[e.Name] = e
+	return m.save()
+}
+
+// Delete removes the entry of the named segment and saves the manifest.
+func (m *Manifest) Delete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[name]; !ok {
+		return nil
+	}
+	delete(m.entries, name)
+	return m.save()
+}
+
+// save atomically writes the signed manifest file. Must hold lock.
+func (m *Manifest) save() error {
+	f := manifestFile{Table: m.table, Segments: make([]ManifestEntry, 0, len(m.entries))}
+	for _, e := range m.entries {
+		f.Segments = append(f.Segments, e)
+	}
+	sort.Slice(f.Segments, func(i, j int) bool { return f.Segments[i].Name < f.Segments[j].Name })
+	f.Signature = hex.EncodeToString(m.sign(f.Segments))
+
+	buf, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmpPath := m.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, buf, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, m.path)
+}
+
+// sign returns the signature of a sorted list of entries.
+func (m *Manifest) sign(entries []ManifestEntry) []byte {
+	h := hmac.New(sha256.New, m.key)
+	fmt.Fprintf(h, "%s\x00", m.table)
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s\x00%d\x00%s\x00", e.Name, e.Size, e.Checksum)
+	}
+	return h.Sum(nil)
+}
+
+// Verify compares the size and checksum of other with the entry.
+func (e ManifestEntry) Verify(other ManifestEntry) error {
+	if e.Size != other.Size {
+		return ErrSegmentSizeMismatch
+	} else if e.Checksum != other.Checksum {
+		return ErrFileSegmentChecksumMismatch
+	}
+	return nil
+}
+
+// NewManifestEntry returns the manifest entry of an immutable segment, read
+// from the header of its local file or of its archived copy.
+func NewManifestEntry(ctx context.Context, s Segment) (ManifestEntry, error) {
+	if s, ok := s.(ArchivedSegment); ok {
+		if fi, err := os.Stat(s.Path()); err != nil || !fi.Mode().IsRegular() {
+			return s.ArchiveEntry(ctx)
+		}
+	}
+	return ReadManifestEntry(s.Name(), s.Path())
+}
+
+// ReadManifestEntry returns the manifest entry of the segment file at path,
+// using the checksum written in its header.
+func ReadManifestEntry(name, path string) (ManifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ReadManifestEntryAt(name, f, fi.Size())
+}
+
+// ReadManifestEntryAt returns the manifest entry of the segment data of the
+// given size read from r, using the checksum written in its header.
+func ReadManifestEntryAt(name string, r io.ReaderAt, size int64) (ManifestEntry, error) {
+	hdr := make([]byte, len(FileSegmentMagic)+FileSegmentChecksumSize)
+	if _, err := r.ReadAt(hdr, 0); err != nil {
+		return ManifestEntry{}, err
+	}
+	switch string(hdr[:len(FileSegmentMagic)]) {
+	case FileSegmentMagic, CompressedFileSegmentMagic:
+	default:
+		return ManifestEntry{}, ErrInvalidSegmentType
+	}
+	return ManifestEntry{
+		Name:     name,
+		Size:     size,
+		Checksum: hex.EncodeToString(hdr[len(FileSegmentMagic):]),
+	}, nil
+}
+
+// LoadManifestKey returns the manifest key stored at path, generating and
+// storing a new key if none exists.
+func LoadManifestKey(path string) ([]byte, error) {
+	if buf, err := ioutil.ReadFile(path); err == nil {
+		key, err := hex.DecodeString(string(bytes.TrimSpace(buf)))
+		if err != nil || len(key) != ManifestKeySize {
+			return nil, fmt.Errorf("ethdb: invalid manifest key: %s", path)
+		}
+		return key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, ManifestKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	} else if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
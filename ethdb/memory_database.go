@@ -1,6 +1,7 @@
 package ethdb
 
 import (
+	"bytes"
 	"sync"
 
 	"github.com/ChainAAS/gendchain/common"
@@ -68,6 +69,31 @@ func (db *MemDatabase) Keys() [][]byte {
 	return keys
 }
 
+// Iterate calls fn for every key/value pair present when called.
+func (db *MemDatabase) Iterate(fn func(key, value []byte) error) error {
+	return db.IteratePrefix(nil, fn)
+}
+
+// IteratePrefix calls fn for every key/value pair present when called whose
+// key starts with prefix.
+func (db *MemDatabase) IteratePrefix(prefix []byte, fn func(key, value []byte) error) error {
+	for _, key := range db.Keys() {
+		if !bytes.HasPrefix(key, prefix) {
+			continue
+		}
+		value, err := db.Get(key)
+		if err == common.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (db *MemDatabase) Delete(key []byte) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
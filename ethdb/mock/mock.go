@@ -3,6 +3,7 @@ package mock
 import (
 	"context"
 
+	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/ethdb"
 )
 
@@ -28,12 +29,24 @@ var _ ethdb.MutableSegment = (*MutableSegment)(nil)
 
 type MutableSegment struct {
 	Segment
-	PutFunc    func(key, value []byte) error
-	DeleteFunc func(key []byte) error
+	PutFunc                 func(key, value []byte) error
+	DeleteFunc              func(key []byte) error
+	NewBatchFunc            func() common.Batch
+	PrefixIteratorFunc      func(prefix []byte) ethdb.SegmentIterator
+	CompactToFunc           func(path string) error
+	CompactToCompressedFunc func(path string, compression ethdb.Compression) error
 }
 
 func (m *MutableSegment) Put(key, value []byte) error { return m.PutFunc(key, value) }
 func (m *MutableSegment) Delete(key []byte) error     { return m.DeleteFunc(key) }
+func (m *MutableSegment) NewBatch() common.Batch      { return m.NewBatchFunc() }
+func (m *MutableSegment) PrefixIterator(prefix []byte) ethdb.SegmentIterator {
+	return m.PrefixIteratorFunc(prefix)
+}
+func (m *MutableSegment) CompactTo(path string) error { return m.CompactToFunc(path) }
+func (m *MutableSegment) CompactToCompressed(path string, compression ethdb.Compression) error {
+	return m.CompactToCompressedFunc(path, compression)
+}
 
 var _ ethdb.SegmentOpener = (*SegmentOpener)(nil)
 
@@ -53,14 +66,14 @@ func (m *SegmentOpener) OpenSegment(table, name, path string) (ethdb.Segment, er
 var _ ethdb.SegmentCompactor = (*SegmentCompactor)(nil)
 
 type SegmentCompactor struct {
-	CompactSegmentFunc   func(ctx context.Context, table string, s *ethdb.LDBSegment) (ethdb.Segment, error)
-	UncompactSegmentFunc func(ctx context.Context, table string, s ethdb.Segment) (*ethdb.LDBSegment, error)
+	CompactSegmentFunc   func(ctx context.Context, table string, s ethdb.MutableSegment) (ethdb.Segment, error)
+	UncompactSegmentFunc func(ctx context.Context, table string, s ethdb.Segment, engine ethdb.Engine) (ethdb.MutableSegment, error)
 }
 
-func (m *SegmentCompactor) CompactSegment(ctx context.Context, table string, s *ethdb.LDBSegment) (ethdb.Segment, error) {
+func (m *SegmentCompactor) CompactSegment(ctx context.Context, table string, s ethdb.MutableSegment) (ethdb.Segment, error) {
 	return m.CompactSegmentFunc(ctx, table, s)
 }
 
-func (m *SegmentCompactor) UncompactSegment(ctx context.Context, table string, s ethdb.Segment) (*ethdb.LDBSegment, error) {
-	return m.UncompactSegmentFunc(ctx, table, s)
+func (m *SegmentCompactor) UncompactSegment(ctx context.Context, table string, s ethdb.Segment, engine ethdb.Engine) (ethdb.MutableSegment, error) {
+	return m.UncompactSegmentFunc(ctx, table, s, engine)
 }
@@ -0,0 +1,205 @@
+package ethdb
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+)
+
+// Ensure implementation implements interface.
+var _ MutableSegment = (*PebbleSegment)(nil)
+
+// PebbleSegment represents a mutable segment in a Table stored in Pebble.
+// These segments can eventually be rebuilt into immutable FileSegments.
+type PebbleSegment struct {
+	db *pebble.DB
+
+	name string
+	path string
+}
+
+// NewPebbleSegment returns a Pebble-based database segment.
+func NewPebbleSegment(name, path string) *PebbleSegment {
+	return &PebbleSegment{name: name, path: path}
+}
+
+// Open initializes the underlying segment database.
+func (s *PebbleSegment) Open() (err error) {
+	cache := pebble.NewCache(16 / 2 * 1024 * 1024)
+	defer cache.Unref()
+
+	s.db, err = pebble.Open(s.path, &pebble.Options{
+		Cache:        cache,
+		MaxOpenFiles: 16,
+		MemTableSize: 16 / 4 * 1024 * 1024,
+		Levels: []pebble.LevelOptions{
+			{FilterPolicy: bloom.FilterPolicy(10)},
+		},
+	})
+	return err
+}
+
+// Close closes the underlying database.
+func (s *PebbleSegment) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+// Name returns the name of the segment.
+func (s *PebbleSegment) Name() string { return s.name }
+
+// Path returns the path to the segment.
+func (s *PebbleSegment) Path() string { return s.path }
+
+// Pebble returns the underlying Pebble database.
+func (s *PebbleSegment) Pebble() *pebble.DB { return s.db }
+
+// Has returns true if the segment contains key.
+func (s *PebbleSegment) Has(key []byte) (bool, error) {
+	_, closer, err := s.db.Get(key)
+	if err == pebble.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, closer.Close()
+}
+
+// Get returns the given key if it's present.
+func (s *PebbleSegment) Get(key []byte) ([]byte, error) {
+	value, closer, err := s.db.Get(key)
+	if err == pebble.ErrNotFound {
+		return nil, common.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	value = common.CopyBytes(value)
+	return value, closer.Close()
+}
+
+// Put inserts a value into a given key.
+func (s *PebbleSegment) Put(key []byte, value []byte) error {
+	return s.db.Set(key, value, pebble.NoSync)
+}
+
+// Delete deletes the key from the database.
+func (s *PebbleSegment) Delete(key []byte) error {
+	return s.db.Delete(key, pebble.NoSync)
+}
+
+// NewBatch returns a batch of writes to the segment.
+func (s *PebbleSegment) NewBatch() common.Batch {
+	return &pebbleSegmentBatch{batch: s.db.NewBatch()}
+}
+
+// Iterator returns a sequential iterator for the segment.
+func (s *PebbleSegment) Iterator() SegmentIterator {
+	return &pebbleSegmentIterator{iter: s.db.NewIter(nil)}
+}
+
+// PrefixIterator returns a sequential iterator over the keys starting with prefix.
+func (s *PebbleSegment) PrefixIterator(prefix []byte) SegmentIterator {
+	return &pebbleSegmentIterator{iter: s.db.NewIter(&pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixLimit(prefix),
+	})}
+}
+
+// CompactTo writes the segment to disk as a file segment.
+func (s *PebbleSegment) CompactTo(path string) error {
+	return encodeSegment(s, NewFileSegmentEncoder(path))
+}
+
+// CompactToCompressed writes the segment to disk as a compressed file segment.
+func (s *PebbleSegment) CompactToCompressed(path string, compression Compression) error {
+	return encodeSegment(s, NewCompressedFileSegmentEncoder(path, compression))
+}
+
+// IsPebbleDir returns true if path contains a Pebble database.
+// Verifies that path contains an OPTIONS file, which LevelDB does not write.
+func IsPebbleDir(path string) (bool, error) {
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+	for _, fi := range fis {
+		if strings.HasPrefix(fi.Name(), "OPTIONS-") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// prefixLimit returns the smallest key greater than all keys starting with
+// prefix, or nil if there is none.
+func prefixLimit(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			limit := common.CopyBytes(prefix[:i+1])
+			limit[i]++
+			return limit
+		}
+	}
+	return nil
+}
+
+// pebbleSegmentIterator represents an adapter between Pebble and the ethdb iterator.
+type pebbleSegmentIterator struct {
+	iter    *pebble.Iterator
+	started bool
+}
+
+func (itr *pebbleSegmentIterator) Next() bool {
+	if !itr.started {
+		itr.started = true
+		return itr.iter.First()
+	}
+	return itr.iter.Next()
+}
+
+func (itr *pebbleSegmentIterator) Key() []byte { return itr.iter.Key() }
+
+func (itr *pebbleSegmentIterator) Value() []byte { return itr.iter.Value() }
+
+func (itr *pebbleSegmentIterator) Close() error {
+	if itr.iter == nil {
+		return nil
+	}
+	err := itr.iter.Close()
+	itr.iter = nil
+	return err
+}
+
+type pebbleSegmentBatch struct {
+	batch *pebble.Batch
+	size  int
+}
+
+func (b *pebbleSegmentBatch) Put(key, value []byte) error {
+	b.size += len(value)
+	return b.batch.Set(key, value, nil)
+}
+
+func (b *pebbleSegmentBatch) Delete(key []byte) error {
+	return b.batch.Delete(key, nil)
+}
+
+func (b *pebbleSegmentBatch) Write() error {
+	return b.batch.Commit(pebble.NoSync)
+}
+
+func (b *pebbleSegmentBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *pebbleSegmentBatch) Reset() {
+	b.batch.Reset()
+	b.size = 0
+}
@@ -0,0 +1,348 @@
+package ethdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/log"
+)
+
+// Ensure implementation implements interface.
+var _ Segment = (*ReaderFileSegment)(nil)
+
+// ReaderFileSegment represents an immutable file or compressed file segment
+// read through an io.ReaderAt instead of a memory map. Only the header and
+// the indexes are read on open, keys and values (or the data blocks holding
+// them) are read on demand. It suits segments stored remotely.
+type ReaderFileSegment struct {
+	name string      // segment name
+	path string      // on-disk or remote path
+	r    io.ReaderAt // segment data
+	size int64       // size of the segment data
+
+	typ              string // SegmentETH1 or SegmentETH2
+	count            int    // number of keys
+	capacity         uint64 // capacity of the key index
+	index            []byte // key index
+	blockIndex       []byte // block index of compressed segments
+	dataOffset       int64  // offset of the first key/value pair or block
+	blockIndexOffset int64  // end of the data blocks of compressed segments
+	indexOffset      int64  // offset of the key index
+}
+
+// NewReaderFileSegment returns a new instance of ReaderFileSegment reading
+// size bytes of segment data from r.
+func NewReaderFileSegment(name, path string, r io.ReaderAt, size int64) *ReaderFileSegment {
+	return &ReaderFileSegment{
+		name: name,
+		path: path,
+		r:    r,
+		size: size,
+	}
+}
+
+// Open reads the header and the indexes of the segment.
+func (s *ReaderFileSegment) Open() error {
+	hdr := make([]byte, CompressedFileSegmentHeaderSize)
+	if s.size < int64(FileSegmentHeaderSize) {
+		return errors.New("ethdb: file header too short")
+	} else if s.size < int64(len(hdr)) {
+		hdr = hdr[:FileSegmentHeaderSize]
+	}
+	if err := s.readAt(hdr, 0); err != nil {
+		log.Error("Cannot read file segment header", "path", s.path, "err", err)
+		return err
+	}
+
+	// Header fields following the magic & checksum.
+	field := func(i int) uint64 {
+		return binary.BigEndian.Uint64(hdr[len(FileSegmentMagic)+FileSegmentChecksumSize+i*8:])
+	}
+	switch string(hdr[:len(FileSegmentMagic)]) {
+	case FileSegmentMagic:
+		s.typ = SegmentETH1
+		s.indexOffset, s.count, s.capacity = int64(field(0)), int(field(1)), field(2)
+		s.dataOffset, s.blockIndexOffset = int64(FileSegmentHeaderSize), int64(field(0))
+		if s.size-s.indexOffset != int64(s.capacity)*8 {
+			return errors.New("ethdb: unexpected index size")
+		}
+	case CompressedFileSegmentMagic:
+		if len(hdr) < CompressedFileSegmentHeaderSize {
+			return errors.New("ethdb: file header too short")
+		}
+		s.typ = SegmentETH2
+		s.blockIndexOffset, s.indexOffset = int64(field(0)), int64(field(2))
+		s.count, s.capacity = int(field(3)), field(4)
+		s.dataOffset = int64(CompressedFileSegmentHeaderSize)
+		if s.indexOffset-s.blockIndexOffset != int64(field(1))*8 || s.size-s.indexOffset != int64(s.capacity)*CompressedFileSegmentIndexElemSize {
+			return errors.New("ethdb: unexpected index size")
+		}
+	default:
+		return errors.New("ethdb: invalid ethdb file")
+	}
+	if s.blockIndexOffset < s.dataOffset || s.indexOffset < s.blockIndexOffset {
+		return errors.New("ethdb: index offset out of bound")
+	}
+
+	// Read the block index (if any) and the key index in one go.
+	indexes := make([]byte, s.size-s.blockIndexOffset)
+	if err := s.readAt(indexes, s.blockIndexOffset); err != nil {
+		log.Error("Cannot read file segment index", "path", s.path, "err", err)
+		return err
+	}
+	s.blockIndex, s.index = indexes[:s.indexOffset-s.blockIndexOffset], indexes[s.indexOffset-s.blockIndexOffset:]
+	return nil
+}
+
+// Close releases the indexes. The underlying reader is owned by the caller.
+func (s *ReaderFileSegment) Close() error {
+	s.index, s.blockIndex = nil, nil
+	return nil
+}
+
+// Name returns the name of the segment.
+func (s *ReaderFileSegment) Name() string { return s.name }
+
+// Path returns the path of the segment.
+func (s *ReaderFileSegment) Path() string { return s.path }
+
+// Type returns the file type of the segment, SegmentETH1 or SegmentETH2.
+func (s *ReaderFileSegment) Type() string { return s.typ }
+
+// Size returns the size of the segment data.
+func (s *ReaderFileSegment) Size() int64 { return s.size }
+
+// Len returns the number of keys in the segment.
+func (s *ReaderFileSegment) Len() int { return s.count }
+
+// Has returns true if the key exists.
+func (s *ReaderFileSegment) Has(key []byte) (bool, error) {
+	value, err := s.get(key)
+	return value != nil, err
+}
+
+// Get returns the value of the given key.
+func (s *ReaderFileSegment) Get(key []byte) ([]byte, error) {
+	value, err := s.get(key)
+	if err != nil {
+		return nil, err
+	} else if value == nil {
+		return nil, common.ErrNotFound
+	}
+	return value, nil
+}
+
+// get returns the value of the given key, or nil if the key doesn't exist.
+func (s *ReaderFileSegment) get(key []byte) ([]byte, error) {
+	if s.capacity == 0 {
+		return nil, nil
+	}
+	mask := s.capacity - 1
+	hash := hashKey(key)
+	pos := hash & mask
+
+	for d := uint64(0); ; d++ {
+		var (
+			currHash uint64
+			curr     []byte
+			next     int64
+			err      error
+		)
+		if s.typ == SegmentETH1 {
+			// Exit if empty slot found, read current key & compute hash otherwise.
+			offset := int64(binary.BigEndian.Uint64(s.index[pos*8:]))
+			if offset == 0 {
+				return nil, nil
+			}
+			if curr, next, err = s.readFrame(offset); err != nil {
+				log.Error("Cannot read key in file segment", "path", s.path, "key", fmt.Sprintf("%x", key), "err", err)
+				return nil, err
+			}
+			currHash = hashKey(curr)
+		} else {
+			// Exit if empty slot found, the hash is stored alongside the position.
+			elem := s.index[pos*CompressedFileSegmentIndexElemSize:]
+			if binary.BigEndian.Uint64(elem[8:]) == 0 {
+				return nil, nil
+			}
+			currHash = binary.BigEndian.Uint64(elem)
+		}
+
+		// Exit if distance exceeds current slot.
+		if d > dist(currHash, pos, s.capacity, mask) {
+			return nil, nil
+		}
+		if currHash == hash {
+			if s.typ == SegmentETH1 {
+				if bytes.Equal(curr, key) {
+					value, _, err := s.readFrame(next)
+					return value, err
+				}
+			} else {
+				loc := binary.BigEndian.Uint64(s.index[pos*CompressedFileSegmentIndexElemSize+8:])
+				block, err := s.block(int(loc>>32) - 1)
+				if err != nil {
+					log.Error("Cannot read block in compressed file segment", "path", s.path, "key", fmt.Sprintf("%x", key), "err", err)
+					return nil, err
+				}
+				curr, value, _, err := readKeyValue(block, int(loc&0xffffffff))
+				if err != nil {
+					return nil, err
+				} else if bytes.Equal(curr, key) {
+					return common.CopyBytes(value), nil
+				}
+			}
+		}
+		pos = (pos + 1) & mask
+	}
+}
+
+// readFrame reads the length prefixed byte slice at offset, returning the
+// offset following it.
+func (s *ReaderFileSegment) readFrame(offset int64) ([]byte, int64, error) {
+	if offset < s.dataOffset || offset >= s.blockIndexOffset {
+		return nil, 0, fmt.Errorf("ethdb: offset out of bound: %d", offset)
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	if max := s.blockIndexOffset - offset; max < int64(len(buf)) {
+		buf = buf[:max]
+	}
+	if err := s.readAt(buf, offset); err != nil {
+		return nil, 0, err
+	}
+	n, sz := binary.Uvarint(buf)
+	if sz <= 0 || n > uint64(s.blockIndexOffset-offset-int64(sz)) {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, n)
+	if err := s.readAt(data, offset+int64(sz)); err != nil {
+		return nil, 0, err
+	}
+	return data, offset + int64(sz) + int64(n), nil
+}
+
+// block reads and decompresses the i-th data block of a compressed segment.
+func (s *ReaderFileSegment) block(i int) ([]byte, error) {
+	if i < 0 || i*8 >= len(s.blockIndex) {
+		return nil, fmt.Errorf("ethdb: block %d out of range", i)
+	}
+	start, end := int64(binary.BigEndian.Uint64(s.blockIndex[i*8:])), s.blockIndexOffset
+	if (i+1)*8 < len(s.blockIndex) {
+		end = int64(binary.BigEndian.Uint64(s.blockIndex[(i+1)*8:]))
+	}
+	if start < s.dataOffset || end < start || end > s.blockIndexOffset {
+		return nil, fmt.Errorf("ethdb: block %d offset out of bound: %d", i, start)
+	}
+	buf := make([]byte, end-start)
+	if err := s.readAt(buf, start); err != nil {
+		return nil, err
+	}
+	block, _, err := decodeBlock(buf)
+	return block, err
+}
+
+// readAt fills buf with the data at offset.
+func (s *ReaderFileSegment) readAt(buf []byte, offset int64) error {
+	n, err := s.r.ReadAt(buf, offset)
+	if n == len(buf) {
+		return nil
+	} else if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Iterator returns an iterator for iterating over all key/value pairs.
+func (s *ReaderFileSegment) Iterator() SegmentIterator {
+	itr := &ReaderFileSegmentIterator{segment: s}
+	if s.typ == SegmentETH1 {
+		itr.r = bufio.NewReader(io.NewSectionReader(s.r, s.dataOffset, s.blockIndexOffset-s.dataOffset))
+	}
+	return itr
+}
+
+// Ensure implementation implements interface.
+var _ SegmentIterator = (*ReaderFileSegmentIterator)(nil)
+
+// ReaderFileSegmentIterator sequentially iterates over a ReaderFileSegment's
+// key/value pairs. Keys and values are only valid until the next call to Next().
+type ReaderFileSegmentIterator struct {
+	segment *ReaderFileSegment
+	r       *bufio.Reader // data reader of plain segments
+	block   int           // index of the next block of compressed segments
+	data    []byte        // current uncompressed block
+	offset  int           // offset within the current block
+	err     error
+
+	key   []byte
+	value []byte
+}
+
+// Close releases the iterator and returns any error encountered while reading.
+func (itr *ReaderFileSegmentIterator) Close() error {
+	itr.segment, itr.r, itr.data = nil, nil, nil
+	itr.key, itr.value = nil, nil
+	return itr.err
+}
+
+// Key returns the current key. Must be called after Next().
+func (itr *ReaderFileSegmentIterator) Key() []byte { return itr.key }
+
+// Value returns the current key. Must be called after Next().
+func (itr *ReaderFileSegmentIterator) Value() []byte { return itr.value }
+
+// Next reads the next key/value pair into the buffer.
+func (itr *ReaderFileSegmentIterator) Next() bool {
+	if itr.segment == nil || itr.err != nil {
+		return false
+	}
+
+	// Plain segments are read sequentially.
+	if itr.r != nil {
+		if _, err := itr.r.Peek(1); err == io.EOF {
+			return false
+		}
+		if itr.key, itr.err = readFrame(itr.r); itr.err != nil {
+			return false
+		}
+		if itr.value, itr.err = readFrame(itr.r); itr.err != nil {
+			return false
+		}
+		return true
+	}
+
+	// Compressed segments are read one block at a time.
+	for itr.offset >= len(itr.data) {
+		if itr.block*8 >= len(itr.segment.blockIndex) {
+			return false
+		}
+		if itr.data, itr.err = itr.segment.block(itr.block); itr.err != nil {
+			return false
+		}
+		itr.block, itr.offset = itr.block+1, 0
+	}
+	var n int
+	if itr.key, itr.value, n, itr.err = readKeyValue(itr.data, itr.offset); itr.err != nil {
+		return false
+	}
+	itr.offset += n
+	return true
+}
+
+// readFrame reads a length prefixed byte slice from r.
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/log"
+)
+
+// ObjectStore represents a store of immutable objects that segments are
+// archived to. Keys are slash separated, e.g. "<table>/<segment>".
+type ObjectStore interface {
+	// ListObjectKeys returns a list of all object keys with a given prefix.
+	ListObjectKeys(prefix string) ([]string, error)
+
+	// ObjectSize returns the size of the object at key.
+	ObjectSize(ctx context.Context, key string) (int64, error)
+
+	// GetObjectRange fills buf with the bytes of the object at key starting at offset.
+	GetObjectRange(ctx context.Context, key string, offset int64, buf []byte) error
+
+	// FGetObject fetches the object at key and atomically writes it to path.
+	FGetObject(ctx context.Context, key, path string) error
+
+	// FPutObject writes an object to key from a file at path.
+	FPutObject(ctx context.Context, key, path string) (int64, error)
+
+	// RemoveObject removes an object by key.
+	RemoveObject(ctx context.Context, key string) error
+}
+
+// Ensure implementations implement interface.
+var (
+	_ ObjectStore = (*Client)(nil)
+	_ ObjectStore = (*DirStore)(nil)
+	_ ObjectStore = (*HTTPStore)(nil)
+)
+
+// Archive stores ethdb immutable segments in an object store, and holds the
+// settings and caches used to serve them, shared by all tables.
+type Archive struct {
+	Store ObjectStore
+
+	// Serve segments with ranged reads instead of downloading them whole.
+	RangeReads bool
+
+	// Cache of ranged reads shared by all segments. Nil disables caching.
+	BlockCache *BlockCache
+
+	// Budget of the local copies of segments shared by all tables. Nil purges
+	// local copies as soon as their segment is closed by its table.
+	DiskCache *DiskCache
+}
+
+// NewArchive returns a new instance of Archive storing segments in store.
+func NewArchive(store ObjectStore) *Archive {
+	return &Archive{Store: store}
+}
+
+// putObject writes the object at key from the file at path, and invalidates
+// the cached blocks of the object it replaces.
+func (a *Archive) putObject(ctx context.Context, key, path string) (int64, error) {
+	n, err := a.Store.FPutObject(ctx, key, path)
+	a.BlockCache.invalidate(key)
+	return n, err
+}
+
+// Archive backends.
+const (
+	ArchiveS3   = "s3"
+	ArchiveDir  = "dir"
+	ArchiveHTTP = "http"
+)
+
+// OpenObjectStore opens the object store of the archive backend selected by
+// config: an S3 compatible bucket, a cold directory or a generic HTTP object
+// store. Returns nil if no archive is configured.
+func OpenObjectStore(config ethdb.Config) (ObjectStore, error) {
+	backend := config.Archive
+	if backend == "" && config.Endpoint != "" && config.Bucket != "" {
+		backend = ArchiveS3
+	}
+
+	switch backend {
+	case "":
+		return nil, nil
+	case ArchiveS3:
+		c := NewClient()
+		c.Endpoint = config.Endpoint
+		c.Bucket = config.Bucket
+		c.AccessKeyID = config.AccessKeyID
+		c.SecretAccessKey = config.SecretAccessKey
+		if err := c.Open(); err != nil {
+			log.Error("Cannot open S3 client", "err", err)
+			return nil, err
+		}
+		return c, nil
+	case ArchiveDir:
+		s := NewDirStore(config.ArchiveDir)
+		if err := s.Open(); err != nil {
+			log.Error("Cannot open archive directory", "err", err)
+			return nil, err
+		}
+		return s, nil
+	case ArchiveHTTP:
+		s := NewHTTPStore(config.ArchiveURL)
+		s.Token = config.ArchiveToken
+		if err := s.Open(); err != nil {
+			log.Error("Cannot open HTTP object store", "err", err)
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("ethdb/s3: unknown archive backend: %q", backend)
+	}
+}
@@ -0,0 +1,329 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/ethdb/s3"
+	"github.com/ChainAAS/gendchain/ethdb/s3/s3test"
+)
+
+func TestObjectStore(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		open func(tb testing.TB, dir string) (s3.ObjectStore, func())
+	}{
+		{"s3", func(tb testing.TB, dir string) (s3.ObjectStore, func()) {
+			srv := s3test.NewServer("test")
+			return MustOpenTestArchive(tb, srv).Store, srv.Close
+		}},
+		{"dir", func(tb testing.TB, dir string) (s3.ObjectStore, func()) {
+			store := s3.NewDirStore(filepath.Join(dir, "archive"))
+			if err := os.Mkdir(store.Path, 0777); err != nil {
+				tb.Fatal(err)
+			} else if err := store.Open(); err != nil {
+				tb.Fatal(err)
+			}
+			return store, func() {}
+		}},
+		{"http", func(tb testing.TB, dir string) (s3.ObjectStore, func()) {
+			srv := s3test.NewHTTPServer()
+			store := s3.NewHTTPStore(srv.URL())
+			if err := store.Open(); err != nil {
+				tb.Fatal(err)
+			}
+			return store, srv.Close
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			store, closeStore := tt.open(t, dir)
+			defer closeStore()
+			ctx := context.Background()
+
+			// Write objects from local files.
+			path := filepath.Join(dir, "obj")
+			if err := ioutil.WriteFile(path, []byte("0123456789"), 0666); err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range []string{"body/0000", "body/0001", "header/0000"} {
+				if n, err := store.FPutObject(ctx, key, path); err != nil {
+					t.Fatal(err)
+				} else if n != 10 {
+					t.Fatalf("unexpected size written: %d", n)
+				}
+			}
+
+			// List keys by table.
+			if keys, err := store.ListObjectKeys("body"); err != nil {
+				t.Fatal(err)
+			} else if !reflect.DeepEqual(keys, []string{"body/0000", "body/0001"}) {
+				t.Fatalf("unexpected keys: %v", keys)
+			}
+
+			// Read objects whole and in part.
+			if n, err := store.ObjectSize(ctx, "body/0001"); err != nil {
+				t.Fatal(err)
+			} else if n != 10 {
+				t.Fatalf("unexpected size: %d", n)
+			}
+			buf := make([]byte, 4)
+			if err := store.GetObjectRange(ctx, "body/0001", 3, buf); err != nil {
+				t.Fatal(err)
+			} else if string(buf) != "3456" {
+				t.Fatalf("unexpected range: %q", buf)
+			}
+			if err := store.FGetObject(ctx, "header/0000", filepath.Join(dir, "copy")); err != nil {
+				t.Fatal(err)
+			} else if data, err := ioutil.ReadFile(filepath.Join(dir, "copy")); err != nil {
+				t.Fatal(err)
+			} else if string(data) != "0123456789" {
+				t.Fatalf("unexpected object: %q", data)
+			}
+
+			// Removed objects no longer exist.
+			if err := store.RemoveObject(ctx, "body/0000"); err != nil {
+				t.Fatal(err)
+			} else if keys, err := store.ListObjectKeys("body"); err != nil {
+				t.Fatal(err)
+			} else if !reflect.DeepEqual(keys, []string{"body/0001"}) {
+				t.Fatalf("unexpected keys: %v", keys)
+			}
+			if _, err := store.ObjectSize(ctx, "body/0000"); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestConfigureDB_DirArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archiveDir := filepath.Join(dir, "archive")
+	if err := os.Mkdir(archiveDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	db := ethdb.NewDB(filepath.Join(dir, "db"))
+	config := ethdb.NewConfig()
+	config.Archive, config.ArchiveDir = s3.ArchiveDir, archiveDir
+	config.FullDownload = true
+	if err := s3.ConfigureDB(db, config); err != nil {
+		t.Fatal(err)
+	}
+	archive := db.SegmentCompactor.(*s3.SegmentCompactor).Archive
+	if _, ok := archive.Store.(*s3.DirStore); !ok {
+		t.Fatalf("unexpected store: %T", archive.Store)
+	} else if archive.RangeReads {
+		t.Fatal("unexpected range reads")
+	}
+
+	// Compacted segments are archived to the directory and fetched back.
+	keys, values := MustCompactTestSegment(t, archive, ethdb.CompressionSnappy, dir)
+	if _, err := os.Stat(filepath.Join(archiveDir, "body", "0000")); err != nil {
+		t.Fatal(err)
+	}
+	segment := s3.NewSegment(archive, "body", "0000", filepath.Join(dir, "0000"))
+	defer segment.Close()
+	if v, err := segment.Get(keys[1]); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, values[1]) {
+		t.Fatal("value mismatch")
+	}
+
+	// Unknown backends are rejected.
+	config.Archive = "ftp"
+	if err := s3.ConfigureDB(db, config); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestTable_PruneArchived(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := s3.NewDirStore(filepath.Join(dir, "archive"))
+	if err := os.Mkdir(store.Path, 0777); err != nil {
+		t.Fatal(err)
+	}
+	archive := s3.NewArchive(store)
+
+	tbl := MustOpenArchivedTable(t, filepath.Join(dir, "body"), archive)
+	key := numHashKey('b', 200, common.Hash{})
+	if err := tbl.Put(key, []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Put(numHashKey('b', 1500, common.Hash{}), []byte("baz")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	name := tbl.Partitioner.Partition(key)
+	if _, err := tbl.Get(key); err != nil {
+		t.Fatal(err)
+	}
+
+	// Archived segments before the tail lose their local copy only.
+	tail := tbl.Partitioner.Partition(numHashKey('b', 1500, common.Hash{}))
+	if names, err := tbl.Prune(context.Background(), tail); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(names, []string{name}) {
+		t.Fatalf("unexpected pruned segments: %v", names)
+	} else if _, err := os.Stat(tbl.SegmentPath(name)); !os.IsNotExist(err) {
+		t.Fatalf("expected local copy to be removed: %v", err)
+	}
+	get := func(tbl *ethdb.Table) {
+		t.Helper()
+		if v, err := tbl.Get(key); err != nil || string(v) != "bar" {
+			t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+		} else if err := tbl.Put(key, []byte("foo")); err != ethdb.ErrImmutableSegment {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	get(tbl)
+
+	// They are still served from the archive after a restart.
+	tbl.Close()
+	tbl = MustOpenArchivedTable(t, filepath.Join(dir, "body"), archive)
+	defer tbl.Close()
+	get(tbl)
+}
+
+func TestTable_ImportSegmentArchived(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := s3.NewDirStore(filepath.Join(dir, "archive"))
+	if err := os.Mkdir(store.Path, 0777); err != nil {
+		t.Fatal(err)
+	}
+	archive := s3.NewArchive(store)
+
+	// Compact a segment in a local table to import.
+	src := ethdb.NewTable("body", filepath.Join(dir, "src"), ethdb.NewBlockNumberPartitioner(1000))
+	src.MinCompactionAge = 0 // compact immediately
+	src.MinMutableSegmentCount = 1
+	if err := src.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	key := numHashKey('b', 200, common.Hash{})
+	if err := src.Put(key, []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := src.Put(numHashKey('b', 1500, common.Hash{}), []byte("baz")); err != nil {
+		t.Fatal(err)
+	} else if err := src.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	segment := src.SegmentSlice()[0]
+
+	// The imported segment is uploaded before it is served.
+	dst := MustOpenArchivedTable(t, filepath.Join(dir, "dst"), archive)
+	defer dst.Close()
+	if err := dst.ImportSegment(context.Background(), segment.Name(), segment.Path()); err != nil {
+		t.Fatal(err)
+	} else if _, err := os.Stat(filepath.Join(store.Path, "body", segment.Name())); err != nil {
+		t.Fatalf("expected archived copy: %v", err)
+	} else if _, ok := dst.Manifest().Entry(segment.Name()); !ok {
+		t.Fatal("expected manifest entry")
+	} else if v, err := dst.Get(key); err != nil || string(v) != "bar" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	}
+
+	// Corrupt files are not imported.
+	MustCorruptFile(t, segment.Path())
+	if err := dst.ImportSegment(context.Background(), "0000000000000bb8", segment.Path()); err != ethdb.ErrFileSegmentChecksumMismatch {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := os.Stat(dst.SegmentPath("0000000000000bb8")); !os.IsNotExist(err) {
+		t.Fatalf("expected copy to be removed: %v", err)
+	}
+}
+
+func TestTable_FetchCorruptRetried(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := &corruptingStore{DirStore: s3.NewDirStore(filepath.Join(dir, "archive"))}
+	if err := os.Mkdir(store.Path, 0777); err != nil {
+		t.Fatal(err)
+	}
+	archive := s3.NewArchive(store)
+
+	tbl := MustOpenArchivedTable(t, filepath.Join(dir, "body"), archive)
+	defer tbl.Close()
+	key := numHashKey('b', 200, common.Hash{})
+	if err := tbl.Put(key, []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Put(numHashKey('b', 1500, common.Hash{}), []byte("baz")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tail := tbl.Partitioner.Partition(numHashKey('b', 1500, common.Hash{}))
+	if _, err := tbl.Prune(context.Background(), tail); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first download is corrupt and fails its checksum, so it is fetched again.
+	store.corrupt = 1
+	if v, err := tbl.Get(key); err != nil || string(v) != "bar" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	} else if store.corrupt != 0 {
+		t.Fatal("expected corrupt download")
+	}
+}
+
+// corruptingStore corrupts the next corrupt downloads from the DirStore.
+type corruptingStore struct {
+	*s3.DirStore
+	corrupt int
+}
+
+func (s *corruptingStore) FGetObject(ctx context.Context, key, path string) error {
+	if err := s.DirStore.FGetObject(ctx, key, path); err != nil {
+		return err
+	}
+	if s.corrupt > 0 {
+		s.corrupt--
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		buf[len(buf)-1] ^= 0xff
+		return ioutil.WriteFile(path, buf, 0666)
+	}
+	return nil
+}
+
+// MustOpenArchivedTable opens a body table at path backed by archive.
+func MustOpenArchivedTable(tb testing.TB, path string, archive *s3.Archive) *ethdb.Table {
+	tb.Helper()
+	tbl := ethdb.NewTable("body", path, ethdb.NewBlockNumberPartitioner(1000))
+	tbl.MinCompactionAge = 0 // compact immediately
+	tbl.MinMutableSegmentCount = 1
+	tbl.SegmentOpener = s3.NewSegmentOpener(archive)
+	tbl.SegmentCompactor = s3.NewSegmentCompactor(archive)
+	if err := tbl.Open(); err != nil {
+		tb.Fatal(err)
+	}
+	return tbl
+}
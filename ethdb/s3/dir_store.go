@@ -0,0 +1,118 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/log"
+)
+
+// DirStore represents an object store in a plain directory, such as a cold
+// storage volume or a mounted network share. Objects are stored as files at
+// their key under the directory.
+type DirStore struct {
+	// Root directory of the store. Must be set before calling Open().
+	Path string
+}
+
+// NewDirStore returns a new instance of DirStore rooted at path.
+func NewDirStore(path string) *DirStore {
+	return &DirStore{Path: path}
+}
+
+// Open verifies the root directory exists.
+func (s *DirStore) Open() error {
+	if s.Path == "" {
+		return fmt.Errorf("ethdb/s3: archive directory required")
+	} else if fi, err := os.Stat(s.Path); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return fmt.Errorf("ethdb/s3: archive path is not a directory: %s", s.Path)
+	}
+	return nil
+}
+
+// objectPath returns the path of the file holding the object at key.
+func (s *DirStore) objectPath(key string) string {
+	return filepath.Join(s.Path, filepath.FromSlash(key))
+}
+
+// ListObjectKeys returns a list of all object keys with a given prefix.
+func (s *DirStore) ListObjectKeys(prefix string) ([]string, error) {
+	log.Info("List archive directory keys", "prefix", prefix)
+
+	var keys []string
+	if err := filepath.Walk(s.Path, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if fi.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.Path, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ObjectSize returns the size of the object at key.
+func (s *DirStore) ObjectSize(ctx context.Context, key string) (int64, error) {
+	fi, err := os.Stat(s.objectPath(key))
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// GetObjectRange fills buf with the bytes of the object at key starting at offset.
+func (s *DirStore) GetObjectRange(ctx context.Context, key string, offset int64, buf []byte) error {
+	f, err := os.Open(s.objectPath(key))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := f.ReadAt(buf, offset)
+	downloadBytesMeter.Mark(int64(n))
+	if n == len(buf) {
+		return nil
+	} else if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// FGetObject copies the object at key and atomically writes it to path.
+func (s *DirStore) FGetObject(ctx context.Context, key, path string) error {
+	n, err := ethdb.CopyFile(s.objectPath(key), path)
+	downloadBytesMeter.Mark(n)
+	return err
+}
+
+// FPutObject atomically writes an object to key from a file at path.
+func (s *DirStore) FPutObject(ctx context.Context, key, path string) (int64, error) {
+	dst := s.objectPath(key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return 0, err
+	}
+	n, err := ethdb.CopyFile(path, dst)
+	uploadBytesMeter.Mark(n)
+	return n, err
+}
+
+// RemoveObject removes an object by key.
+func (s *DirStore) RemoveObject(ctx context.Context, key string) error {
+	return os.Remove(s.objectPath(key))
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/metrics"
+)
+
+var (
+	diskCacheHitMeter   = metrics.NewRegisteredMeter("ethdb/s3/disk/hit", nil)
+	diskCacheMissMeter  = metrics.NewRegisteredMeter("ethdb/s3/disk/miss", nil)
+	diskCacheEvictMeter = metrics.NewRegisteredMeter("ethdb/s3/disk/evict", nil)
+)
+
+// DiskCache bounds the disk space used by the local copies of segments
+// fetched from S3. It tracks segment access across all tables and purges the
+// least recently used local copies when over budget.
+type DiskCache struct {
+	mu      sync.Mutex
+	list    *list.List               // local copies, most recently used first
+	entries map[string]*list.Element // local copies by path
+	size    int64                    // total size of local copies
+	maxSize int64                    // budget in bytes
+
+	wg sync.WaitGroup // pending purges
+}
+
+// diskCacheEntry is a local copy of a segment.
+type diskCacheEntry struct {
+	segment *Segment
+	size    int64
+}
+
+// NewDiskCache returns a new instance of DiskCache holding up to maxSize bytes.
+func NewDiskCache(maxSize int64) *DiskCache {
+	return &DiskCache{
+		list:    list.New(),
+		entries: make(map[string]*list.Element),
+		maxSize: maxSize,
+	}
+}
+
+// Len returns the number of local copies tracked.
+func (c *DiskCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.list.Len()
+}
+
+// Size returns the total size of the local copies tracked.
+func (c *DiskCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// MaxSize returns the budget of the cache in bytes.
+func (c *DiskCache) MaxSize() int64 { return c.maxSize }
+
+// Wait blocks until pending purges of evicted local copies complete.
+func (c *DiskCache) Wait() { c.wg.Wait() }
+
+// add tracks the local copy of s of the given size as the most recently used,
+// then evicts the least recently used copies while over budget. If recent is
+// false the copy is tracked as the least recently used instead, as done for
+// copies found on disk on startup.
+func (c *DiskCache) add(s *Segment, size int64, recent bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	if elem := c.entries[s.path]; elem != nil {
+		c.size -= elem.Value.(*diskCacheEntry).size
+		c.list.Remove(elem)
+	}
+	entry := &diskCacheEntry{segment: s, size: size}
+	if recent {
+		c.entries[s.path] = c.list.PushFront(entry)
+	} else {
+		c.entries[s.path] = c.list.PushBack(entry)
+	}
+	c.size += size
+
+	// Collect victims, never evicting the copy just added.
+	var victims []*Segment
+	for elem := c.list.Back(); c.size > c.maxSize && elem != nil && elem.Value != entry; {
+		prev := elem.Prev()
+		victim := elem.Value.(*diskCacheEntry)
+		c.list.Remove(elem)
+		delete(c.entries, victim.segment.path)
+		c.size -= victim.size
+		victims = append(victims, victim.segment)
+		elem = prev
+	}
+	c.mu.Unlock()
+
+	if len(victims) == 0 {
+		return
+	}
+	diskCacheEvictMeter.Mark(int64(len(victims)))
+
+	// Purge in the background as the caller may hold locks on other segments.
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for _, s := range victims {
+			log.Info("Evict local segment", "key", SegmentKey(s.table, s.name), "path", s.path)
+			if err := s.Purge(); err != nil {
+				log.Error("Cannot purge local segment", "path", s.path, "err", err)
+			}
+		}
+	}()
+}
+
+// touch marks the local copy of s as the most recently used.
+func (c *DiskCache) touch(s *Segment) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if elem := c.entries[s.path]; elem != nil {
+		c.list.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+}
+
+// remove stops tracking the local copy of s.
+func (c *DiskCache) remove(s *Segment) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if elem := c.entries[s.path]; elem != nil {
+		c.size -= elem.Value.(*diskCacheEntry).size
+		c.list.Remove(elem)
+		delete(c.entries, s.path)
+	}
+	c.mu.Unlock()
+}
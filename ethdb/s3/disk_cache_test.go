@@ -0,0 +1,108 @@
+package s3_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/ethdb/s3"
+	"github.com/ChainAAS/gendchain/ethdb/s3/s3test"
+)
+
+func TestDiskCache_Evict(t *testing.T) {
+	srv := s3test.NewServer("test")
+	defer srv.Close()
+	archive := MustOpenTestArchive(t, srv)
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Upload a segment and copy it to three segment names.
+	keys, values := MustCompactTestSegment(t, archive, ethdb.CompressionNone, dir)
+	object, _ := srv.Object(s3.SegmentKey("body", "0000"))
+	for _, name := range []string{"0001", "0002"} {
+		srv.PutObject(s3.SegmentKey("body", name), object)
+	}
+
+	// Budget local copies for two segments.
+	archive.DiskCache = s3.NewDiskCache(int64(2*len(object) + len(object)/2))
+	opener := s3.NewSegmentOpener(archive)
+	var segments []ethdb.Segment
+	for _, name := range []string{"0000", "0001", "0002"} {
+		s, err := opener.OpenSegment("body", name, filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		segments = append(segments, s)
+	}
+	get := func(i int) {
+		t.Helper()
+		if v, err := segments[i].Get(keys[i]); err != nil {
+			t.Fatal(err)
+		} else if string(v) != string(values[i]) {
+			t.Fatalf("value mismatch for segment %d", i)
+		}
+		archive.DiskCache.Wait()
+	}
+	exists := func(i int) bool {
+		_, err := os.Stat(segments[i].Path())
+		return err == nil
+	}
+
+	// Fetching two segments stays within budget.
+	get(0)
+	get(1)
+	get(0)
+	if !exists(0) || !exists(1) {
+		t.Fatal("expected local copies")
+	} else if n := archive.DiskCache.Len(); n != 2 {
+		t.Fatalf("unexpected cache len: %d", n)
+	}
+
+	// Fetching a third segment evicts the least recently used copy.
+	get(2)
+	if exists(1) {
+		t.Fatal("expected segment 1 to be evicted")
+	} else if !exists(0) || !exists(2) {
+		t.Fatal("expected local copies of segments 0 & 2")
+	} else if size := archive.DiskCache.Size(); size > archive.DiskCache.MaxSize() {
+		t.Fatalf("cache over budget: %d", size)
+	}
+
+	// Evicted segments are fetched again on access.
+	get(1)
+	if !exists(1) || exists(0) {
+		t.Fatal("expected segment 0 to be evicted for segment 1")
+	}
+
+	// Closing a segment keeps its local copy while tracked by the cache.
+	if err := segments[1].(*s3.Segment).Evict(); err != nil {
+		t.Fatal(err)
+	} else if !exists(1) {
+		t.Fatal("expected local copy to be kept")
+	}
+
+	// Local copies found on open are tracked, and evicted first.
+	archive.DiskCache = s3.NewDiskCache(int64(2*len(object) + len(object)/2))
+	opener = s3.NewSegmentOpener(archive)
+	for i, name := range []string{"0001", "0002"} {
+		s, err := opener.OpenSegment("body", name, filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments[i+1] = s
+	}
+	if n := archive.DiskCache.Len(); n != 2 {
+		t.Fatalf("unexpected cache len: %d", n)
+	}
+	get(0)
+	if !exists(0) || exists(1) == exists(2) {
+		t.Fatal("expected one local copy found on open to be evicted")
+	}
+}
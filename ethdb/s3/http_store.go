@@ -0,0 +1,196 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/ChainAAS/gendchain/log"
+)
+
+// HTTPStore represents a generic HTTP object store, such as a gateway in front
+// of a GCS or Azure blob container. Objects live at "<URL>/<key>" and are
+// read with GET and HEAD (honoring Range headers), written with PUT and
+// removed with DELETE. Keys are listed by a GET of "<URL>/?prefix=<prefix>",
+// answered with a JSON array of keys.
+type HTTPStore struct {
+	// Base URL of the store. Must be set before calling Open().
+	URL string
+
+	// Bearer token sent with every request, if set.
+	Token string
+
+	// HTTP client used for requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// NewHTTPStore returns a new instance of HTTPStore at the given base URL.
+func NewHTTPStore(url string) *HTTPStore {
+	return &HTTPStore{URL: url}
+}
+
+// Open verifies the base URL.
+func (s *HTTPStore) Open() error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("ethdb/s3: invalid object store url: %q", s.URL)
+	}
+	s.URL = strings.TrimSuffix(s.URL, "/")
+	return nil
+}
+
+// do sends a request for the object at key, or for the store itself if key
+// is blank, and verifies the response status.
+func (s *HTTPStore) do(ctx context.Context, method, key string, body io.Reader, fn func(*http.Request)) (*http.Response, error) {
+	u := s.URL + "/" + (&url.URL{Path: key}).EscapedPath()
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	if fn != nil {
+		fn(req)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("ethdb/s3: %s %s: %s", method, key, resp.Status)
+	}
+	return resp, nil
+}
+
+// ListObjectKeys returns a list of all object keys with a given prefix.
+func (s *HTTPStore) ListObjectKeys(prefix string) ([]string, error) {
+	log.Info("List object store keys", "prefix", prefix)
+
+	resp, err := s.do(context.TODO(), http.MethodGet, "", nil, func(req *http.Request) {
+		req.URL.RawQuery = url.Values{"prefix": {prefix}}.Encode()
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var keys []string
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// ObjectSize returns the size of the object at key.
+func (s *HTTPStore) ObjectSize(ctx context.Context, key string) (int64, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.ContentLength < 0 {
+		return 0, fmt.Errorf("ethdb/s3: unknown object size: %s", key)
+	}
+	return resp.ContentLength, nil
+}
+
+// GetObjectRange fills buf with the bytes of the object at key starting at offset.
+func (s *HTTPStore) GetObjectRange(ctx context.Context, key string, offset int64, buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, func(req *http.Request) {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+int64(len(buf))-1))
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("ethdb/s3: object store ignored range request: %s", key)
+	}
+
+	n, err := io.ReadFull(resp.Body, buf)
+	downloadBytesMeter.Mark(int64(n))
+	return err
+}
+
+// FGetObject fetches the object at key and atomically writes it to path.
+func (s *HTTPStore) FGetObject(ctx context.Context, key, path string) (err error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	n, err := io.Copy(f, resp.Body)
+	downloadBytesMeter.Mark(n)
+	if err != nil {
+		f.Close()
+		return err
+	} else if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// FPutObject writes an object to key from a file at path.
+func (s *HTTPStore) FPutObject(ctx context.Context, key, path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, f, func(req *http.Request) {
+		req.ContentLength = fi.Size()
+		req.Header.Set("Content-Type", "application/octet-stream")
+	})
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	uploadBytesMeter.Mark(fi.Size())
+	return fi.Size(), nil
+}
+
+// RemoveObject removes an object by key.
+func (s *HTTPStore) RemoveObject(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
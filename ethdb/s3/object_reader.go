@@ -0,0 +1,198 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/ChainAAS/gendchain/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+var (
+	blockCacheHitMeter  = metrics.NewRegisteredMeter("ethdb/s3/cache/hit", nil)
+	blockCacheMissMeter = metrics.NewRegisteredMeter("ethdb/s3/cache/miss", nil)
+)
+
+const (
+	// BlockSize is the size of the object blocks fetched by ranged reads and
+	// kept in the block cache.
+	BlockSize = 64 * 1024
+
+	// MaxCachedReadBlocks is the number of blocks above which a single read
+	// bypasses the block cache, so that reading indexes doesn't evict the
+	// blocks of other segments.
+	MaxCachedReadBlocks = 16
+)
+
+// BlockCache is a bounded LRU cache of object blocks, shared by segments.
+// Blocks are cached per generation of their object, which is bumped whenever
+// the object is overwritten, so blocks of the replaced object are never
+// served and age out of the cache.
+type BlockCache struct {
+	cache *lru.Cache
+
+	mu          sync.Mutex
+	generations map[string]uint64 // by object key, if ever overwritten
+}
+
+// NewBlockCache returns a new instance of BlockCache holding up to size bytes.
+func NewBlockCache(size int) *BlockCache {
+	n := size / BlockSize
+	if n < 1 {
+		n = 1
+	}
+	cache, _ := lru.New(n)
+	return &BlockCache{
+		cache:       cache,
+		generations: make(map[string]uint64),
+	}
+}
+
+// blockCacheKey identifies a block of a generation of an object.
+type blockCacheKey struct {
+	key        string
+	generation uint64
+	index      int64
+}
+
+// generation returns the current generation of an object.
+func (c *BlockCache) generation(key string) uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generations[key]
+}
+
+// invalidate stops serving the cached blocks of an object once it is
+// overwritten.
+func (c *BlockCache) invalidate(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.generations[key]++
+	c.mu.Unlock()
+}
+
+// get returns a cached block, if any.
+func (c *BlockCache) get(key blockCacheKey) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	if block, ok := c.cache.Get(key); ok {
+		blockCacheHitMeter.Mark(1)
+		return block.([]byte), true
+	}
+	blockCacheMissMeter.Mark(1)
+	return nil, false
+}
+
+// contains returns whether a block is cached.
+func (c *BlockCache) contains(key blockCacheKey) bool {
+	return c != nil && c.cache.Contains(key)
+}
+
+// add caches a block.
+func (c *BlockCache) add(key blockCacheKey, block []byte) {
+	if c != nil {
+		c.cache.Add(key, block)
+	}
+}
+
+// Len returns the number of cached blocks.
+func (c *BlockCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.cache.Len()
+}
+
+// Ensure implementation implements interface.
+var _ io.ReaderAt = (*ObjectReader)(nil)
+
+// ObjectReader reads an archived object with ranged reads, in whole blocks
+// which are kept in the archive's block cache.
+type ObjectReader struct {
+	archive *Archive
+	key     string
+	size    int64
+}
+
+// NewObjectReader returns a new instance of ObjectReader for the object at
+// key of the given size.
+func NewObjectReader(archive *Archive, key string, size int64) *ObjectReader {
+	return &ObjectReader{
+		archive: archive,
+		key:     key,
+		size:    size,
+	}
+}
+
+// ReadAt reads len(p) bytes of the object at offset. Implements io.ReaderAt.
+func (r *ObjectReader) ReadAt(p []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, io.ErrUnexpectedEOF
+	} else if offset >= r.size {
+		return 0, io.EOF
+	}
+
+	// Only read up to the end of the object.
+	var eof error
+	if offset+int64(len(p)) > r.size {
+		p, eof = p[:r.size-offset], io.EOF
+	}
+	if len(p) == 0 {
+		return 0, eof
+	}
+
+	// Serve cached blocks, fetching each run of missing blocks in one request.
+	first, last := offset/BlockSize, (offset+int64(len(p))-1)/BlockSize
+	cache := r.archive.BlockCache
+	if last-first+1 > MaxCachedReadBlocks {
+		cache = nil
+	}
+	// Blocks fetched while the object is overwritten are cached under its
+	// previous generation.
+	generation := cache.generation(r.key)
+	for i := first; i <= last; {
+		if block, ok := cache.get(blockCacheKey{r.key, generation, i}); ok {
+			r.copyBlock(p, offset, i, block)
+			i++
+			continue
+		}
+		j := i + 1
+		for j <= last && !cache.contains(blockCacheKey{r.key, generation, j}) {
+			j++
+		}
+		start, end := i*BlockSize, j*BlockSize
+		if end > r.size {
+			end = r.size
+		}
+		buf := make([]byte, end-start)
+		if err := r.archive.Store.GetObjectRange(context.TODO(), r.key, start, buf); err != nil {
+			return 0, err
+		}
+		for ; i < j; i++ {
+			block := buf[i*BlockSize-start:]
+			if len(block) > BlockSize {
+				block = block[:BlockSize]
+			}
+			cache.add(blockCacheKey{r.key, generation, i}, block)
+			r.copyBlock(p, offset, i, block)
+		}
+	}
+	return len(p), eof
+}
+
+// copyBlock copies the part of block i overlapping with the read of p at offset.
+func (r *ObjectReader) copyBlock(p []byte, offset, i int64, block []byte) {
+	start := i * BlockSize
+	if start < offset {
+		block = block[offset-start:]
+		start = offset
+	}
+	copy(p[start-offset:], block)
+}
@@ -0,0 +1,225 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/ethdb/s3"
+	"github.com/ChainAAS/gendchain/ethdb/s3/s3test"
+)
+
+func TestObjectReader_ReadAt(t *testing.T) {
+	srv := s3test.NewServer("test")
+	defer srv.Close()
+	archive := MustOpenTestArchive(t, srv)
+	archive.BlockCache = s3.NewBlockCache(4 * s3.BlockSize)
+
+	data := make([]byte, 10*s3.BlockSize+123)
+	rand.Read(data)
+	srv.PutObject("obj", data)
+
+	r := s3.NewObjectReader(archive, "obj", int64(len(data)))
+	for i := 0; i < 100; i++ {
+		offset := rand.Int63n(int64(len(data)))
+		buf := make([]byte, rand.Intn(3*s3.BlockSize))
+		n, err := r.ReadAt(buf, offset)
+		if want := int64(len(data)) - offset; want < int64(len(buf)) {
+			if err != io.EOF || int64(n) != want {
+				t.Fatalf("short read at %d: n=%d err=%v, want n=%d EOF", offset, n, err, want)
+			}
+		} else if err != nil || n != len(buf) {
+			t.Fatalf("read at %d: n=%d err=%v", offset, n, err)
+		}
+		if !bytes.Equal(buf[:n], data[offset:offset+int64(n)]) {
+			t.Fatalf("data mismatch at %d", offset)
+		}
+	}
+	if n := archive.BlockCache.Len(); n > 4 {
+		t.Fatalf("block cache exceeds bound: %d blocks", n)
+	}
+
+	// Reading a cached block again doesn't hit the server.
+	buf := make([]byte, 10)
+	r.ReadAt(buf, 0)
+	srv.ResetStats()
+	if _, err := r.ReadAt(buf, 5); err != nil {
+		t.Fatal(err)
+	} else if srv.Requests != 0 {
+		t.Fatalf("unexpected requests: %d", srv.Requests)
+	}
+}
+
+func TestObjectReader_ReadAt_Overwritten(t *testing.T) {
+	srv := s3test.NewServer("test")
+	defer srv.Close()
+	archive := MustOpenTestArchive(t, srv)
+	archive.BlockCache = s3.NewBlockCache(4 * s3.BlockSize)
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Cache the first block of the archived object.
+	key := s3.SegmentKey("body", "0000")
+	data := make([]byte, 2*s3.BlockSize)
+	rand.Read(data)
+	srv.PutObject(key, data)
+	r := s3.NewObjectReader(archive, key, int64(len(data)))
+	buf := make([]byte, 10)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restoring the archived copy overwrites the object with the local copy.
+	local := make([]byte, len(data))
+	rand.Read(local)
+	path := filepath.Join(dir, "0000")
+	if err := ioutil.WriteFile(path, local, 0666); err != nil {
+		t.Fatal(err)
+	} else if err := s3.NewSegment(archive, "body", "0000", path).RestoreArchive(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocks of the overwritten object are no longer served.
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(buf, local[:10]) {
+		t.Fatal("stale block served")
+	}
+}
+
+func TestSegment_RangeReads(t *testing.T) {
+	for _, compression := range []ethdb.Compression{ethdb.CompressionNone, ethdb.CompressionSnappy, ethdb.CompressionZstd} {
+		t.Run(compression.String(), func(t *testing.T) {
+			srv := s3test.NewServer("test")
+			defer srv.Close()
+			archive := MustOpenTestArchive(t, srv)
+			archive.RangeReads = true
+			archive.BlockCache = s3.NewBlockCache(1 << 20)
+
+			dir, err := ioutil.TempDir("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			// Compact and upload a segment with random values.
+			keys, values := MustCompactTestSegment(t, archive, compression, dir)
+
+			// Keys are served with ranged reads, without downloading the file.
+			segment := s3.NewSegment(archive, "body", "0000", filepath.Join(dir, "0000"))
+			defer segment.Close()
+			object, _ := srv.Object(s3.SegmentKey("body", "0000"))
+			srv.ResetStats()
+			for _, i := range []int{0, 2500, 4999} {
+				if v, err := segment.Get(keys[i]); err != nil {
+					t.Fatal(err)
+				} else if !bytes.Equal(v, values[i]) {
+					t.Fatalf("value mismatch for key %d", i)
+				}
+			}
+			if ok, err := segment.Has([]byte("no_such_key")); err != nil || ok {
+				t.Fatalf("unexpected has: %v, %v", ok, err)
+			} else if _, err := segment.Get([]byte("no_such_key")); err != common.ErrNotFound {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := os.Stat(segment.Path()); !os.IsNotExist(err) {
+				t.Fatalf("segment downloaded to disk: %v", err)
+			} else if srv.RangeBytes >= int64(len(object)) {
+				t.Fatalf("read %d bytes of a %d byte segment", srv.RangeBytes, len(object))
+			}
+
+			// Iterating reads back all key/value pairs.
+			itr, i := segment.Iterator(), 0
+			for ; itr.Next(); i++ {
+				if !bytes.Equal(itr.Key(), keys[i]) || !bytes.Equal(itr.Value(), values[i]) {
+					t.Fatalf("iterator mismatch at %d", i)
+				}
+			}
+			if err := itr.Close(); err != nil {
+				t.Fatal(err)
+			} else if i != len(keys) {
+				t.Fatalf("short iterator: %d", i)
+			}
+
+			// Purging a range read segment leaves nothing to remove.
+			if err := segment.Purge(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestSegment_FullDownload(t *testing.T) {
+	srv := s3test.NewServer("test")
+	defer srv.Close()
+	archive := MustOpenTestArchive(t, srv)
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keys, values := MustCompactTestSegment(t, archive, ethdb.CompressionSnappy, dir)
+
+	// Without ranged reads the segment is downloaded on first access.
+	segment := s3.NewSegment(archive, "body", "0000", filepath.Join(dir, "0000"))
+	defer segment.Close()
+	if v, err := segment.Get(keys[10]); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(v, values[10]) {
+		t.Fatal("value mismatch")
+	} else if _, err := os.Stat(segment.Path()); err != nil {
+		t.Fatalf("segment not downloaded: %v", err)
+	}
+}
+
+// MustOpenTestArchive returns an archive in the bucket served by srv.
+func MustOpenTestArchive(tb testing.TB, srv *s3test.Server) *s3.Archive {
+	tb.Helper()
+	client := s3.NewClient()
+	client.Endpoint = srv.Endpoint()
+	client.Bucket = srv.Bucket
+	client.AccessKeyID, client.SecretAccessKey = "test", "test"
+	if err := client.Open(); err != nil {
+		tb.Fatal(err)
+	}
+	return s3.NewArchive(client)
+}
+
+// MustCompactTestSegment writes 5000 random key/value pairs into an LDB
+// segment in dir and compacts it into the "0000" body segment of archive.
+func MustCompactTestSegment(tb testing.TB, archive *s3.Archive, compression ethdb.Compression, dir string) (keys, values [][]byte) {
+	tb.Helper()
+	ldb := ethdb.NewLDBSegment("0000", filepath.Join(dir, "0000"))
+	if err := ldb.Open(); err != nil {
+		tb.Fatal(err)
+	}
+	for i := 0; i < 5000; i++ {
+		key, value := []byte(fmt.Sprintf("key%04d", i)), make([]byte, 1000)
+		rand.Read(value[:100])
+		if err := ldb.Put(key, value); err != nil {
+			tb.Fatal(err)
+		}
+		keys, values = append(keys, key), append(values, value)
+	}
+
+	sc := s3.NewSegmentCompactor(archive)
+	sc.Compression = compression
+	if _, err := sc.CompactSegment(context.Background(), "body", ldb); err != nil {
+		tb.Fatal(err)
+	}
+	return keys, values
+}
@@ -1,9 +1,12 @@
 package s3
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"sort"
@@ -26,26 +29,61 @@ const (
 	// FGetObjectInterval represents the time between attempts to successfully
 	// fetch objects from the S3 store.
 	FGetObjectInterval = 2 * time.Second
+
+	// VerifyReadSize is the size of the ranged reads verifying archived copies.
+	VerifyReadSize = 4 * 1024 * 1024
 )
 
-// ConfigureDB updates db to archive to S3 if S3 configuration enabled.
+// ConfigureDB updates db to archive to an object store if archive configuration
+// enabled, and sets the compression and scrubbing of compacted segments and the
+// engine of mutable segments.
 func ConfigureDB(db *ethdb.DB, config ethdb.Config) error {
-	if config.Endpoint == "" || config.Bucket == "" {
-		return nil
+	compression, err := ethdb.ParseCompression(config.Compression)
+	if err != nil {
+		return err
 	}
+	if db.Engine, err = ethdb.ParseEngine(config.Engine); err != nil {
+		return err
+	}
+	if config.PartitionSize > 0 {
+		db.PartitionSize = config.PartitionSize
+	}
+	db.HistoryWindow = config.HistoryWindow
+	db.ScrubInterval = config.ScrubInterval
+	if db.ScrubInterval == 0 {
+		db.ScrubInterval = ethdb.DefaultScrubInterval
+	} else if db.ScrubInterval < 0 {
+		db.ScrubInterval = 0
+	}
+	db.ScrubArchive = config.ScrubArchive
 
-	c := NewClient()
-	c.Endpoint = config.Endpoint
-	c.Bucket = config.Bucket
-	c.AccessKeyID = config.AccessKeyID
-	c.SecretAccessKey = config.SecretAccessKey
-	if err := c.Open(); err != nil {
-		log.Error("Cannot open S3 client", "err", err)
+	store, err := OpenObjectStore(config)
+	if err != nil {
 		return err
+	} else if store == nil {
+		compactor := ethdb.NewFileSegmentCompactor()
+		compactor.Compression = compression
+		db.SegmentCompactor = compactor
+		return nil
+	}
+
+	archive := NewArchive(store)
+	archive.RangeReads = !config.FullDownload
+	if archive.RangeReads {
+		size := config.BlockCacheSize
+		if size == 0 {
+			size = ethdb.DefaultBlockCacheSize
+		}
+		archive.BlockCache = NewBlockCache(size * 1024 * 1024)
+	}
+	if config.LocalCacheSize > 0 {
+		archive.DiskCache = NewDiskCache(int64(config.LocalCacheSize) * 1024 * 1024)
 	}
 
-	db.SegmentOpener = NewSegmentOpener(c)
-	db.SegmentCompactor = NewSegmentCompactor(c)
+	db.SegmentOpener = NewSegmentOpener(archive)
+	compactor := NewSegmentCompactor(archive)
+	compactor.Compression = compression
+	db.SegmentCompactor = compactor
 
 	return nil
 }
@@ -103,20 +141,7 @@ func (c *Client) ListObjectKeys(prefix string) ([]string, error) {
 }
 
 // FGetObject fetches the object at key and atomically writes it to path.
-// Attempts multiple times until a successful fetch has been acheived.
 func (c *Client) FGetObject(ctx context.Context, key, path string) (err error) {
-	const retry = 5
-	for i := 0; i < retry; i++ {
-		if err = c.tryFGetObject(ctx, key, path); err == nil {
-			return nil
-		}
-		log.Error("Error fetching S3 file segment", "i", i, "path", path, "err", err)
-		time.Sleep(FGetObjectInterval)
-	}
-	return err
-}
-
-func (c *Client) tryFGetObject(ctx context.Context, key, path string) (err error) {
 	tmpPath := path + ".tmp"
 	if err := c.client.FGetObjectWithContext(ctx, c.Bucket, key, tmpPath, minio.GetObjectOptions{}); err != nil {
 		return err
@@ -129,12 +154,6 @@ func (c *Client) tryFGetObject(ctx context.Context, key, path string) (err error
 		downloadBytesMeter.Mark(fi.Size())
 	}
 
-	// Verify file segment checksum matches computed.
-	if err := ethdb.VerifyFileSegment(tmpPath); err != nil {
-		os.Remove(tmpPath)
-		return err
-	}
-
 	// Move file from temp path to actual path.
 	if err := os.Rename(tmpPath, path); err != nil {
 		os.Remove(tmpPath)
@@ -143,6 +162,37 @@ func (c *Client) tryFGetObject(ctx context.Context, key, path string) (err error
 	return nil
 }
 
+// ObjectSize returns the size of the object at key.
+func (c *Client) ObjectSize(ctx context.Context, key string) (int64, error) {
+	info, err := c.client.StatObject(c.Bucket, key, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return 0, os.ErrNotExist
+	} else if err != nil {
+		return 0, err
+	}
+	return info.Size, nil
+}
+
+// GetObjectRange fills buf with the bytes of the object at key starting at offset.
+func (c *Client) GetObjectRange(ctx context.Context, key string, offset int64, buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(offset, offset+int64(len(buf))-1); err != nil {
+		return err
+	}
+	obj, err := c.client.GetObjectWithContext(ctx, c.Bucket, key, opts)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	n, err := io.ReadFull(obj, buf)
+	downloadBytesMeter.Mark(int64(n))
+	return err
+}
+
 // PutObject writes an object to a key.
 func (c *Client) PutObject(ctx context.Context, key string, value []byte) (n int64, err error) {
 	n, err = c.client.PutObjectWithContext(ctx, c.Bucket, key, bytes.NewReader(value), int64(len(value)), minio.PutObjectOptions{})
@@ -162,25 +212,26 @@ func (c *Client) RemoveObject(ctx context.Context, key string) error {
 	return c.client.RemoveObject(c.Bucket, key)
 }
 
-// Segment represents an ethdb.FileSegment stored in S3.
+// Segment represents an ethdb.FileSegment or ethdb.CompressedFileSegment
+// stored in an archive.
 type Segment struct {
 	mu       sync.RWMutex
 	muEnsure sync.Mutex // lock during check for file existence.
 
-	client  *Client
-	segment *ethdb.FileSegment
+	archive *Archive
+	segment ethdb.Segment
 	table   string // table name
 	name    string // segment name
 	path    string // local path
 }
 
 // NewSegment returns a new instance of Segment.
-func NewSegment(client *Client, table, name, path string) *Segment {
+func NewSegment(archive *Archive, table, name, path string) *Segment {
 	return &Segment{
-		client: client,
-		table:  table,
-		name:   name,
-		path:   path,
+		archive: archive,
+		table:   table,
+		name:    name,
+		path:    path,
 	}
 }
 
@@ -190,14 +241,21 @@ func (s *Segment) Name() string { return s.name }
 // Path returns the local path of the segment.
 func (s *Segment) Path() string { return s.path }
 
-// Close closes the underlying file segment.
+// Close closes the underlying file segment. The local file is kept and the
+// segment is reopened on next access.
 func (s *Segment) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.segment != nil {
-		return s.segment.Close()
+	return s.close()
+}
+
+func (s *Segment) close() error {
+	if s.segment == nil {
+		return nil
 	}
-	return nil
+	err := s.segment.Close()
+	s.segment = nil
+	return err
 }
 
 // Purge closes the underlying file segment and removes the on-disk file.
@@ -205,22 +263,28 @@ func (s *Segment) Purge() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.segment == nil {
-		return nil
-	}
-
-	if err := s.segment.Close(); err != nil {
+	if err := s.close(); err != nil {
 		return err
-	} else if err := os.Remove(s.segment.Path()); err != nil {
+	} else if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	s.segment = nil
+	s.archive.DiskCache.remove(s)
 
 	return nil
 }
 
+// Evict closes the segment once its table stops holding it open. The local
+// file is purged, unless the archive's disk cache decides when to purge it.
+func (s *Segment) Evict() error {
+	if s.archive.DiskCache != nil {
+		return s.Close()
+	}
+	return s.Purge()
+}
+
 // ensureFileSegment instantiates the underlying file segment from the local disk.
-// If the segment does not exist locally on disk then it is fetched from S3.
+// If the segment does not exist locally on disk then it is either read from the
+// archive with ranged reads, or fetched from the archive as a whole.
 func (s *Segment) ensureFileSegment(ctx context.Context) error {
 	s.muEnsure.Lock()
 	defer s.muEnsure.Unlock()
@@ -231,25 +295,148 @@ func (s *Segment) ensureFileSegment(ctx context.Context) error {
 	}
 
 	// Fetch segment if it doesn't exist on disk.
-	if _, err := os.Stat(s.path); os.IsNotExist(err) {
-		log.Info("Fetch segment from s3", "key", SegmentKey(s.table, s.name))
-		if err := s.client.FGetObject(ctx, SegmentKey(s.table, s.name), s.path); err != nil {
-			log.Error("Cannot fetch segment from s3", "key", SegmentKey(s.table, s.name), "err", err)
+	fi, err := os.Stat(s.path)
+	if os.IsNotExist(err) && s.archive.RangeReads {
+		diskCacheMissMeter.Mark(1)
+		return s.openRangeSegment(ctx)
+	} else if os.IsNotExist(err) {
+		diskCacheMissMeter.Mark(1)
+		log.Info("Fetch segment from archive", "key", SegmentKey(s.table, s.name))
+		if err := s.fetch(ctx); err != nil {
+			log.Error("Cannot fetch segment from archive", "key", SegmentKey(s.table, s.name), "err", err)
+			return err
+		} else if fi, err = os.Stat(s.path); err != nil {
 			return err
 		}
 	} else if err != nil {
 		return err
+	} else {
+		diskCacheHitMeter.Mark(1)
 	}
 
 	// Open file segment on the local file.
-	s.segment = ethdb.NewFileSegment(s.name, s.path)
-	if err := s.segment.Open(); err != nil {
+	segment, err := ethdb.OpenFileSegment(s.name, s.path)
+	if err != nil {
+		return err
+	}
+	s.segment = segment
+	s.archive.DiskCache.add(s, fi.Size(), true)
+
+	return nil
+}
+
+// fetch downloads the segment to its local path, verifying its checksum.
+func (s *Segment) fetch(ctx context.Context) (err error) {
+	const retry = 5
+	for i := 0; i < retry; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(FGetObjectInterval):
+			}
+		}
+		if err = s.tryFetch(ctx); err == nil {
+			return nil
+		}
+		log.Error("Error fetching segment", "i", i, "path", s.path, "err", err)
+	}
+	return err
+}
+
+// tryFetch downloads the segment once and verifies its checksum before moving
+// it into place, so a corrupt download is retried like a failed one.
+func (s *Segment) tryFetch(ctx context.Context) error {
+	tmpPath := s.path + ".tmp"
+	if err := s.archive.Store.FGetObject(ctx, SegmentKey(s.table, s.name), tmpPath); err != nil {
 		return err
 	}
 
+	// Verify file segment checksum matches computed.
+	if err := ethdb.VerifyFileSegment(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Move file from temp path to actual path.
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// openRangeSegment instantiates the underlying segment from its header and
+// indexes in the archive. Keys are then read with ranged reads.
+func (s *Segment) openRangeSegment(ctx context.Context) error {
+	key := SegmentKey(s.table, s.name)
+	size, err := s.archive.Store.ObjectSize(ctx, key)
+	if err != nil {
+		log.Error("Cannot stat segment in archive", "key", key, "err", err)
+		return err
+	}
+
+	segment := ethdb.NewReaderFileSegment(s.name, s.path, NewObjectReader(s.archive, key, size), size)
+	if err := segment.Open(); err != nil {
+		log.Error("Cannot open segment in archive", "key", key, "err", err)
+		return err
+	}
+	s.segment = segment
+
 	return nil
 }
 
+// ArchiveEntry returns the manifest entry of the archived copy, using the
+// checksum written in its header.
+func (s *Segment) ArchiveEntry(ctx context.Context) (ethdb.ManifestEntry, error) {
+	key := SegmentKey(s.table, s.name)
+	size, err := s.archive.Store.ObjectSize(ctx, key)
+	if err != nil {
+		return ethdb.ManifestEntry{}, err
+	}
+	return ethdb.ReadManifestEntryAt(s.name, &storeReaderAt{ctx: ctx, store: s.archive.Store, key: key}, size)
+}
+
+// VerifyArchive compares the computed and stored checksum of the archived
+// copy. The whole object is read, bypassing the block cache.
+func (s *Segment) VerifyArchive(ctx context.Context) error {
+	entry, err := s.ArchiveEntry(ctx)
+	if err != nil {
+		return err
+	}
+
+	const hdrSize = int64(len(ethdb.FileSegmentMagic) + ethdb.FileSegmentChecksumSize)
+	r := io.NewSectionReader(&storeReaderAt{ctx: ctx, store: s.archive.Store, key: SegmentKey(s.table, s.name)}, hdrSize, entry.Size-hdrSize)
+	checksum, err := ethdb.ChecksumFileSegmentData(bufio.NewReaderSize(r, VerifyReadSize))
+	if err != nil {
+		return err
+	} else if hex.EncodeToString(checksum) != entry.Checksum {
+		return ethdb.ErrFileSegmentChecksumMismatch
+	}
+	return nil
+}
+
+// RestoreArchive replaces the archived copy with the local copy.
+func (s *Segment) RestoreArchive(ctx context.Context) error {
+	log.Info("Restore archived segment", "key", SegmentKey(s.table, s.name), "path", s.path)
+	_, err := s.archive.putObject(ctx, SegmentKey(s.table, s.name), s.path)
+	return err
+}
+
+// storeReaderAt reads an object of a store with uncached ranged reads.
+type storeReaderAt struct {
+	ctx   context.Context
+	store ObjectStore
+	key   string
+}
+
+func (r *storeReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	if err := r.store.GetObjectRange(r.ctx, r.key, offset, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 // Has returns true if the key exists.
 func (s *Segment) Has(key []byte) (bool, error) {
 	s.mu.RLock()
@@ -257,6 +444,7 @@ func (s *Segment) Has(key []byte) (bool, error) {
 	if err := s.ensureFileSegment(context.TODO()); err != nil {
 		return false, err
 	}
+	s.archive.DiskCache.touch(s)
 	return s.segment.Has(key)
 }
 
@@ -267,12 +455,17 @@ func (s *Segment) Get(key []byte) ([]byte, error) {
 	if err := s.ensureFileSegment(context.TODO()); err != nil {
 		return nil, err
 	}
+	s.archive.DiskCache.touch(s)
 	return s.segment.Get(key)
 }
 
 // Iterator returns an iterator for the segment.
 func (s *Segment) Iterator() ethdb.SegmentIterator {
 	s.mu.RLock() // unlocked by SegmentIterator.Close()
+	if err := s.ensureFileSegment(context.TODO()); err != nil {
+		return &SegmentIterator{segment: s, err: err}
+	}
+	s.archive.DiskCache.touch(s)
 	return &SegmentIterator{
 		SegmentIterator: s.segment.Iterator(),
 		segment:         s,
@@ -287,30 +480,46 @@ var _ ethdb.SegmentIterator = (*SegmentIterator)(nil)
 type SegmentIterator struct {
 	ethdb.SegmentIterator
 	segment *Segment
+	err     error // error opening the segment
+}
+
+// Next moves to the next key/value pair. Returns false if the segment could
+// not be opened.
+func (itr *SegmentIterator) Next() bool {
+	if itr.err != nil {
+		return false
+	}
+	return itr.SegmentIterator.Next()
 }
 
 // Close releases iterator resources and releases the read lock on the segment.
 func (itr *SegmentIterator) Close() error {
 	itr.segment.mu.RUnlock()
+	if itr.err != nil {
+		return itr.err
+	}
 	return itr.SegmentIterator.Close()
 }
 
-// SegmentKey returns the key used for the segment on S3.
+// SegmentKey returns the key used for the segment in the archive.
 func SegmentKey(table, name string) string {
 	return path.Join(table, name)
 }
 
+// Ensure implementation fulfills interface.
+var _ ethdb.ArchivedSegment = (*Segment)(nil)
+
 // Ensure implementation fulfills interface.
 var _ ethdb.SegmentOpener = (*SegmentOpener)(nil)
 
 // SegmentOpener opens segments as a s3.Segments.
 type SegmentOpener struct {
-	Client *Client
+	Archive *Archive
 }
 
 // NewSegmentOpener returns a new instance of SegmentOpener.
-func NewSegmentOpener(client *Client) *SegmentOpener {
-	return &SegmentOpener{Client: client}
+func NewSegmentOpener(archive *Archive) *SegmentOpener {
+	return &SegmentOpener{Archive: archive}
 }
 
 // ListSegmentNames returns a list of segment names for a table.
@@ -322,7 +531,7 @@ func (o *SegmentOpener) ListSegmentNames(path, table string) ([]string, error) {
 	}
 
 	// Fetch remote keys.
-	remoteKeys, err := o.Client.ListObjectKeys(table)
+	remoteKeys, err := o.Archive.Store.ListObjectKeys(table)
 	if err != nil {
 		return nil, err
 	}
@@ -350,35 +559,47 @@ func (o *SegmentOpener) ListSegmentNames(path, table string) ([]string, error) {
 }
 
 // OpenSegment returns creates and opens a reference to a remote immutable segment.
+// A local copy left on disk is tracked by the disk cache as least recently used.
 func (o *SegmentOpener) OpenSegment(table, name, path string) (ethdb.Segment, error) {
-	return NewSegment(o.Client, table, name, path), nil
+	s := NewSegment(o.Archive, table, name, path)
+	if o.Archive.DiskCache != nil {
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			o.Archive.DiskCache.add(s, fi.Size(), false)
+		}
+	}
+	return s, nil
 }
 
 // Ensure implementation fulfills interface.
 var _ ethdb.SegmentCompactor = (*SegmentCompactor)(nil)
 
-// SegmentCompactor wraps ethdb.FileSegmentCompactor and uploads to S3 after compaction.
+// SegmentCompactor wraps ethdb.FileSegmentCompactor and uploads to the archive
+// after compaction.
 type SegmentCompactor struct {
-	Client *Client
+	Archive *Archive
+
+	// Compression of the data blocks of compacted segments.
+	Compression ethdb.Compression
 }
 
 // NewSegmentCompactor returns a new instance of SegmentCompactor.
-func NewSegmentCompactor(client *Client) *SegmentCompactor {
+func NewSegmentCompactor(archive *Archive) *SegmentCompactor {
 	return &SegmentCompactor{
-		Client: client,
+		Archive: archive,
 	}
 }
 
-// CompactSegment compacts s into a FileSegement and uploads it to S3.
-func (c *SegmentCompactor) CompactSegment(ctx context.Context, table string, s *ethdb.LDBSegment) (ethdb.Segment, error) {
+// CompactSegment compacts s into a FileSegement and uploads it to the archive.
+func (c *SegmentCompactor) CompactSegment(ctx context.Context, table string, s ethdb.MutableSegment) (ethdb.Segment, error) {
 	fsc := ethdb.NewFileSegmentCompactor()
+	fsc.Compression = c.Compression
 
 	tmpPath := s.Path() + ".tmp"
 	if err := fsc.CompactSegmentTo(ctx, s, tmpPath); err != nil {
 		return nil, err
 	}
 
-	if _, err := c.Client.FPutObject(ctx, SegmentKey(table, s.Name()), tmpPath); err != nil {
+	if _, err := c.Archive.putObject(ctx, SegmentKey(table, s.Name()), tmpPath); err != nil {
 		return nil, err
 	}
 
@@ -391,10 +612,10 @@ func (c *SegmentCompactor) CompactSegment(ctx context.Context, table string, s *
 		return nil, err
 	}
 
-	return NewSegment(c.Client, table, s.Name(), s.Path()), nil
+	return NewSegment(c.Archive, table, s.Name(), s.Path()), nil
 }
 
-// UncompactSegment uncompacts s into an LDBSegement.
-func (c *SegmentCompactor) UncompactSegment(ctx context.Context, table string, s ethdb.Segment) (*ethdb.LDBSegment, error) {
-	return ethdb.NewFileSegmentCompactor().UncompactSegment(ctx, table, s)
+// UncompactSegment uncompacts s into a mutable segment of engine.
+func (c *SegmentCompactor) UncompactSegment(ctx context.Context, table string, s ethdb.Segment, engine ethdb.Engine) (ethdb.MutableSegment, error) {
+	return ethdb.NewFileSegmentCompactor().UncompactSegment(ctx, table, s, engine)
 }
@@ -54,7 +54,7 @@ func TestSegmentCompactor(t *testing.T) {
 
 	// Compact and upload segment.
 	client := MustOpenClient()
-	sc := s3.NewSegmentCompactor(client)
+	sc := s3.NewSegmentCompactor(s3.NewArchive(client))
 	segment, err := sc.CompactSegment(context.Background(), table, ldb)
 	if err != nil {
 		t.Fatal(err)
@@ -100,7 +100,7 @@ func TestSegmentOpener_ListSegmentKeys(t *testing.T) {
 	}
 
 	// List names.
-	so := s3.NewSegmentOpener(client)
+	so := s3.NewSegmentOpener(s3.NewArchive(client))
 	if names, err := so.ListSegmentNames("", table); err != nil {
 		t.Fatal(err)
 	} else if !reflect.DeepEqual(names, []string{"0000", "0001"}) {
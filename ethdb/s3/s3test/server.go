@@ -0,0 +1,328 @@
+// Package s3test implements in-process stand-ins for an S3 compatible object
+// store and a generic HTTP object store, serving just enough of their APIs
+// for the ethdb/s3 clients.
+package s3test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an in-memory S3 compatible object store holding a single bucket.
+// Requests are not authenticated.
+type Server struct {
+	mu      sync.Mutex
+	server  *httptest.Server
+	objects map[string][]byte
+	modTime time.Time
+	generic bool // serve the generic HTTP object store protocol
+
+	// Bucket is the name of the only bucket served.
+	Bucket string
+
+	// Requests counts the object GET requests and RangeBytes the bytes served
+	// by them, so tests can check how much of an object was read.
+	Requests   int
+	RangeBytes int64
+}
+
+// NewServer starts and returns a new Server with an empty bucket.
+func NewServer(bucket string) *Server {
+	s := &Server{
+		objects: make(map[string][]byte),
+		modTime: time.Now().UTC().Truncate(time.Second),
+		Bucket:  bucket,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewHTTPServer starts and returns a new Server with no objects, serving the
+// generic HTTP object store protocol of s3.HTTPStore.
+func NewHTTPServer() *Server {
+	s := &Server{
+		objects: make(map[string][]byte),
+		modTime: time.Now().UTC().Truncate(time.Second),
+		generic: true,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL returns the base URL of the server.
+func (s *Server) URL() string { return s.server.URL }
+
+// Close shuts down the server.
+func (s *Server) Close() { s.server.Close() }
+
+// Endpoint returns the host and port the server listens on.
+func (s *Server) Endpoint() string {
+	return strings.TrimPrefix(s.server.URL, "http://")
+}
+
+// Object returns the object stored at key.
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	return data, ok
+}
+
+// PutObject stores an object at key.
+func (s *Server) PutObject(key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+}
+
+// ResetStats zeroes the request counters.
+func (s *Server) ResetStats() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Requests, s.RangeBytes = 0, 0
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if s.generic {
+		s.serveGeneric(w, r, path)
+		return
+	}
+	bucket, key := path, ""
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		bucket, key = path[:i], path[i+1:]
+	}
+	if bucket != s.Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodGet && hasQuery(r, "location"):
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+		}{})
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet:
+		s.serveList(w, r)
+	case r.Method == http.MethodPut:
+		s.servePut(w, r, key)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.serveGet(w, r, key)
+	case r.Method == http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (s *Server) serveGeneric(w http.ResponseWriter, r *http.Request, key string) {
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		prefix := r.URL.Query().Get("prefix")
+		keys := []string{}
+		s.mu.Lock()
+		for key := range s.objects {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		s.mu.Unlock()
+		sort.Strings(keys)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(keys)
+	case r.Method == http.MethodPut:
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.PutObject(key, data)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.serveGet(w, r, key)
+	case r.Method == http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveGet(w http.ResponseWriter, r *http.Request, key string) {
+	data, ok := s.Object(key)
+	if !ok {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		n := int64(len(data))
+		if rng := r.Header.Get("Range"); rng != "" {
+			var start, end int64
+			if _, err := fmt.Sscanf(rng, "bytes=%d-%d", &start, &end); err == nil && end >= start {
+				if end >= int64(len(data)) {
+					end = int64(len(data)) - 1
+				}
+				n = end - start + 1
+			}
+		}
+		s.mu.Lock()
+		s.Requests++
+		s.RangeBytes += n
+		s.mu.Unlock()
+	}
+
+	w.Header().Set("ETag", etag(data))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, key, s.modTime, bytes.NewReader(data))
+}
+
+func (s *Server) servePut(w http.ResponseWriter, r *http.Request, key string) {
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		body = &chunkedReader{r: bufio.NewReader(r.Body)}
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	s.PutObject(key, data)
+
+	w.Header().Set("ETag", etag(data))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request) {
+	type object struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int64
+		StorageClass string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []object
+	}{Name: s.Bucket, Prefix: r.URL.Query().Get("prefix"), MaxKeys: 1000}
+
+	s.mu.Lock()
+	for key, data := range s.objects {
+		if strings.HasPrefix(key, result.Prefix) {
+			result.Contents = append(result.Contents, object{
+				Key:          key,
+				LastModified: s.modTime.Format(time.RFC3339),
+				ETag:         etag(data),
+				Size:         int64(len(data)),
+				StorageClass: "STANDARD",
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+	writeXML(w, result)
+}
+
+func hasQuery(r *http.Request, name string) bool {
+	_, ok := r.URL.Query()[name]
+	return ok
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: code})
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// chunkedReader decodes an aws-chunked request body, as sent by clients
+// signing streamed uploads.
+type chunkedReader struct {
+	r    *bufio.Reader
+	left int64
+	done bool
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.left == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		// Each chunk starts with "<hex size>;chunk-signature=<signature>\r\n".
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		if i := strings.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+		if err != nil {
+			return 0, err
+		}
+		if size == 0 {
+			c.done = true
+			continue
+		}
+		c.left = size
+	}
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	if c.left == 0 && err == nil {
+		// Skip the "\r\n" trailing the chunk data.
+		_, err = c.r.Discard(2)
+	}
+	return n, err
+}
@@ -0,0 +1,114 @@
+package s3_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/ethdb/s3"
+)
+
+func TestScrubber_ScrubTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := s3.NewDirStore(filepath.Join(dir, "archive"))
+	if err := os.Mkdir(store.Path, 0777); err != nil {
+		t.Fatal(err)
+	}
+	archive := s3.NewArchive(store)
+
+	tbl := ethdb.NewTable("body", filepath.Join(dir, "body"), ethdb.NewBlockNumberPartitioner(1000))
+	tbl.MinCompactionAge = 0 // compact immediately
+	tbl.MinMutableSegmentCount = 1
+	tbl.SegmentOpener = s3.NewSegmentOpener(archive)
+	tbl.SegmentCompactor = s3.NewSegmentCompactor(archive)
+	tbl.ManifestKey = []byte("secret")
+	if err := tbl.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	key := numHashKey('b', 200, common.Hash{})
+	if err := tbl.Put(key, []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Put(numHashKey('b', 1500, common.Hash{}), []byte("baz")); err != nil {
+		t.Fatal(err)
+	} else if err := tbl.Compact(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	name := tbl.Partitioner.Partition(key)
+	if _, ok := tbl.Manifest().Entry(name); !ok {
+		t.Fatal("expected manifest entry")
+	}
+
+	scrubber := &ethdb.Scrubber{VerifyArchive: true}
+	scrub := func() ethdb.ScrubResult {
+		t.Helper()
+		var result ethdb.ScrubResult
+		if err := scrubber.ScrubTable(context.Background(), tbl, &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	get := func() {
+		t.Helper()
+		if v, err := tbl.Get(key); err != nil {
+			t.Fatal(err)
+		} else if string(v) != "bar" {
+			t.Fatalf("unexpected value: %q", v)
+		}
+	}
+
+	// Only the archived copy exists before the first read.
+	if result := scrub(); result != (ethdb.ScrubResult{Verified: 1}) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	get()
+
+	// A corrupt local copy is replaced from the archive.
+	localPath := tbl.SegmentPath(name)
+	MustCorruptFile(t, localPath)
+	if result := scrub(); result != (ethdb.ScrubResult{Corrupt: 1, Repaired: 1}) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	get()
+	if result := scrub(); result != (ethdb.ScrubResult{Verified: 1}) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// A corrupt archived copy is replaced from the local copy.
+	MustCorruptFile(t, filepath.Join(store.Path, "body", name))
+	if result := scrub(); result != (ethdb.ScrubResult{Corrupt: 1, Repaired: 1}) {
+		t.Fatalf("unexpected result: %+v", result)
+	} else if result := scrub(); result != (ethdb.ScrubResult{Verified: 1}) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+// MustCorruptFile flips the last byte of the file at path.
+func MustCorruptFile(tb testing.TB, path string) {
+	tb.Helper()
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	buf[len(buf)-1] ^= 0xff
+	if err := ioutil.WriteFile(path, buf, 0666); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func numHashKey(prefix byte, number uint64, hash common.Hash) []byte {
+	key := make([]byte, 41)
+	key[0] = prefix
+	binary.BigEndian.PutUint64(key[1:9], number)
+	copy(key[9:], hash[:])
+	return key
+}
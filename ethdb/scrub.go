@@ -0,0 +1,206 @@
+package ethdb
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"time"
+
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/metrics"
+)
+
+var (
+	scrubVerifiedMeter   = metrics.NewRegisteredMeter("ethdb/scrub/verified", nil)
+	scrubCorruptMeter    = metrics.NewRegisteredMeter("ethdb/scrub/corrupt", nil)
+	scrubRepairedMeter   = metrics.NewRegisteredMeter("ethdb/scrub/repaired", nil)
+	scrubUnrepairedGauge = metrics.NewRegisteredGauge("ethdb/scrub/unrepaired", nil)
+)
+
+// DefaultScrubInterval is the default time between scrubs of the database.
+const DefaultScrubInterval = 24 * time.Hour
+
+// Scrubber verifies the immutable segments of a database against their
+// table manifests. A corrupt segment is repaired from its other copy: a
+// corrupt local copy is quarantined and fetched again from the archive, and a
+// corrupt archived copy is uploaded again from the local one. Segments left
+// without a valid copy are kept in service and reported on every scrub.
+type Scrubber struct {
+	DB *DB
+
+	// Compute the checksum of archived copies, instead of only comparing
+	// their size and stored checksum. Reads whole archived segments.
+	VerifyArchive bool
+}
+
+// NewScrubber returns a new instance of Scrubber for db.
+func NewScrubber(db *DB) *Scrubber {
+	return &Scrubber{DB: db}
+}
+
+// ScrubResult counts the segments checked by a scrub.
+type ScrubResult struct {
+	Verified   int // segments with valid copies
+	Corrupt    int // segments with a corrupt copy
+	Repaired   int // corrupt segments replaced by a valid copy
+	Unrepaired int // corrupt segments left in service without a valid copy
+}
+
+// Scrub verifies all immutable segments of the database once.
+func (s *Scrubber) Scrub(ctx context.Context) (ScrubResult, error) {
+	var result ScrubResult
+	for _, tbl := range s.DB.Tables() {
+		if err := s.ScrubTable(ctx, tbl, &result); err != nil {
+			return result, err
+		}
+	}
+	scrubUnrepairedGauge.Update(int64(result.Unrepaired))
+	return result, nil
+}
+
+// ScrubTable verifies the immutable segments of a table and adds to result.
+func (s *Scrubber) ScrubTable(ctx context.Context, tbl *Table, result *ScrubResult) error {
+	for _, segment := range tbl.SegmentSlice() {
+		if _, ok := segment.(MutableSegment); ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.scrubSegment(ctx, tbl, segment, result); err != nil {
+			log.Error("Cannot scrub segment", "table", tbl.Name, "name", segment.Name(), "err", err)
+		}
+	}
+	return nil
+}
+
+func (s *Scrubber) scrubSegment(ctx context.Context, tbl *Table, segment Segment, result *ScrubResult) error {
+	name, path := segment.Name(), segment.Path()
+	archived, isArchived := segment.(ArchivedSegment)
+	fi, err := os.Stat(path)
+	hasLocal := err == nil && fi.Mode().IsRegular()
+
+	// Adopt segments missing from the manifest, trusting their header.
+	entry, ok := tbl.Manifest().Entry(name)
+	if !ok {
+		if !hasLocal && !isArchived {
+			return nil
+		}
+		if entry, err = NewManifestEntry(ctx, segment); err != nil {
+			return err
+		} else if err := tbl.Manifest().Put(entry); err != nil {
+			return err
+		}
+		log.Info("Added segment to manifest", "table", tbl.Name, "name", name)
+	}
+
+	// Verify each copy, only counting mismatches as corruption.
+	var localErr, archiveErr error
+	if hasLocal {
+		localErr = verifyLocalSegment(path, entry)
+		if localErr != nil && !isCorruption(localErr) {
+			return localErr
+		}
+	}
+	if isArchived {
+		archiveErr = s.verifyArchivedSegment(ctx, archived, entry)
+		if archiveErr != nil && !isCorruption(archiveErr) {
+			return archiveErr
+		}
+	}
+	if localErr == nil && archiveErr == nil {
+		scrubVerifiedMeter.Mark(1)
+		result.Verified++
+		return nil
+	}
+	scrubCorruptMeter.Mark(1)
+	result.Corrupt++
+	log.Error("Corrupt segment", "table", tbl.Name, "name", name, "local", localErr, "archive", archiveErr)
+
+	switch {
+	case localErr != nil && isArchived && archiveErr == nil:
+		// Set aside the local copy, the archived one is fetched on next access.
+		if _, err := tbl.QuarantineSegment(ctx, name); err != nil {
+			return err
+		}
+	case archiveErr != nil && hasLocal && localErr == nil:
+		// Upload the valid local copy over the archived one.
+		if err := archived.RestoreArchive(ctx); err != nil {
+			return err
+		}
+	default:
+		// No valid copy is left. Keep serving what is still readable rather
+		// than dropping the segment's data.
+		log.Error("Corrupt segment has no valid copy, repair manually", "table", tbl.Name, "name", name)
+		result.Unrepaired++
+		return nil
+	}
+
+	log.Info("Repaired segment", "table", tbl.Name, "name", name)
+	scrubRepairedMeter.Mark(1)
+	result.Repaired++
+	return nil
+}
+
+// verifyArchivedSegment verifies the archived copy of a segment against entry.
+func (s *Scrubber) verifyArchivedSegment(ctx context.Context, segment ArchivedSegment, entry ManifestEntry) error {
+	archiveEntry, err := segment.ArchiveEntry(ctx)
+	if err != nil {
+		return err
+	} else if err := entry.Verify(archiveEntry); err != nil {
+		return err
+	} else if s.VerifyArchive {
+		return segment.VerifyArchive(ctx)
+	}
+	return nil
+}
+
+// verifyLocalSegment verifies the segment file at path against entry.
+func verifyLocalSegment(path string, entry ManifestEntry) error {
+	localEntry, err := ReadManifestEntry(entry.Name, path)
+	if err != nil {
+		return err
+	} else if err := entry.Verify(localEntry); err != nil {
+		return err
+	}
+
+	// Compare the computed checksum with the stored one, matching the entry.
+	checksum, err := ChecksumFileSegment(path)
+	if err != nil {
+		return err
+	} else if hex.EncodeToString(checksum) != entry.Checksum {
+		return ErrFileSegmentChecksumMismatch
+	}
+	return nil
+}
+
+// isCorruption returns true if err reports a corrupt or missing segment copy,
+// as opposed to a failure to check it.
+func isCorruption(err error) bool {
+	switch err {
+	case ErrFileSegmentChecksumMismatch, ErrSegmentSizeMismatch, ErrInvalidSegmentType:
+		return true
+	}
+	return os.IsNotExist(err)
+}
+
+// Run scrubs the database every interval until ctx is done.
+func (s *Scrubber) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		startTime := time.Now()
+		result, err := s.Scrub(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Error("Cannot scrub database", "path", s.DB.Path, "err", err)
+		}
+		log.Info("Scrubbed database", "path", s.DB.Path, "verified", result.Verified, "corrupt", result.Corrupt, "repaired", result.Repaired, "unrepaired", result.Unrepaired, "elapsed", time.Since(startTime))
+	}
+}
@@ -0,0 +1,165 @@
+package ethdb_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/ethdb"
+)
+
+func TestTable_Manifest(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	tbl := MustOpenCompactedTable(t, dir)
+	defer tbl.Close()
+
+	// Compacted segments are listed with the checksum of their header.
+	segment := tbl.SegmentSlice()[0]
+	entry, ok := tbl.Manifest().Entry(segment.Name())
+	if !ok {
+		t.Fatal("expected manifest entry")
+	} else if want, err := ethdb.ReadManifestEntry(segment.Name(), segment.Path()); err != nil {
+		t.Fatal(err)
+	} else if entry != want {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	// The manifest is reloaded with a matching key.
+	m := ethdb.NewManifest("test", filepath.Join(dir, ethdb.ManifestFile), tbl.ManifestKey)
+	if err := m.Load(); err != nil {
+		t.Fatal(err)
+	} else if len(m.Entries()) != 1 {
+		t.Fatalf("unexpected entries: %+v", m.Entries())
+	}
+
+	// Edits without the key are detected.
+	if err := ethdb.NewManifest("test", filepath.Join(dir, ethdb.ManifestFile), []byte("other")).Load(); err != ethdb.ErrManifestSignatureMismatch {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf, err := ioutil.ReadFile(m.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf = bytes.Replace(buf, []byte(entry.Checksum), bytes.Repeat([]byte("0"), len(entry.Checksum)), 1)
+	if err := ioutil.WriteFile(m.Path(), buf, 0666); err != nil {
+		t.Fatal(err)
+	} else if err := m.Load(); err != ethdb.ErrManifestSignatureMismatch {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestScrubber_ScrubTable(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		dir := MustTempDir()
+		defer os.RemoveAll(dir)
+		tbl := MustOpenCompactedTable(t, dir)
+		defer tbl.Close()
+
+		if result := MustScrubTable(t, &ethdb.Scrubber{}, tbl); result != (ethdb.ScrubResult{Verified: 1}) {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		dir := MustTempDir()
+		defer os.RemoveAll(dir)
+		tbl := MustOpenCompactedTable(t, dir)
+		defer tbl.Close()
+
+		segment := tbl.SegmentSlice()[0]
+		MustCorruptFile(t, segment.Path())
+
+		// Without an archived copy the segment is kept in service.
+		if result := MustScrubTable(t, &ethdb.Scrubber{}, tbl); result != (ethdb.ScrubResult{Corrupt: 1, Unrepaired: 1}) {
+			t.Fatalf("unexpected result: %+v", result)
+		} else if _, err := os.Stat(segment.Path()); err != nil {
+			t.Fatalf("expected segment file to be kept: %v", err)
+		} else if names := tbl.SegmentNames(); len(names) != 3 {
+			t.Fatalf("unexpected segments: %v", names)
+		}
+	})
+
+	t.Run("Adopt", func(t *testing.T) {
+		dir := MustTempDir()
+		defer os.RemoveAll(dir)
+		tbl := MustOpenCompactedTable(t, dir)
+		segment := tbl.SegmentSlice()[0]
+		tbl.Close()
+
+		// Reopening with an invalid manifest sets it aside.
+		if err := ioutil.WriteFile(filepath.Join(dir, ethdb.ManifestFile), []byte(`{}`), 0666); err != nil {
+			t.Fatal(err)
+		}
+		tbl = MustOpenTable(t, dir)
+		defer tbl.Close()
+		if _, err := os.Stat(filepath.Join(dir, ethdb.ManifestFile+".invalid")); err != nil {
+			t.Fatal(err)
+		} else if len(tbl.Manifest().Entries()) != 0 {
+			t.Fatal("expected empty manifest")
+		}
+
+		// Segments missing from the manifest are added by the scrubber.
+		if result := MustScrubTable(t, &ethdb.Scrubber{}, tbl); result != (ethdb.ScrubResult{Verified: 1}) {
+			t.Fatalf("unexpected result: %+v", result)
+		} else if _, ok := tbl.Manifest().Entry(segment.Name()); !ok {
+			t.Fatal("expected manifest entry")
+		}
+	})
+}
+
+// MustOpenTable opens a table in dir compacting all but two segments.
+func MustOpenTable(tb testing.TB, dir string) *ethdb.Table {
+	tb.Helper()
+	tbl := ethdb.NewTable("test", dir, ethdb.NewBlockNumberPartitioner(1000))
+	tbl.MinCompactionAge = 0 // compact immediately
+	tbl.MinMutableSegmentCount = 2
+	tbl.ManifestKey = []byte("secret")
+	if err := tbl.Open(); err != nil {
+		tb.Fatal(err)
+	}
+	return tbl
+}
+
+// MustOpenCompactedTable opens a table in dir holding one file segment.
+func MustOpenCompactedTable(tb testing.TB, dir string) *ethdb.Table {
+	tb.Helper()
+	tbl := MustOpenTable(tb, dir)
+	for _, n := range []uint64{200, 1500, 2100} {
+		if err := tbl.Put(numHashKey('b', n, common.Hash{}), []byte("foo")); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := tbl.Compact(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+	return tbl
+}
+
+// MustScrubTable scrubs tbl once and returns the result.
+func MustScrubTable(tb testing.TB, s *ethdb.Scrubber, tbl *ethdb.Table) ethdb.ScrubResult {
+	tb.Helper()
+	var result ethdb.ScrubResult
+	if err := s.ScrubTable(context.Background(), tbl, &result); err != nil {
+		tb.Fatal(err)
+	}
+	return result
+}
+
+// MustCorruptFile flips the last byte of the file at path.
+func MustCorruptFile(tb testing.TB, path string) {
+	tb.Helper()
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	buf[len(buf)-1] ^= 0xff
+	if err := ioutil.WriteFile(path, buf, 0666); err != nil {
+		tb.Fatal(err)
+	}
+}
@@ -15,8 +15,9 @@ type SegmentSet struct {
 	mu       sync.RWMutex
 	segments map[string]Segment // all segments
 
-	semaphore *semaphore.Weighted // cache semaphore
-	cache     *lru.Cache          // opened segments
+	maxOpenCount int64
+	semaphore    *semaphore.Weighted // cache semaphore
+	cache        *lru.Cache          // opened segments
 }
 
 // NewSegmentSet returns a new instance of SegmentSet.
@@ -26,8 +27,9 @@ func NewSegmentSet(maxOpenCount int) *SegmentSet {
 	}
 
 	ss := &SegmentSet{
-		semaphore: semaphore.NewWeighted(int64(maxOpenCount)),
-		segments:  make(map[string]Segment),
+		maxOpenCount: int64(maxOpenCount),
+		semaphore:    semaphore.NewWeighted(int64(maxOpenCount)),
+		segments:     make(map[string]Segment),
 	}
 	ss.cache, _ = lru.NewWithEvict(maxOpenCount, ss.onEvicted)
 	return ss
@@ -69,6 +71,50 @@ func (ss *SegmentSet) Remove(ctx context.Context, name string) {
 	ss.semaphore.Release(1)
 }
 
+// RemoveAndClose removes the segment with the given name from the set and
+// closes it, once no segment of the set is in use.
+func (ss *SegmentSet) RemoveAndClose(ctx context.Context, name string) error {
+	if err := ss.semaphore.Acquire(ctx, ss.maxOpenCount); err != nil {
+		return err
+	}
+	defer ss.semaphore.Release(ss.maxOpenCount)
+
+	ss.mu.Lock()
+	s := ss.segments[name]
+	delete(ss.segments, name)
+	ss.mu.Unlock()
+
+	ss.cache.Remove(name)
+	if s == nil {
+		return nil
+	}
+	return s.Close()
+}
+
+// Replace swaps s for the segment of the same name in the set, and returns
+// the replaced segment, if any. The replaced segment is left open for its
+// current users and must be closed with CloseUnused.
+func (ss *SegmentSet) Replace(s Segment) Segment {
+	ss.mu.Lock()
+	prev := ss.segments[s.Name()]
+	ss.segments[s.Name()] = s
+	ss.mu.Unlock()
+
+	// Not evicted from the cache, as it is no longer in the set.
+	ss.cache.Remove(s.Name())
+	return prev
+}
+
+// CloseUnused closes s, once no segment of the set is in use. The segment
+// must no longer be in the set.
+func (ss *SegmentSet) CloseUnused(ctx context.Context, s Segment) error {
+	if err := ss.semaphore.Acquire(ctx, ss.maxOpenCount); err != nil {
+		return err
+	}
+	defer ss.semaphore.Release(ss.maxOpenCount)
+	return s.Close()
+}
+
 // Acquire returns a segment by name from the set and adds increments the semaphore.
 // If the segment is unopened then it is opened before returning. If a segment
 // is successfully retruns then Release() must always be called by the caller.
@@ -113,13 +159,20 @@ func (ss *SegmentSet) onEvicted(key, value interface{}) {
 	s := ss.segments[key.(string)]
 	ss.mu.Unlock()
 
-	if s == nil {
+	// Skip segments removed or replaced since they were cached.
+	if s == nil || s != value.(Segment) {
 		return
 	}
 
 	var err error
 	var action string
 	switch s := s.(type) {
+	case interface {
+		Segment
+		Evict() error
+	}:
+		err = s.Evict()
+		action = "evict"
 	case interface {
 		Segment
 		Purge() error
@@ -1,6 +1,7 @@
 package ethdb_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -98,6 +99,58 @@ func TestSegmentSet_AcquireRelease(t *testing.T) {
 	ss.Release() // #2
 }
 
+func TestSegmentSet_Replace(t *testing.T) {
+	var closed bool
+	segment0 := &purgeableSegment{Segment: &mock.Segment{
+		PathFunc:  func() string { return "/path/to/0000" },
+		NameFunc:  func() string { return "0000" },
+		CloseFunc: func() error { closed = true; return nil },
+	}}
+	replacement := &purgeableSegment{Segment: &mock.Segment{
+		PathFunc: func() string { return "/path/to/0000" },
+		NameFunc: func() string { return "0000" },
+	}}
+
+	ss := ethdb.NewSegmentSet(2)
+	ss.Add(segment0)
+	if s, err := ss.Acquire("0000"); err != nil {
+		t.Fatal(err)
+	} else if s != segment0 {
+		t.Fatal("unexpected segment")
+	}
+
+	// The replacement is served at once, while the replaced segment is kept
+	// open for its current user.
+	if prev := ss.Replace(replacement); prev != segment0 {
+		t.Fatal("unexpected replaced segment")
+	} else if s, err := ss.Acquire("0000"); err != nil {
+		t.Fatal(err)
+	} else if s != replacement {
+		t.Fatal("expected replacement")
+	} else if segment0.purged || closed {
+		t.Fatal("unexpected close of replaced segment")
+	}
+	ss.Release()
+
+	// Closing the replaced segment waits for its user.
+	done := make(chan error)
+	go func() { done <- ss.CloseUnused(context.Background(), segment0) }()
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("unexpected close while in use")
+	default:
+	}
+	ss.Release()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	} else if !closed {
+		t.Fatal("expected close of replaced segment")
+	} else if segment0.purged || replacement.purged {
+		t.Fatal("unexpected purge")
+	}
+}
+
 type purgeableSegment struct {
 	ethdb.Segment
 	opened bool
@@ -3,10 +3,13 @@ package ethdb
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,13 +17,22 @@ import (
 	"github.com/ChainAAS/gendchain/log"
 )
 
+// ErrHistoryPruned is returned when accessing a segment dropped by Prune.
+var ErrHistoryPruned = errors.New("ethdb: history pruned")
+
+// HistoryTailFile is the name of the file within a table directory holding
+// the name of the oldest segment kept by Prune.
+const HistoryTailFile = "history.tail"
+
 // Table represents key/value storage for a particular data type.
 // Contains zero or more segments that are separated by partitioner.
 type Table struct {
-	mu          sync.RWMutex
-	active      string                 // active segment name
-	ldbSegments map[string]*LDBSegment // writable segments
-	segments    *SegmentSet            // all segments
+	mu              sync.RWMutex
+	active          string                    // active segment name
+	mutableSegments map[string]MutableSegment // writable segments
+	segments        *SegmentSet               // all segments
+	manifest        *Manifest                 // immutable segments
+	tail            string                    // oldest segment kept by Prune
 
 	Name        string
 	Path        string
@@ -34,6 +46,13 @@ type Table struct {
 
 	SegmentOpener    SegmentOpener
 	SegmentCompactor SegmentCompactor
+
+	// Engine of new mutable segments. Existing segments keep their engine.
+	// Blank creates LevelDB segments.
+	Engine Engine
+
+	// Key the table manifest is signed with.
+	ManifestKey []byte
 }
 
 // NewTable returns a new instance of Table.
@@ -54,13 +73,32 @@ func NewTable(name, path string, partitioner Partitioner) *Table {
 
 // Open initializes the table and all existing segments.
 func (t *Table) Open() error {
-	t.ldbSegments = make(map[string]*LDBSegment)
+	t.mutableSegments = make(map[string]MutableSegment)
 	t.segments = NewSegmentSet(t.MaxOpenSegmentCount)
 
 	if err := os.MkdirAll(t.Path, 0777); err != nil {
 		return err
 	}
 
+	// Load the manifest. An invalid manifest is set aside and rebuilt by the
+	// scrubber from the segment headers.
+	t.manifest = NewManifest(t.Name, filepath.Join(t.Path, ManifestFile), t.ManifestKey)
+	if err := t.manifest.Load(); err == ErrManifestSignatureMismatch {
+		log.Error("Invalid table manifest, rebuilding", "path", t.manifest.Path(), "err", err)
+		if err := os.Rename(t.manifest.Path(), t.manifest.Path()+".invalid"); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// Load the history tail, if the table was pruned.
+	if buf, err := ioutil.ReadFile(filepath.Join(t.Path, HistoryTailFile)); err == nil {
+		t.tail = strings.TrimSpace(string(buf))
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
 	names, err := t.SegmentOpener.ListSegmentNames(t.Path, t.Name)
 	if err != nil {
 		log.Error("Cannot list segment names", "path", t.Path, "name", t.Name, "err", err)
@@ -70,6 +108,19 @@ func (t *Table) Open() error {
 	for _, name := range names {
 		path := filepath.Join(t.Path, name)
 
+		// Keep archived segments before the tail without their local copy,
+		// removing other files left by an interrupted prune.
+		if name < t.tail {
+			if ok, err := t.openPrunedSegment(name, path); err != nil {
+				return err
+			} else if !ok {
+				if err := os.RemoveAll(path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
 		// Determine the segment file type.
 		typ, err := SegmentFileType(path)
 		if err == ErrInvalidSegmentType {
@@ -81,14 +132,15 @@ func (t *Table) Open() error {
 
 		// Open appropriate segment type.
 		switch typ {
-		case SegmentLDB1:
-			ldbSegment := NewLDBSegment(name, path)
-			if err := ldbSegment.Open(); err != nil {
-				log.Error("Cannot open ldb segment", "path", path, "name", name, "err", err)
+		case SegmentLDB1, SegmentPBL1:
+			engine, _ := SegmentEngine(typ)
+			segment, err := engine.OpenSegment(name, path)
+			if err != nil {
+				log.Error("Cannot open mutable segment", "path", path, "name", name, "engine", engine, "err", err)
 				t.Close()
 				return err
 			}
-			t.ldbSegments[name] = ldbSegment
+			t.mutableSegments[name] = segment
 
 		default:
 			segment, err := t.SegmentOpener.OpenSegment(t.Name, name, path)
@@ -110,11 +162,36 @@ func (t *Table) Open() error {
 	return nil
 }
 
+// openPrunedSegment adds the named segment before the tail to the set if it
+// is archived, purging its local copy. Returns false for other segments.
+func (t *Table) openPrunedSegment(name, path string) (bool, error) {
+	if typ, err := SegmentFileType(path); err == nil && (typ == SegmentLDB1 || typ == SegmentPBL1) {
+		return false, nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return false, nil
+	}
+
+	segment, err := t.SegmentOpener.OpenSegment(t.Name, name, path)
+	if err == ErrSegmentTypeUnknown {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	p, ok := segment.(purgeableSegment)
+	if !ok {
+		return false, segment.Close()
+	} else if err := p.Purge(); err != nil {
+		return false, err
+	}
+	t.segments.Add(segment)
+	return true, nil
+}
+
 // Close closes all segments within the table.
 func (t *Table) Close() error {
-	for _, segment := range t.ldbSegments {
+	for _, segment := range t.mutableSegments {
 		if err := segment.Close(); err != nil {
-			log.Error("Failed to close leveldb segment", "path", segment.Path(), "name", segment.Name(), "error", err)
+			log.Error("Failed to close mutable segment", "path", segment.Path(), "name", segment.Name(), "error", err)
 		}
 	}
 	if t.segments != nil {
@@ -123,6 +200,11 @@ func (t *Table) Close() error {
 	return nil
 }
 
+// Manifest returns the manifest of the immutable segments of the table.
+func (t *Table) Manifest() *Manifest {
+	return t.manifest
+}
+
 // ActiveSegmentName the name of the current active segment.
 func (t *Table) ActiveSegmentName() string {
 	t.mu.RLock()
@@ -134,7 +216,7 @@ func (t *Table) ActiveSegmentName() string {
 func (t *Table) ActiveSegment() MutableSegment {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
-	return t.ldbSegments[t.active]
+	return t.mutableSegments[t.active]
 }
 
 // SegmentPath returns the path of the named segment.
@@ -148,7 +230,7 @@ func (t *Table) AcquireSegment(name string) (Segment, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	if s := t.ldbSegments[name]; s != nil {
+	if s := t.mutableSegments[name]; s != nil {
 		return s, nil
 	}
 	return t.segments.Acquire(name)
@@ -157,7 +239,7 @@ func (t *Table) AcquireSegment(name string) (Segment, error) {
 // ReleaseSegment releases a given segment.
 func (t *Table) ReleaseSegment(s Segment) {
 	switch s.(type) {
-	case *LDBSegment:
+	case MutableSegment:
 		return
 	default:
 		t.segments.Release()
@@ -169,8 +251,8 @@ func (t *Table) SegmentNames() []string {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	a := make([]string, 0, len(t.ldbSegments)+t.segments.Len())
-	for _, s := range t.ldbSegments {
+	a := make([]string, 0, len(t.mutableSegments)+t.segments.Len())
+	for _, s := range t.mutableSegments {
 		a = append(a, s.Name())
 	}
 	for _, s := range t.segments.Slice() {
@@ -188,8 +270,8 @@ func (t *Table) SegmentSlice() []Segment {
 }
 
 func (t *Table) segmentSlice() []Segment {
-	a := make([]Segment, 0, len(t.ldbSegments)+t.segments.Len())
-	for _, s := range t.ldbSegments {
+	a := make([]Segment, 0, len(t.mutableSegments)+t.segments.Len())
+	for _, s := range t.mutableSegments {
 		a = append(a, s)
 	}
 	for _, s := range t.segments.Slice() {
@@ -199,20 +281,66 @@ func (t *Table) segmentSlice() []Segment {
 	return a
 }
 
-func (t *Table) ldbSegmentSlice() []*LDBSegment {
-	a := make([]*LDBSegment, 0, len(t.ldbSegments))
-	for _, s := range t.ldbSegments {
+func (t *Table) mutableSegmentSlice() []MutableSegment {
+	a := make([]MutableSegment, 0, len(t.mutableSegments))
+	for _, s := range t.mutableSegments {
 		a = append(a, s)
 	}
 	sort.Slice(a, func(i, j int) bool { return a[i].Name() < a[j].Name() })
 	return a
 }
 
+// Iterate calls fn for every key/value pair in the table, segment by segment.
+// Keys and values are only valid during the call. Iteration stops at the first
+// error returned by fn.
+func (t *Table) Iterate(fn func(key, value []byte) error) error {
+	return t.IteratePrefix(nil, fn)
+}
+
+// IteratePrefix calls fn for every key/value pair in the table whose key starts
+// with prefix, segment by segment.
+func (t *Table) IteratePrefix(prefix []byte, fn func(key, value []byte) error) error {
+	for _, name := range t.SegmentNames() {
+		s, err := t.AcquireSegment(name)
+		if err != nil {
+			return err
+		} else if s == nil {
+			continue
+		}
+		err = iterateSegment(s, prefix, fn)
+		t.ReleaseSegment(s)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func iterateSegment(s Segment, prefix []byte, fn func(key, value []byte) error) error {
+	var itr SegmentIterator
+	if ms, ok := s.(MutableSegment); ok {
+		itr = ms.PrefixIterator(prefix)
+	} else {
+		itr = s.Iterator()
+	}
+	defer itr.Close()
+
+	for itr.Next() {
+		if !bytes.HasPrefix(itr.Key(), prefix) {
+			continue
+		}
+		if err := fn(itr.Key(), itr.Value()); err != nil {
+			return err
+		}
+	}
+	return itr.Close()
+}
+
 // CreateSegmentIfNotExists returns a mutable segment by name.
 // Creates a new segment if it does not exist.
-func (t *Table) CreateSegmentIfNotExists(ctx context.Context, name string) (*LDBSegment, error) {
+func (t *Table) CreateSegmentIfNotExists(ctx context.Context, name string) (MutableSegment, error) {
 	t.mu.RLock()
-	if s := t.ldbSegments[name]; s != nil {
+	if s := t.mutableSegments[name]; s != nil {
 		t.mu.RUnlock()
 		return s, nil
 	}
@@ -222,10 +350,18 @@ func (t *Table) CreateSegmentIfNotExists(ctx context.Context, name string) (*LDB
 	defer t.mu.Unlock()
 
 	// Recheck under write lock.
-	if s := t.ldbSegments[name]; s != nil {
+	if s := t.mutableSegments[name]; s != nil {
 		return s, nil
 	}
 
+	// Pruned segments cannot be written to again. Archived ones are read-only.
+	if name < t.tail {
+		if t.segments.Contains(name) {
+			return nil, ErrImmutableSegment
+		}
+		return nil, ErrHistoryPruned
+	}
+
 	// Uncompact segment if it has already become compacted.
 	if t.segments.Contains(name) {
 		return t.uncompact(ctx, name)
@@ -238,11 +374,11 @@ func (t *Table) CreateSegmentIfNotExists(ctx context.Context, name string) (*LDB
 	}
 
 	// Create new mutable segment.
-	ldbSegment := NewLDBSegment(name, t.SegmentPath(name))
-	if err := ldbSegment.Open(); err != nil {
+	segment, err := t.Engine.OpenSegment(name, t.SegmentPath(name))
+	if err != nil {
 		return nil, err
 	}
-	t.ldbSegments[name] = ldbSegment
+	t.mutableSegments[name] = segment
 
 	// Set as active segment.
 	t.active = name
@@ -253,12 +389,15 @@ func (t *Table) CreateSegmentIfNotExists(ctx context.Context, name string) (*LDB
 		return nil, err
 	}
 
-	return ldbSegment, nil
+	return segment, nil
 }
 
 // Has returns true if key exists in the table.
 func (t *Table) Has(key []byte) (bool, error) {
 	name := t.Partitioner.Partition(key)
+	if t.isPruned(name) {
+		return false, ErrHistoryPruned
+	}
 	s, err := t.AcquireSegment(name)
 	if err != nil {
 		return false, err
@@ -272,6 +411,9 @@ func (t *Table) Has(key []byte) (bool, error) {
 // Get returns the value associated with key.
 func (t *Table) Get(key []byte) ([]byte, error) {
 	name := t.Partitioner.Partition(key)
+	if t.isPruned(name) {
+		return nil, ErrHistoryPruned
+	}
 	s, err := t.AcquireSegment(name)
 	if err != nil {
 		return nil, err
@@ -300,7 +442,11 @@ func (t *Table) Put(key, value []byte) error {
 
 // Delete removes key from the database.
 func (t *Table) Delete(key []byte) error {
-	s, err := t.AcquireSegment(t.Partitioner.Partition(key))
+	name := t.Partitioner.Partition(key)
+	if t.isPruned(name) {
+		return ErrHistoryPruned
+	}
+	s, err := t.AcquireSegment(name)
 	if err != nil {
 		return err
 	} else if s == nil {
@@ -317,10 +463,10 @@ func (t *Table) Delete(key []byte) error {
 }
 
 func (t *Table) NewBatch() common.Batch {
-	return &tableBatch{table: t, batches: make(map[string]*ldbSegmentBatch)}
+	return &tableBatch{table: t, batches: make(map[string]common.Batch)}
 }
 
-// Compact converts LDB segments into immutable file segments.
+// Compact converts mutable segments into immutable file segments.
 func (t *Table) Compact(ctx context.Context) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -328,36 +474,141 @@ func (t *Table) Compact(ctx context.Context) error {
 }
 
 func (t *Table) compact(ctx context.Context) error {
-	// Retrieve LDB segments. Exit if too few mutable segments.
-	ldbSegmentSlice := t.ldbSegmentSlice()
-	if len(ldbSegmentSlice) < t.MinMutableSegmentCount {
+	// Retrieve mutable segments. Exit if too few mutable segments.
+	mutableSegmentSlice := t.mutableSegmentSlice()
+	if len(mutableSegmentSlice) < t.MinMutableSegmentCount {
 		return nil
 	}
 
-	for _, ldbSegment := range ldbSegmentSlice[:len(ldbSegmentSlice)-t.MinMutableSegmentCount] {
+	for _, segment := range mutableSegmentSlice[:len(mutableSegmentSlice)-t.MinMutableSegmentCount] {
 		startTime := time.Now()
 
-		if fi, err := os.Stat(ldbSegment.Path()); err != nil {
+		if fi, err := os.Stat(segment.Path()); err != nil {
 			return err
 		} else if time.Since(fi.ModTime()) < t.MinCompactionAge {
-			log.Debug("LDB segment too young, skipping compaction", "table", t.Name, "name", ldbSegment.Name(), "elapsed", time.Since(startTime))
+			log.Debug("Mutable segment too young, skipping compaction", "table", t.Name, "name", segment.Name(), "elapsed", time.Since(startTime))
 			continue
 		}
 
-		newSegment, err := t.SegmentCompactor.CompactSegment(ctx, t.Name, ldbSegment)
-		if err != nil {
+		if err := t.compactSegment(ctx, segment); err != nil {
 			return err
 		}
-		t.segments.Add(newSegment)
-		delete(t.ldbSegments, ldbSegment.Name())
 
-		log.Info("Compacted segment", "table", t.Name, "name", ldbSegment.Name(), "elapsed", time.Since(startTime))
+		log.Info("Compacted segment", "table", t.Name, "name", segment.Name(), "elapsed", time.Since(startTime))
+	}
+	return nil
+}
+
+// CompactSegment converts the named mutable segment into an immutable segment,
+// regardless of its age or the number of mutable segments.
+func (t *Table) CompactSegment(ctx context.Context, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	segment := t.mutableSegments[name]
+	if segment == nil {
+		return fmt.Errorf("ethdb: mutable segment not found: %s", name)
+	}
+	return t.compactSegment(ctx, segment)
+}
+
+func (t *Table) compactSegment(ctx context.Context, segment MutableSegment) error {
+	newSegment, err := t.SegmentCompactor.CompactSegment(ctx, t.Name, segment)
+	if err != nil {
+		return err
+	}
+	t.segments.Add(newSegment)
+	delete(t.mutableSegments, segment.Name())
+
+	if entry, err := NewManifestEntry(ctx, newSegment); err != nil {
+		log.Error("Cannot read compacted segment manifest entry", "table", t.Name, "name", newSegment.Name(), "err", err)
+	} else if err := t.manifest.Put(entry); err != nil {
+		return err
 	}
 	return nil
 }
 
+// UncompactSegment converts the named immutable segment back into a mutable
+// segment of the table engine.
+func (t *Table) UncompactSegment(ctx context.Context, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.segments.Contains(name) {
+		return fmt.Errorf("ethdb: immutable segment not found: %s", name)
+	}
+	_, err := t.uncompact(ctx, name)
+	return err
+}
+
+// ImportSegment copies the immutable segment file at path into the table as
+// the named segment. The segment must not already exist in the table. Archived
+// segments are uploaded and verified before the segment is registered.
+func (t *Table) ImportSegment(ctx context.Context, name, path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.mutableSegments[name] != nil || t.segments.Contains(name) {
+		return fmt.Errorf("ethdb: segment already exists: %s", name)
+	} else if typ, err := SegmentFileType(path); err != nil {
+		return err
+	} else if _, ok := SegmentEngine(typ); ok {
+		return ErrInvalidSegmentType
+	}
+
+	dst := t.SegmentPath(name)
+	if _, err := CopyFile(path, dst); err != nil {
+		return err
+	}
+	segment, entry, err := t.openImportedSegment(ctx, name, dst)
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	t.segments.Add(segment)
+	return t.manifest.Put(entry)
+}
+
+// openImportedSegment opens the segment file copied to path and verifies it.
+// Archived segments are uploaded and their archived copy verified as well.
+func (t *Table) openImportedSegment(ctx context.Context, name, path string) (Segment, ManifestEntry, error) {
+	entry, err := ReadManifestEntry(name, path)
+	if err != nil {
+		return nil, ManifestEntry{}, err
+	} else if err := verifyLocalSegment(path, entry); err != nil {
+		return nil, ManifestEntry{}, err
+	}
+
+	segment, err := t.SegmentOpener.OpenSegment(t.Name, name, path)
+	if err != nil {
+		return nil, ManifestEntry{}, err
+	}
+	if archived, ok := segment.(ArchivedSegment); ok {
+		if err := uploadSegment(ctx, archived, entry); err != nil {
+			segment.Close()
+			return nil, ManifestEntry{}, err
+		}
+	}
+	return segment, entry, nil
+}
+
+// uploadSegment uploads the local copy of segment to its archive and verifies
+// the archived copy against entry.
+func uploadSegment(ctx context.Context, segment ArchivedSegment, entry ManifestEntry) error {
+	if err := segment.RestoreArchive(ctx); err != nil {
+		return err
+	}
+	archiveEntry, err := segment.ArchiveEntry(ctx)
+	if err != nil {
+		return err
+	} else if err := entry.Verify(archiveEntry); err != nil {
+		return err
+	}
+	return segment.VerifyArchive(ctx)
+}
+
 // uncompact converts an immutable segment to a mutable segment.
-func (t *Table) uncompact(ctx context.Context, name string) (*LDBSegment, error) {
+func (t *Table) uncompact(ctx context.Context, name string) (MutableSegment, error) {
 	startTime := time.Now()
 
 	s, err := t.segments.Acquire(name)
@@ -366,21 +617,176 @@ func (t *Table) uncompact(ctx context.Context, name string) (*LDBSegment, error)
 	}
 	defer t.segments.Release()
 
-	ldbSegment, err := t.SegmentCompactor.UncompactSegment(ctx, t.Name, s)
+	segment, err := t.SegmentCompactor.UncompactSegment(ctx, t.Name, s, t.Engine)
 	if err != nil {
 		return nil, err
 	}
-	t.ldbSegments[name] = ldbSegment
+	t.mutableSegments[name] = segment
 	t.segments.Remove(ctx, name)
+	if err := t.manifest.Delete(name); err != nil {
+		return nil, err
+	}
 
 	log.Info("Uncompacted segment", "table", t.Name, "name", name, "elapsed", time.Since(startTime))
 
-	return ldbSegment, nil
+	return segment, nil
+}
+
+// Tail returns the name of the oldest segment kept by Prune. Returns blank if
+// the table was never pruned.
+func (t *Table) Tail() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tail
+}
+
+// isPruned returns true if the named segment was dropped by Prune. Archived
+// segments before the tail are not dropped.
+func (t *Table) isPruned(name string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return name < t.tail && !t.segments.Contains(name)
+}
+
+// Prune drops all segments named before tail and their local files. Archived
+// segments are kept in service and read back from the archive on access. Keys
+// of dropped segments return ErrHistoryPruned from then on. Returns the names
+// of the segments whose local files were dropped.
+func (t *Table) Prune(ctx context.Context, tail string) ([]string, error) {
+	names, segments, err := t.setTail(tail)
+	if err != nil {
+		return names, err
+	}
+
+	// Close dropped segments without holding the table lock, as it waits for
+	// readers of the set.
+	for _, s := range segments {
+		if p, ok := s.(purgeableSegment); ok {
+			if err := p.Purge(); err != nil {
+				return names, err
+			}
+			names = append(names, s.Name())
+			continue
+		}
+		if err := t.segments.RemoveAndClose(ctx, s.Name()); err != nil {
+			return names, err
+		} else if err := os.Remove(s.Path()); err != nil && !os.IsNotExist(err) {
+			return names, err
+		} else if err := t.manifest.Delete(s.Name()); err != nil {
+			return names, err
+		}
+		names = append(names, s.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// setTail records tail and drops the mutable segments before it. Returns the
+// names of the dropped mutable segments and the immutable segments before tail.
+func (t *Table) setTail(tail string) ([]string, []Segment, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tail <= t.tail {
+		return nil, nil, nil
+	} else if tail > t.active && t.active != "" {
+		return nil, nil, fmt.Errorf("ethdb: cannot prune active segment: %s", t.active)
+	}
+
+	// Record the tail first so dropped segments are not reopened on restart.
+	tmpPath := filepath.Join(t.Path, HistoryTailFile+".tmp")
+	if err := ioutil.WriteFile(tmpPath, []byte(tail), 0666); err != nil {
+		return nil, nil, err
+	} else if err := os.Rename(tmpPath, filepath.Join(t.Path, HistoryTailFile)); err != nil {
+		return nil, nil, err
+	}
+	t.tail = tail
+
+	var names []string
+	for _, s := range t.mutableSegmentSlice() {
+		if s.Name() >= tail {
+			continue
+		}
+		delete(t.mutableSegments, s.Name())
+		if err := s.Close(); err != nil {
+			return names, nil, err
+		} else if err := os.RemoveAll(s.Path()); err != nil {
+			return names, nil, err
+		}
+		names = append(names, s.Name())
+	}
+
+	var segments []Segment
+	for _, s := range t.segments.Slice() {
+		if s.Name() < tail {
+			segments = append(segments, s)
+		}
+	}
+	return names, segments, nil
+}
+
+// purgeableSegment is implemented by archived segments whose local copy can
+// be dropped while they are still served from the archive.
+type purgeableSegment interface {
+	ArchivedSegment
+	Purge() error
+}
+
+// QuarantineSegment takes the local copy of an archived segment out of
+// service. Its local file, if any, is set aside with a ".corrupt" extension,
+// and the segment is swapped in one step for a copy reopened from the segment
+// opener, which fetches it again from the archive, so readers never miss it.
+// The replaced segment is closed once no longer in use. Returns the path of
+// the set aside file.
+func (t *Table) QuarantineSegment(ctx context.Context, name string) (string, error) {
+	path := t.SegmentPath(name)
+	quarantinePath, err := t.setAsideSegment(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	// Open the replacement first so the segment stays in service.
+	segment, err := t.SegmentOpener.OpenSegment(t.Name, name, path)
+	if err != nil {
+		return quarantinePath, err
+	}
+	t.mu.Lock()
+	prev := t.segments.Replace(segment)
+	t.mu.Unlock()
+	log.Warn("Quarantined segment", "table", t.Name, "name", name, "path", quarantinePath)
+
+	// Close without holding the table lock, as it waits for readers of the set.
+	if prev != nil {
+		if err := t.segments.CloseUnused(ctx, prev); err != nil {
+			log.Error("Cannot close quarantined segment", "table", t.Name, "name", name, "err", err)
+		}
+	}
+	return quarantinePath, nil
+}
+
+// setAsideSegment renames the local file of the named immutable segment, if
+// any, and returns its new path.
+func (t *Table) setAsideSegment(name, path string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.segments.Contains(name) {
+		return "", fmt.Errorf("ethdb: immutable segment not found: %s", name)
+	}
+	fi, err := os.Stat(path)
+	if err != nil || !fi.Mode().IsRegular() {
+		return "", nil
+	}
+	quarantinePath := fmt.Sprintf("%s.%d.corrupt", path, time.Now().Unix())
+	if err := os.Rename(path, quarantinePath); err != nil {
+		return "", err
+	}
+	return quarantinePath, nil
 }
 
 type tableBatch struct {
 	table   *Table
-	batches map[string]*ldbSegmentBatch
+	batches map[string]common.Batch
 	size    int
 }
 
@@ -393,7 +799,7 @@ func (b *tableBatch) Put(key, value []byte) error {
 	}
 
 	name := b.table.Partitioner.Partition(key)
-	ldbSegment, err := b.table.CreateSegmentIfNotExists(context.TODO(), name)
+	segment, err := b.table.CreateSegmentIfNotExists(context.TODO(), name)
 	if err != nil {
 		log.Error("tableBatch.Put: error", "table", b.table.Name, "segment", name, "key", fmt.Sprintf("%x", key))
 		return err
@@ -401,7 +807,7 @@ func (b *tableBatch) Put(key, value []byte) error {
 
 	sb := b.batches[name]
 	if sb == nil {
-		sb = ldbSegment.newBatch()
+		sb = segment.NewBatch()
 		b.batches[name] = sb
 	}
 	if err := sb.Put(key, value); err != nil {
@@ -420,7 +826,7 @@ func (b *tableBatch) Delete(key []byte) error {
 	}
 
 	name := b.table.Partitioner.Partition(key)
-	ldbSegment, err := b.table.CreateSegmentIfNotExists(context.TODO(), name)
+	segment, err := b.table.CreateSegmentIfNotExists(context.TODO(), name)
 	if err != nil {
 		log.Error("tableBatch.Delete: error", "table", b.table.Name, "segment", name, "key", fmt.Sprintf("%x", key))
 		return err
@@ -428,7 +834,7 @@ func (b *tableBatch) Delete(key []byte) error {
 
 	sb := b.batches[name]
 	if sb == nil {
-		sb = ldbSegment.newBatch()
+		sb = segment.NewBatch()
 		b.batches[name] = sb
 	}
 	if err := sb.Delete(key); err != nil {
@@ -195,3 +195,216 @@ func TestTable_Compact(t *testing.T) {
 		}
 	})
 }
+
+func TestTable_CompactSegment(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	tbl := MustOpenTable(t, dir)
+	defer tbl.Close()
+	if err := tbl.Put(numHashKey('b', 200, common.Hash{}), []byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The active segment is compacted on demand, regardless of count.
+	name := tbl.ActiveSegmentName()
+	if err := tbl.CompactSegment(context.Background(), name); err != nil {
+		t.Fatal(err)
+	} else if _, ok := tbl.SegmentSlice()[0].(*ethdb.FileSegment); !ok {
+		t.Fatalf("expected file segment, got %T", tbl.SegmentSlice()[0])
+	} else if _, ok := tbl.Manifest().Entry(name); !ok {
+		t.Fatal("expected manifest entry")
+	} else if err := tbl.CompactSegment(context.Background(), name); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// And converted back.
+	if err := tbl.UncompactSegment(context.Background(), name); err != nil {
+		t.Fatal(err)
+	} else if _, ok := tbl.SegmentSlice()[0].(*ethdb.LDBSegment); !ok {
+		t.Fatalf("expected ldb segment, got %T", tbl.SegmentSlice()[0])
+	} else if _, ok := tbl.Manifest().Entry(name); ok {
+		t.Fatal("unexpected manifest entry")
+	} else if v, err := tbl.Get(numHashKey('b', 200, common.Hash{})); err != nil || string(v) != "foo" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	} else if err := tbl.UncompactSegment(context.Background(), name); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestTable_ImportSegment(t *testing.T) {
+	srcDir, dstDir := MustTempDir(), MustTempDir()
+	defer os.RemoveAll(srcDir)
+	defer os.RemoveAll(dstDir)
+
+	src := MustOpenCompactedTable(t, srcDir)
+	defer src.Close()
+	segment := src.SegmentSlice()[0]
+
+	dst := MustOpenTable(t, dstDir)
+	defer dst.Close()
+	if err := dst.Put(numHashKey('b', 5000, common.Hash{}), []byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Segments older than the active segment are imported as files.
+	if err := dst.ImportSegment(context.Background(), segment.Name(), segment.Path()); err != nil {
+		t.Fatal(err)
+	} else if v, err := dst.Get(numHashKey('b', 200, common.Hash{})); err != nil || string(v) != "foo" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	} else if _, ok := dst.Manifest().Entry(segment.Name()); !ok {
+		t.Fatal("expected manifest entry")
+	}
+
+	// Existing segments and LDB segments cannot be imported.
+	if err := dst.ImportSegment(context.Background(), segment.Name(), segment.Path()); err == nil {
+		t.Fatal("expected error")
+	} else if err := dst.ImportSegment(context.Background(), "0000000000000064", src.SegmentPath(src.ActiveSegmentName())); err != ethdb.ErrInvalidSegmentType {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTable_Prune(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	tbl := MustOpenCompactedTable(t, dir)
+	fileSegment := tbl.SegmentSlice()[0]
+
+	// Segments before the tail are dropped, both compacted and LDB.
+	tail := ethdb.NewBlockNumberPartitioner(1000).Partition(numHashKey('b', 2000, common.Hash{}))
+	if names, err := tbl.Prune(context.Background(), tail); err != nil {
+		t.Fatal(err)
+	} else if len(names) != 2 || names[0] != fileSegment.Name() {
+		t.Fatalf("unexpected pruned segments: %v", names)
+	} else if _, err := os.Stat(fileSegment.Path()); !os.IsNotExist(err) {
+		t.Fatalf("expected segment file to be removed: %v", err)
+	} else if _, ok := tbl.Manifest().Entry(fileSegment.Name()); ok {
+		t.Fatal("unexpected manifest entry")
+	}
+
+	// Pruned keys are reported as such, newer keys are kept.
+	if _, err := tbl.Get(numHashKey('b', 1500, common.Hash{})); err != ethdb.ErrHistoryPruned {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := tbl.Put(numHashKey('b', 200, common.Hash{}), []byte("foo")); err != ethdb.ErrHistoryPruned {
+		t.Fatalf("unexpected error: %v", err)
+	} else if v, err := tbl.Get(numHashKey('b', 2100, common.Hash{})); err != nil || string(v) != "foo" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	}
+
+	// Segments after the active segment cannot be pruned.
+	if _, err := tbl.Prune(context.Background(), "ffffffffffffffff"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// The tail is kept across reopens.
+	tbl.Close()
+	tbl = MustOpenTable(t, dir)
+	defer tbl.Close()
+	if v := tbl.Tail(); v != tail {
+		t.Fatalf("unexpected tail: %s", v)
+	} else if names := tbl.SegmentNames(); len(names) != 1 || names[0] != tail {
+		t.Fatalf("unexpected segments: %v", names)
+	} else if _, err := tbl.Get(numHashKey('b', 200, common.Hash{})); err != ethdb.ErrHistoryPruned {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTable_IteratePrefix(t *testing.T) {
+	dir := MustTempDir()
+	tbl := ethdb.NewTable("test", dir, &ethdb.StaticPartitioner{Name: "data"})
+	defer os.RemoveAll(tbl.Path)
+
+	if err := tbl.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	for _, key := range []string{"a1", "a2", "b1", "ab"} {
+		if err := tbl.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keys []string
+	if err := tbl.IteratePrefix([]byte("a"), func(key, value []byte) error {
+		if string(value) != "v"+string(key) {
+			t.Fatalf("unexpected value for %q: %q", key, value)
+		}
+		keys = append(keys, string(key))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 3 || keys[0] != "a1" || keys[1] != "a2" || keys[2] != "ab" {
+		t.Fatalf("unexpected keys: %q", keys)
+	}
+}
+
+func TestTable_Engine(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	tbl := ethdb.NewTable("test", dir, ethdb.NewBlockNumberPartitioner(1000))
+	tbl.Engine = ethdb.EnginePebble
+	tbl.MinCompactionAge = 0
+	tbl.MinMutableSegmentCount = 1
+	if err := tbl.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	batch := tbl.NewBatch()
+	if err := batch.Put(numHashKey('b', 200, common.Hash{}), []byte("foo")); err != nil {
+		t.Fatal(err)
+	} else if err := batch.Put(numHashKey('r', 300, common.Hash{}), []byte("bar")); err != nil {
+		t.Fatal(err)
+	} else if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := tbl.ActiveSegment().(*ethdb.PebbleSegment); !ok {
+		t.Fatalf("expected pebble segment, got %T", tbl.ActiveSegment())
+	}
+
+	var keys int
+	if err := tbl.IteratePrefix([]byte("b"), func(key, value []byte) error {
+		keys++
+		if string(value) != "foo" {
+			t.Fatalf("unexpected value: %q", value)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	} else if keys != 1 {
+		t.Fatalf("unexpected keys: %d", keys)
+	}
+
+	// A new segment compacts the previous one, which is uncompacted back into
+	// a pebble segment when written to.
+	if err := tbl.Put(numHashKey('b', 1200, common.Hash{}), []byte("baz")); err != nil {
+		t.Fatal(err)
+	} else if _, ok := tbl.SegmentSlice()[0].(*ethdb.FileSegment); !ok {
+		t.Fatalf("expected file segment, got %T", tbl.SegmentSlice()[0])
+	} else if err := tbl.Delete(numHashKey('r', 300, common.Hash{})); err != ethdb.ErrImmutableSegment {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := tbl.UncompactSegment(context.Background(), "0000000000000000"); err != nil {
+		t.Fatal(err)
+	} else if _, ok := tbl.SegmentSlice()[0].(*ethdb.PebbleSegment); !ok {
+		t.Fatalf("expected pebble segment, got %T", tbl.SegmentSlice()[0])
+	} else if v, err := tbl.Get(numHashKey('b', 200, common.Hash{})); err != nil || string(v) != "foo" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	} else if err := tbl.Delete(numHashKey('r', 300, common.Hash{})); err != nil {
+		t.Fatal(err)
+	} else if _, err := tbl.Get(numHashKey('r', 300, common.Hash{})); err != common.ErrNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Segments are reopened with their engine.
+	tbl.Close()
+	tbl.Engine = ""
+	if err := tbl.Open(); err != nil {
+		t.Fatal(err)
+	} else if typ, err := ethdb.SegmentFileType(tbl.SegmentPath("0000000000000000")); err != nil || typ != ethdb.SegmentPBL1 {
+		t.Fatalf("unexpected type: typ=%q / err=%v", typ, err)
+	} else if v, err := tbl.Get(numHashKey('b', 1200, common.Hash{})); err != nil || string(v) != "baz" {
+		t.Fatalf("unexpected value: v=%q / err=%v", v, err)
+	}
+}
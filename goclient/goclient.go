@@ -76,6 +76,12 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 	} else if len(raw) == 0 {
 		return nil, gendchain.NotFound
 	}
+	return ec.decodeBlock(ctx, raw)
+}
+
+// decodeBlock decodes a block with full transactions from its RPC representation,
+// loading its uncles.
+func (ec *Client) decodeBlock(ctx context.Context, raw json.RawMessage) (*types.Block, error) {
 	// Decode header and transactions.
 	var head *types.Header
 	var body rpcBlock
@@ -274,6 +280,77 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 	return r, err
 }
 
+// BlockReceipts returns the receipts of all transactions in the given block, in
+// the order of the transactions.
+func (ec *Client) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var r []*types.Receipt
+	err := ec.c.CallContext(ctx, &r, "eth_getBlockReceipts", toBlockNumOrHashArg(blockNrOrHash))
+	if err == nil && r == nil {
+		return nil, gendchain.NotFound
+	}
+	return r, err
+}
+
+// BlockWithReceipts is a block returned by BlockRange, with the receipts of its
+// transactions if they were requested.
+type BlockWithReceipts struct {
+	Block    *types.Block
+	Receipts []*types.Receipt
+}
+
+// BlockRange returns the blocks from from to to, inclusive, with full transactions
+// and, if includeReceipts is set, their receipts. A nil to selects the latest block.
+// The node returns at most ethapi.MaxBlockRange blocks per call, and fewer if the
+// range extends past its head.
+func (ec *Client) BlockRange(ctx context.Context, from, to *big.Int, includeReceipts bool) ([]*BlockWithReceipts, error) {
+	var raw []json.RawMessage
+	if err := ec.c.CallContext(ctx, &raw, "gendchain_getBlockRange", toBlockNumArg(from), toBlockNumArg(to), true, includeReceipts); err != nil {
+		return nil, err
+	}
+	blocks := make([]*BlockWithReceipts, len(raw))
+	for i, msg := range raw {
+		block, err := ec.decodeBlock(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = &BlockWithReceipts{Block: block}
+		if includeReceipts {
+			var body struct {
+				Receipts []*types.Receipt `json:"receipts"`
+			}
+			if err := json.Unmarshal(msg, &body); err != nil {
+				return nil, err
+			}
+			blocks[i].Receipts = body.Receipts
+		}
+	}
+	return blocks, nil
+}
+
+func toBlockNumOrHashArg(blockNrOrHash rpc.BlockNumberOrHash) interface{} {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		if blockNrOrHash.RequireCanonical {
+			return map[string]interface{}{"blockHash": hash, "requireCanonical": true}
+		}
+		return hash
+	}
+	number, ok := blockNrOrHash.Number()
+	if !ok {
+		return "latest"
+	}
+	switch number {
+	case rpc.LatestBlockNumber:
+		return "latest"
+	case rpc.PendingBlockNumber:
+		return "pending"
+	case rpc.FinalizedBlockNumber:
+		return "finalized"
+	case rpc.SafeBlockNumber:
+		return "safe"
+	}
+	return hexutil.EncodeUint64(uint64(number))
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -350,6 +427,14 @@ func (ec *Client) BalanceAt(ctx context.Context, account common.Address, blockNu
 	return (*big.Int)(&result), err
 }
 
+// BalanceAtBlock returns the wei balance of the given account in the state of the
+// block selected by number or hash.
+func (ec *Client) BalanceAtBlock(ctx context.Context, account common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error) {
+	var result hexutil.Big
+	err := ec.c.CallContext(ctx, &result, "eth_getBalance", account, toBlockNumOrHashArg(blockNrOrHash))
+	return (*big.Int)(&result), err
+}
+
 // StorageAt returns the value of key in the contract storage of the given account.
 // The block number can be nil, in which case the value is taken from the latest known block.
 func (ec *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
@@ -358,6 +443,14 @@ func (ec *Client) StorageAt(ctx context.Context, account common.Address, key com
 	return result, err
 }
 
+// StorageAtBlock returns the value of key in the contract storage of the given
+// account in the state of the block selected by number or hash.
+func (ec *Client) StorageAtBlock(ctx context.Context, account common.Address, key common.Hash, blockNrOrHash rpc.BlockNumberOrHash) ([]byte, error) {
+	var result hexutil.Bytes
+	err := ec.c.CallContext(ctx, &result, "eth_getStorageAt", account, key, toBlockNumOrHashArg(blockNrOrHash))
+	return result, err
+}
+
 // CodeAt returns the contract code of the given account.
 // The block number can be nil, in which case the code is taken from the latest known block.
 func (ec *Client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
@@ -366,6 +459,14 @@ func (ec *Client) CodeAt(ctx context.Context, account common.Address, blockNumbe
 	return result, err
 }
 
+// CodeAtBlock returns the contract code of the given account in the state of the
+// block selected by number or hash.
+func (ec *Client) CodeAtBlock(ctx context.Context, account common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]byte, error) {
+	var result hexutil.Bytes
+	err := ec.c.CallContext(ctx, &result, "eth_getCode", account, toBlockNumOrHashArg(blockNrOrHash))
+	return result, err
+}
+
 // NonceAt returns the account nonce of the given account.
 // The block number can be nil, in which case the nonce is taken from the latest known block.
 func (ec *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
@@ -374,6 +475,14 @@ func (ec *Client) NonceAt(ctx context.Context, account common.Address, blockNumb
 	return uint64(result), err
 }
 
+// NonceAtBlock returns the account nonce of the given account in the state of the
+// block selected by number or hash.
+func (ec *Client) NonceAtBlock(ctx context.Context, account common.Address, blockNrOrHash rpc.BlockNumberOrHash) (uint64, error) {
+	var result hexutil.Uint64
+	err := ec.c.CallContext(ctx, &result, "eth_getTransactionCount", account, toBlockNumOrHashArg(blockNrOrHash))
+	return uint64(result), err
+}
+
 // Filters
 
 // FilterLogs executes a filter query.
@@ -474,6 +583,112 @@ func (ec *Client) CallContract(ctx context.Context, msg gendchain.CallMsg, block
 	return hex, nil
 }
 
+// CallContractAtBlock executes a message call transaction like CallContract, on
+// the state of the block selected by number or hash. Selecting the block by hash
+// ensures that several calls read the same state, even across a reorg.
+func (ec *Client) CallContractAtBlock(ctx context.Context, msg gendchain.CallMsg, blockNrOrHash rpc.BlockNumberOrHash) ([]byte, error) {
+	var hex hexutil.Bytes
+	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumOrHashArg(blockNrOrHash))
+	if err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
+
+// OverrideAccount specifies the fields of an account to override for a call. Nil
+// fields are left as they are. State replaces the entire storage of the account,
+// StateDiff only the given slots; they can't be used together.
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[common.Hash]common.Hash
+	StateDiff map[common.Hash]common.Hash
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a OverrideAccount) MarshalJSON() ([]byte, error) {
+	type override struct {
+		Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+		Code      *hexutil.Bytes              `json:"code,omitempty"`
+		Balance   *hexutil.Big                `json:"balance,omitempty"`
+		State     interface{}                 `json:"state,omitempty"`
+		StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+	}
+	enc := override{Nonce: (*hexutil.Uint64)(a.Nonce), Balance: (*hexutil.Big)(a.Balance), StateDiff: a.StateDiff}
+	if a.Code != nil {
+		enc.Code = (*hexutil.Bytes)(&a.Code)
+	}
+	if a.State != nil {
+		// An empty state still clears the storage
+		enc.State = a.State
+	}
+	return json.Marshal(enc)
+}
+
+// CallContractWithOverrides executes a message call transaction like CallContract,
+// on the state of the block selected by number or hash with the given accounts
+// overridden. The overrides only apply to this call.
+func (ec *Client) CallContractWithOverrides(ctx context.Context, msg gendchain.CallMsg, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]OverrideAccount) ([]byte, error) {
+	var hex hexutil.Bytes
+	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), toBlockNumOrHashArg(blockNrOrHash), overrides)
+	if err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
+
+// SimulateResult is the outcome of a single call run by Simulate.
+type SimulateResult struct {
+	ReturnData []byte
+	Logs       []*types.Log
+	GasUsed    uint64
+	Failed     bool // whether the call failed in the EVM
+}
+
+// Simulate runs the message calls in order on a single copy of the state of the
+// block selected by number or hash, with the given accounts overridden first.
+// Every call sees the state changes of the calls before it, nothing is persisted.
+// The node runs at most ethapi.MaxSimulateCalls calls per request.
+func (ec *Client) Simulate(ctx context.Context, msgs []gendchain.CallMsg, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]OverrideAccount) ([]*SimulateResult, error) {
+	calls := make([]interface{}, len(msgs))
+	for i, msg := range msgs {
+		calls[i] = toCallArg(msg)
+	}
+	return ec.simulate(ctx, calls, blockNrOrHash, overrides)
+}
+
+// SimulateTransactions is like Simulate, but runs signed transactions. Unlike
+// message calls, they must pass the nonce and fee checks of the block.
+func (ec *Client) SimulateTransactions(ctx context.Context, txs []*types.Transaction, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]OverrideAccount) ([]*SimulateResult, error) {
+	calls := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = hexutil.Bytes(data)
+	}
+	return ec.simulate(ctx, calls, blockNrOrHash, overrides)
+}
+
+func (ec *Client) simulate(ctx context.Context, calls []interface{}, blockNrOrHash rpc.BlockNumberOrHash, overrides map[common.Address]OverrideAccount) ([]*SimulateResult, error) {
+	var raw []struct {
+		ReturnData hexutil.Bytes  `json:"returnData"`
+		Logs       []*types.Log   `json:"logs"`
+		GasUsed    hexutil.Uint64 `json:"gasUsed"`
+		Failed     bool           `json:"failed"`
+	}
+	if err := ec.c.CallContext(ctx, &raw, "gendchain_simulate", calls, toBlockNumOrHashArg(blockNrOrHash), overrides); err != nil {
+		return nil, err
+	}
+	results := make([]*SimulateResult, len(raw))
+	for i, r := range raw {
+		results[i] = &SimulateResult{ReturnData: r.ReturnData, Logs: r.Logs, GasUsed: uint64(r.GasUsed), Failed: r.Failed}
+	}
+	return results, nil
+}
+
 // PendingCallContract executes a message call transaction using the EVM.
 // The state seen by the contract call is the pending state.
 func (ec *Client) PendingCallContract(ctx context.Context, msg gendchain.CallMsg) ([]byte, error) {
@@ -540,6 +755,17 @@ func (ec *Client) SignersAtHash(ctx context.Context, blockHash common.Hash) ([]c
 	return signers, nil
 }
 
+// SignersAtBlock returns the set of clique signers at the block selected by number
+// or hash.
+func (ec *Client) SignersAtBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]common.Address, error) {
+	var signers []common.Address
+	err := ec.c.CallContext(ctx, &signers, "clique_getSigners", toBlockNumOrHashArg(blockNrOrHash))
+	if err != nil {
+		return nil, err
+	}
+	return signers, nil
+}
+
 // VotersAt returns the set of clique voters at the given block.
 func (ec *Client) VotersAt(ctx context.Context, blockNumber *big.Int) ([]common.Address, error) {
 	var voters []common.Address
@@ -553,7 +779,7 @@ func (ec *Client) VotersAt(ctx context.Context, blockNumber *big.Int) ([]common.
 // VotersAtHash returns the set of clique voters at the given block.
 func (ec *Client) VotersAtHash(ctx context.Context, blockHash common.Hash) ([]common.Address, error) {
 	var voters []common.Address
-	err := ec.c.CallContext(ctx, &voters, "clique_getVotersAtHash", blockHash)
+	err := ec.c.CallContext(ctx, &voters, "clique_getVoters", blockHash)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,8 @@
 package goclient
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -8,6 +10,12 @@ import (
 
 	"github.com/ChainAAS/gendchain"
 	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/hexutil"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/crypto"
+	"github.com/ChainAAS/gendchain/internal/ethapi"
+	"github.com/ChainAAS/gendchain/params"
+	"github.com/ChainAAS/gendchain/rpc"
 )
 
 // Verify that Client implements the gendchain interfaces.
@@ -134,3 +142,186 @@ func TestToFilterArg(t *testing.T) {
 		})
 	}
 }
+
+func TestToBlockNumOrHashArg(t *testing.T) {
+	hash := common.HexToHash("0xeb94bb7d78b73657a9d7a99792413f50c0a45c51fc62bdcb08a53f18e9a2b4eb")
+	for _, tt := range []struct {
+		input  rpc.BlockNumberOrHash
+		output interface{}
+	}{
+		{rpc.BlockNumberOrHash{}, "latest"},
+		{rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), "latest"},
+		{rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber), "finalized"},
+		{rpc.BlockNumberOrHashWithNumber(10), "0xa"},
+		{rpc.BlockNumberOrHashWithHash(hash, false), hash},
+		{rpc.BlockNumberOrHashWithHash(hash, true), map[string]interface{}{"blockHash": hash, "requireCanonical": true}},
+	} {
+		if output := toBlockNumOrHashArg(tt.input); !reflect.DeepEqual(output, tt.output) {
+			t.Errorf("%+v: expected %v but got %v", tt.input, tt.output, output)
+		}
+	}
+}
+
+// testBlockService serves a fixed chain like the eth and gendchain APIs.
+type testBlockService struct {
+	blocks   []*types.Block
+	receipts []types.Receipts
+}
+
+func (s *testBlockService) GetBlockReceipts(blockNrOrHash rpc.BlockNumberOrHash) []*types.Receipt {
+	if number, ok := blockNrOrHash.Number(); ok && int(number) < len(s.blocks) {
+		return s.receipts[number]
+	}
+	return nil
+}
+
+func (s *testBlockService) GetBlockRange(from, to rpc.BlockNumber, fullTx, includeReceipts bool) ([]map[string]interface{}, error) {
+	var blocks []map[string]interface{}
+	for i := from; i <= to && int(i) < len(s.blocks); i++ {
+		fields, err := ethapi.RPCMarshalBlock(s.blocks[i], true, fullTx)
+		if err != nil {
+			return nil, err
+		}
+		if includeReceipts {
+			fields["receipts"] = s.receipts[i]
+		}
+		blocks = append(blocks, fields)
+	}
+	return blocks, nil
+}
+
+func TestBlockRange(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	service := new(testBlockService)
+	for i := 0; i < 3; i++ {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), common.Address{1}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: params.TxGas, GasUsed: params.TxGas, TxHash: tx.Hash(), Logs: []*types.Log{}}
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1), Time: big.NewInt(int64(i)), GasLimit: params.TxGas}
+		service.blocks = append(service.blocks, types.NewBlock(header, []*types.Transaction{tx}, nil, []*types.Receipt{receipt}))
+		service.receipts = append(service.receipts, types.Receipts{receipt})
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	for _, namespace := range []string{"eth", "gendchain"} {
+		if err := server.RegisterName(namespace, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+	client := NewClient(rpcClient)
+
+	blocks, err := client.BlockRange(context.Background(), big.NewInt(1), big.NewInt(5), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+	for i, block := range blocks {
+		want := service.blocks[i+1]
+		if block.Block.Hash() != want.Hash() || block.Block.Transactions()[0].Hash() != want.Transactions()[0].Hash() {
+			t.Errorf("block %d: mismatch", i+1)
+		}
+		if len(block.Receipts) != 1 || block.Receipts[0].TxHash != want.Transactions()[0].Hash() {
+			t.Errorf("block %d: unexpected receipts %v", i+1, block.Receipts)
+		}
+	}
+
+	receipts, err := client.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 1 || receipts[0].TxHash != service.blocks[2].Transactions()[0].Hash() {
+		t.Fatalf("unexpected receipts %v", receipts)
+	}
+	if _, err := client.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(9)); err != gendchain.NotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+// testSimulateService echoes the calls and checks the overrides it receives.
+type testSimulateService struct {
+	t         *testing.T
+	overrides ethapi.StateOverride
+}
+
+func (s *testSimulateService) Simulate(calls []ethapi.SimulateArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverride) []*ethapi.SimulateResult {
+	if hash, ok := blockNrOrHash.Hash(); !ok || hash != (common.Hash{1}) || !blockNrOrHash.RequireCanonical {
+		s.t.Errorf("unexpected block %v", blockNrOrHash)
+	}
+	if overrides == nil || !reflect.DeepEqual(*overrides, s.overrides) {
+		s.t.Errorf("unexpected overrides %+v", overrides)
+	}
+	results := make([]*ethapi.SimulateResult, len(calls))
+	for i, call := range calls {
+		if call.Tx != nil {
+			log := &types.Log{Address: *call.Tx.To(), Topics: []common.Hash{}, Data: []byte{}, TxIndex: uint(i)}
+			results[i] = &ethapi.SimulateResult{ReturnData: call.Tx.Data(), Logs: []*types.Log{log}, GasUsed: hexutil.Uint64(call.Tx.Gas()), Failed: i > 0}
+			continue
+		}
+		log := &types.Log{Address: *call.To, Topics: []common.Hash{}, Data: []byte{}, TxIndex: uint(i)}
+		results[i] = &ethapi.SimulateResult{ReturnData: *call.Data, Logs: []*types.Log{log}, GasUsed: *call.Gas, Failed: i > 0}
+	}
+	return results
+}
+
+func TestSimulate(t *testing.T) {
+	var (
+		addr    = common.Address{2}
+		nonce   = hexutil.Uint64(3)
+		code    = hexutil.Bytes{0x60, 0x00}
+		storage = map[common.Hash]common.Hash{}
+		diff    = map[common.Hash]common.Hash{{1}: {2}}
+	)
+	service := &testSimulateService{t: t, overrides: ethapi.StateOverride{
+		addr:              {Nonce: &nonce, Code: &code, Balance: (*hexutil.Big)(big.NewInt(5)), State: &storage},
+		common.Address{3}: {StateDiff: &diff},
+	}}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("gendchain", service); err != nil {
+		t.Fatal(err)
+	}
+	rpcClient := rpc.DialInProc(server)
+	defer rpcClient.Close()
+	client := NewClient(rpcClient)
+
+	n := uint64(nonce)
+	overrides := map[common.Address]OverrideAccount{
+		addr:              {Nonce: &n, Code: code, Balance: big.NewInt(5), State: storage},
+		common.Address{3}: {StateDiff: diff},
+	}
+	msgs := []gendchain.CallMsg{{To: &addr, Gas: 30000, Data: []byte{1}}, {To: &addr, Gas: 40000, Data: []byte{2}}}
+	results, err := client.Simulate(context.Background(), msgs, rpc.BlockNumberOrHashWithHash(common.Hash{1}, true), overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(msgs) {
+		t.Fatalf("expected %d results, got %d", len(msgs), len(results))
+	}
+	for i, result := range results {
+		if !bytes.Equal(result.ReturnData, msgs[i].Data) || result.GasUsed != msgs[i].Gas || result.Failed != (i > 0) {
+			t.Errorf("call %d: unexpected result %+v", i, result)
+		}
+		if len(result.Logs) != 1 || result.Logs[0].Address != addr || result.Logs[0].TxIndex != uint(i) {
+			t.Errorf("call %d: unexpected logs %v", i, result.Logs)
+		}
+	}
+	txs := make([]*types.Transaction, len(msgs))
+	for i, msg := range msgs {
+		txs[i] = types.NewTransaction(uint64(i), *msg.To, new(big.Int), msg.Gas, big.NewInt(1), msg.Data)
+	}
+	results, err = client.SimulateTransactions(context.Background(), txs, rpc.BlockNumberOrHashWithHash(common.Hash{1}, true), overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(txs) {
+		t.Fatalf("expected %d results, got %d", len(txs), len(results))
+	}
+	for i, result := range results {
+		if !bytes.Equal(result.ReturnData, txs[i].Data()) || result.GasUsed != txs[i].Gas() || result.Failed != (i > 0) {
+			t.Errorf("transaction %d: unexpected result %+v", i, result)
+		}
+	}
+}
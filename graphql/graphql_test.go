@@ -0,0 +1,275 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/common/hexutil"
+	"github.com/ChainAAS/gendchain/consensus/clique"
+	"github.com/ChainAAS/gendchain/core"
+	"github.com/ChainAAS/gendchain/core/bloombits"
+	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/types"
+	"github.com/ChainAAS/gendchain/core/vm"
+	"github.com/ChainAAS/gendchain/crypto"
+	"github.com/ChainAAS/gendchain/ethdb"
+	"github.com/ChainAAS/gendchain/params"
+	"github.com/ChainAAS/gendchain/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+	testFunds   = big.NewInt(1000000000000000)
+	testRecv    = common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// logCode is the init code PUSH1 0 PUSH1 0 LOG0 STOP, emitting an empty log.
+	logCode = common.Hex2Bytes("60006000a000")
+)
+
+// testBackend resolves queries against a local chain.
+type testBackend struct {
+	Backend
+	chain *core.BlockChain
+	db    common.Database
+}
+
+func (b *testBackend) ChainDb() common.Database                          { return b.db }
+func (b *testBackend) ChainConfig() *params.ChainConfig                  { return b.chain.Config() }
+func (b *testBackend) CurrentBlock() *types.Block                        { return b.chain.CurrentBlock() }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int                   { return b.chain.GetTdByHash(hash) }
+func (b *testBackend) BloomStatus() (uint64, uint64)                     { return params.BloomBitsBlocks, 0 }
+func (b *testBackend) GetPoolTransaction(common.Hash) *types.Transaction { return nil }
+
+func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		return b.chain.CurrentBlock().Header(), nil
+	}
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber {
+		return b.chain.CurrentBlock(), nil
+	}
+	return b.chain.GetBlockByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.chain.GetReceiptsByHash(hash), nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	receipts := b.chain.GetReceiptsByHash(hash)
+	logs := make([][]*types.Log, len(receipts))
+	for i, receipt := range receipts {
+		logs[i] = receipt.Logs
+	}
+	return logs, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	header, _ := b.HeaderByNumber(ctx, number)
+	if header == nil {
+		return nil, nil, nil
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+// newTestService returns a service over a chain of two blocks: the first sends
+// funds to testRecv, the second creates a contract emitting a log.
+func newTestService(t *testing.T) (*Service, []*types.Block) {
+	db := ethdb.NewMemDatabase()
+	gspec := core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{testAddress: {Balance: testFunds}}}
+	genesis := gspec.MustCommit(db)
+	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, clique.NewFaker(), db, 2, func(i int, gen *core.BlockGen) {
+		signer := types.HomesteadSigner{}
+		var tx *types.Transaction
+		switch i {
+		case 0:
+			tx = types.NewTransaction(gen.TxNonce(testAddress), testRecv, big.NewInt(1000), params.TxGas, big.NewInt(1), nil)
+		case 1:
+			tx = types.NewContractCreation(gen.TxNonce(testAddress), big.NewInt(0), 100000, big.NewInt(1), logCode)
+		}
+		tx, _ = types.SignTx(tx, signer, testKey)
+		gen.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, clique.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(&testBackend{chain: chain, db: db}, chain.Engine(), chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, blocks
+}
+
+func query(t *testing.T, s *Service, q string, result interface{}) {
+	if errs := queryErrors(t, s, q, result); len(errs) > 0 {
+		t.Fatalf("query failed: %v", errs)
+	}
+}
+
+// queryErrors runs the query and returns the errors it failed with.
+func queryErrors(t *testing.T, s *Service, q string, result interface{}) []string {
+	body, _ := json.Marshal(map[string]string{"query": q})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data   json.RawMessage
+		Errors []struct{ Message string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	if len(resp.Errors) > 0 {
+		errs := make([]string, len(resp.Errors))
+		for i, err := range resp.Errors {
+			errs[i] = err.Message
+		}
+		return errs
+	}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		t.Fatal(err)
+	}
+	return nil
+}
+
+func TestGraphQL_Block(t *testing.T) {
+	s, blocks := newTestService(t)
+
+	var result struct {
+		Block struct {
+			Number           int64
+			Hash             common.Hash
+			Parent           struct{ Number int64 }
+			TransactionCount int32
+			Transactions     []struct {
+				Hash            common.Hash
+				Index           int32
+				From            struct{ Address common.Address }
+				To              *struct{ Address common.Address }
+				Status          int64
+				GasUsed         int64
+				CreatedContract struct{ Address common.Address }
+				Logs            []struct {
+					Index   int32
+					Account struct{ Address common.Address }
+				}
+			}
+			Account struct {
+				Balance          hexutil.Big
+				TransactionCount int64
+			}
+			CliqueSnapshot *struct{ Number int64 }
+		}
+	}
+	query(t, s, `{
+		block(number: 2) {
+			number hash parent { number } transactionCount
+			transactions {
+				hash index from { address } to { address } status gasUsed
+				createdContract { address }
+				logs { index account { address } }
+			}
+			account(address: "`+testAddress.Hex()+`") { balance transactionCount }
+			cliqueSnapshot { number }
+		}
+	}`, &result)
+
+	block := result.Block
+	if block.Number != 2 || block.Hash != blocks[1].Hash() || block.Parent.Number != 1 {
+		t.Fatalf("unexpected block: %+v", block)
+	}
+	if block.TransactionCount != 1 || len(block.Transactions) != 1 {
+		t.Fatalf("unexpected transactions: %+v", block.Transactions)
+	}
+	tx := block.Transactions[0]
+	contract := crypto.CreateAddress(testAddress, 1)
+	if tx.Hash != blocks[1].Transactions()[0].Hash() || tx.From.Address != testAddress || tx.To != nil || tx.Status != 1 {
+		t.Fatalf("unexpected transaction: %+v", tx)
+	}
+	if tx.CreatedContract.Address != contract || len(tx.Logs) != 1 || tx.Logs[0].Account.Address != contract {
+		t.Fatalf("unexpected receipt: %+v", tx)
+	}
+	spent := new(big.Int).Add(big.NewInt(1000), big.NewInt(int64(params.TxGas)+tx.GasUsed))
+	if balance := block.Account.Balance.ToInt(); balance.Cmp(new(big.Int).Sub(testFunds, spent)) != 0 {
+		t.Fatalf("unexpected balance: %v", balance)
+	} else if block.Account.TransactionCount != 2 {
+		t.Fatalf("unexpected nonce: %v", block.Account.TransactionCount)
+	}
+	if block.CliqueSnapshot != nil {
+		t.Fatalf("unexpected clique snapshot without clique engine: %+v", block.CliqueSnapshot)
+	}
+}
+
+func TestGraphQL_Logs(t *testing.T) {
+	s, blocks := newTestService(t)
+
+	var result struct {
+		Logs []struct {
+			Transaction struct {
+				Hash  common.Hash
+				Block struct{ Number int64 }
+			}
+		}
+		Transaction struct {
+			Value hexutil.Big
+			Block struct{ Number int64 }
+		}
+		Blocks []struct{ Number int64 }
+	}
+	query(t, s, `{
+		logs(filter: {fromBlock: 0}) { transaction { hash block { number } } }
+		transaction(hash: "`+blocks[0].Transactions()[0].Hash().Hex()+`") { value block { number } }
+		blocks(from: 1) { number }
+	}`, &result)
+
+	if len(result.Logs) != 1 || result.Logs[0].Transaction.Hash != blocks[1].Transactions()[0].Hash() || result.Logs[0].Transaction.Block.Number != 2 {
+		t.Fatalf("unexpected logs: %+v", result.Logs)
+	}
+	if result.Transaction.Value.ToInt().Int64() != 1000 || result.Transaction.Block.Number != 1 {
+		t.Fatalf("unexpected transaction: %+v", result.Transaction)
+	}
+	if len(result.Blocks) != 2 || result.Blocks[0].Number != 1 || result.Blocks[1].Number != 2 {
+		t.Fatalf("unexpected blocks: %+v", result.Blocks)
+	}
+}
+
+func TestGraphQL_BlocksRange(t *testing.T) {
+	s, _ := newTestService(t)
+
+	for q, want := range map[string]string{
+		`{ blocks(from: -1, to: 1) { number } }`:         errBlockNegative.Error(),
+		`{ blocks(from: 0, to: 256) { number } }`:        "block range of 257 exceeds the limit of 256 blocks",
+		`{ blocks(from: 0, to: 1000000000) { number } }`: "block range of 1000000001 exceeds the limit of 256 blocks",
+	} {
+		var result struct{ Blocks []struct{ Number int64 } }
+		if errs := queryErrors(t, s, q, &result); len(errs) != 1 || !strings.Contains(errs[0], want) {
+			t.Errorf("%s: unexpected errors %v, want %q", q, errs, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"net/http"
+
+	"github.com/ChainAAS/gendchain/consensus"
+	"github.com/ChainAAS/gendchain/consensus/clique"
+	"github.com/ChainAAS/gendchain/node"
+	"github.com/ChainAAS/gendchain/p2p"
+	"github.com/ChainAAS/gendchain/rpc"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+// Service serves GraphQL queries on the node's HTTP endpoint.
+type Service struct {
+	handler http.Handler
+}
+
+// New returns a GraphQL service resolving queries against backend. The clique
+// snapshot of blocks is available if engine is a clique engine and chain is
+// not nil, which light clients don't provide.
+func New(backend Backend, engine consensus.Engine, chain consensus.ChainReader) (*Service, error) {
+	resolver := &Resolver{backend: backend, engine: engine}
+	if c, ok := engine.(*clique.Clique); ok && chain != nil {
+		resolver.clique = clique.NewAPI(chain, c)
+	}
+	s, err := graphql.ParseSchema(schema, resolver)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{handler: &relay.Handler{Schema: s}}, nil
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the GraphQL service (nil as it doesn't use the devp2p overlay network).
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints provided by the
+// GraphQL service (nil as it serves queries over HTTP only).
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service. Queries are served by the node's HTTP server.
+func (s *Service) Start(server *p2p.Server) error { return nil }
+
+// Stop implements node.Service.
+func (s *Service) Stop() error { return nil }
+
+// HTTPHandlers implements node.HTTPService, serving queries at /graphql. Each
+// query counts as a call of the "graphql" namespace for authenticated clients.
+func (s *Service) HTTPHandlers() []node.HTTPHandler {
+	return []node.HTTPHandler{{Path: "/graphql", Namespace: "graphql", Handler: s.handler}}
+}
@@ -4,6 +4,7 @@ package ethapi
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -21,8 +22,10 @@ import (
 	"github.com/ChainAAS/gendchain/common/hexutil"
 	"github.com/ChainAAS/gendchain/common/math"
 	"github.com/ChainAAS/gendchain/consensus/clique"
+	"github.com/ChainAAS/gendchain/consensus/misc"
 	"github.com/ChainAAS/gendchain/core"
 	"github.com/ChainAAS/gendchain/core/rawdb"
+	"github.com/ChainAAS/gendchain/core/state"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/core/vm"
 	"github.com/ChainAAS/gendchain/crypto"
@@ -47,10 +50,79 @@ func NewPublicEthereumAPI(b Backend) *PublicEthereumAPI {
 
 // GasPrice returns a suggestion for a gas price.
 func (s *PublicEthereumAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
-	price, err := s.b.SuggestPrice(ctx)
+	price, err := suggestGasPrice(ctx, s.b)
 	return (*hexutil.Big)(price), err
 }
 
+// suggestGasPrice returns a suggestion for a legacy gas price. After EIP-1559
+// the suggested tip is added on top of the next block's base fee.
+func suggestGasPrice(ctx context.Context, b Backend) (*big.Int, error) {
+	tip, err := b.SuggestTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if baseFee := nextBaseFee(ctx, b); baseFee != nil {
+		tip = new(big.Int).Add(tip, baseFee)
+	}
+	return tip, nil
+}
+
+// nextBaseFee returns the base fee of the block following the current head, or
+// nil if EIP-1559 is not active for it.
+func nextBaseFee(ctx context.Context, b Backend) *big.Int {
+	config := b.ChainConfig()
+	head, _ := b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil || !config.IsLondon(new(big.Int).Add(head.Number, common.Big1), head.Time) {
+		return nil
+	}
+	return misc.CalcBaseFee(config, head)
+}
+
+// MaxPriorityFeePerGas returns a suggestion for a gas tip cap for dynamic fee transactions.
+func (s *PublicEthereumAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tip, err := s.b.SuggestTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(tip), err
+}
+
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the fee market history of up to blockCount blocks ending
+// at lastBlock.
+func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
+	oldest, reward, baseFee, gasUsed, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	results := &feeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		GasUsedRatio: gasUsed,
+	}
+	if reward != nil {
+		results.Reward = make([][]*hexutil.Big, len(reward))
+		for i, w := range reward {
+			results.Reward[i] = make([]*hexutil.Big, len(w))
+			for j, v := range w {
+				results.Reward[i][j] = (*hexutil.Big)(v)
+			}
+		}
+	}
+	if baseFee != nil {
+		results.BaseFee = make([]*hexutil.Big, len(baseFee))
+		for i, v := range baseFee {
+			results.BaseFee[i] = (*hexutil.Big)(v)
+		}
+	}
+	return results, nil
+}
+
 // ProtocolVersion returns the current Ethereum protocol version this node supports
 func (s *PublicEthereumAPI) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(s.b.ProtocolVersion())
@@ -383,7 +455,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 		log.Warn("Failed transaction sign attempt", "from", args.From, "to", args.To, "value", args.Value.ToInt(), "err", err)
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(signed)
+	data, err := signed.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -466,31 +538,48 @@ func (s *PublicBlockChainAPI) BlockNumber() hexutil.Uint64 {
 	return hexutil.Uint64(header.Number.Uint64())
 }
 
-// TotalSupply returns the total supply in wei as of the given block number. The
-// rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block numbers are also allowed.
-func (s *PublicBlockChainAPI) TotalSupply(ctx context.Context, blockNr rpc.BlockNumber) (*hexutil.Big, error) {
+// TotalSupply returns the total supply in wei as of the given block number or
+// hash. The rpc.LatestBlockNumber, rpc.PendingBlockNumber, rpc.FinalizedBlockNumber
+// and rpc.SafeBlockNumber meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) TotalSupply(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
 	initial := s.b.InitialSupply()
 	if initial == nil {
 		return nil, fmt.Errorf("unknown initial allocation")
 	}
+	blockNr, ok := blockNrOrHash.Number()
 	var n *big.Int
-	switch blockNr {
-	default:
-		if blockNr < rpc.PendingBlockNumber {
-			return nil, fmt.Errorf("illegal block number %d", blockNr)
+	switch {
+	case !ok:
+		header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+		if err != nil {
+			return nil, err
 		}
-		n = big.NewInt(int64(blockNr))
-	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		if header == nil {
+			return nil, fmt.Errorf("unknown block %s", blockNrOrHash)
+		}
+		n = header.Number
+	case blockNr == rpc.LatestBlockNumber, blockNr == rpc.PendingBlockNumber:
 		header, err := s.b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 		if err != nil {
 			return nil, err
 		}
-		switch blockNr {
-		case rpc.LatestBlockNumber:
-			n = header.Number
-		case rpc.PendingBlockNumber:
-			n = n.Add(big.NewInt(1), header.Number)
+		n = header.Number
+		if blockNr == rpc.PendingBlockNumber {
+			n = new(big.Int).Add(big.NewInt(1), header.Number)
 		}
+	case blockNr == rpc.FinalizedBlockNumber, blockNr == rpc.SafeBlockNumber:
+		header, err := s.b.HeaderByNumber(ctx, blockNr)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("unknown finalized block")
+		}
+		n = header.Number
+	case blockNr < rpc.PendingBlockNumber:
+		return nil, fmt.Errorf("illegal block number %d", blockNr)
+	default:
+		n = big.NewInt(int64(blockNr))
 	}
 	rewards := new(big.Int).Mul(n, clique.BlockReward)
 	return (*hexutil.Big)(rewards.Add(rewards, initial)), nil
@@ -506,12 +595,12 @@ func (s *PublicBlockChainAPI) GenesisAlloc(ctx context.Context) (core.GenesisAll
 }
 
 // GetBalance returns the amount of wei for the given address in the state of the
-// given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
-// block numbers are also allowed.
-func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Big, error) {
+// given block number or hash. The rpc.LatestBlockNumber and rpc.PendingBlockNumber
+// meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
 	ctx, span := trace.StartSpan(ctx, "PublicBlockChainAPI.GetBalance")
 	defer span.End()
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -536,8 +625,8 @@ type StorageResult struct {
 }
 
 // GetProof returns the Merkle-proof for a given account and optionally some storage keys.
-func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*AccountResult, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -666,9 +755,9 @@ func (s *PublicBlockChainAPI) GetUncleCountByBlockHash(ctx context.Context, bloc
 	return nil
 }
 
-// GetCode returns the code stored at the given address in the state for the given block number.
-func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+// GetCode returns the code stored at the given address in the state for the given block number or hash.
+func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -676,10 +765,10 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 }
 
 // GetStorageAt returns the storage from the state at the given address, key and
-// block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
-// numbers are also allowed.
-func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+// block number or hash. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
+// block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -695,15 +784,62 @@ type CallArgs struct {
 	GasPrice *hexutil.Big    `json:"gasPrice"`
 	Value    *hexutil.Big    `json:"value"`
 	Data     *hexutil.Bytes  `json:"data"`
+
+	AccessList *types.AccessList `json:"accessList"`
+
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
 }
 
-func DoCall(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+// OverrideAccount indicates the overriding fields of an account during the
+// execution of a message call. State and StateDiff can't be specified at the
+// same time: State replaces the entire storage of the account, while StateDiff
+// only overrides the given slots.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce"`
+	Code      *hexutil.Bytes               `json:"code"`
+	Balance   *hexutil.Big                 `json:"balance"`
+	State     *map[common.Hash]common.Hash `json:"state"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
 
-	state, header, err := b.StateAndHeaderByNumber(ctx, blockNr)
-	if state == nil || err != nil {
-		return nil, 0, false, err
+// StateOverride is the collection of overridden accounts.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply overrides the fields of the specified accounts in the given state.
+func (diff *StateOverride) Apply(state *state.StateDB) error {
+	if diff == nil {
+		return nil
 	}
+	for addr, account := range *diff {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		if account.Nonce != nil {
+			state.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Code != nil {
+			state.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			state.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		// Replace the entire storage, or only the given slots
+		if account.State != nil {
+			state.SetStorage(addr, *account.State)
+		}
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				state.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// toMessage converts the call arguments to a message executed on top of the
+// block of header, filling in defaults for the missing fields.
+func (args *CallArgs) toMessage(b Backend, header *types.Header) *types.Message {
 	// Set sender address or use a default if none specified
 	var addr common.Address
 	if args.From == nil {
@@ -724,6 +860,18 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumb
 	if args.GasPrice != nil {
 		gasPrice = args.GasPrice.ToInt()
 	}
+	gasFeeCap, gasTipCap := gasPrice, gasPrice
+	if args.MaxFeePerGas != nil {
+		// Dynamic fee call, derive the effective gas price from the caps
+		gasFeeCap, gasTipCap = args.MaxFeePerGas.ToInt(), new(big.Int)
+		if args.MaxPriorityFeePerGas != nil {
+			gasTipCap = args.MaxPriorityFeePerGas.ToInt()
+		}
+		gasPrice = gasFeeCap
+		if header.BaseFee != nil {
+			gasPrice = math.BigMin(new(big.Int).Add(gasTipCap, header.BaseFee), gasFeeCap)
+		}
+	}
 
 	value := new(big.Int)
 	if args.Value != nil {
@@ -735,8 +883,25 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumb
 		data = []byte(*args.Data)
 	}
 
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+
 	// Create new call message
-	msg := types.NewMessage(addr, args.To, 0, value, gas, gasPrice, data, false)
+	return types.NewMessage(addr, args.To, 0, value, gas, gasPrice, gasFeeCap, gasTipCap, data, accessList, false)
+}
+
+func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
+	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, 0, false, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, 0, false, err
+	}
 
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
@@ -750,6 +915,19 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumb
 	// this makes sure resources are cleaned up.
 	defer cancel()
 
+	return applyCall(ctx, b, args, state, header, vmCfg)
+}
+
+// applyCall executes the call on the given state, on top of the block of header.
+// The execution is aborted when ctx is done. Calls are not charged against the
+// base fee, so gas prices below it (including zero) are accepted.
+func applyCall(ctx context.Context, b Backend, args CallArgs, state *state.StateDB, header *types.Header, vmCfg vm.Config) ([]byte, uint64, bool, error) {
+	vmCfg.NoBaseFee = true
+	return applyMessage(ctx, b, args.toMessage(b, header), state, header, vmCfg)
+}
+
+// applyMessage executes the message on the given state, until the context is done.
+func applyMessage(ctx context.Context, b Backend, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) ([]byte, uint64, bool, error) {
 	// Get a new instance of the EVM.
 	evm, err := b.GetEVM(ctx, msg, state, header, vmCfg)
 	if err != nil {
@@ -768,14 +946,16 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumb
 	return core.ApplyMessage(evm, msg, gp)
 }
 
-// Call executes the given transaction on the state for the given block number.
+// Call executes the given transaction on the state for the given block number or hash.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := DoCall(ctx, s.b, args, blockNr, vm.Config{}, 5*time.Second)
+// The optional state overrides replace the balance, nonce, code or storage of
+// accounts for this call only.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) (hexutil.Bytes, error) {
+	result, _, _, err := DoCall(ctx, s.b, args, blockNrOrHash, overrides, vm.Config{}, 5*time.Second)
 	return (hexutil.Bytes)(result), err
 }
 
-func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Uint64, error) {
+func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
 		lo  uint64 = params.TxGas - 1
@@ -786,10 +966,13 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNr rpc.Bl
 		hi = uint64(*args.Gas)
 	} else {
 		// Retrieve the block to act as the gas ceiling
-		block, err := b.BlockByNumber(ctx, blockNr)
+		block, err := b.BlockByNumberOrHash(ctx, blockNrOrHash)
 		if err != nil {
 			return 0, err
 		}
+		if block == nil {
+			return 0, fmt.Errorf("unknown block %s", blockNrOrHash)
+		}
 		hi = block.GasLimit()
 	}
 	cap = hi
@@ -799,7 +982,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNr rpc.Bl
 		g := hexutil.Uint64(gas)
 		args.Gas = &g
 
-		_, _, failed, err := DoCall(ctx, b, args, rpc.PendingBlockNumber, vm.Config{}, 0)
+		_, _, failed, err := DoCall(ctx, b, args, blockNrOrHash, nil, vm.Config{}, 0)
 		if err != nil || failed {
 			return false
 		}
@@ -824,9 +1007,98 @@ func DoEstimateGas(ctx context.Context, b Backend, args CallArgs, blockNr rpc.Bl
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
-// given transaction against the current pending block.
-func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
-	return DoEstimateGas(ctx, s.b, args, rpc.PendingBlockNumber)
+// given transaction. An optional block number or hash selects the state to run
+// it on, the pending block is used by default.
+func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	return DoEstimateGas(ctx, s.b, args, bNrOrHash)
+}
+
+// accessListResult is the result of the eth_createAccessList RPC call. It
+// contains an error if the transaction itself failed.
+type accessListResult struct {
+	Accesslist *types.AccessList `json:"accessList"`
+	Error      string            `json:"error,omitempty"`
+	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+}
+
+// CreateAccessList creates an EIP-2930 type AccessList for the given transaction.
+// An optional block number or hash selects the state to run it on, the pending
+// block is used by default.
+func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*accessListResult, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	acl, gasUsed, vmerr, err := AccessList(ctx, s.b, bNrOrHash, args)
+	if err != nil {
+		return nil, err
+	}
+	result := &accessListResult{Accesslist: &acl, GasUsed: hexutil.Uint64(gasUsed)}
+	if vmerr != nil {
+		result.Error = vmerr.Error()
+	}
+	return result, nil
+}
+
+// AccessList creates an access list for the given transaction.
+// If the accesslist creation fails an error is returned.
+// If the transaction itself fails, an vmErr is returned.
+func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, args CallArgs) (acl types.AccessList, gasUsed uint64, vmErr error, err error) {
+	_, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, 0, nil, err
+	}
+	if !b.ChainConfig().IsBerlin(header.Number, header.Time) {
+		return nil, 0, nil, errors.New("access lists are not supported before the berlin fork")
+	}
+	// Retrieve the precompiles since they don't need to be added to the access list
+	precompiles := vm.ActivePrecompiles(b.ChainConfig().Rules(header.Number, header.Time))
+
+	// Mirror the sender and recipient used by DoCall, a creation targets the
+	// address derived from the sender and a zero nonce
+	var from common.Address
+	if args.From != nil {
+		from = *args.From
+	} else if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
+		if accounts := wallets[0].Accounts(); len(accounts) > 0 {
+			from = accounts[0].Address
+		}
+	}
+	args.From = &from
+	to := crypto.CreateAddress(from, 0)
+	if args.To != nil {
+		to = *args.To
+	}
+
+	// Create an initial tracer
+	prevTracer := vm.NewAccessListTracer(nil, from, to, precompiles)
+	if args.AccessList != nil {
+		prevTracer = vm.NewAccessListTracer(*args.AccessList, from, to, precompiles)
+	}
+	for {
+		// Retrieve the current access list to expand
+		accessList := prevTracer.AccessList()
+		log.Trace("Creating access list", "input", accessList)
+
+		// Apply the transaction with the access list tracer
+		args.AccessList = &accessList
+		tracer := vm.NewAccessListTracer(accessList, from, to, precompiles)
+		_, gas, failed, err := DoCall(ctx, b, args, blockNrOrHash, nil, vm.Config{Tracer: tracer, Debug: true}, 0)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to apply transaction: err: %v", err)
+		}
+		if tracer.Equal(prevTracer) {
+			if failed {
+				vmErr = errors.New("execution reverted or failed")
+			}
+			return accessList, gas, vmErr, nil
+		}
+		prevTracer = tracer
+	}
 }
 
 // ExecutionResult groups all structured logs emitted by the EVM
@@ -917,6 +1189,9 @@ func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]inter
 		"transactionsRoot": head.TxHash,
 		"receiptsRoot":     head.ReceiptHash,
 	}
+	if head.BaseFee != nil {
+		fields["baseFeePerGas"] = (*hexutil.Big)(head.BaseFee)
+	}
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -959,6 +1234,172 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 	return fields, err
 }
 
+// MaxBlockRange is the maximum number of blocks returned by a single
+// gendchain_getBlockRange call.
+const MaxBlockRange = 256
+
+// PublicGendChainAPI provides GendChain specific methods, which batch chain access
+// that would otherwise take one call per block or transaction.
+type PublicGendChainAPI struct {
+	b  Backend
+	bc *PublicBlockChainAPI
+}
+
+// NewPublicGendChainAPI creates a new GendChain API.
+func NewPublicGendChainAPI(b Backend) *PublicGendChainAPI {
+	return &PublicGendChainAPI{b: b, bc: NewPublicBlockChainAPI(b)}
+}
+
+// GetBlockRange returns the blocks from from to to, inclusive, in ascending order. When fullTx is true all
+// transactions are returned in full detail, otherwise only their hashes. When includeReceipts is true every
+// block also carries the receipts of its transactions. At most MaxBlockRange blocks are returned, and the
+// range ends early at the head of the chain.
+func (s *PublicGendChainAPI) GetBlockRange(ctx context.Context, from, to rpc.BlockNumber, fullTx, includeReceipts bool) ([]map[string]interface{}, error) {
+	begin, err := s.resolveNumber(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.resolveNumber(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	if end < begin {
+		return nil, fmt.Errorf("invalid block range: %d is after %d", begin, end)
+	}
+	if count := end - begin + 1; count > MaxBlockRange {
+		return nil, &rpc.LimitExceededError{Message: fmt.Sprintf("block range of %d exceeds the limit of %d blocks", count, MaxBlockRange)}
+	}
+	blocks := make([]map[string]interface{}, 0, end-begin+1)
+	for number := begin; number <= end; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block, err := s.b.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		} else if block == nil {
+			break
+		}
+		fields, err := s.bc.rpcOutputBlock(block, true, fullTx)
+		if err != nil {
+			return nil, err
+		}
+		if includeReceipts {
+			if fields["receipts"], err = blockReceipts(ctx, s.b, block); err != nil {
+				return nil, err
+			}
+		}
+		blocks = append(blocks, fields)
+	}
+	return blocks, nil
+}
+
+// MaxSimulateCalls is the maximum number of calls run by a single Simulate request.
+const MaxSimulateCalls = 256
+
+// SimulateArgs is a single call run by Simulate, given either as call arguments or as
+// a signed transaction encoded like for eth_sendRawTransaction.
+type SimulateArgs struct {
+	CallArgs
+	Tx *types.Transaction
+}
+
+// UnmarshalJSON decodes call arguments from a JSON object and a signed transaction
+// from a hex string.
+func (args *SimulateArgs) UnmarshalJSON(input []byte) error {
+	if len(input) == 0 || input[0] != '"' {
+		return json.Unmarshal(input, &args.CallArgs)
+	}
+	var encoded hexutil.Bytes
+	if err := json.Unmarshal(input, &encoded); err != nil {
+		return err
+	}
+	args.Tx = new(types.Transaction)
+	return args.Tx.UnmarshalBinary(encoded)
+}
+
+// SimulateResult is the outcome of a single call run by Simulate.
+type SimulateResult struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Logs       []*types.Log   `json:"logs"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Failed     bool           `json:"failed"`
+}
+
+// Simulate runs the calls in order on a single copy of the state of the given block number or hash, after
+// applying the optional state overrides. Every call sees the state changes of the calls before it, and none
+// are persisted. A call failing in the EVM is marked as failed without aborting the following calls, while
+// a call which can't be executed at all fails the request. Signed transactions are run from their sender
+// and must pass the nonce and fee checks of the block, while plain calls skip them like eth_call. Logs carry
+// the index of their call as transaction index. At most MaxSimulateCalls calls are run, sharing the timeout
+// of a single eth_call.
+func (s *PublicGendChainAPI) Simulate(ctx context.Context, calls []SimulateArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride) ([]*SimulateResult, error) {
+	if len(calls) > MaxSimulateCalls {
+		return nil, &rpc.LimitExceededError{Message: fmt.Sprintf("%d calls exceed the limit of %d calls", len(calls), MaxSimulateCalls)}
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	config := s.b.ChainConfig()
+	deleteEmpty := config.IsEIP158(header.Number)
+	signer := types.MakeSigner(config, header.Number, header.Time)
+	results := make([]*SimulateResult, len(calls))
+	for i, args := range calls {
+		// Logs of all calls are collected under the zero transaction hash
+		state.Prepare(common.Hash{}, header.Hash(), i)
+		logged := len(state.GetLogs(common.Hash{}))
+
+		var (
+			result []byte
+			gas    uint64
+			failed bool
+		)
+		if args.Tx != nil {
+			var msg *types.Message
+			if msg, err = args.Tx.AsMessage(signer, header.BaseFee); err == nil {
+				result, gas, failed, err = applyMessage(ctx, s.b, msg, state, header, vm.Config{})
+			}
+		} else {
+			result, gas, failed, err = applyCall(ctx, s.b, args.CallArgs, state, header, vm.Config{})
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("execution aborted at call %d: %v", i, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %v", i, err)
+		}
+		state.Finalise(deleteEmpty)
+
+		logs := append([]*types.Log{}, state.GetLogs(common.Hash{})[logged:]...)
+		for _, entry := range logs {
+			entry.BlockNumber = header.Number.Uint64()
+		}
+		results[i] = &SimulateResult{ReturnData: result, Logs: logs, GasUsed: hexutil.Uint64(gas), Failed: failed}
+	}
+	return results, nil
+}
+
+// resolveNumber returns the number of the block selected by blockNr, resolving tags.
+func (s *PublicGendChainAPI) resolveNumber(ctx context.Context, blockNr rpc.BlockNumber) (uint64, error) {
+	if blockNr >= 0 {
+		return uint64(blockNr), nil
+	}
+	header, err := s.b.HeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return 0, err
+	} else if header == nil {
+		return 0, fmt.Errorf("block %d not found", blockNr)
+	}
+	return header.Number.Uint64(), nil
+}
+
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
 	BlockHash        common.Hash     `json:"blockHash"`
@@ -975,14 +1416,22 @@ type RPCTransaction struct {
 	V                *hexutil.Big    `json:"v"`
 	R                *hexutil.Big    `json:"r"`
 	S                *hexutil.Big    `json:"s"`
+
+	Type       hexutil.Uint64    `json:"type"`
+	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+	GasFeeCap  *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	GasTipCap  *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
-// representation, with the given location metadata set (if available).
-func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) *RPCTransaction {
+// representation, with the given location metadata set (if available). For
+// dynamic fee transactions included in a block with the given base fee the
+// gas price is the effective one paid.
+func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 	v, r, s := tx.RawSignatureValues()
@@ -999,6 +1448,20 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		V:        (*hexutil.Big)(v),
 		R:        (*hexutil.Big)(r),
 		S:        (*hexutil.Big)(s),
+		Type:     hexutil.Uint64(tx.Type()),
+	}
+	if tx.Type() != types.LegacyTxType {
+		al := tx.AccessList()
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+		result.AccessList = &al
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		result.GasFeeCap = (*hexutil.Big)(tx.GasFeeCap())
+		result.GasTipCap = (*hexutil.Big)(tx.GasTipCap())
+		if baseFee != nil && blockHash != (common.Hash{}) {
+			price := new(big.Int).Add(tx.GasTipCap(), baseFee)
+			result.GasPrice = (*hexutil.Big)(math.BigMin(price, tx.GasFeeCap()))
+		}
 	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
@@ -1010,7 +1473,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 
 // newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
 func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
-	return newRPCTransaction(tx, common.Hash{}, 0, 0)
+	return newRPCTransaction(tx, common.Hash{}, 0, 0, nil)
 }
 
 // newRPCTransactionFromBlockIndex returns a transaction that will serialize to the RPC representation.
@@ -1019,7 +1482,7 @@ func newRPCTransactionFromBlockIndex(b *types.Block, index uint64) *RPCTransacti
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index)
+	return newRPCTransaction(txs[index], b.Hash(), b.NumberU64(), index, b.BaseFee())
 }
 
 // newRPCRawTransactionFromBlockIndex returns the bytes of a transaction given a block and a transaction index.
@@ -1028,7 +1491,7 @@ func newRPCRawTransactionFromBlockIndex(b *types.Block, index uint64) hexutil.By
 	if index >= uint64(len(txs)) {
 		return nil
 	}
-	blob, _ := rlp.EncodeToBytes(txs[index])
+	blob, _ := txs[index].MarshalBinary()
 	return blob
 }
 
@@ -1103,18 +1566,18 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByBlockHashAndIndex(ctx cont
 	return nil
 }
 
-// GetTransactionCount returns the number of transactions the given address has sent for the given block number
-func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Uint64, error) {
+// GetTransactionCount returns the number of transactions the given address has sent for the given block number or hash
+func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Uint64, error) {
 	// Ask transaction pool for the nonce which includes pending transactions
-	if blockNr == rpc.PendingBlockNumber {
+	if blockNr, ok := blockNrOrHash.Number(); ok && blockNr == rpc.PendingBlockNumber {
 		nonce, err := s.b.GetPoolNonce(ctx, address)
 		if err != nil {
 			return nil, err
 		}
 		return (*hexutil.Uint64)(&nonce), nil
 	}
-	// Resolve block number and use its state to ask for the nonce
-	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	// Resolve block number or hash and use its state to ask for the nonce
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -1126,7 +1589,11 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr
 func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) *RPCTransaction {
 	// Try to return an already finalized transaction
 	if tx, blockHash, blockNumber, index := rawdb.ReadTransaction(s.b.ChainDb(), hash); tx != nil {
-		return newRPCTransaction(tx, blockHash, blockNumber, index)
+		var baseFee *big.Int
+		if header := rawdb.ReadHeader(s.b.ChainDb().HeaderTable(), blockHash, blockNumber); header != nil {
+			baseFee = header.BaseFee
+		}
+		return newRPCTransaction(tx, blockHash, blockNumber, index, baseFee)
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
@@ -1147,8 +1614,8 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 			return nil, nil
 		}
 	}
-	// Serialize to RLP and return
-	return rlp.EncodeToBytes(tx)
+	// Serialize to binary and return
+	return tx.MarshalBinary()
 }
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
@@ -1166,16 +1633,54 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	}
 	receipt := receipts[index]
 
+	var baseFee *big.Int
+	if header := rawdb.ReadHeader(s.b.ChainDb().HeaderTable(), blockHash, blockNumber); header != nil {
+		baseFee = header.BaseFee
+	}
+	return marshalReceipt(receipt, tx, blockHash, blockNumber, index, baseFee), nil
+}
+
+// GetBlockReceipts returns the receipts of all transactions in the given block,
+// in the order of the transactions.
+func (s *PublicTransactionPoolAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	block, err := s.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return blockReceipts(ctx, s.b, block)
+}
+
+// blockReceipts returns the marshaled receipts of all transactions in block.
+func blockReceipts(ctx context.Context, b Backend, block *types.Block) ([]map[string]interface{}, error) {
+	receipts, err := b.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("receipts length mismatch: %d receipts for %d transactions", len(receipts), len(txs))
+	}
+	result := make([]map[string]interface{}, len(receipts))
+	for i, receipt := range receipts {
+		result[i] = marshalReceipt(receipt, txs[i], block.Hash(), block.NumberU64(), uint64(i), block.BaseFee())
+	}
+	return result, nil
+}
+
+// marshalReceipt converts the receipt of tx, at index of the given block, into
+// the RPC representation.
+func marshalReceipt(receipt *types.Receipt, tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) map[string]interface{} {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
+		"type":              hexutil.Uint(tx.Type()),
 		"blockHash":         blockHash,
 		"blockNumber":       hexutil.Uint64(blockNumber),
-		"transactionHash":   hash,
+		"transactionHash":   tx.Hash(),
 		"transactionIndex":  hexutil.Uint64(index),
 		"from":              from,
 		"to":                tx.To(),
@@ -1199,7 +1704,14 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	return fields, nil
+	// Report the gas price actually paid, which differs from the fee cap after EIP-1559
+	fields["effectiveGasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	if baseFee != nil {
+		if tip, err := tx.EffectiveGasTip(baseFee); err == nil {
+			fields["effectiveGasPrice"] = (*hexutil.Big)(tip.Add(tip, baseFee))
+		}
+	}
+	return fields
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -1227,6 +1739,16 @@ type SendTxArgs struct {
 	// newer name and should be preferred by clients.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+
+	// Typed transaction fields. An access list turns the transaction into an
+	// EIP-2930 one, signed for the given chain (the node's by default).
+	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+
+	// EIP-1559 fee caps. Setting either turns the transaction into a dynamic
+	// fee one and excludes gasPrice.
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
 }
 
 // setDefaults is a helper function that fills in default values for unspecified tx fields.
@@ -1235,8 +1757,34 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 		args.Gas = new(hexutil.Uint64)
 		*(*uint64)(args.Gas) = 90000
 	}
-	if args.GasPrice == nil {
-		price, err := b.SuggestPrice(ctx)
+	if args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil {
+		if args.GasPrice != nil {
+			return errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+		}
+		baseFee := nextBaseFee(ctx, b)
+		if baseFee == nil {
+			return errors.New("maxFeePerGas or maxPriorityFeePerGas specified but london is not active yet")
+		}
+		if args.MaxPriorityFeePerGas == nil {
+			tip, err := b.SuggestTipCap(ctx)
+			if err != nil {
+				return err
+			}
+			args.MaxPriorityFeePerGas = (*hexutil.Big)(tip)
+		}
+		if args.MaxFeePerGas == nil {
+			// Leave room for the base fee to double before the transaction is stuck
+			feeCap := new(big.Int).Add(args.MaxPriorityFeePerGas.ToInt(), new(big.Int).Mul(baseFee, big.NewInt(2)))
+			args.MaxFeePerGas = (*hexutil.Big)(feeCap)
+		}
+		if args.MaxFeePerGas.ToInt().Cmp(args.MaxPriorityFeePerGas.ToInt()) < 0 {
+			return fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", args.MaxFeePerGas, args.MaxPriorityFeePerGas)
+		}
+		if args.ChainID == nil {
+			args.ChainID = (*hexutil.Big)(b.ChainConfig().ChainId)
+		}
+	} else if args.GasPrice == nil {
+		price, err := suggestGasPrice(ctx, b)
 		if err != nil {
 			return err
 		}
@@ -1267,6 +1815,9 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 			return errors.New(`contract creation without any data provided`)
 		}
 	}
+	if args.AccessList != nil && args.ChainID == nil {
+		args.ChainID = (*hexutil.Big)(b.ChainConfig().ChainId)
+	}
 	return nil
 }
 
@@ -1277,6 +1828,16 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	} else if args.Input != nil {
 		input = *args.Input
 	}
+	if args.MaxFeePerGas != nil {
+		var al types.AccessList
+		if args.AccessList != nil {
+			al = *args.AccessList
+		}
+		return types.NewDynamicFeeTransaction((*big.Int)(args.ChainID), uint64(*args.Nonce), args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.MaxPriorityFeePerGas), (*big.Int)(args.MaxFeePerGas), input, al)
+	}
+	if args.AccessList != nil {
+		return types.NewAccessListTransaction((*big.Int)(args.ChainID), uint64(*args.Nonce), args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input, *args.AccessList)
+	}
 	if args.To == nil {
 		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 	}
@@ -1289,7 +1850,8 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		return common.Hash{}, err
 	}
 	if tx.To() == nil {
-		signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
+		head := b.CurrentBlock()
+		signer := types.MakeSigner(b.ChainConfig(), head.Number(), head.Time())
 		from, err := types.Sender(signer, tx)
 		if err != nil {
 			return common.Hash{}, err
@@ -1346,7 +1908,7 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	ctx, span := trace.StartSpan(ctx, "PublicTransactionPoolAPI.SendRawTransaction")
 	defer span.End()
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(encodedTx, tx); err != nil {
+	if err := tx.UnmarshalBinary(encodedTx); err != nil {
 		return common.Hash{}, err
 	}
 	return SubmitTransaction(ctx, s.b, tx)
@@ -1403,7 +1965,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if err != nil {
 		return nil, err
 	}
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1424,7 +1986,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 	for _, tx := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if tx.Protected() {
-			signer = types.NewEIP155Signer(tx.ChainId())
+			signer = types.LatestSignerForChainID(tx.ChainId())
 		}
 		from, _ := types.Sender(signer, tx)
 		if _, exists := accounts[from]; exists {
@@ -1449,7 +2011,7 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 	for _, p := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
 		if p.Protected() {
-			signer = types.NewEIP155Signer(p.ChainId())
+			signer = types.LatestSignerForChainID(p.ChainId())
 		}
 		wantSigHash := signer.Hash(matchTx)
 
@@ -0,0 +1,26 @@
+package ethapi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ChainAAS/gendchain/rpc"
+)
+
+var _ rpc.Error = new(PrunedHistoryError)
+
+// ErrBlockNotCanonical is returned when a block selected by hash with
+// requireCanonical set is not part of the canonical chain.
+var ErrBlockNotCanonical = errors.New("hash is not currently canonical")
+
+// PrunedHistoryError is returned when the body or receipts of a block have
+// been dropped from the local history window.
+type PrunedHistoryError struct {
+	Tail uint64 // oldest block with a body and receipts
+}
+
+func (e *PrunedHistoryError) ErrorCode() int { return 4444 }
+
+func (e *PrunedHistoryError) Error() string {
+	return fmt.Sprintf("pruned history unavailable: bodies and receipts before block %d are not retained", e.Tail)
+}
@@ -117,7 +117,7 @@ func New(ctx context.Context, sctx *node.ServiceContext, config *eth.Config) (*L
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
-		leth.blockchain.SetHead(compat.RewindTo)
+		leth.blockchain.SetHead(core.RewindHeight(leth.blockchain, compat))
 		rawdb.WriteChainConfig(chainDb.GlobalTable(), genesisHash, chainConfig)
 	}
 
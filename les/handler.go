@@ -17,6 +17,7 @@ import (
 	"github.com/ChainAAS/gendchain/core/state"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/eth/downloader"
+	"github.com/ChainAAS/gendchain/ethdb"
 	"github.com/ChainAAS/gendchain/light"
 	"github.com/ChainAAS/gendchain/log"
 	"github.com/ChainAAS/gendchain/p2p"
@@ -658,7 +659,11 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			// Retrieve the requested block's receipts, skipping if unknown to us
 			var results types.Receipts
 			if number := rawdb.ReadHeaderNumber(pm.chainDb.GlobalTable(), hash); number != nil {
-				results = rawdb.ReadRawReceipts(pm.chainDb.ReceiptTable(), hash, *number)
+				// Derive the receipts so typed ones are encoded as envelopes
+				var err error
+				if results, err = rawdb.ReadReceipts(pm.chainDb, hash, *number, pm.chainConfig); err == ethdb.ErrHistoryPruned {
+					continue
+				}
 			}
 			if results == nil {
 				if header := pm.blockchain.GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
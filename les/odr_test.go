@@ -49,7 +49,7 @@ func odrGetReceipts(ctx context.Context, db common.Database, config *params.Chai
 	var receipts types.Receipts
 	if bc != nil {
 		if number := rawdb.ReadHeaderNumber(db.GlobalTable(), bhash); number != nil {
-			receipts = rawdb.ReadReceipts(db, bhash, *number, config)
+			receipts, _ = rawdb.ReadReceipts(db, bhash, *number, config)
 		}
 	} else {
 		if number := rawdb.ReadHeaderNumber(db.GlobalTable(), bhash); number != nil {
@@ -117,7 +117,7 @@ func odrContractCall(ctx context.Context, db common.Database, config *params.Cha
 				from := statedb.GetOrNewStateObject(testBankAddress)
 				from.SetBalance(math.MaxBig256)
 
-				msg := callmsg{types.NewMessage(from.Address(), &testContractAddr, 0, new(big.Int), 100000, new(big.Int), data, false)}
+				msg := callmsg{types.NewMessage(from.Address(), &testContractAddr, 0, new(big.Int), 100000, new(big.Int), new(big.Int), new(big.Int), data, nil, false)}
 
 				context := core.NewEVMContext(&msg, header, bc, nil)
 				vmenv := vm.NewEVM(context, statedb, config, vm.Config{})
@@ -131,7 +131,7 @@ func odrContractCall(ctx context.Context, db common.Database, config *params.Cha
 			header := lc.GetHeaderByHash(bhash)
 			state := light.NewState(ctx, header, lc.Odr())
 			state.SetBalance(testBankAddress, math.MaxBig256)
-			msg := callmsg{types.NewMessage(testBankAddress, &testContractAddr, 0, new(big.Int), 100000, new(big.Int), data, false)}
+			msg := callmsg{types.NewMessage(testBankAddress, &testContractAddr, 0, new(big.Int), 100000, new(big.Int), new(big.Int), new(big.Int), data, nil, false)}
 			context := core.NewEVMContext(&msg, header, lc, nil)
 			vmenv := vm.NewEVM(context, state, config, vm.Config{})
 			gp := new(core.GasPool).AddGas(math.MaxUint64)
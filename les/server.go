@@ -109,6 +109,15 @@ func (s *LesServer) Start(srvr *p2p.Server) {
 	s.protocolManager.blockLoop()
 }
 
+// historyTail returns the oldest block whose body and receipts are served,
+// advertised to clients as serveChainSince.
+func (s *LesServer) historyTail() uint64 {
+	if bc, ok := s.protocolManager.blockchain.(interface{ HistoryTail() uint64 }); ok {
+		return bc.HistoryTail()
+	}
+	return 0
+}
+
 func (s *LesServer) SetBloomBitsIndexer(bloomIndexer *core.ChainIndexer) {
 	bloomIndexer.AddChildIndexer(s.bloomTrieIndexer)
 }
@@ -382,6 +382,16 @@ func (self *LightChain) CurrentHeader() *types.Header {
 	return self.hc.CurrentHeader()
 }
 
+// FinalizedNumber returns the number of the latest canonical block the consensus
+// engine considers final, or zero if the engine has no deterministic finality.
+func (self *LightChain) FinalizedNumber() (uint64, error) {
+	engine, ok := self.engine.(consensus.FinalityReader)
+	if !ok {
+		return 0, nil
+	}
+	return engine.FinalizedNumber(self.hc, self.hc.CurrentHeader())
+}
+
 // GetTd retrieves a block's total difficulty in the canonical chain from the
 // database by hash and number, caching it if found.
 func (self *LightChain) GetTd(hash common.Hash, number uint64) *big.Int {
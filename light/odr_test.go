@@ -101,7 +101,7 @@ func odrGetReceipts(ctx context.Context, db common.Database, bc *core.BlockChain
 	if bc != nil {
 		number := rawdb.ReadHeaderNumber(db.GlobalTable(), bhash)
 		if number != nil {
-			receipts = rawdb.ReadReceipts(db, bhash, *number, bc.Config())
+			receipts, _ = rawdb.ReadReceipts(db, bhash, *number, bc.Config())
 		}
 	} else {
 		number := rawdb.ReadHeaderNumber(db.GlobalTable(), bhash)
@@ -173,7 +173,7 @@ func odrContractCall(ctx context.Context, db common.Database, bc *core.BlockChai
 
 		// Perform read-only call.
 		st.SetBalance(testBankAddress, math.MaxBig256)
-		msg := callmsg{types.NewMessage(testBankAddress, &testContractAddr, 0, new(big.Int), 1000000, new(big.Int), data, false)}
+		msg := callmsg{types.NewMessage(testBankAddress, &testContractAddr, 0, new(big.Int), 1000000, new(big.Int), new(big.Int), new(big.Int), data, nil, false)}
 		context := core.NewEVMContext(&msg, header, chain, nil)
 		vmenv := vm.NewEVM(context, st, config, vm.Config{})
 		gp := new(core.GasPool).AddGas(math.MaxUint64)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/core/state"
+	"github.com/ChainAAS/gendchain/core/state/snapshot"
 	"github.com/ChainAAS/gendchain/core/types"
 	"github.com/ChainAAS/gendchain/crypto"
 	"github.com/ChainAAS/gendchain/trie"
@@ -73,6 +74,10 @@ func (db *odrDatabase) TrieDB() *trie.Database {
 	return nil
 }
 
+func (db *odrDatabase) Snapshots() *snapshot.Tree {
+	return nil
+}
+
 type odrTrie struct {
 	db   *odrDatabase
 	id   *TrieID
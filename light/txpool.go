@@ -3,6 +3,7 @@ package light
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -51,6 +52,9 @@ type TxPool struct {
 	clearIdx uint64                               // earliest block nr that can contain mined tx info
 
 	homestead bool
+	istanbul  bool // Fork indicator whether we are in the istanbul stage
+	eip2718   bool // Fork indicator whether we are using EIP-2718 type transactions
+	eip1559   bool // Fork indicator whether we are using EIP-1559 type transactions
 }
 
 // TxRelayBackend provides an interface to the mechanism that forwards transacions
@@ -72,7 +76,7 @@ type TxRelayBackend interface {
 func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBackend) *TxPool {
 	pool := &TxPool{
 		config:      config,
-		signer:      types.NewEIP155Signer(config.ChainId),
+		signer:      types.LatestSignerForChainID(config.ChainId),
 		nonce:       make(map[common.Address]uint64),
 		pending:     make(map[common.Hash]*types.Transaction),
 		mined:       make(map[common.Hash][]*types.Transaction),
@@ -284,7 +288,11 @@ func (pool *TxPool) setNewHead(head *types.Header) {
 	m, r := txc.getLists()
 	pool.relay.NewHead(pool.head, m, r)
 	pool.homestead = pool.config.IsHomestead(head.Number)
-	pool.signer = types.MakeSigner(pool.config, head.Number)
+	next := new(big.Int).Add(head.Number, big.NewInt(1))
+	pool.istanbul = pool.config.IsIstanbul(next, head.Time)
+	pool.eip2718 = pool.config.IsBerlin(next, head.Time)
+	pool.eip1559 = pool.config.IsLondon(next, head.Time)
+	pool.signer = types.MakeSigner(pool.config, next, head.Time)
 }
 
 // Stop stops the light transaction pool
@@ -325,6 +333,18 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 		err  error
 	)
 
+	// Accept only legacy transactions until EIP-2718/2930 activates
+	if !pool.eip2718 && tx.Type() != types.LegacyTxType {
+		return core.ErrTxTypeNotSupported
+	}
+	// Reject dynamic fee transactions until EIP-1559 activates
+	if !pool.eip1559 && tx.Type() == types.DynamicFeeTxType {
+		return core.ErrTxTypeNotSupported
+	}
+	// Ensure the tip never exceeds the fee cap
+	if tx.GasTipCap().Cmp(tx.GasFeeCap()) > 0 {
+		return core.ErrTipAboveFeeCap
+	}
 	// Validate the transaction sender and it's sig. Throw
 	// if the from fields is invalid.
 	if from, err = types.Sender(pool.signer, tx); err != nil {
@@ -357,7 +377,7 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	}
 
 	// Should supply enough intrinsic gas
-	gas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	gas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.homestead, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/ChainAAS/gendchain/common"
 	"github.com/ChainAAS/gendchain/consensus"
 	"github.com/ChainAAS/gendchain/consensus/clique"
+	"github.com/ChainAAS/gendchain/consensus/misc"
 	"github.com/ChainAAS/gendchain/core"
 	"github.com/ChainAAS/gendchain/core/state"
 	"github.com/ChainAAS/gendchain/core/types"
@@ -602,7 +603,7 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		return err
 	}
 	env := &environment{
-		signer: types.NewEIP155Signer(w.config.ChainId),
+		signer: types.MakeSigner(w.config, header.Number, header.Time),
 		state:  state,
 		header: header,
 	}
@@ -745,6 +746,13 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			}
 			txs.Pop()
 
+		case core.ErrFeeCapTooLow:
+			// The fee cap doesn't cover the base fee of this block, skip account
+			if tracing {
+				log.Trace("Skipping account with fee cap below base fee", "sender", from, "feecap", tx.GasFeeCap())
+			}
+			txs.Pop()
+
 		case nil:
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
@@ -823,6 +831,11 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		log.Error("Failed to prepare header for mining", "err", err)
 		return
 	}
+	// Set the base fee of the block if EIP-1559 is active, which may depend
+	// on the timestamp chosen by the engine
+	if w.config.IsLondon(header.Number, header.Time) {
+		header.BaseFee = misc.CalcBaseFee(w.config, parent.Header())
+	}
 
 	// Could potentially happen if starting to mine in an odd state.
 	err := w.makeCurrent(parent, header)
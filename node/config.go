@@ -150,6 +150,11 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCAuth, if set, requires HTTP and websocket connections to authenticate as
+	// one of the configured clients, which restricts the methods they may call
+	// and how often. IPC and in-process connections are not affected.
+	RPCAuth *rpc.AuthConfig `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
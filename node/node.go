@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -38,8 +39,9 @@ type Node struct {
 	serviceFuncs []ServiceConstructor     // Service constructors (in dependency order)
 	services     map[reflect.Type]Service // Currently running services
 
-	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
-	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	rpcAPIs       []rpc.API     // List of APIs currently provided by the node
+	httpHandlers  []HTTPHandler // List of HTTP handlers provided by the services
+	inprocHandler *rpc.Server   // In-process RPC request handler to process the API requests
 
 	ipcEndpoint string       // IPC endpoint to listen at (empty = IPC disabled)
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
@@ -243,8 +245,12 @@ func (n *Node) openDataDir() error {
 func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	// Gather all the possible APIs to surface
 	apis := n.apis()
+	n.httpHandlers = nil
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
+		if service, ok := service.(HTTPService); ok {
+			n.httpHandlers = append(n.httpHandlers, service.HTTPHandlers()...)
+		}
 	}
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
@@ -339,7 +345,22 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if err != nil {
 		return err
 	}
-	handler := NewHTTPHandlerStack(srv, cors, vhosts, tracing)
+	if n.config.RPCAuth != nil {
+		if err := srv.SetAuth(n.config.RPCAuth); err != nil {
+			return err
+		}
+	}
+	var handler http.Handler = srv
+	if len(n.httpHandlers) > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/", srv)
+		for _, h := range n.httpHandlers {
+			mux.Handle(h.Path, srv.AuthHandler(h.Namespace, h.Handler))
+			n.log.Debug("HTTP handler registered", "path", h.Path, "namespace", h.Namespace)
+		}
+		handler = mux
+	}
+	handler = NewHTTPHandlerStack(handler, cors, vhosts, tracing)
 	// wrap handler in websocket handler only if websocket port is the same as http rpc
 	if n.httpEndpoint == n.wsEndpoint {
 		handler = NewWebsocketUpgradeHandler(handler, srv.WebsocketHandler(wsOrigins))
@@ -391,6 +412,11 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if err != nil {
 		return err
 	}
+	if n.config.RPCAuth != nil {
+		if err := srv.SetAuth(n.config.RPCAuth); err != nil {
+			return err
+		}
+	}
 	listener, err := startWSEndpoint(endpoint, handler)
 	if err != nil {
 		return err
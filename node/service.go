@@ -2,6 +2,7 @@
 package node
 
 import (
+	"net/http"
 	"reflect"
 
 	"github.com/ChainAAS/gendchain/accounts"
@@ -88,3 +89,17 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPService is implemented by services which serve HTTP handlers on the node's
+// HTTP endpoint, next to JSON-RPC.
+type HTTPService interface {
+	// HTTPHandlers retrieves the HTTP handlers the service provides.
+	HTTPHandlers() []HTTPHandler
+}
+
+// HTTPHandler describes an HTTP handler served on the node's HTTP endpoint.
+type HTTPHandler struct {
+	Path      string // Path of the handler, e.g. "/graphql"
+	Namespace string // Namespace checked against the allow-lists of authenticated RPC clients
+	Handler   http.Handler
+}
@@ -64,13 +64,14 @@ var (
 	// adding flags to the config to also have to set these fields.
 	AllCliqueProtocolChanges = &ChainConfig{ChainId: big.NewInt(1337), HomesteadBlock: big.NewInt(0),
 		EIP150Block: big.NewInt(0), EIP155Block: big.NewInt(0), EIP158Block: big.NewInt(0),
-		ByzantiumBlock: big.NewInt(0), ConstantinopleBlock: big.NewInt(0), Clique: DefaultCliqueConfig()}
+		ByzantiumBlock: big.NewInt(0), ConstantinopleBlock: big.NewInt(0), IstanbulBlock: big.NewInt(0),
+		BerlinBlock: big.NewInt(0), Clique: DefaultCliqueConfig()}
 
 	TestChainConfig = &ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: big.NewInt(0),
 		EIP150Block: big.NewInt(0), EIP155Block: big.NewInt(0), EIP158Block: big.NewInt(0),
 		ByzantiumBlock: big.NewInt(0), Clique: DefaultCliqueConfig(),
 	}
-	TestRules = TestChainConfig.Rules(new(big.Int))
+	TestRules = TestChainConfig.Rules(new(big.Int), new(big.Int))
 )
 
 // ChainConfig is the core config which determines the blockchain settings.
@@ -97,8 +98,22 @@ type ChainConfig struct {
 	DarvazaDefaultGas   *big.Int       `json:"darvazaDefaultGas,omitempty"`   // Darvaza default gas value (nil = no change)
 	HafthorBlock        *big.Int       `json:"hafthorBlock,omitempty"`        // Hafthor switch block (nil = no fork, 0 = already activated)
 	HafthorStakeAddress common.Address `json:"hafthorStakeAddress"`           // Hafthor stake address to send rewards
+	IstanbulBlock       *big.Int       `json:"istanbulBlock,omitempty"`       // Istanbul switch block (nil = no fork, 0 = already activated)
+	BerlinBlock         *big.Int       `json:"berlinBlock,omitempty"`         // Berlin switch block (nil = no fork, 0 = already activated)
+	LondonBlock         *big.Int       `json:"londonBlock,omitempty"`         // London switch block (nil = no fork, 0 = already activated)
+	PragueBlock         *big.Int       `json:"pragueBlock,omitempty"`         // Prague switch block (nil = no fork, 0 = already activated)
 	EWASMBlock          *big.Int       `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
+	// Forks from Istanbul on may alternatively be scheduled by block timestamp,
+	// since clique block times vary too much to agree on a block number far in
+	// advance. A fork is active once either its block or its time is reached.
+	IstanbulTime *big.Int `json:"istanbulTime,omitempty"` // Istanbul switch time (nil = no fork, 0 = already activated)
+	BerlinTime   *big.Int `json:"berlinTime,omitempty"`   // Berlin switch time (nil = no fork, 0 = already activated)
+	LondonTime   *big.Int `json:"londonTime,omitempty"`   // London switch time (nil = no fork, 0 = already activated)
+	PragueTime   *big.Int `json:"pragueTime,omitempty"`   // Prague switch time (nil = no fork, 0 = already activated)
+
+	RewardSchedules []*RewardSchedule `json:"rewardSchedules,omitempty"` // Block reward policies, each active from its block until the next one
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
@@ -116,6 +131,12 @@ func (c *EthashConfig) String() string {
 type CliqueConfig struct {
 	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
 	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+
+	EvictionBlock     *big.Int `json:"evictionBlock,omitempty"`     // Inactive signer eviction switch block (nil = no fork, 0 = already activated)
+	EvictionThreshold uint64   `json:"evictionThreshold,omitempty"` // Consecutive missed in-turn slots before a signer is proposed for removal (0 = disabled)
+
+	GovernanceBlock    *big.Int       `json:"governanceBlock,omitempty"`    // Contract governance switch block (nil = no fork, 0 = already activated)
+	GovernanceContract common.Address `json:"governanceContract,omitempty"` // Contract holding the authorized signer and voter lists under governance
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -123,6 +144,19 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// IsEviction returns whether num is either equal to the inactive signer eviction
+// fork block or greater, and a non-zero threshold is configured.
+func (c *CliqueConfig) IsEviction(num *big.Int) bool {
+	return c.EvictionThreshold > 0 && isForked(c.EvictionBlock, num)
+}
+
+// IsGovernance returns whether num is either equal to the contract governance
+// fork block or greater. Signers and voters are then read from the governance
+// contract at each checkpoint, and votes in header extra-data are ignored.
+func (c *CliqueConfig) IsGovernance(num *big.Int) bool {
+	return isForked(c.GovernanceBlock, num)
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -135,7 +169,8 @@ func (c *ChainConfig) String() string {
 		engine = "unknown"
 	}
 	return fmt.Sprintf("{ChainID: %v Homestead: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople:"+
-		" %v ConstantinopleFix: %v Darvaza: %v Hafthor: %v EWASM: %v Engine: %v}",
+		" %v ConstantinopleFix: %v Darvaza: %v Hafthor: %v Istanbul: %v Berlin: %v London: %v Prague: %v EWASM: %v"+
+		" IstanbulTime: %v BerlinTime: %v LondonTime: %v PragueTime: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.EIP150Block,
@@ -146,7 +181,15 @@ func (c *ChainConfig) String() string {
 		c.PetersburgBlock,
 		c.DarvazaBlock,
 		c.HafthorBlock,
+		c.IstanbulBlock,
+		c.BerlinBlock,
+		c.LondonBlock,
+		c.PragueBlock,
 		c.EWASMBlock,
+		c.IstanbulTime,
+		c.BerlinTime,
+		c.LondonTime,
+		c.PragueTime,
 		engine,
 	)
 }
@@ -198,19 +241,99 @@ func (c *ChainConfig) IsHafthor(num *big.Int) bool {
 	return isForked(c.HafthorBlock, num)
 }
 
+// IsIstanbul returns whether num is either equal to the Istanbul fork block or greater,
+// or time is either equal to the Istanbul fork time or later.
+func (c *ChainConfig) IsIstanbul(num, time *big.Int) bool {
+	return isForked(c.IstanbulBlock, num) || isForked(c.IstanbulTime, time)
+}
+
+// IsBerlin returns whether num is either equal to the Berlin fork block or greater,
+// or time is either equal to the Berlin fork time or later.
+func (c *ChainConfig) IsBerlin(num, time *big.Int) bool {
+	return isForked(c.BerlinBlock, num) || isForked(c.BerlinTime, time)
+}
+
+// IsLondon returns whether num is either equal to the London fork block or greater,
+// or time is either equal to the London fork time or later.
+func (c *ChainConfig) IsLondon(num, time *big.Int) bool {
+	return isForked(c.LondonBlock, num) || isForked(c.LondonTime, time)
+}
+
+// InitialBaseFee returns the base fee of the London fork block. It continues
+// from the Darvaza default gas price where one is configured, so the fee level
+// doesn't jump at the fork.
+func (c *ChainConfig) InitialBaseFee() *big.Int {
+	if c.DarvazaDefaultGas != nil && c.DarvazaBlock != nil && (c.LondonBlock == nil || c.DarvazaBlock.Cmp(c.LondonBlock) <= 0) {
+		return new(big.Int).Set(c.DarvazaDefaultGas)
+	}
+	return new(big.Int).SetUint64(InitialBaseFee)
+}
+
+// IsPrague returns whether num is either equal to the Prague fork block or greater,
+// or time is either equal to the Prague fork time or later.
+func (c *ChainConfig) IsPrague(num, time *big.Int) bool {
+	return isForked(c.PragueBlock, num) || isForked(c.PragueTime, time)
+}
+
 // IsEWASM returns whether num represents a block number after the EWASM fork
 func (c *ChainConfig) IsEWASM(num *big.Int) bool {
 	return isForked(c.EWASMBlock, num)
 }
 
+// Fork is a protocol change scheduled by a chain configuration, either at a
+// block number or at a block timestamp.
+type Fork struct {
+	Name  string
+	Block *big.Int // Activation block (nil = not scheduled by block)
+	Time  *big.Int // Activation time (nil = not scheduled by time)
+}
+
+// Forks returns the protocol changes of the configuration in activation order,
+// leaving out those which are not scheduled at all.
+func (c *ChainConfig) Forks() []Fork {
+	all := []Fork{
+		{Name: "Homestead", Block: c.HomesteadBlock},
+		{Name: "EIP150", Block: c.EIP150Block},
+		{Name: "EIP155", Block: c.EIP155Block},
+		{Name: "EIP158", Block: c.EIP158Block},
+		{Name: "Byzantium", Block: c.ByzantiumBlock},
+		{Name: "Constantinople", Block: c.ConstantinopleBlock},
+		{Name: "ConstantinopleFix", Block: c.PetersburgBlock},
+		{Name: "Darvaza", Block: c.DarvazaBlock},
+		{Name: "Hafthor", Block: c.HafthorBlock},
+		{Name: "Istanbul", Block: c.IstanbulBlock, Time: c.IstanbulTime},
+		{Name: "Berlin", Block: c.BerlinBlock, Time: c.BerlinTime},
+		{Name: "London", Block: c.LondonBlock, Time: c.LondonTime},
+		{Name: "Prague", Block: c.PragueBlock, Time: c.PragueTime},
+		{Name: "EWASM", Block: c.EWASMBlock},
+	}
+	if c.Clique != nil {
+		if c.Clique.EvictionThreshold > 0 {
+			all = append(all, Fork{Name: "CliqueEviction", Block: c.Clique.EvictionBlock})
+		}
+		all = append(all, Fork{Name: "CliqueGovernance", Block: c.Clique.GovernanceBlock})
+	}
+	var forks []Fork
+	for _, fork := range all {
+		if fork.Block != nil || fork.Time != nil {
+			forks = append(forks, fork)
+		}
+	}
+	return forks
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
-func (c *ChainConfig) GasTable(num *big.Int) GasTable {
+func (c *ChainConfig) GasTable(num, time *big.Int) GasTable {
 	if num == nil {
 		return GasTableHomestead
 	}
 	switch {
+	case c.IsBerlin(num, time):
+		return GasTableBerlin
+	case c.IsIstanbul(num, time):
+		return GasTableIstanbul
 	case c.IsConstantinople(num):
 		return GasTableConstantinople
 	case c.IsEIP158(num):
@@ -223,24 +346,30 @@ func (c *ChainConfig) GasTable(num *big.Int) GasTable {
 }
 
 // CheckCompatible checks whether scheduled fork transitions have been imported
-// with a mismatching chain configuration.
-func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
+// with a mismatching chain configuration. The height and time are those of the
+// current head block.
+func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height, time uint64) *ConfigCompatError {
 	bhead := new(big.Int).SetUint64(height)
+	btime := new(big.Int).SetUint64(time)
 
 	// Iterate checkCompatible to find the lowest conflict.
 	var lasterr *ConfigCompatError
 	for {
-		err := c.checkCompatible(newcfg, bhead)
-		if err == nil || (lasterr != nil && err.RewindTo == lasterr.RewindTo) {
+		err := c.checkCompatible(newcfg, bhead, btime)
+		if err == nil || (lasterr != nil && err.RewindTo == lasterr.RewindTo && err.RewindToTime == lasterr.RewindToTime) {
 			break
 		}
 		lasterr = err
-		bhead.SetUint64(err.RewindTo)
+		if err.RewindToTime > 0 {
+			btime.SetUint64(err.RewindToTime)
+		} else {
+			bhead.SetUint64(err.RewindTo)
+		}
 	}
 	return lasterr
 }
 
-func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head, headTime *big.Int) *ConfigCompatError {
 	if isForkIncompatible(c.HomesteadBlock, newcfg.HomesteadBlock, head) {
 		return newCompatError("Homestead fork block", c.HomesteadBlock, newcfg.HomesteadBlock)
 	}
@@ -268,19 +397,48 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.DarvazaBlock, newcfg.DarvazaBlock, head) {
 		return newCompatError("Darvaza fork block", c.DarvazaBlock, newcfg.DarvazaBlock)
 	}
+	if isForkIncompatible(c.IstanbulBlock, newcfg.IstanbulBlock, head) {
+		return newCompatError("Istanbul fork block", c.IstanbulBlock, newcfg.IstanbulBlock)
+	}
+	if isForkIncompatible(c.BerlinBlock, newcfg.BerlinBlock, head) {
+		return newCompatError("Berlin fork block", c.BerlinBlock, newcfg.BerlinBlock)
+	}
+	if isForkIncompatible(c.LondonBlock, newcfg.LondonBlock, head) {
+		return newCompatError("London fork block", c.LondonBlock, newcfg.LondonBlock)
+	}
+	if isForkIncompatible(c.PragueBlock, newcfg.PragueBlock, head) {
+		return newCompatError("Prague fork block", c.PragueBlock, newcfg.PragueBlock)
+	}
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.IstanbulTime, newcfg.IstanbulTime, headTime) {
+		return newTimestampCompatError("Istanbul fork time", c.IstanbulTime, newcfg.IstanbulTime)
+	}
+	if isForkIncompatible(c.BerlinTime, newcfg.BerlinTime, headTime) {
+		return newTimestampCompatError("Berlin fork time", c.BerlinTime, newcfg.BerlinTime)
+	}
+	if isForkIncompatible(c.LondonTime, newcfg.LondonTime, headTime) {
+		return newTimestampCompatError("London fork time", c.LondonTime, newcfg.LondonTime)
+	}
+	if isForkIncompatible(c.PragueTime, newcfg.PragueTime, headTime) {
+		return newTimestampCompatError("Prague fork time", c.PragueTime, newcfg.PragueTime)
+	}
+	if err := c.checkRewardSchedules(newcfg, head); err != nil {
+		return err
+	}
 	return nil
 }
 
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
-// block s2 because head is already past the fork.
+// s2 because head is already past the fork. It applies to block numbers and
+// timestamps alike.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
 	return (isForked(s1, head) || isForked(s2, head)) && !configNumEqual(s1, s2)
 }
 
-// isForked returns whether a fork scheduled at block s is active at the given head block.
+// isForked returns whether a fork scheduled at block (or time) s is active at the
+// given head block (or time).
 func isForked(s, head *big.Int) bool {
 	if s == nil || head == nil {
 		return false
@@ -302,10 +460,13 @@ func configNumEqual(x, y *big.Int) bool {
 // ChainConfig that would alter the past.
 type ConfigCompatError struct {
 	What string
-	// block numbers of the stored and new configurations
+	// block numbers (or timestamps) of the stored and new configurations
 	StoredConfig, NewConfig *big.Int
 	// the block number to which the local chain must be rewound to correct the error
 	RewindTo uint64
+	// the timestamp to which the local chain must be rewound to correct the error,
+	// set instead of RewindTo for forks scheduled by time
+	RewindToTime uint64
 }
 
 func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatError {
@@ -318,14 +479,23 @@ func newCompatError(what string, storedblock, newblock *big.Int) *ConfigCompatEr
 	default:
 		rew = newblock
 	}
-	err := &ConfigCompatError{what, storedblock, newblock, 0}
+	err := &ConfigCompatError{What: what, StoredConfig: storedblock, NewConfig: newblock}
 	if rew != nil && rew.Sign() > 0 {
 		err.RewindTo = rew.Uint64() - 1
 	}
 	return err
 }
 
+func newTimestampCompatError(what string, storedtime, newtime *big.Int) *ConfigCompatError {
+	err := newCompatError(what, storedtime, newtime)
+	err.RewindTo, err.RewindToTime = 0, err.RewindTo
+	return err
+}
+
 func (err *ConfigCompatError) Error() string {
+	if err.RewindToTime > 0 {
+		return fmt.Sprintf("mismatching %s in database (have timestamp %d, want timestamp %d, rewindto timestamp %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindToTime)
+	}
 	return fmt.Sprintf("mismatching %s in database (have %d, want %d, rewindto %d)", err.What, err.StoredConfig, err.NewConfig, err.RewindTo)
 }
 
@@ -338,12 +508,13 @@ type Rules struct {
 	ChainId                                     *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158   bool
 	IsByzantium, IsConstantinople, IsPetersburg bool
-	IsDarvaza, IsHafthor                        bool
-	IsEWASM                                     bool
+	IsDarvaza, IsHafthor, IsIstanbul, IsBerlin  bool
+	IsLondon, IsPrague, IsEWASM                 bool
 }
 
-// Rules ensures c's ChainID is not nil.
-func (c *ChainConfig) Rules(num *big.Int) Rules {
+// Rules ensures c's ChainID is not nil. The time is that of the block, and
+// only matters for forks scheduled by timestamp.
+func (c *ChainConfig) Rules(num, time *big.Int) Rules {
 	chainId := c.ChainId
 	if chainId == nil {
 		chainId = new(big.Int)
@@ -359,6 +530,10 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsPetersburg:     c.IsPetersburg(num),
 		IsDarvaza:        c.IsDarvaza(num),
 		IsHafthor:        c.IsHafthor(num),
+		IsIstanbul:       c.IsIstanbul(num, time),
+		IsBerlin:         c.IsBerlin(num, time),
+		IsLondon:         c.IsLondon(num, time),
+		IsPrague:         c.IsPrague(num, time),
 		IsEWASM:          c.IsEWASM(num),
 	}
 }
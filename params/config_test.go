@@ -4,12 +4,15 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
 	type test struct {
 		stored, new *ChainConfig
 		head        uint64
+		headTime    uint64
 		wantErr     *ConfigCompatError
 	}
 	tests := []test{
@@ -54,12 +57,154 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{RewardSchedules: []*RewardSchedule{{Block: big.NewInt(10), Reward: big.NewInt(1)}}},
+			new:     &ChainConfig{RewardSchedules: []*RewardSchedule{{Block: big.NewInt(10), Reward: big.NewInt(2)}}},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{RewardSchedules: []*RewardSchedule{{Block: big.NewInt(10), Reward: big.NewInt(1)}}},
+			new:    &ChainConfig{RewardSchedules: []*RewardSchedule{{Block: big.NewInt(10), Reward: big.NewInt(2)}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "reward schedule",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:   &ChainConfig{LondonTime: big.NewInt(1000)},
+			new:      &ChainConfig{LondonTime: big.NewInt(2000)},
+			head:     50,
+			headTime: 999,
+			wantErr:  nil,
+		},
+		{
+			stored:   &ChainConfig{LondonTime: big.NewInt(1000)},
+			new:      &ChainConfig{LondonTime: big.NewInt(2000)},
+			head:     50,
+			headTime: 1500,
+			wantErr: &ConfigCompatError{
+				What:         "London fork time",
+				StoredConfig: big.NewInt(1000),
+				NewConfig:    big.NewInt(2000),
+				RewindToTime: 999,
+			},
+		},
+		{
+			stored:   &ChainConfig{LondonBlock: big.NewInt(30), PragueTime: big.NewInt(1000)},
+			new:      &ChainConfig{LondonBlock: big.NewInt(40), PragueTime: big.NewInt(1000)},
+			head:     50,
+			headTime: 1500,
+			wantErr: &ConfigCompatError{
+				What:         "London fork block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(40),
+				RewindTo:     29,
+			},
+		},
 	}
 
 	for _, test := range tests {
-		err := test.stored.CheckCompatible(test.new, test.head)
+		err := test.stored.CheckCompatible(test.new, test.head, test.headTime)
 		if !reflect.DeepEqual(err, test.wantErr) {
 			t.Errorf("error mismatch:\nstored: %v\nnew: %v\nhead: %v\nerr: %v\nwant: %v", test.stored, test.new, test.head, err, test.wantErr)
 		}
 	}
 }
+
+func TestTimestampForks(t *testing.T) {
+	config := &ChainConfig{
+		BerlinBlock: big.NewInt(10),
+		LondonTime:  big.NewInt(1000),
+		PragueBlock: big.NewInt(100),
+		PragueTime:  big.NewInt(2000),
+	}
+	for _, test := range []struct {
+		number, time           int64
+		berlin, london, prague bool
+	}{
+		{number: 9, time: 999},
+		{number: 10, time: 999, berlin: true},
+		{number: 10, time: 1000, berlin: true, london: true},
+		{number: 99, time: 2000, berlin: true, london: true, prague: true},
+		{number: 100, time: 1500, berlin: true, london: true, prague: true},
+	} {
+		rules := config.Rules(big.NewInt(test.number), big.NewInt(test.time))
+		if rules.IsBerlin != test.berlin || rules.IsLondon != test.london || rules.IsPrague != test.prague {
+			t.Errorf("block %d at %d: rules mismatch: have berlin %v london %v prague %v, want %v %v %v", test.number, test.time,
+				rules.IsBerlin, rules.IsLondon, rules.IsPrague, test.berlin, test.london, test.prague)
+		}
+	}
+	var names []string
+	for _, fork := range config.Forks() {
+		names = append(names, fork.Name)
+	}
+	if want := []string{"Berlin", "London", "Prague"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("fork list mismatch: have %v, want %v", names, want)
+	}
+}
+
+func TestRewardSchedule(t *testing.T) {
+	var (
+		treasury = common.HexToAddress("0x01")
+		stakers  = common.HexToAddress("0x02")
+	)
+	config := &ChainConfig{RewardSchedules: []*RewardSchedule{
+		{Block: big.NewInt(100), Reward: big.NewInt(1000), DecayPeriod: 10, DecayPercent: 10,
+			Beneficiaries: []RewardShare{{Address: treasury, Percent: 20}, {Address: stakers, Percent: 30}}},
+		{Block: big.NewInt(10), Reward: big.NewInt(500)},
+	}}
+	if err := config.CheckRewardSchedules(); err != nil {
+		t.Fatalf("valid schedules rejected: %v", err)
+	}
+	for _, test := range []struct {
+		number      int64
+		reward      int64
+		signer      int64
+		shares      []int64
+		noSchedule  bool
+		schedulePos int
+	}{
+		{number: 9, noSchedule: true},
+		{number: 10, reward: 500, signer: 500, schedulePos: 1},
+		{number: 99, reward: 500, signer: 500, schedulePos: 1},
+		{number: 100, reward: 1000, signer: 500, shares: []int64{200, 300}},
+		{number: 109, reward: 1000, signer: 500, shares: []int64{200, 300}},
+		{number: 110, reward: 900, signer: 450, shares: []int64{180, 270}},
+		{number: 125, reward: 810, signer: 405, shares: []int64{162, 243}},
+	} {
+		num := big.NewInt(test.number)
+		schedule := config.RewardSchedule(num)
+		if test.noSchedule {
+			if schedule != nil {
+				t.Errorf("block %d: unexpected schedule %v", test.number, schedule)
+			}
+			continue
+		}
+		if schedule != config.RewardSchedules[test.schedulePos] {
+			t.Errorf("block %d: wrong schedule %v", test.number, schedule)
+			continue
+		}
+		reward := schedule.BlockReward(num)
+		if reward.Int64() != test.reward {
+			t.Errorf("block %d: reward mismatch: have %v, want %d", test.number, reward, test.reward)
+		}
+		shares, signer := schedule.Split(reward)
+		if signer.Int64() != test.signer {
+			t.Errorf("block %d: signer reward mismatch: have %v, want %d", test.number, signer, test.signer)
+		}
+		for i, share := range shares {
+			if share.Int64() != test.shares[i] {
+				t.Errorf("block %d: share %d mismatch: have %v, want %d", test.number, i, share, test.shares[i])
+			}
+		}
+	}
+	// Overcommitted shares must be rejected
+	config.RewardSchedules[0].Beneficiaries[0].Percent = 80
+	if err := config.CheckRewardSchedules(); err == nil {
+		t.Errorf("overcommitted schedule accepted")
+	}
+}
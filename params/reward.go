@@ -0,0 +1,149 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ChainAAS/gendchain/common"
+)
+
+// RewardSchedule is a block reward policy that takes effect at a given block and
+// stays active until the next schedule in ChainConfig.RewardSchedules.
+type RewardSchedule struct {
+	Block         *big.Int      `json:"block"`                   // Block the schedule activates at
+	Reward        *big.Int      `json:"reward"`                  // Block reward in wei at activation
+	DecayPeriod   uint64        `json:"decayPeriod,omitempty"`   // Number of blocks between reward reductions (0 = no decay)
+	DecayPercent  uint64        `json:"decayPercent,omitempty"`  // Percentage the reward is reduced by every decay period
+	Beneficiaries []RewardShare `json:"beneficiaries,omitempty"` // Fixed reward recipients, the signer receives the remainder
+}
+
+// RewardShare is a beneficiary's percentage of the block reward.
+type RewardShare struct {
+	Address common.Address `json:"address"` // Account credited with the share
+	Percent uint64         `json:"percent"` // Percentage of the block reward
+}
+
+// BlockReward returns the reward in wei for block num, after any decay.
+func (s *RewardSchedule) BlockReward(num *big.Int) *big.Int {
+	reward := new(big.Int).Set(s.Reward)
+	if s.DecayPeriod == 0 || s.DecayPercent == 0 {
+		return reward
+	}
+	periods := new(big.Int).Sub(num, s.Block)
+	periods.Div(periods, new(big.Int).SetUint64(s.DecayPeriod))
+	var (
+		keep    = big.NewInt(int64(100 - s.DecayPercent))
+		hundred = big.NewInt(100)
+	)
+	// Decay one period at a time, which ends quickly once the reward hits zero.
+	for i := int64(0); i < periods.Int64() && reward.Sign() > 0; i++ {
+		reward.Mul(reward, keep)
+		reward.Div(reward, hundred)
+	}
+	return reward
+}
+
+// Split divides reward among the beneficiaries, returning each of their shares
+// and the remainder which goes to the signer.
+func (s *RewardSchedule) Split(reward *big.Int) (shares []*big.Int, signer *big.Int) {
+	signer = new(big.Int).Set(reward)
+	shares = make([]*big.Int, len(s.Beneficiaries))
+	for i, b := range s.Beneficiaries {
+		shares[i] = new(big.Int).Mul(reward, new(big.Int).SetUint64(b.Percent))
+		shares[i].Div(shares[i], big.NewInt(100))
+		signer.Sub(signer, shares[i])
+	}
+	return shares, signer
+}
+
+// validate checks that the schedule is well formed.
+func (s *RewardSchedule) validate() error {
+	if s.Block == nil || s.Reward == nil {
+		return fmt.Errorf("missing block or reward")
+	}
+	if s.Reward.Sign() < 0 {
+		return fmt.Errorf("negative reward %v", s.Reward)
+	}
+	if s.DecayPercent > 100 {
+		return fmt.Errorf("decay of %d%% exceeds 100%%", s.DecayPercent)
+	}
+	var total uint64
+	for _, b := range s.Beneficiaries {
+		total += b.Percent
+	}
+	if total > 100 {
+		return fmt.Errorf("beneficiary shares of %d%% exceed 100%%", total)
+	}
+	return nil
+}
+
+func (s *RewardSchedule) equal(o *RewardSchedule) bool {
+	if !configNumEqual(s.Block, o.Block) || !configNumEqual(s.Reward, o.Reward) ||
+		s.DecayPeriod != o.DecayPeriod || s.DecayPercent != o.DecayPercent ||
+		len(s.Beneficiaries) != len(o.Beneficiaries) {
+		return false
+	}
+	for i := range s.Beneficiaries {
+		if s.Beneficiaries[i] != o.Beneficiaries[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// RewardSchedule returns the reward schedule active at block num, or nil if the
+// chain's built-in reward rules apply.
+func (c *ChainConfig) RewardSchedule(num *big.Int) *RewardSchedule {
+	var active *RewardSchedule
+	for _, s := range c.RewardSchedules {
+		if isForked(s.Block, num) && (active == nil || s.Block.Cmp(active.Block) >= 0) {
+			active = s
+		}
+	}
+	return active
+}
+
+// CheckRewardSchedules returns an error if any reward schedule is malformed or
+// two schedules activate at the same block.
+func (c *ChainConfig) CheckRewardSchedules() error {
+	seen := make(map[string]struct{})
+	for i, s := range c.RewardSchedules {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("invalid reward schedule %d: %v", i, err)
+		}
+		if _, ok := seen[s.Block.String()]; ok {
+			return fmt.Errorf("invalid reward schedule %d: duplicate activation block %v", i, s.Block)
+		}
+		seen[s.Block.String()] = struct{}{}
+	}
+	return nil
+}
+
+// checkRewardSchedules returns a compatibility error for the lowest schedule
+// active at head which differs between c and newcfg.
+func (c *ChainConfig) checkRewardSchedules(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+	var lowest *big.Int
+	check := func(s *RewardSchedule, other []*RewardSchedule) {
+		if !isForked(s.Block, head) {
+			return
+		}
+		for _, o := range other {
+			if s.equal(o) {
+				return
+			}
+		}
+		if lowest == nil || s.Block.Cmp(lowest) < 0 {
+			lowest = s.Block
+		}
+	}
+	for _, s := range c.RewardSchedules {
+		check(s, newcfg.RewardSchedules)
+	}
+	for _, s := range newcfg.RewardSchedules {
+		check(s, c.RewardSchedules)
+	}
+	if lowest == nil {
+		return nil
+	}
+	return newCompatError("reward schedule", lowest, lowest)
+}
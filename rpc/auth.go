@@ -0,0 +1,342 @@
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChainAAS/gendchain/log"
+	"github.com/ChainAAS/gendchain/metrics"
+)
+
+// AuthConfig configures the clients allowed to connect to an HTTP or WebSocket
+// endpoint. Clients authenticate with an API key, passed in the X-API-Key header,
+// as a bearer token or as the basic auth password of the endpoint URL, or with a
+// JWT bearer token signed with JWTSecret.
+type AuthConfig struct {
+	// JWTSecret is the hex encoded HMAC-SHA256 secret of JWT bearer tokens. The
+	// "sub" claim of a token names its client. Tokens must expire with an "exp"
+	// claim, or be issued with an "iat" claim within jwtIssuedAtWindow of now.
+	// Empty disables JWT tokens.
+	JWTSecret string `toml:",omitempty"`
+
+	Clients []AuthClient
+}
+
+// AuthClient configures the credentials and limits of a client.
+type AuthClient struct {
+	Name string
+
+	// APIKeys are the API keys of the client.
+	APIKeys []string `toml:",omitempty"`
+
+	// Modules lists the namespaces the client may call, and Methods additional
+	// single methods. If both are empty all methods of the endpoint are allowed.
+	Modules []string `toml:",omitempty"`
+	Methods []string `toml:",omitempty"`
+
+	// RateLimit is the number of calls per second the client may make, shared by
+	// all of its connections, with bursts of up to RateBurst calls. Zero disables
+	// the limit.
+	RateLimit float64 `toml:",omitempty"`
+	RateBurst int     `toml:",omitempty"`
+
+	// MaxLogsBlockRange caps the number of blocks a log query may span. Zero
+	// disables the cap.
+	MaxLogsBlockRange uint64 `toml:",omitempty"`
+}
+
+// jwtIssuedAtWindow is how far the "iat" claim of a JWT token without an "exp"
+// claim may be from now.
+const jwtIssuedAtWindow = 60 * time.Second
+
+type authClientKey struct{}
+
+// AuthClientFromContext returns the client making the call of ctx, if the
+// endpoint requires authentication.
+func AuthClientFromContext(ctx context.Context) (AuthClient, bool) {
+	client, ok := ctx.Value(authClientKey{}).(*authClient)
+	if !ok {
+		return AuthClient{}, false
+	}
+	return client.AuthClient, true
+}
+
+// auth authenticates connections as configured clients.
+type auth struct {
+	secret  []byte
+	clients map[string]*authClient
+	keys    map[[sha256.Size]byte]*authClient // by key hash, to not compare keys directly
+}
+
+func newAuth(config *AuthConfig) (*auth, error) {
+	a := &auth{
+		clients: make(map[string]*authClient),
+		keys:    make(map[[sha256.Size]byte]*authClient),
+	}
+	if config.JWTSecret != "" {
+		secret, err := hex.DecodeString(strings.TrimPrefix(config.JWTSecret, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret: %v", err)
+		} else if len(secret) < 32 {
+			return nil, errors.New("JWT secret must be at least 32 bytes")
+		}
+		a.secret = secret
+	}
+	for _, cfg := range config.Clients {
+		if cfg.Name == "" {
+			return nil, errors.New("client without name")
+		} else if a.clients[cfg.Name] != nil {
+			return nil, fmt.Errorf("duplicate client %q", cfg.Name)
+		} else if cfg.RateLimit < 0 || cfg.RateBurst < 0 {
+			return nil, fmt.Errorf("negative rate limit for client %q", cfg.Name)
+		}
+		client := newAuthClient(cfg)
+		a.clients[cfg.Name] = client
+		for _, key := range cfg.APIKeys {
+			if key == "" {
+				return nil, fmt.Errorf("empty API key for client %q", cfg.Name)
+			}
+			hash := sha256.Sum256([]byte(key))
+			if a.keys[hash] != nil {
+				return nil, fmt.Errorf("duplicate API key for client %q", cfg.Name)
+			}
+			a.keys[hash] = client
+		}
+	}
+	return a, nil
+}
+
+// authenticate returns the client presenting the credentials of r.
+func (a *auth) authenticate(r *http.Request) (*authClient, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.apiKeyClient(key)
+	}
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, &unauthorizedError{"missing credentials"}
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return a.apiKeyClient(password)
+	}
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return nil, &unauthorizedError{"unsupported authorization scheme"}
+	}
+	token := strings.TrimSpace(header[7:])
+	if a.secret != nil && strings.Count(token, ".") == 2 {
+		return a.jwtClient(token, time.Now())
+	}
+	return a.apiKeyClient(token)
+}
+
+func (a *auth) apiKeyClient(key string) (*authClient, error) {
+	client := a.keys[sha256.Sum256([]byte(key))]
+	if client == nil {
+		return nil, &unauthorizedError{"invalid API key"}
+	}
+	return client, nil
+}
+
+// jwtClient verifies an HS256 signed token and returns the client named by its
+// subject.
+func (a *auth) jwtClient(token string, now time.Time) (*authClient, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, &unauthorizedError{"invalid token header"}
+	} else if header.Alg != "HS256" {
+		return nil, &unauthorizedError{"unsupported token algorithm " + header.Alg}
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, &unauthorizedError{"invalid token signature"}
+	}
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, &unauthorizedError{"invalid token signature"}
+	}
+	var claims struct {
+		Sub string `json:"sub"`
+		Exp *int64 `json:"exp"`
+		Nbf *int64 `json:"nbf"`
+		Iat *int64 `json:"iat"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, &unauthorizedError{"invalid token claims"}
+	} else if claims.Exp != nil && now.Unix() >= *claims.Exp {
+		return nil, &unauthorizedError{"token expired"}
+	} else if claims.Nbf != nil && now.Unix() < *claims.Nbf {
+		return nil, &unauthorizedError{"token not yet valid"}
+	} else if claims.Exp == nil {
+		// Tokens that never expire must be fresh instead.
+		if claims.Iat == nil {
+			return nil, &unauthorizedError{"token without exp or iat claim"}
+		} else if d := now.Sub(time.Unix(*claims.Iat, 0)); d > jwtIssuedAtWindow || d < -jwtIssuedAtWindow {
+			return nil, &unauthorizedError{"stale token"}
+		}
+	}
+	client := a.clients[claims.Sub]
+	if client == nil {
+		return nil, &unauthorizedError{"unknown token subject"}
+	}
+	return client, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// authenticate returns ctx with the client of r, or writes an error response and
+// returns false if r isn't authenticated. It always succeeds without auth.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request, ctx context.Context) (context.Context, bool) {
+	if s.auth == nil {
+		return ctx, true
+	}
+	client, err := s.auth.authenticate(r)
+	if err != nil {
+		unauthorizedCounter.Inc(1)
+		log.Debug("Rejected unauthenticated RPC connection", "remote", r.RemoteAddr, "err", err)
+		w.Header().Set("content-type", contentType)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(errorMessage(err))
+		return ctx, false
+	}
+	return context.WithValue(ctx, authClientKey{}, client), true
+}
+
+// AuthHandler returns a handler which authenticates requests like JSON-RPC
+// connections before passing them to next. Each request counts as a call of
+// method against the allow-list and rate limit of the client. The client is
+// available to next through AuthClientFromContext.
+func (s *Server) AuthHandler(method string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, ok := s.authenticate(w, r, r.Context())
+		if !ok {
+			return
+		}
+		if client, ok := ctx.Value(authClientKey{}).(*authClient); ok {
+			if err := client.authorize(method); err != nil {
+				status := http.StatusForbidden
+				if _, ok := err.(*LimitExceededError); ok {
+					status = http.StatusTooManyRequests
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authClient enforces the allow-list and rate limit of a client.
+type authClient struct {
+	AuthClient
+	modules map[string]bool
+	methods map[string]bool
+	limiter *rateLimiter
+
+	requests metrics.Counter
+	rejected metrics.Counter
+}
+
+func newAuthClient(cfg AuthClient) *authClient {
+	c := &authClient{
+		AuthClient: cfg,
+		requests:   newClientCounter(cfg.Name, "requests"),
+		rejected:   newClientCounter(cfg.Name, "rejected"),
+	}
+	if len(cfg.Modules) > 0 || len(cfg.Methods) > 0 {
+		c.modules = make(map[string]bool)
+		for _, module := range cfg.Modules {
+			c.modules[module] = true
+		}
+		c.methods = make(map[string]bool)
+		for _, method := range cfg.Methods {
+			c.methods[method] = true
+		}
+	}
+	if cfg.RateLimit > 0 {
+		burst := float64(cfg.RateBurst)
+		if burst < 1 {
+			burst = cfg.RateLimit
+			if burst < 1 {
+				burst = 1
+			}
+		}
+		c.limiter = newRateLimiter(cfg.RateLimit, burst)
+	}
+	return c
+}
+
+// authorize returns an error if the client may not call method now.
+func (c *authClient) authorize(method string) error {
+	c.requests.Inc(1)
+	if c.modules != nil && !c.methods[method] {
+		namespace := method
+		if i := strings.Index(method, serviceMethodSeparator); i >= 0 {
+			namespace = method[:i]
+		}
+		if !c.modules[namespace] {
+			notAllowedCounter.Inc(1)
+			c.rejected.Inc(1)
+			return &methodNotAllowedError{method}
+		}
+	}
+	if c.limiter != nil && !c.limiter.allow(time.Now()) {
+		rateLimitCounter.Inc(1)
+		c.rejected.Inc(1)
+		return &LimitExceededError{fmt.Sprintf("rate limit of %v requests per second exceeded", c.RateLimit)}
+	}
+	return nil
+}
+
+// rateLimiter is a token bucket refilled at rate tokens per second.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: burst, tokens: burst}
+}
+
+// allow takes a token if one is available at now.
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() && now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	if now.After(l.last) {
+		l.last = now
+	}
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,253 @@
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testAuthSecret = strings.Repeat("ab", 32)
+
+func newTestAuthServer(t *testing.T) *Server {
+	server := newTestServer()
+	if err := server.SetAuth(&AuthConfig{
+		JWTSecret: testAuthSecret,
+		Clients: []AuthClient{
+			{Name: "partner", APIKeys: []string{"partner-key"}, Modules: []string{"test"}, Methods: []string{"rpc_modules"}},
+			{Name: "limited", APIKeys: []string{"limited-key"}, RateLimit: 0.001, RateBurst: 2},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestAuth_Config(t *testing.T) {
+	for _, config := range []*AuthConfig{
+		{JWTSecret: "zz"},
+		{JWTSecret: "abcd"},
+		{Clients: []AuthClient{{APIKeys: []string{"key"}}}},
+		{Clients: []AuthClient{{Name: "a"}, {Name: "a"}}},
+		{Clients: []AuthClient{{Name: "a", APIKeys: []string{"key"}}, {Name: "b", APIKeys: []string{"key"}}}},
+		{Clients: []AuthClient{{Name: "a", RateLimit: -1}}},
+	} {
+		if _, err := newAuth(config); err == nil {
+			t.Errorf("expected error for %+v", config)
+		}
+	}
+}
+
+func TestAuth_HTTP(t *testing.T) {
+	server := newTestAuthServer(t)
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// Unauthenticated requests are refused.
+	for _, header := range []string{"", "Bearer wrong-key", "Digest partner-key"} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]}`))
+		req.Header.Set("content-type", contentType)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		confirmStatusCode(t, resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetHeader("X-API-Key", "partner-key")
+
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	} else if result.String != "hello" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	var modules map[string]string
+	if err := client.Call(&modules, "rpc_modules"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Calls outside the allow-list are refused, also within batches.
+	batch := []BatchElem{
+		{Method: "test_echo", Args: []interface{}{"hello", 10, &echoArgs{"world"}}, Result: new(echoResult)},
+		{Method: "nftest_echo", Args: []interface{}{1}, Result: new(int)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	} else if batch[0].Error != nil {
+		t.Fatal(batch[0].Error)
+	} else if err, ok := batch[1].Error.(Error); !ok || err.ErrorCode() != -32004 {
+		t.Fatalf("unexpected error: %v", batch[1].Error)
+	}
+}
+
+func TestAuth_RateLimit(t *testing.T) {
+	server := newTestAuthServer(t)
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetHeader("Authorization", "Bearer limited-key")
+
+	// The burst is shared with other connections of the client.
+	if err := client.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatal(err)
+	}
+	wsts := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer wsts.Close()
+	ws, err := DialWebsocket(context.Background(), "ws://x:limited-key@"+strings.TrimPrefix(wsts.URL, "http://"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	if err := ws.Call(nil, "nftest_echo", 1); err != nil {
+		t.Fatal(err)
+	}
+	err = client.Call(nil, "test_noArgsRets")
+	if err, ok := err.(Error); !ok || err.ErrorCode() != -32005 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = ws.Call(nil, "test_noArgsRets")
+	if err, ok := err.(Error); !ok || err.ErrorCode() != -32005 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAuth_Websocket(t *testing.T) {
+	server := newTestAuthServer(t)
+	defer server.Stop()
+	ts := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer ts.Close()
+	endpoint := strings.TrimPrefix(ts.URL, "http://")
+
+	if _, err := DialWebsocket(context.Background(), "ws://"+endpoint, ""); err == nil {
+		t.Fatal("expected unauthenticated connection to fail")
+	}
+	client, err := DialWebsocket(context.Background(), "ws://x:partner-key@"+endpoint, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatal(err)
+	}
+	err = client.Call(nil, "nftest_echo", 1)
+	if err, ok := err.(Error); !ok || err.ErrorCode() != -32004 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAuth_Handler(t *testing.T) {
+	server := newTestAuthServer(t)
+	defer server.Stop()
+	ts := httptest.NewServer(server.AuthHandler("graphql", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if client, ok := AuthClientFromContext(r.Context()); !ok || client.Name != "limited" {
+			t.Errorf("unexpected client: %+v", client)
+		}
+	})))
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		key    string
+		status int
+	}{
+		{"", http.StatusUnauthorized},
+		{"partner-key", http.StatusForbidden},
+		{"limited-key", http.StatusOK},
+		{"limited-key", http.StatusOK},
+		{"limited-key", http.StatusTooManyRequests},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, nil)
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		confirmStatusCode(t, resp.StatusCode, tt.status)
+	}
+}
+
+func TestAuth_JWT(t *testing.T) {
+	a, err := newAuth(&AuthConfig{JWTSecret: testAuthSecret, Clients: []AuthClient{{Name: "partner"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	for _, tt := range []struct {
+		header, claims string
+		ok             bool
+	}{
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner"}`, false},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","iat":950}`, true},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","iat":1050}`, true},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","iat":900}`, false},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","iat":1100}`, false},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","iat":900,"exp":1001}`, true},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","exp":1001,"nbf":1000}`, true},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","exp":1000}`, false},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"partner","nbf":1001}`, false},
+		{`{"alg":"HS256","typ":"JWT"}`, `{"sub":"other","exp":1001}`, false},
+		{`{"alg":"none","typ":"JWT"}`, `{"sub":"partner","exp":1001}`, false},
+	} {
+		token := signTestJWT(tt.header, tt.claims, testAuthSecret)
+		if client, err := a.jwtClient(token, now); tt.ok && (err != nil || client.Name != "partner") {
+			t.Errorf("%s: unexpected error: %v", tt.claims, err)
+		} else if !tt.ok && err == nil {
+			t.Errorf("%s: expected error", tt.claims)
+		}
+	}
+	token := signTestJWT(`{"alg":"HS256"}`, `{"sub":"partner","exp":1001}`, strings.Repeat("cd", 32))
+	if _, err := a.jwtClient(token, now); err == nil {
+		t.Error("expected invalid signature")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, 2)
+	now := time.Unix(1000, 0)
+	if !l.allow(now) || !l.allow(now) || l.allow(now) {
+		t.Fatal("expected burst of 2")
+	}
+	if l.allow(now.Add(400 * time.Millisecond)) {
+		t.Fatal("expected no token yet")
+	}
+	if !l.allow(now.Add(500*time.Millisecond)) || l.allow(now.Add(500*time.Millisecond)) {
+		t.Fatal("expected single refilled token")
+	}
+	if !l.allow(now.Add(time.Hour)) || !l.allow(now.Add(time.Hour)) || l.allow(now.Add(time.Hour)) {
+		t.Fatal("expected refill up to burst")
+	}
+}
+
+func signTestJWT(header, claims, secret string) string {
+	enc := base64.RawURLEncoding
+	data := enc.EncodeToString([]byte(header)) + "." + enc.EncodeToString([]byte(claims))
+	key, _ := hex.DecodeString(secret)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return data + "." + enc.EncodeToString(mac.Sum(nil))
+}
@@ -10,6 +10,9 @@ var (
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
+	_ Error = new(unauthorizedError)
+	_ Error = new(methodNotAllowedError)
+	_ Error = new(LimitExceededError)
 )
 
 const defaultErrorCode = -32000
@@ -57,3 +60,27 @@ type invalidParamsError struct{ message string }
 func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
+
+// missing or invalid credentials
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string { return e.message }
+
+// the method exists but the client may not call it
+type methodNotAllowedError struct{ method string }
+
+func (e *methodNotAllowedError) ErrorCode() int { return -32004 }
+
+func (e *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("the method %s is not allowed", e.method)
+}
+
+// LimitExceededError is returned when a call exceeds a limit of the calling client,
+// such as its rate limit.
+type LimitExceededError struct{ Message string }
+
+func (e *LimitExceededError) ErrorCode() int { return -32005 }
+
+func (e *LimitExceededError) Error() string { return e.Message }
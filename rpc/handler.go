@@ -45,6 +45,7 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	client         *authClient // authenticated client of the connection, if any
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -72,6 +73,10 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 	if conn.remoteAddr() != "" {
 		h.log = h.log.New("conn", conn.remoteAddr())
 	}
+	if client, ok := connCtx.Value(authClientKey{}).(*authClient); ok {
+		h.client = client
+		h.log = h.log.New("client", client.Name)
+	}
 	h.unsubscribeCb = newCallback(reflect.Value{}, reflect.ValueOf(h.unsubscribe))
 	return h
 }
@@ -301,6 +306,11 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if h.client != nil {
+		if err := h.client.authorize(msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -332,6 +342,12 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		rpcServingTimer.UpdateSince(start)
 		newRPCServingTimer(msg.Method, answer.Error == nil).UpdateSince(start)
 	}
+	if answer.Error != nil && answer.Error.Code == (&LimitExceededError{}).ErrorCode() {
+		limitCounter.Inc(1)
+		if h.client != nil {
+			h.client.rejected.Inc(1)
+		}
+	}
 	return answer
 }
 
@@ -11,6 +11,11 @@ var (
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedReqeustGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
 	rpcServingTimer        = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	unauthorizedCounter = metrics.NewRegisteredCounter("rpc/rejected/unauthorized", nil) // Connections without valid credentials
+	notAllowedCounter   = metrics.NewRegisteredCounter("rpc/rejected/notallowed", nil)   // Calls outside the client's allow-list
+	rateLimitCounter    = metrics.NewRegisteredCounter("rpc/rejected/ratelimit", nil)    // Calls over the client's rate limit
+	limitCounter        = metrics.NewRegisteredCounter("rpc/rejected/limit", nil)        // Calls over other client limits
 )
 
 func newRPCServingTimer(method string, valid bool) metrics.Timer {
@@ -21,3 +26,7 @@ func newRPCServingTimer(method string, valid bool) metrics.Timer {
 	m := fmt.Sprintf("rpc/duration/%s/%s", method, flag)
 	return metrics.GetOrRegisterTimer(m, nil)
 }
+
+func newClientCounter(client, name string) metrics.Counter {
+	return metrics.GetOrRegisterCounter(fmt.Sprintf("rpc/clients/%s/%s", client, name), nil)
+}
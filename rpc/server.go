@@ -31,6 +31,7 @@ type Server struct {
 	idgen    func() ID
 	run      int32
 	codecs   mapset.Set
+	auth     *auth // authenticates HTTP and WebSocket connections, if set
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -51,12 +52,29 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// SetAuth requires HTTP and WebSocket connections to authenticate as one of the
+// clients in config, and restricts their calls to what the client is allowed.
+// It must be called before the server starts serving.
+func (s *Server) SetAuth(config *AuthConfig) error {
+	auth, err := newAuth(config)
+	if err != nil {
+		return err
+	}
+	s.auth = auth
+	return nil
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(context.Background(), codec)
+}
+
+// serveCodec serves codec like ServeCodec, deriving the context of calls from ctx.
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -68,7 +86,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(ctx, codec, s.idgen, &s.services)
 	<-codec.closed()
 	c.Close()
 }
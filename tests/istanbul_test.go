@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ChainAAS/gendchain/common"
+	"github.com/ChainAAS/gendchain/core/vm"
+)
+
+// istanbulStateTest stores CHAINID, SELFBALANCE, the success flag and the output
+// of a blake2F call on the EIP-152 "abc" vector, which is passed as calldata.
+// Before Istanbul CHAINID is an invalid opcode and the transaction only pays gas.
+const istanbulStateTest = `{
+	"env": {
+		"currentCoinbase": "2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+		"currentDifficulty": "0x020000",
+		"currentGasLimit": "0x7fffffffffffffff",
+		"currentNumber": "0x01",
+		"currentTimestamp": "0x03e8"
+	},
+	"pre": {
+		"0x095e7baea6a6c7c4c2dfeb977efac326af552d87": {"balance": "0x0de0b6b3a7640000", "code": "0x466000554760015560d560006000376040600060d5600060095afa60035560005160025560205160045500", "nonce": "0x00", "storage": {}},
+		"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"balance": "0x0de0b6b3a7640000", "code": "0x", "nonce": "0x00", "storage": {}}
+	},
+	"transaction": {
+		"data": ["0x0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001"],
+		"gasLimit": ["0x061a80"],
+		"gasPrice": "0x01",
+		"nonce": "0x00",
+		"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+		"to": "0x095e7baea6a6c7c4c2dfeb977efac326af552d87",
+		"value": ["0x00"]
+	},
+	"post": {
+		"ConstantinopleFix": [{"hash": "b1dc9afae7808b977a898fb296fce7618175a8664a9bd0c59c2bdeaea04db467", "logs": "1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", "indexes": {"data": 0, "gas": 0, "value": 0}}],
+		"Istanbul": [{"hash": "59aa0b4898965669a1fc910a61dee5b9887ff6c13d762e5d3b204c04c9a7c4eb", "logs": "1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", "indexes": {"data": 0, "gas": 0, "value": 0}}]
+	}
+}`
+
+// Tests the Istanbul opcodes, precompile and gas schedule through the state test
+// runner.
+func TestStateIstanbul(t *testing.T) {
+	var test StateTest
+	if err := json.Unmarshal([]byte(istanbulStateTest), &test); err != nil {
+		t.Fatalf("failed to decode test: %v", err)
+	}
+	for _, subtest := range test.Subtests() {
+		statedb, err := test.Run(subtest, vm.Config{})
+		if err != nil {
+			t.Fatalf("%s: %v", subtest.Fork, err)
+		}
+		if subtest.Fork != "Istanbul" {
+			continue
+		}
+		contract := common.HexToAddress("0x095e7baea6a6c7c4c2dfeb977efac326af552d87")
+		for slot, want := range []string{
+			"0x01",               // CHAINID
+			"0x0de0b6b3a7640000", // SELFBALANCE
+			"0xba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d1", // blake2F output, first word
+			"0x01", // blake2F success
+			"0x7d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923", // blake2F output, second word
+		} {
+			key := common.BytesToHash([]byte{byte(slot)})
+			if have := statedb.GetState(contract, key); have != common.HexToHash(want) {
+				t.Errorf("slot %d mismatch: have %x, want %s", slot, have, want)
+			}
+		}
+	}
+}
@@ -73,6 +73,8 @@ type Database struct {
 	dirtiesSize   common.StorageSize // Storage size of the dirty node cache (exc. flushlist)
 	preimagesSize common.StorageSize // Storage size of the preimages cache
 
+	onFlush func(hash common.Hash) // Called before a node is written to disk, if set
+
 	lock sync.RWMutex
 }
 
@@ -539,6 +541,15 @@ func (db *Database) dereference(child common.Hash, parent common.Hash) {
 	}
 }
 
+// SetFlushHook sets a function called with the hash of every node before it is
+// written to disk by Cap or Commit. A nil function removes the hook.
+func (db *Database) SetFlushHook(fn func(hash common.Hash)) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.onFlush = fn
+}
+
 // Cap iteratively flushes old but still referenced trie nodes until the total
 // memory usage goes below the given threshold.
 func (db *Database) Cap(limit common.StorageSize) error {
@@ -580,6 +591,9 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	for size > limit && oldest != (common.Hash{}) {
 		// Fetch the oldest referenced node and push into the batch
 		node := db.dirties[oldest]
+		if db.onFlush != nil {
+			db.onFlush(oldest)
+		}
 		if err := batch.Put(oldest[:], node.rlp()); err != nil {
 			db.lock.RUnlock()
 			return err
@@ -722,6 +736,9 @@ func (db *Database) commit(hash common.Hash, batch common.Batch) error {
 			return err
 		}
 	}
+	if db.onFlush != nil {
+		db.onFlush(hash)
+	}
 	if err := batch.Put(hash[:], node.rlp()); err != nil {
 		return err
 	}